@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIndicatorStreamSendsSnapshots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{}
+	router := gin.New()
+	router.GET("/api/indicators/stream", s.handleIndicatorStream)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	// 持续推送，直到读够快照（响应头在第一条快照写出后才返回）
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for price := 100.0; ; price++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				market.IndicatorStreamCli.Publish(&market.Data{Symbol: "BTCUSDT", CurrentPrice: price, CurrentRSI7: 55})
+			}
+		}
+	}()
+
+	resp, err := http.Get(srv.URL + "/api/indicators/stream?symbol=btc")
+	if err != nil {
+		t.Fatalf("GET stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var snapshots []market.IndicatorSnapshot
+	event := ""
+	for len(snapshots) < 5 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream after %d snapshots: %v", len(snapshots), err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if event != "indicator" {
+				t.Fatalf("event = %q, want indicator", event)
+			}
+			var snapshot market.IndicatorSnapshot
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &snapshot); err != nil {
+				t.Fatalf("decode snapshot %q: %v", line, err)
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	for i, snapshot := range snapshots {
+		if snapshot.Symbol != "BTCUSDT" || snapshot.RSI7 != 55 {
+			t.Errorf("snapshot %d = %+v, want BTCUSDT with RSI7 55", i, snapshot)
+		}
+		if i > 0 && snapshot.Price <= snapshots[i-1].Price {
+			t.Errorf("snapshot %d price %.0f not after %.0f", i, snapshot.Price, snapshots[i-1].Price)
+		}
+	}
+}
+
+func TestIndicatorStreamRequiresSymbol(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{}
+	router := gin.New()
+	router.GET("/api/indicators/stream", s.handleIndicatorStream)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/indicators/stream", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
@@ -3,12 +3,15 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"nofx/auth"
 	"nofx/config"
 	"nofx/decision"
 	"nofx/manager"
+	"nofx/market"
+	"nofx/trader"
 	"strconv"
 	"strings"
 	"time"
@@ -108,6 +111,11 @@ func (s *Server) setupRoutes() {
 			protected.DELETE("/traders/:id", s.handleDeleteTrader)
 			protected.POST("/traders/:id/start", s.handleStartTrader)
 			protected.POST("/traders/:id/stop", s.handleStopTrader)
+			protected.POST("/traders/:id/emergency-exit", s.handleEmergencyExit)
+			protected.POST("/traders/:id/reenable", s.handleReEnableStrategy)
+			protected.POST("/traders/:id/resume", s.handleResumeTrading)
+			protected.GET("/traders/:id/config/export", s.handleExportTraderConfig)
+			protected.POST("/traders/:id/config/import", s.handleImportTraderConfig)
 			protected.PUT("/traders/:id/prompt", s.handleUpdateTraderPrompt)
 
 			// AI模型配置
@@ -130,6 +138,9 @@ func (s *Server) setupRoutes() {
 			protected.GET("/decisions/latest", s.handleLatestDecisions)
 			protected.GET("/statistics", s.handleStatistics)
 			protected.GET("/performance", s.handlePerformance)
+			protected.GET("/risk-status", s.handleRiskStatus)
+			protected.GET("/risk", s.handleRiskDashboard)
+			protected.GET("/indicators/stream", s.handleIndicatorStream)
 		}
 	}
 }
@@ -608,6 +619,187 @@ func (s *Server) handleStopTrader(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "交易员已停止"})
 }
 
+// handleEmergencyExit 紧急平仓：撤销挂单、市价平掉全部持仓并暂停交易24小时
+func (s *Server) handleEmergencyExit(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	// 校验交易员是否属于当前用户
+	_, _, _, err := s.database.GetTraderConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	c.ShouldBindJSON(&req)
+	if req.Reason == "" {
+		req.Reason = "手动紧急平仓"
+	}
+
+	report, err := trader.EmergencyExit(req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  fmt.Sprintf("紧急平仓失败: %v", err),
+			"report": report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// handleReEnableStrategy 手动解除策略熔断
+func (s *Server) handleReEnableStrategy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	// 校验交易员是否属于当前用户
+	_, _, _, err := s.database.GetTraderConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		return
+	}
+
+	trader.ReEnableStrategy()
+	c.JSON(http.StatusOK, gin.H{"message": "策略已重新启用"})
+}
+
+// handleResumeTrading 手动恢复交易（解除回撤硬止损和风控暂停）
+func (s *Server) handleResumeTrading(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	// 校验交易员是否属于当前用户
+	_, _, _, err := s.database.GetTraderConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		return
+	}
+
+	trader.ManualResumeTrading()
+	c.JSON(http.StatusOK, gin.H{"message": "已恢复交易"})
+}
+
+// handleExportTraderConfig 导出交易员当前生效的策略配置（带版本号的JSON，不含凭证）
+func (s *Server) handleExportTraderConfig(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	// 校验交易员是否属于当前用户
+	_, _, _, err := s.database.GetTraderConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		return
+	}
+
+	data, err := trader.ExportConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// handleImportTraderConfig 导入 config/export 导出的策略配置
+// 交易员自身的参数写入交易员记录；风控参数（risk_config、日亏损/回撤限制）为所有交易员共用，只在管理员模式下导入
+func (s *Server) handleImportTraderConfig(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	existing, _, _, err := s.database.GetTraderConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("读取请求失败: %v", err)})
+		return
+	}
+	var cfg trader.AutoTraderConfig
+	if err := cfg.LoadConfig(data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record := *existing
+	record.InitialBalance = cfg.InitialBalance
+	record.ScanIntervalMinutes = int(cfg.ScanInterval.Minutes())
+	record.BTCETHLeverage = cfg.BTCETHLeverage
+	record.AltcoinLeverage = cfg.AltcoinLeverage
+	record.TradingSymbols = strings.Join(cfg.TradingCoins, ",")
+	record.SystemPromptTemplate = cfg.SystemPromptTemplate
+	record.IsCrossMargin = cfg.IsCrossMargin
+	if record.ScanIntervalMinutes < 1 {
+		record.ScanIntervalMinutes = 1
+	}
+	if err := s.database.UpdateTrader(&record); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新交易员失败: %v", err)})
+		return
+	}
+
+	riskImported := auth.IsAdminMode()
+	if riskImported {
+		riskJSON, err := json.Marshal(cfg.Risk)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("序列化风控配置失败: %v", err)})
+			return
+		}
+		globals := map[string]string{
+			"risk_config":          string(riskJSON),
+			"max_daily_loss":       fmt.Sprintf("%.1f", cfg.MaxDailyLoss),
+			"max_drawdown":         fmt.Sprintf("%.1f", cfg.MaxDrawdown),
+			"stop_trading_minutes": strconv.Itoa(int(cfg.StopTradingTime.Minutes())),
+		}
+		for key, value := range globals {
+			if err := s.database.SetSystemConfig(key, value); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存%s失败: %v", key, err)})
+				return
+			}
+		}
+	}
+
+	// 重新加载交易员到内存
+	if err := s.traderManager.LoadUserTraders(s.database, userID); err != nil {
+		log.Printf("⚠️ 重新加载用户交易员到内存失败: %v", err)
+	}
+
+	log.Printf("✓ 已导入交易员策略配置: %s (风控参数: %t)", traderID, riskImported)
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":     traderID,
+		"risk_imported": riskImported,
+		"message":       "策略配置导入成功",
+	})
+}
+
 // handleUpdateTraderPrompt 更新交易员自定义Prompt
 func (s *Server) handleUpdateTraderPrompt(c *gin.Context) {
 	traderID := c.Param("id")
@@ -1154,6 +1346,89 @@ func (s *Server) handlePerformance(c *gin.Context) {
 	c.JSON(http.StatusOK, performance)
 }
 
+// handleRiskStatus 持仓风险热度
+func (s *Server) handleRiskStatus(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	riskStatus, err := trader.GetRiskStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取风险状态失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, riskStatus)
+}
+
+// handleRiskDashboard 结构化风险看板（需在风控配置中开启 enable_http_dashboard）
+func (s *Server) handleRiskDashboard(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !trader.RiskDashboardEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "风险看板未启用"})
+		return
+	}
+
+	dashboard, err := trader.GenerateRiskDashboard()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取风险看板失败: %v", err),
+		})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, dashboard)
+}
+
+// handleIndicatorStream 实时推送指标快照（Server-Sent Events）
+func (s *Server) handleIndicatorStream(c *gin.Context) {
+	symbol := c.Query("symbol")
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少symbol参数"})
+		return
+	}
+
+	snapshots, cancel, err := market.IndicatorStreamCli.Subscribe(symbol)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	defer cancel()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case snapshot, ok := <-snapshots:
+			if !ok {
+				return false
+			}
+			c.SSEvent("indicator", snapshot)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
 // authMiddleware JWT认证中间件
 func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -1476,6 +1751,11 @@ func (s *Server) Start() error {
 	log.Printf("  • DELETE /api/traders/:id    - 删除AI交易员")
 	log.Printf("  • POST /api/traders/:id/start - 启动AI交易员")
 	log.Printf("  • POST /api/traders/:id/stop  - 停止AI交易员")
+	log.Printf("  • POST /api/traders/:id/emergency-exit - 紧急平仓（平掉全部持仓并暂停交易24小时）")
+	log.Printf("  • POST /api/traders/:id/resume - 手动恢复交易（解除回撤硬止损和风控暂停）")
+	log.Printf("  • POST /api/traders/:id/reenable - 手动解除策略熔断")
+	log.Printf("  • GET  /api/traders/:id/config/export - 导出交易员策略配置（带版本号，不含凭证）")
+	log.Printf("  • POST /api/traders/:id/config/import - 导入交易员策略配置（校验版本号，全局风控参数仅管理员模式导入）")
 	log.Printf("  • GET  /api/models           - 获取AI模型配置")
 	log.Printf("  • PUT  /api/models           - 更新AI模型配置")
 	log.Printf("  • GET  /api/exchanges        - 获取交易所配置")
@@ -1487,6 +1767,9 @@ func (s *Server) Start() error {
 	log.Printf("  • GET  /api/decisions/latest?trader_id=xxx - 指定trader的最新决策")
 	log.Printf("  • GET  /api/statistics?trader_id=xxx - 指定trader的统计信息")
 	log.Printf("  • GET  /api/performance?trader_id=xxx - 指定trader的AI学习表现分析")
+	log.Printf("  • GET  /api/risk-status?trader_id=xxx - 指定trader的持仓风险热度")
+	log.Printf("  • GET  /api/risk?trader_id=xxx - 指定trader的结构化风险看板（需开启 enable_http_dashboard）")
+	log.Printf("  • GET  /api/indicators/stream?symbol=xxx - 实时指标推送（SSE）")
 	log.Println()
 
 	return s.router.Run(addr)
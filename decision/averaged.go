@@ -0,0 +1,120 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/logger"
+	"nofx/mcp"
+	"strings"
+	"time"
+)
+
+// MaxDecisionSamples 每周期AI调用次数上限（每次调用都是一次完整的付费请求，并线性拉长决策耗时）
+const MaxDecisionSamples = 5
+
+// callAveraged 对同一组提示词调用AI n 次（最多 MaxDecisionSamples 次），按币种合并为一组决策（见 AverageDecisions）
+// 部分调用失败时用成功的结果合并，全部失败时返回最后一个错误。思维链保留全部成功调用的输出
+func callAveraged(mcpClient *mcp.Client, systemPrompt, userPrompt string, n int, ctx *Context) (*FullDecision, error) {
+	if n > MaxDecisionSamples {
+		n = MaxDecisionSamples
+	}
+	var runs [][]Decision
+	var traces []string
+	var first *FullDecision
+	var lastErr error
+	aiStart := time.Now()
+	for i := 0; i < n; i++ {
+		aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+		if err != nil {
+			lastErr = fmt.Errorf("调用AI API失败: %w", classifyCallError(err))
+			logger.Warnf("⚠️  第 %d/%d 次AI调用失败: %v", i+1, n, err)
+			continue
+		}
+		parsed, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+		if err != nil {
+			lastErr = fmt.Errorf("解析AI响应失败: %w", err)
+			logger.Warnf("⚠️  第 %d/%d 次AI响应解析失败: %v", i+1, n, err)
+			continue
+		}
+		if first == nil {
+			first = parsed
+		}
+		runs = append(runs, parsed.Decisions)
+		traces = append(traces, fmt.Sprintf("===== 第 %d/%d 次调用 =====\n%s", i+1, n, parsed.CoTTrace))
+	}
+	aiMs := time.Since(aiStart).Milliseconds()
+	if first == nil {
+		return &FullDecision{AIMs: aiMs}, lastErr
+	}
+
+	first.Decisions = AverageDecisions(runs)
+	first.CoTTrace = strings.Join(traces, "\n\n")
+	first.AIMs = aiMs
+	logger.Infof("🎲 AI调用 %d 次（成功 %d 次），按币种合并为 %d 条决策", n, len(runs), len(first.Decisions))
+	return first, nil
+}
+
+// AverageDecisions 合并多次AI调用的决策（按币种）
+// 同一币种的每个操作需超过半数调用给出才保留（分母是调用次数，某次调用没有提到该币种视为未投票），
+// 同一次调用里重复的操作只计一票；同一币种可同时保留多个过半数的操作（如反手的平多+开空）。
+// 没有任何过半数的操作时（方向分歧或多数调用未提及）改为观望。
+// 保留的操作取信心度最高的一条作为基础（止损止盈等参数保持一致），信心度取该操作各票的平均值
+func AverageDecisions(runs [][]Decision) []Decision {
+	type tally struct {
+		actions []string              // 操作（按首次出现顺序）
+		votes   map[string][]Decision // 操作 -> 各次调用给出的决策（每次调用一条）
+	}
+	var symbols []string
+	bySymbol := make(map[string]*tally)
+	for _, run := range runs {
+		seen := make(map[string]bool)
+		for _, d := range run {
+			t, ok := bySymbol[d.Symbol]
+			if !ok {
+				t = &tally{votes: make(map[string][]Decision)}
+				bySymbol[d.Symbol] = t
+				symbols = append(symbols, d.Symbol)
+			}
+			key := d.Symbol + "|" + d.Action
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := t.votes[d.Action]; !ok {
+				t.actions = append(t.actions, d.Action)
+			}
+			t.votes[d.Action] = append(t.votes[d.Action], d)
+		}
+	}
+
+	result := make([]Decision, 0, len(symbols))
+	for _, symbol := range symbols {
+		t := bySymbol[symbol]
+		kept := 0
+		for _, action := range t.actions {
+			votes := t.votes[action]
+			if len(votes)*2 <= len(runs) {
+				continue
+			}
+			base := votes[0]
+			sum := 0
+			for _, d := range votes {
+				if d.Confidence > base.Confidence {
+					base = d
+				}
+				sum += d.Confidence
+			}
+			base.Confidence = sum / len(votes)
+			base.Reasoning = fmt.Sprintf("[%d/%d次调用一致] %s", len(votes), len(runs), base.Reasoning)
+			result = append(result, base)
+			kept++
+		}
+		if kept == 0 {
+			result = append(result, Decision{
+				Symbol:    symbol,
+				Action:    "wait",
+				Reasoning: fmt.Sprintf("[%d次调用方向分歧，改为观望]", len(runs)),
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package decision
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"nofx/mcp"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAverageDecisionsUsesRunCountAsDenominator(t *testing.T) {
+	// 3次调用中只有1次提到 ETHUSDT：1/3 不过半，应观望
+	runs := [][]Decision{
+		{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 80}, {Symbol: "ETHUSDT", Action: "open_short", Confidence: 90}},
+		{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70}},
+		{{Symbol: "BTCUSDT", Action: "wait"}},
+	}
+
+	got := AverageDecisions(runs)
+	if len(got) != 2 {
+		t.Fatalf("got %d decisions, want 2: %+v", len(got), got)
+	}
+	if got[0].Symbol != "BTCUSDT" || got[0].Action != "open_long" || got[0].Confidence != 75 {
+		t.Errorf("BTCUSDT = %+v, want open_long with confidence 75", got[0])
+	}
+	if got[1].Symbol != "ETHUSDT" || got[1].Action != "wait" {
+		t.Errorf("ETHUSDT = %+v, want wait", got[1])
+	}
+}
+
+func TestAverageDecisionsSplitVoteWaits(t *testing.T) {
+	runs := [][]Decision{
+		{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 80}},
+		{{Symbol: "BTCUSDT", Action: "open_short", Confidence: 80}},
+	}
+	got := AverageDecisions(runs)
+	if len(got) != 1 || got[0].Action != "wait" {
+		t.Fatalf("got %+v, want a single wait", got)
+	}
+}
+
+func TestAverageDecisionsCountsDuplicatesOnce(t *testing.T) {
+	// 同一次调用重复给出的操作只计一票
+	runs := [][]Decision{
+		{{Symbol: "BTCUSDT", Action: "open_long", Confidence: 80}, {Symbol: "BTCUSDT", Action: "open_long", Confidence: 80}},
+		{{Symbol: "BTCUSDT", Action: "wait"}},
+		{{Symbol: "BTCUSDT", Action: "wait"}},
+	}
+	got := AverageDecisions(runs)
+	if len(got) != 1 || got[0].Action != "wait" {
+		t.Fatalf("got %+v, want wait", got)
+	}
+}
+
+func TestAverageDecisionsKeepsReversal(t *testing.T) {
+	runs := [][]Decision{
+		{{Symbol: "BTCUSDT", Action: "close_long", Confidence: 70}, {Symbol: "BTCUSDT", Action: "open_short", Confidence: 80}},
+		{{Symbol: "BTCUSDT", Action: "close_long", Confidence: 90}, {Symbol: "BTCUSDT", Action: "open_short", Confidence: 60, StopLoss: 105}},
+	}
+	got := AverageDecisions(runs)
+	if len(got) != 2 || got[0].Action != "close_long" || got[1].Action != "open_short" {
+		t.Fatalf("got %+v, want close_long then open_short", got)
+	}
+	if got[1].Confidence != 70 || got[1].StopLoss != 0 {
+		t.Errorf("open_short = %+v, want averaged confidence 70 on the highest-confidence vote", got[1])
+	}
+}
+
+// sequenceServer 依次返回给定的AI回复（超出后重复最后一条）
+func sequenceServer(t *testing.T, replies []string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(calls.Add(1)) - 1
+		if i >= len(replies) {
+			i = len(replies) - 1
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": replies[i]}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestCallAveragedCombinesVariedResponses(t *testing.T) {
+	replies := []string{
+		`[{"symbol":"BTCUSDT","action":"close_long","confidence":80,"reasoning":"a"},{"symbol":"ETHUSDT","action":"close_short","confidence":90,"reasoning":"outlier"}]`,
+		`[{"symbol":"BTCUSDT","action":"close_long","confidence":60,"reasoning":"b"}]`,
+		`无法解析的回复`,
+		`[{"symbol":"BTCUSDT","action":"hold","confidence":50,"reasoning":"c"}]`,
+		`[{"symbol":"BTCUSDT","action":"close_long","confidence":70,"reasoning":"d"}]`,
+	}
+	srv, calls := sequenceServer(t, replies)
+	client := mcp.New()
+	client.APIKey = "test"
+	client.BaseURL = srv.URL
+
+	full, err := callAveraged(client, "system", "user", 5, &Context{Account: AccountInfo{TotalEquity: 1000}, BTCETHLeverage: 5, AltcoinLeverage: 5})
+	if err != nil {
+		t.Fatalf("callAveraged: %v", err)
+	}
+	if calls.Load() != 5 {
+		t.Errorf("AI called %d times, want 5", calls.Load())
+	}
+	// 4次成功调用：BTC close_long 3票过半、信心度平均 70；ETH 只有1票为离群值，改为观望
+	got := full.Decisions
+	if len(got) != 2 {
+		t.Fatalf("decisions = %+v, want BTC and ETH", got)
+	}
+	if got[0].Symbol != "BTCUSDT" || got[0].Action != "close_long" || got[0].Confidence != 70 {
+		t.Errorf("BTCUSDT = %+v, want close_long with confidence 70", got[0])
+	}
+	if got[1].Symbol != "ETHUSDT" || got[1].Action != "wait" {
+		t.Errorf("ETHUSDT = %+v, want wait", got[1])
+	}
+}
+
+func TestCallAveragedCapsSamples(t *testing.T) {
+	srv, calls := sequenceServer(t, []string{`[{"symbol":"BTCUSDT","action":"hold","confidence":50,"reasoning":"a"}]`})
+	client := mcp.New()
+	client.APIKey = "test"
+	client.BaseURL = srv.URL
+
+	if _, err := callAveraged(client, "system", "user", 20, &Context{Account: AccountInfo{TotalEquity: 1000}}); err != nil {
+		t.Fatalf("callAveraged: %v", err)
+	}
+	if calls.Load() != MaxDecisionSamples {
+		t.Errorf("AI called %d times, want capped at %d", calls.Load(), MaxDecisionSamples)
+	}
+}
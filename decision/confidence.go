@@ -0,0 +1,179 @@
+package decision
+
+import (
+	"nofx/logger"
+	"nofx/market"
+)
+
+// MeetsConfidence 判断信心度是否达到开仓门槛
+// 门槛是包含的：信心度恰好等于 minConfidence 视为达标（75 满足 ≥75）。
+// minConfidence <= 0 表示不限制。
+// 决策校验、复用衰减和开仓执行统一使用此函数判断，保证边界行为一致。
+func MeetsConfidence(confidence, minConfidence int) bool {
+	return minConfidence <= 0 || confidence >= minConfidence
+}
+
+// filterLowConfidenceEntries 将信心度不足的开仓决策改为观望
+func filterLowConfidenceEntries(decisions []Decision, minConfidence int) {
+	for i := range decisions {
+		d := &decisions[i]
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		if !MeetsConfidence(d.Confidence, minConfidence) {
+			logger.Warnf("⚠️  %s %s 信心度 %d 低于门槛 %d，改为观望", d.Symbol, d.Action, d.Confidence, minConfidence)
+			d.Action = "wait"
+			d.Reasoning = "[信心度不足，禁止开仓] " + d.Reasoning
+		}
+	}
+}
+
+// ConfidenceFactor 技术面确认/冲突因子
+type ConfidenceFactor struct {
+	Name      string `json:"name"`
+	Confirmed bool   `json:"confirmed"` // true=支持开仓方向，false=与开仓方向冲突
+}
+
+// MTFDivergenceConfidenceBoost 多周期RSI背离共振与开仓方向一致时的信心度加分（0-100刻度，即+0.08）
+const MTFDivergenceConfidenceBoost = 8
+
+// technicalFactors 从市场数据中提取与开仓方向相关的独立技术因子（含多周期RSI背离）
+func technicalFactors(data *market.Data, isLong bool) []ConfidenceFactor {
+	factors := trendFactors(data, isLong)
+	if f, ok := divergenceFactor(data, isLong); ok {
+		factors = append(factors, f)
+	}
+	return factors
+}
+
+// trendFactors 均线、MACD等趋势类技术因子
+func trendFactors(data *market.Data, isLong bool) []ConfidenceFactor {
+	var factors []ConfidenceFactor
+	add := func(name string, bullish bool) {
+		factors = append(factors, ConfidenceFactor{Name: name, Confirmed: bullish == isLong})
+	}
+
+	if data.CurrentEMA20 > 0 {
+		add("3m价格与EMA20", data.CurrentPrice > data.CurrentEMA20)
+	}
+	if data.CurrentMACD != 0 {
+		add("3m MACD", data.CurrentMACD > 0)
+	}
+	if lt := data.LongerTermContext; lt != nil {
+		if lt.EMA20 > 0 && lt.EMA50 > 0 {
+			add("4h EMA20与EMA50", lt.EMA20 > lt.EMA50)
+		}
+		if n := len(lt.MACDValues); n > 0 && lt.MACDValues[n-1] != 0 {
+			add("4h MACD", lt.MACDValues[n-1] > 0)
+		}
+	}
+	return factors
+}
+
+// divergenceFactor 1h/4h/1d 多周期RSI背离共振因子（无共振时返回 false）
+func divergenceFactor(data *market.Data, isLong bool) (ConfidenceFactor, bool) {
+	switch {
+	case data.MTFRSIBullishConfluence:
+		return ConfidenceFactor{Name: "多周期RSI底背离", Confirmed: isLong}, true
+	case data.MTFRSIBearishConfluence:
+		return ConfidenceFactor{Name: "多周期RSI顶背离", Confirmed: !isLong}, true
+	default:
+		return ConfidenceFactor{}, false
+	}
+}
+
+// TechnicalDirection 仅由技术指标判断的方向：看多因子多于看空为"long"，反之为"short"，
+// 持平或无数据为""；同时返回看多、看空因子数
+func TechnicalDirection(data *market.Data) (direction string, bullish, bearish int) {
+	if data == nil {
+		return "", 0, 0
+	}
+	for _, f := range technicalFactors(data, true) {
+		if f.Confirmed {
+			bullish++
+		} else {
+			bearish++
+		}
+	}
+	switch {
+	case bullish > bearish:
+		return "long", bullish, bearish
+	case bearish > bullish:
+		return "short", bullish, bearish
+	default:
+		return "", bullish, bearish
+	}
+}
+
+// EntrySignals 开仓时各技术指标相对开仓方向的信号（支持为+1，冲突为-1），用于按指标复盘胜率
+func EntrySignals(d *Decision, data *market.Data) []logger.SignalContribution {
+	if data == nil || (d.Action != "open_long" && d.Action != "open_short") {
+		return nil
+	}
+	factors := technicalFactors(data, d.Action == "open_long")
+	signals := make([]logger.SignalContribution, 0, len(factors))
+	for _, f := range factors {
+		weight := 1.0
+		if !f.Confirmed {
+			weight = -1
+		}
+		signals = append(signals, logger.SignalContribution{Indicator: f.Name, Weight: weight})
+	}
+	return signals
+}
+
+// AdjustConfidence 按多周期技术面一致程度调整开仓信心度
+// 每个支持开仓方向的趋势因子 +perFactor，每个冲突因子 -perFactor（perFactor <= 0 时不按因子调整）；
+// 多周期RSI背离共振与开仓方向一致时另加 MTFDivergenceConfidenceBoost，方向相反时不扣分。
+// 结果限制在 [0, maxConfidence]；AI给出的信心度高于 maxConfidence 时不会被拉低，只是加分不会超过上限。
+// 返回参与调整的因子（便于复盘）
+func AdjustConfidence(d *Decision, data *market.Data, perFactor, maxConfidence int) []ConfidenceFactor {
+	if data == nil || (d.Action != "open_long" && d.Action != "open_short") {
+		return nil
+	}
+	isLong := d.Action == "open_long"
+
+	var factors []ConfidenceFactor
+	delta := 0
+	if perFactor > 0 {
+		factors = trendFactors(data, isLong)
+		for _, f := range factors {
+			if f.Confirmed {
+				delta += perFactor
+			} else {
+				delta -= perFactor
+			}
+		}
+	}
+	if f, ok := divergenceFactor(data, isLong); ok {
+		factors = append(factors, f)
+		if f.Confirmed {
+			delta += MTFDivergenceConfidenceBoost
+		}
+	}
+
+	adjusted := d.Confidence + delta
+	if delta > 0 && adjusted > maxConfidence {
+		adjusted = maxConfidence
+		if d.Confidence > adjusted {
+			adjusted = d.Confidence
+		}
+	}
+	if adjusted < 0 {
+		adjusted = 0
+	}
+	d.Confidence = adjusted
+	return factors
+}
+
+// adjustEntryConfidence 对所有开仓决策按技术面调整信心度
+func adjustEntryConfidence(decisions []Decision, marketDataMap map[string]*market.Data, perFactor, maxConfidence int) {
+	for i := range decisions {
+		d := &decisions[i]
+		original := d.Confidence
+		d.ConfidenceFactors = AdjustConfidence(d, marketDataMap[d.Symbol], perFactor, maxConfidence)
+		if d.Confidence != original {
+			logger.Infof("📐 %s %s 技术面调整信心度 %d → %d（%d 个因子）", d.Symbol, d.Action, original, d.Confidence, len(d.ConfidenceFactors))
+		}
+	}
+}
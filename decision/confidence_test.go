@@ -0,0 +1,98 @@
+package decision
+
+import (
+	"nofx/market"
+	"testing"
+)
+
+func TestAdjustConfidenceBoostsAlignedDivergence(t *testing.T) {
+	data := &market.Data{MTFRSIBullishConfluence: true}
+
+	long := &Decision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70}
+	factors := AdjustConfidence(long, data, 0, 95)
+	if long.Confidence != 70+MTFDivergenceConfidenceBoost {
+		t.Errorf("long confidence = %d, want %d", long.Confidence, 70+MTFDivergenceConfidenceBoost)
+	}
+	if len(factors) != 1 || !factors[0].Confirmed {
+		t.Errorf("factors = %+v, want one confirmed divergence factor", factors)
+	}
+
+	// 方向相反时只记录因子，不扣分
+	short := &Decision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 70}
+	AdjustConfidence(short, data, 0, 95)
+	if short.Confidence != 70 {
+		t.Errorf("short confidence = %d, want 70", short.Confidence)
+	}
+}
+
+func TestAdjustConfidenceDivergenceRespectsCap(t *testing.T) {
+	d := &Decision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 90}
+	AdjustConfidence(d, &market.Data{MTFRSIBearishConfluence: true}, 0, 95)
+	if d.Confidence != 95 {
+		t.Errorf("confidence = %d, want capped at 95", d.Confidence)
+	}
+}
+
+func TestAdjustConfidenceCombinesTrendFactorsAndDivergence(t *testing.T) {
+	// 3m价格在EMA20之上、MACD为正：两个看多因子 + 底背离共振
+	data := &market.Data{CurrentPrice: 105, CurrentEMA20: 100, CurrentMACD: 1, MTFRSIBullishConfluence: true}
+	d := &Decision{Symbol: "BTCUSDT", Action: "open_long", Confidence: 60}
+	factors := AdjustConfidence(d, data, 5, 95)
+	if want := 60 + 2*5 + MTFDivergenceConfidenceBoost; d.Confidence != want {
+		t.Errorf("confidence = %d, want %d", d.Confidence, want)
+	}
+	if len(factors) != 3 {
+		t.Errorf("factors = %+v, want 3", factors)
+	}
+}
+
+func TestAdjustEntryConfidenceWithoutPerFactor(t *testing.T) {
+	decisions := []Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70},
+		{Symbol: "ETHUSDT", Action: "hold", Confidence: 70},
+	}
+	data := map[string]*market.Data{
+		"BTCUSDT": {MTFRSIBullishConfluence: true},
+		"ETHUSDT": {MTFRSIBullishConfluence: true},
+	}
+	adjustEntryConfidence(decisions, data, 0, 95)
+	if decisions[0].Confidence != 78 || decisions[1].Confidence != 70 {
+		t.Errorf("confidences = %d/%d, want 78/70", decisions[0].Confidence, decisions[1].Confidence)
+	}
+}
+
+func TestMeetsConfidenceThresholdIsInclusive(t *testing.T) {
+	cases := []struct {
+		confidence, min int
+		want            bool
+	}{
+		{75, 75, true},
+		{74, 75, false},
+		{76, 75, true},
+		{0, 0, true},   // 0 表示不限制
+		{10, -1, true}, // 负数同样不限制
+	}
+	for _, tc := range cases {
+		if got := MeetsConfidence(tc.confidence, tc.min); got != tc.want {
+			t.Errorf("MeetsConfidence(%d, %d) = %v, want %v", tc.confidence, tc.min, got, tc.want)
+		}
+	}
+}
+
+func TestFilterLowConfidenceEntriesAtThreshold(t *testing.T) {
+	decisions := []Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 75},
+		{Symbol: "ETHUSDT", Action: "open_short", Confidence: 74},
+		{Symbol: "SOLUSDT", Action: "close_long", Confidence: 10},
+	}
+	filterLowConfidenceEntries(decisions, 75)
+	if decisions[0].Action != "open_long" {
+		t.Errorf("confidence exactly at the threshold filtered: %s", decisions[0].Action)
+	}
+	if decisions[1].Action != "wait" {
+		t.Errorf("confidence below the threshold kept: %s", decisions[1].Action)
+	}
+	if decisions[2].Action != "close_long" {
+		t.Errorf("close filtered by confidence: %s", decisions[2].Action)
+	}
+}
@@ -0,0 +1,69 @@
+package decision
+
+import (
+	"time"
+)
+
+// DecayDecisions 复用旧决策时按年龄衰减开仓信心度
+// 信心度每分钟减少 decayPerMinute 点，仓位按衰减比例同步缩小；
+// 衰减后低于 minConfidence（或降到0）的开仓决策改为观望。
+// 平仓、持有、观望决策原样保留。返回新的决策列表，不修改入参。
+func DecayDecisions(decisions []Decision, age time.Duration, decayPerMinute float64, minConfidence int) []Decision {
+	result := make([]Decision, len(decisions))
+	copy(result, decisions)
+
+	decay := decayPerMinute * age.Minutes()
+	for i := range result {
+		d := &result[i]
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+
+		original := d.Confidence
+		decayed := float64(original) - decay
+		if decayed <= 0 || !MeetsConfidence(int(decayed), minConfidence) {
+			d.Action = "wait"
+			d.Confidence = 0
+			d.Reasoning = "[决策已过期，信心度衰减后不足] " + d.Reasoning
+			continue
+		}
+
+		d.Confidence = int(decayed)
+		if original > 0 {
+			ratio := decayed / float64(original)
+			d.PositionSizeUSD *= ratio
+			d.RiskUSD *= ratio
+		}
+	}
+
+	return result
+}
+
+// RevalidateEntries 复用旧决策时按当前价格重新检查开仓的止损止盈
+// 多仓要求 止损 < 当前价 < 止盈，空仓要求 止盈 < 当前价 < 止损；价格已越过任一价位或缺少当前价格时开仓改为观望。
+// 平仓、持有、观望决策原样保留。返回新的决策列表，不修改入参。
+func RevalidateEntries(decisions []Decision, prices map[string]float64) []Decision {
+	result := make([]Decision, len(decisions))
+	copy(result, decisions)
+
+	for i := range result {
+		d := &result[i]
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		price := prices[d.Symbol]
+		valid := price > 0
+		if valid && d.Action == "open_long" {
+			valid = (d.StopLoss <= 0 || d.StopLoss < price) && (d.TakeProfit <= 0 || price < d.TakeProfit)
+		}
+		if valid && d.Action == "open_short" {
+			valid = (d.StopLoss <= 0 || price < d.StopLoss) && (d.TakeProfit <= 0 || d.TakeProfit < price)
+		}
+		if !valid {
+			d.Action = "wait"
+			d.Confidence = 0
+			d.Reasoning = "[决策已过期，当前价格已越过止损止盈] " + d.Reasoning
+		}
+	}
+	return result
+}
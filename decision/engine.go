@@ -3,12 +3,13 @@ package decision
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"nofx/logger"
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // PositionInfo 持仓信息
@@ -35,6 +36,7 @@ type AccountInfo struct {
 	MarginUsed       float64 `json:"margin_used"`       // 已用保证金
 	MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
 	PositionCount    int     `json:"position_count"`    // 持仓数量
+	Currency         string  `json:"currency"`          // 账户计价币种（空=USDT，金额字段均已换算为USD）
 }
 
 // CandidateCoin 候选币种（来自币种池）
@@ -66,6 +68,31 @@ type Context struct {
 	Performance     interface{}             `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
 	BTCETHLeverage  int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
 	AltcoinLeverage int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
+
+	MinValidCandidates  int      `json:"-"` // 开仓所需的最少有效候选币种数（0=不限制）
+	EntriesBlocked      bool     `json:"-"` // 本周期是否禁止开新仓（有效数据不足）
+	MinConfidence       int      `json:"-"` // 开仓最低信心度（0-100，0=不限制，见 MeetsConfidence）
+	MinATRRatio         float64  `json:"-"` // 候选币种的最低ATR/价格比例（0=不限制）
+	ConfidencePerFactor int      `json:"-"` // 每个技术面确认/冲突因子调整的信心度点数（0=不调整）
+	MaxConfidence       int      `json:"-"` // 技术面加分后的信心度上限
+	RiskWarnings        []string `json:"-"` // 风险警告（显示在提示词中）
+	StrategyMode        string   `json:"-"` // 策略模式（见 StrategyModeTrend 等）
+	DecisionSamples     int      `json:"-"` // 每周期AI调用次数，>1 时按 AverageDecisions 合并
+	StreamAIResponse    bool     `json:"-"` // 单次调用时是否使用流式响应（见 mcp.Client.StreamAIDecision）
+	PlanTemplates       []string `json:"-"` // 可用的开仓计划模板名称（开仓决策可通过 plan_template 指定）
+	PromptBudgetChars   int      `json:"-"` // User Prompt 长度预算（字符数，0=不限制，见 buildUserPrompt）
+
+	PromptBudgetAllocation []int `json:"-"` // 本周期 User Prompt 各部分的最终预算（概况、账户持仓、候选币种）
+
+	// 风险摘要（见 BuildRiskContext）
+	PeakEquity       float64 `json:"-"` // 历史最高净值
+	DailyStartEquity float64 `json:"-"` // 当日起始净值
+	PortfolioHeat    string  `json:"-"` // 持仓中最高的风险热度等级（cool/warm/hot/critical）
+
+	RegimeMemory     *MarketRegimeMemory `json:"-"` // 跨周期的市场状态记忆（nil=每周期重新判断）
+	MarketRegime     string              `json:"-"` // 本周期市场状态（见 market.ClassifyRegime）
+	MarketBreadth    float64             `json:"-"` // 候选币种的市场广度评分（0-100，见 market.ComputeMarketBreadth）
+	RegimeConfidence float64             `json:"-"` // 市场状态置信度
 }
 
 // Decision AI的交易决策
@@ -79,6 +106,19 @@ type Decision struct {
 	Confidence      int     `json:"confidence,omitempty"` // 信心度 (0-100)
 	RiskUSD         float64 `json:"risk_usd,omitempty"`   // 最大美元风险
 	Reasoning       string  `json:"reasoning"`
+
+	// 波动率模型估算（见 EstimateHitProbabilities，由系统计算而非AI输出）
+	PStop   float64 `json:"p_stop,omitempty"`   // 先触及止损的概率
+	PTarget float64 `json:"p_target,omitempty"` // 先触及止盈的概率
+	ModelEV float64 `json:"model_ev,omitempty"` // 模型期望收益（USDT）
+
+	BreakEvenWinRate float64 `json:"break_even_win_rate,omitempty"` // 扣除手续费后的盈亏平衡胜率（0-1，由风控规则计算）
+
+	ConfidenceFactors []ConfidenceFactor `json:"confidence_factors,omitempty"` // 调整信心度的技术面因子（见 AdjustConfidence）
+
+	CloseRatio float64 `json:"close_ratio,omitempty"` // 平仓比例（0或1=全部平仓，由系统设置）
+
+	PlanTemplate string `json:"plan_template,omitempty"` // 开仓使用的计划模板名称（按模板分级入场，见 trader.PlanTemplate）
 }
 
 // FullDecision AI的完整决策（包含思维链）
@@ -88,6 +128,10 @@ type FullDecision struct {
 	CoTTrace     string     `json:"cot_trace"`     // 思维链分析（AI输出）
 	Decisions    []Decision `json:"decisions"`     // 具体决策列表
 	Timestamp    time.Time  `json:"timestamp"`
+	DataMs       int64      `json:"data_ms"` // 市场数据获取耗时（毫秒）
+	AIMs         int64      `json:"ai_ms"`   // AI调用耗时（毫秒）
+
+	PromptBudget []int `json:"prompt_budget,omitempty"` // User Prompt 各部分的预算分配（未设置预算时为空）
 }
 
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
@@ -98,29 +142,67 @@ func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error)
 // GetFullDecisionWithCustomPrompt 获取AI的完整交易决策（支持自定义prompt和模板选择）
 func GetFullDecisionWithCustomPrompt(ctx *Context, mcpClient *mcp.Client, customPrompt string, overrideBase bool, templateName string) (*FullDecision, error) {
 	// 1. 为所有币种获取市场数据
+	dataStart := time.Now()
 	if err := fetchMarketDataForContext(ctx); err != nil {
 		return nil, fmt.Errorf("获取市场数据失败: %w", err)
 	}
+	dataMs := time.Since(dataStart).Milliseconds()
+	resolveMarketRegime(ctx)
 
 	// 2. 构建 System Prompt（固定规则）和 User Prompt（动态数据）
 	systemPrompt := buildSystemPromptWithCustom(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, customPrompt, overrideBase, templateName)
-	userPrompt := buildUserPrompt(ctx)
-
-	// 3. 调用AI API（使用 system + user prompt）
-	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	userPrompt, err := buildCheckedUserPrompt(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("调用AI API失败: %w", err)
+		return nil, fmt.Errorf("构建User Prompt失败: %w", err)
 	}
 
-	// 4. 解析AI响应
-	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
-	if err != nil {
-		return decision, fmt.Errorf("解析AI响应失败: %w", err)
+	// 3. 调用AI API（使用 system + user prompt）并解析响应
+	var decision *FullDecision
+	if ctx.DecisionSamples > 1 {
+		// 多次调用按信心度合并，降低单次回答的随机性
+		decision, err = callAveraged(mcpClient, systemPrompt, userPrompt, ctx.DecisionSamples, ctx)
+		decision.DataMs = dataMs
+		if err != nil {
+			return decision, err
+		}
+	} else {
+		aiStart := time.Now()
+		var aiResponse string
+		if ctx.StreamAIResponse {
+			aiResponse, err = callStreaming(mcpClient, systemPrompt, userPrompt)
+		} else {
+			aiResponse, err = mcpClient.CallWithMessages(systemPrompt, userPrompt)
+		}
+		aiMs := time.Since(aiStart).Milliseconds()
+		if err != nil {
+			return &FullDecision{DataMs: dataMs, AIMs: aiMs}, fmt.Errorf("调用AI API失败: %w", classifyCallError(err))
+		}
+
+		// 4. 解析AI响应
+		decision, err = parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+		decision.DataMs = dataMs
+		decision.AIMs = aiMs
+		if err != nil {
+			return decision, fmt.Errorf("解析AI响应失败: %w", err)
+		}
+	}
+
+	// 5. 有效数据不足时禁止开新仓（仍允许平仓和持有）
+	if ctx.EntriesBlocked {
+		blockEntryDecisions(decision.Decisions)
 	}
 
+	// 6. 按多周期技术面一致程度调整信心度，不足门槛的开仓改为观望
+	adjustEntryConfidence(decision.Decisions, ctx.MarketDataMap, ctx.ConfidencePerFactor, ctx.MaxConfidence)
+	filterLowConfidenceEntries(decision.Decisions, ctx.MinConfidence)
+
+	// 7. 估算止损/止盈命中概率
+	annotateHitProbabilities(decision.Decisions, ctx.MarketDataMap)
+
 	decision.Timestamp = time.Now()
 	decision.SystemPrompt = systemPrompt // 保存系统prompt
 	decision.UserPrompt = userPrompt     // 保存输入prompt
+	decision.PromptBudget = ctx.PromptBudgetAllocation
 	return decision, nil
 }
 
@@ -160,24 +242,44 @@ func fetchMarketDataForContext(ctx *Context) error {
 			continue
 		}
 
+		isExistingPosition := positionSymbols[symbol]
+
+		// 价格数据异常的候选币种不参与决策（持仓币种保留，以便决策是否平仓）
+		if !isExistingPosition && !data.IsValid() {
+			logger.Warnf("⚠️  %s 市场数据无效，跳过此币种: %s", symbol, data.PriceError)
+			continue
+		}
+
 		// ⚠️ 流动性过滤：持仓价值低于15M USD的币种不做（多空都不做）
 		// 持仓价值 = 持仓量 × 当前价格
 		// 但现有持仓必须保留（需要决策是否平仓）
-		isExistingPosition := positionSymbols[symbol]
 		if !isExistingPosition && data.OpenInterest != nil && data.CurrentPrice > 0 {
 			// 计算持仓价值（USD）= 持仓量 × 当前价格
 			oiValue := data.OpenInterest.Latest * data.CurrentPrice
 			oiValueInMillions := oiValue / 1_000_000 // 转换为百万美元单位
 			if oiValueInMillions < 15 {
-				log.Printf("⚠️  %s 持仓价值过低(%.2fM USD < 15M)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
+				logger.Warnf("⚠️  %s 持仓价值过低(%.2fM USD < 15M)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
 					symbol, oiValueInMillions, data.OpenInterest.Latest, data.CurrentPrice)
 				continue
 			}
 		}
 
+		// 波动过滤：ATR占价格比例过低的币种几乎不动，无利可图且止损会过紧
+		if !isExistingPosition && belowMinATR(data, ctx.MinATRRatio) {
+			logger.Warnf("⚠️  %s 波动过低(ATR/价格 %.3f%% < %.3f%%)，跳过此币种",
+				symbol, data.ATRRatio()*100, ctx.MinATRRatio*100)
+			continue
+		}
+
+		if report := market.GetDataQualityReport(data); report.Completeness < 1 {
+			logger.Debugf("  %s 数据完整度 %.0f%%，缺失: %s", symbol, report.Completeness*100, strings.Join(report.MissingFields, ", "))
+		}
 		ctx.MarketDataMap[symbol] = data
 	}
 
+	// 有效候选币种数量检查：数据源整体异常时，只剩个别币种有数据，此时开仓过于草率
+	checkValidCandidates(ctx)
+
 	// 加载OI Top数据（不影响主流程）
 	oiPositions, err := pool.GetOITopPositions()
 	if err == nil {
@@ -245,11 +347,11 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 	template, err := GetPromptTemplate(templateName)
 	if err != nil {
 		// 如果模板不存在，记录错误并使用 default
-		log.Printf("⚠️  提示词模板 '%s' 不存在，使用 default: %v", templateName, err)
+		logger.Warnf("⚠️  提示词模板 '%s' 不存在，使用 default: %v", templateName, err)
 		template, err = GetPromptTemplate("default")
 		if err != nil {
 			// 如果连 default 都不存在，使用内置的简化版本
-			log.Printf("❌ 无法加载任何提示词模板，使用内置简化版本")
+			logger.Errorf("❌ 无法加载任何提示词模板，使用内置简化版本")
 			sb.WriteString("你是专业的加密货币交易AI。请根据市场数据做出交易决策。\n\n")
 		} else {
 			sb.WriteString(template.Content)
@@ -277,18 +379,46 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 	sb.WriteString(fmt.Sprintf("  {\"symbol\": \"BTCUSDT\", \"action\": \"open_short\", \"leverage\": %d, \"position_size_usd\": %.0f, \"stop_loss\": 97000, \"take_profit\": 91000, \"confidence\": 85, \"risk_usd\": 300, \"reasoning\": \"下跌趋势+MACD死叉\"},\n", btcEthLeverage, accountEquity*5))
 	sb.WriteString("  {\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"止盈离场\"}\n")
 	sb.WriteString("]\n```\n\n")
-	sb.WriteString("字段说明:\n")
-	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | hold | wait\n")
-	sb.WriteString("- `confidence`: 0-100（开仓建议≥75）\n")
-	sb.WriteString("- 开仓时必填: leverage, position_size_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n\n")
+	sb.WriteString(DecisionSchemaPrompt())
+	sb.WriteString("\n")
 
 	return sb.String()
 }
 
 // buildUserPrompt 构建 User Prompt（动态数据）
+// 设置了 PromptBudgetChars 时，概况、账户持仓、候选币种三部分按 mcp.TokenBudgetManager 分配的预算分别截断，
+// 前一部分没用完的预算按权重分给后面的部分
 func buildUserPrompt(ctx *Context) string {
+	sections := []string{
+		buildOverviewSection(ctx),
+		buildPositionsSection(ctx),
+		buildCandidatesSection(ctx),
+	}
+	if ctx.PromptBudgetChars > 0 {
+		sections, ctx.PromptBudgetAllocation = fitPromptBudget(sections, ctx.PromptBudgetChars)
+	}
+	return strings.Join(sections, "") + "---\n\n现在请分析并输出决策（思维链 + JSON）\n"
+}
+
+// fitPromptBudget 按预算截断各部分，返回截断后的内容和最终的预算分配
+func fitPromptBudget(sections []string, totalBudget int) ([]string, []int) {
+	budget := mcp.NewTokenBudgetManager()
+	budget.AllocateBudget(totalBudget, len(sections))
+	fitted := make([]string, len(sections))
+	for i, section := range sections {
+		fitted[i] = mcp.TruncatePrompt(section, budget.CurrentBudgetAllocation()[i])
+		budget.Release(i, utf8.RuneCountInString(fitted[i]))
+	}
+	return fitted, budget.CurrentBudgetAllocation()
+}
+
+// buildOverviewSection 概况：风险摘要、系统状态、BTC行情、市场状态和策略提示
+func buildOverviewSection(ctx *Context) string {
 	var sb strings.Builder
 
+	// 风险摘要
+	sb.WriteString(BuildRiskContext(ctx) + "\n")
+
 	// 系统状态
 	sb.WriteString(fmt.Sprintf("时间: %s | 周期: #%d | 运行: %d分钟\n\n",
 		ctx.CurrentTime, ctx.CallCount, ctx.RuntimeMinutes))
@@ -299,6 +429,27 @@ func buildUserPrompt(ctx *Context) string {
 			btcData.CurrentPrice, btcData.PriceChange1h, btcData.PriceChange4h,
 			btcData.CurrentMACD, btcData.CurrentRSI7))
 	}
+	if ctx.MarketRegime != "" {
+		sb.WriteString(fmt.Sprintf("市场状态: %s（置信度 %.2f）| 市场广度: %.0f/100\n", regimeName(ctx.MarketRegime), ctx.RegimeConfidence, ctx.MarketBreadth))
+		if market.BreadthDiverges(ctx.MarketRegime, ctx.MarketBreadth) {
+			sb.WriteString("⚠️ BTC走势与多数币种背离，趋势判断可靠性较低\n")
+		}
+		sb.WriteString("\n")
+	}
+	if guidance := strategyModeGuidance(ctx.StrategyMode); guidance != "" {
+		sb.WriteString(guidance + "\n\n")
+	}
+	if len(ctx.PlanTemplates) > 0 {
+		sb.WriteString(fmt.Sprintf("可用开仓计划模板: %s（开仓决策可加 \"plan_template\": \"模板名\" 按模板分级入场）\n\n",
+			strings.Join(ctx.PlanTemplates, ", ")))
+	}
+
+	return sb.String()
+}
+
+// buildPositionsSection 账户、持仓和风险警告
+func buildPositionsSection(ctx *Context) string {
+	var sb strings.Builder
 
 	// 账户
 	sb.WriteString(fmt.Sprintf("账户: 净值%.2f | 余额%.2f (%.1f%%) | 盈亏%+.2f%% | 保证金%.1f%% | 持仓%d个\n\n",
@@ -342,7 +493,22 @@ func buildUserPrompt(ctx *Context) string {
 		sb.WriteString("当前持仓: 无\n\n")
 	}
 
+	// 风险警告
+	for _, warning := range ctx.RiskWarnings {
+		sb.WriteString(fmt.Sprintf("🚨 %s\n\n", warning))
+	}
+
+	return sb.String()
+}
+
+// buildCandidatesSection 候选币种和历史表现
+func buildCandidatesSection(ctx *Context) string {
+	var sb strings.Builder
+
 	// 候选币种（完整市场数据）
+	if ctx.EntriesBlocked {
+		sb.WriteString("⚠️ 本周期市场数据不完整，禁止开新仓，只需管理现有持仓\n\n")
+	}
 	sb.WriteString(fmt.Sprintf("## 候选币种 (%d个)\n\n", len(ctx.MarketDataMap)))
 	displayedCount := 0
 	for _, coin := range ctx.CandidateCoins {
@@ -380,9 +546,6 @@ func buildUserPrompt(ctx *Context) string {
 		}
 	}
 
-	sb.WriteString("---\n\n")
-	sb.WriteString("现在请分析并输出决策（思维链 + JSON）\n")
-
 	return sb.String()
 }
 
@@ -397,7 +560,7 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: []Decision{},
-		}, fmt.Errorf("提取决策失败: %w", err)
+		}, fmt.Errorf("提取决策失败: %w", newDecisionError(ErrAIMalformed, err))
 	}
 
 	// 3. 验证决策
@@ -405,7 +568,7 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: decisions,
-		}, fmt.Errorf("决策验证失败: %w", err)
+		}, fmt.Errorf("决策验证失败: %w", newDecisionError(ErrAISchema, err))
 	}
 
 	return &FullDecision{
@@ -428,6 +591,11 @@ func extractCoTTrace(response string) string {
 	return strings.TrimSpace(response)
 }
 
+// maxDecisionJSONBytes 决策JSON数组的长度上限
+// AI调用的 max_tokens 为2000，正常的决策数组远小于此值；超长内容（如 reasoning 中同一个字重复上万次）
+// 是模型陷入重复输出，不再完整解码，直接按格式错误处理
+const maxDecisionJSONBytes = 16 << 10
+
 // extractDecisions 提取JSON决策列表
 func extractDecisions(response string) ([]Decision, error) {
 	// 直接查找JSON数组 - 找第一个完整的JSON数组
@@ -442,6 +610,9 @@ func extractDecisions(response string) ([]Decision, error) {
 		return nil, fmt.Errorf("无法找到JSON数组结束")
 	}
 
+	if size := arrayEnd + 1 - arrayStart; size > maxDecisionJSONBytes {
+		return nil, fmt.Errorf("JSON数组过长（%d 字节，上限 %d 字节），模型可能陷入了重复输出", size, maxDecisionJSONBytes)
+	}
 	jsonContent := strings.TrimSpace(response[arrayStart : arrayEnd+1])
 
 	// 🔧 修复常见的JSON格式错误：缺少引号的字段值
@@ -450,11 +621,17 @@ func extractDecisions(response string) ([]Decision, error) {
 	// 使用简单的字符串扫描而不是正则表达式
 	jsonContent = fixMissingQuotes(jsonContent)
 
-	// 解析JSON
-	var decisions []Decision
-	if err := json.Unmarshal([]byte(jsonContent), &decisions); err != nil {
+	// 按Schema严格解析（错误信息带字段路径），不合格的决策逐条丢弃
+	decisions, invalid, err := ParseDecisionsStrict(jsonContent)
+	if err != nil {
 		return nil, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
 	}
+	for _, verr := range invalid {
+		logger.Warnf("⚠️  丢弃不符合Schema的决策 %s", verr.Error())
+	}
+	if len(decisions) == 0 && len(invalid) > 0 {
+		return nil, fmt.Errorf("全部 %d 条决策不符合Schema（%s）\nJSON内容: %s", len(invalid), invalid[0].Error(), jsonContent)
+	}
 
 	return decisions, nil
 }
@@ -478,6 +655,39 @@ func validateDecisions(decisions []Decision, accountEquity float64, btcEthLevera
 	return nil
 }
 
+// belowMinATR ATR/价格比例是否低于 minRatio（minRatio<=0 表示不限制）
+func belowMinATR(data *market.Data, minRatio float64) bool {
+	return minRatio > 0 && data.ATRRatio() < minRatio
+}
+
+// checkValidCandidates 有效候选币种（已取得市场数据）少于 MinValidCandidates 时标记本周期禁止开仓
+func checkValidCandidates(ctx *Context) {
+	if ctx.MinValidCandidates <= 0 {
+		return
+	}
+	validCount := 0
+	for _, coin := range ctx.CandidateCoins {
+		if _, ok := ctx.MarketDataMap[coin.Symbol]; ok {
+			validCount++
+		}
+	}
+	if validCount < ctx.MinValidCandidates {
+		ctx.EntriesBlocked = true
+		logger.Warnf("⚠️  有效候选币种仅 %d 个（要求至少 %d 个），本周期禁止开新仓", validCount, ctx.MinValidCandidates)
+	}
+}
+
+// blockEntryDecisions 将开仓决策改为观望（平仓和持有决策不受影响）
+func blockEntryDecisions(decisions []Decision) {
+	for i := range decisions {
+		if decisions[i].Action == "open_long" || decisions[i].Action == "open_short" {
+			logger.Warnf("⚠️  有效数据不足，%s %s 改为观望", decisions[i].Symbol, decisions[i].Action)
+			decisions[i].Action = "wait"
+			decisions[i].Reasoning = "[有效数据不足，禁止开仓] " + decisions[i].Reasoning
+		}
+	}
+}
+
 // findMatchingBracket 查找匹配的右括号
 func findMatchingBracket(s string, start int) int {
 	if start >= len(s) || s[start] != '[' {
@@ -0,0 +1,50 @@
+package decision
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AI决策失败的类型（配合 errors.Is 使用）
+var (
+	ErrAITimeout     = errors.New("AI调用超时")
+	ErrAIRateLimited = errors.New("AI调用被限流")
+	ErrAIMalformed   = errors.New("AI响应格式错误")
+	ErrAISchema      = errors.New("AI决策不符合约束")
+)
+
+// DecisionError 带失败类型的决策错误
+// 调用方可以根据类型选择不同的处理方式（超时可重试/复用旧决策，格式错误可回退，约束违规直接放弃）
+type DecisionError struct {
+	Kind error // ErrAITimeout / ErrAIRateLimited / ErrAIMalformed / ErrAISchema
+	Err  error // 原始错误
+}
+
+func (e *DecisionError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+// Unwrap 同时暴露失败类型和原始错误
+func (e *DecisionError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// newDecisionError 包装错误并标记失败类型
+func newDecisionError(kind, err error) error {
+	return &DecisionError{Kind: kind, Err: err}
+}
+
+// classifyCallError 识别AI调用错误中的超时和限流，其他错误（鉴权失败、熔断等）原样返回
+func classifyCallError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return newDecisionError(ErrAITimeout, err)
+	}
+	// mcp 客户端以 "API返回错误 (status 429)" 的形式返回HTTP错误
+	if strings.Contains(err.Error(), "status 429") {
+		return newDecisionError(ErrAIRateLimited, err)
+	}
+	return err
+}
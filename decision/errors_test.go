@@ -0,0 +1,67 @@
+package decision
+
+import (
+	"errors"
+	"fmt"
+	"nofx/mcp"
+	"strings"
+	"testing"
+)
+
+// timeoutErr 模拟超时的网络错误
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestClassifyCallError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		kind error // nil 表示不归类（致命错误，原样返回）
+	}{
+		{"network timeout is retryable", fmt.Errorf("发送请求失败: %w", timeoutErr{}), ErrAITimeout},
+		{"rate limit", fmt.Errorf("API返回错误 (status 429): too many requests"), ErrAIRateLimited},
+		{"auth failure is fatal", fmt.Errorf("API返回错误 (status 401): invalid api key"), nil},
+		{"server error is not classified", fmt.Errorf("API返回错误 (status 500): internal"), nil},
+		{"open circuit passes through", mcp.ErrCircuitOpen, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := classifyCallError(tc.err)
+			if !errors.Is(got, tc.err) {
+				t.Errorf("classified error %v no longer wraps the original", got)
+			}
+			for _, kind := range []error{ErrAITimeout, ErrAIRateLimited, ErrAIMalformed, ErrAISchema} {
+				if want := kind == tc.kind; errors.Is(got, kind) != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", got, kind, !want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFullDecisionResponseErrorKinds(t *testing.T) {
+	cases := []struct {
+		name     string
+		response string
+		kind     error
+	}{
+		{"no json array", "市场震荡，暂不操作", ErrAIMalformed},
+		{"repeated output", `[{"symbol":"BTCUSDT","action":"wait","reasoning":"` + strings.Repeat("长", maxDecisionJSONBytes/3) + `"}]`, ErrAIMalformed},
+		{"leverage over the limit", `[{"symbol":"SOLUSDT","action":"open_long","leverage":50,"position_size_usd":100,"stop_loss":90,"take_profit":120,"confidence":80,"risk_usd":10,"reasoning":"突破"}]`, ErrAISchema},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseFullDecisionResponse(tc.response, 1000, 10, 5)
+			if !errors.Is(err, tc.kind) {
+				t.Errorf("err = %v, want kind %v", err, tc.kind)
+			}
+			var decisionErr *DecisionError
+			if !errors.As(err, &decisionErr) || decisionErr.Kind != tc.kind {
+				t.Errorf("err = %v, want a *DecisionError of kind %v", err, tc.kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package decision
+
+import (
+	"math"
+	"nofx/logger"
+	"nofx/market"
+)
+
+// hitProbabilityHorizon 估算止损/止盈命中概率的时间范围（4小时K线根数，6根=24小时）
+const hitProbabilityHorizon = 6
+
+// hitProbabilitySeriesTerms 级数展开的项数
+const hitProbabilitySeriesTerms = 200
+
+// EstimateHitProbabilities 用波动率模型估算在 horizonCandles 根K线内先触及止损、先触及止盈的概率
+//
+// 模型假设:
+//   - 价格为无漂移的布朗运动（随机游走），不考虑趋势、跳空和手续费
+//   - 每根K线的价格标准差近似等于 ATR
+//   - 止损和止盈是两个吸收边界，先到者生效；两者之外的概率为到期都未触及
+//
+// 无时间限制时先触及止盈的概率为 止损距离/(止损距离+止盈距离)，
+// 有限时间内的结果由双边界首达时间的级数解给出。参数无效时返回 (0, 0)
+func EstimateHitProbabilities(entry, stop, target, atr float64, horizonCandles int) (pStop, pTarget float64) {
+	stopDist := math.Abs(entry - stop)
+	targetDist := math.Abs(target - entry)
+	if stopDist == 0 || targetDist == 0 || atr <= 0 || horizonCandles <= 0 {
+		return 0, 0
+	}
+	// 止损和止盈必须在入场价两侧
+	if (stop-entry)*(target-entry) >= 0 {
+		return 0, 0
+	}
+
+	width := stopDist + targetDist
+	variance := atr * atr * float64(horizonCandles)
+
+	pTarget = firstExitProbability(stopDist, width, variance)
+	pStop = firstExitProbability(targetDist, width, variance)
+	return pStop, pTarget
+}
+
+// firstExitProbability 布朗运动从 x（距下边界0的距离）出发，在方差 variance 内先触及上边界 width 的概率
+// P = x/L + Σ 2(-1)^n/(nπ) · sin(nπx/L) · exp(-n²π²·variance/(2L²))
+func firstExitProbability(x, width, variance float64) float64 {
+	y := x / width
+	p := y
+	for n := 1; n <= hitProbabilitySeriesTerms; n++ {
+		nPi := float64(n) * math.Pi
+		decay := math.Exp(-nPi * nPi * variance / (2 * width * width))
+		if decay < 1e-12 {
+			break
+		}
+		sign := 1.0
+		if n%2 == 1 {
+			sign = -1.0
+		}
+		p += sign * 2 / nPi * math.Sin(nPi*y) * decay
+	}
+	return math.Max(0, math.Min(1, p))
+}
+
+// annotateHitProbabilities 为开仓决策计算止损/止盈命中概率和模型期望收益（不依赖AI信心度）
+func annotateHitProbabilities(decisions []Decision, marketDataMap map[string]*market.Data) {
+	for i := range decisions {
+		d := &decisions[i]
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		data, ok := marketDataMap[d.Symbol]
+		if !ok || data.CurrentPrice <= 0 || data.LongerTermContext == nil {
+			continue
+		}
+
+		entry := data.CurrentPrice
+		d.PStop, d.PTarget = EstimateHitProbabilities(entry, d.StopLoss, d.TakeProfit, data.LongerTermContext.ATR14, hitProbabilityHorizon)
+		if d.PStop == 0 && d.PTarget == 0 {
+			continue
+		}
+
+		gain := d.PositionSizeUSD * math.Abs(d.TakeProfit-entry) / entry
+		loss := d.PositionSizeUSD * math.Abs(entry-d.StopLoss) / entry
+		d.ModelEV = d.PTarget*gain - d.PStop*loss
+		logger.Infof("🎲 %s %s 24h内触及止盈 %.0f%% / 止损 %.0f%%，模型期望收益 %.2f USDT",
+			d.Symbol, d.Action, d.PTarget*100, d.PStop*100, d.ModelEV)
+	}
+}
@@ -0,0 +1,76 @@
+package decision
+
+import (
+	"math"
+	"nofx/market"
+	"testing"
+)
+
+func TestEstimateHitProbabilitiesLongHorizon(t *testing.T) {
+	// ATR远大于止损止盈距离时几乎必然触及其一，先触及止盈的概率 = 止损距离/(止损距离+止盈距离)
+	pStop, pTarget := EstimateHitProbabilities(100, 99, 103, 50, 6)
+	if math.Abs(pTarget-0.25) > 1e-6 || math.Abs(pStop-0.75) > 1e-6 {
+		t.Errorf("long pStop/pTarget = %.4f/%.4f, want 0.75/0.25", pStop, pTarget)
+	}
+
+	// 空单方向对称
+	pStop, pTarget = EstimateHitProbabilities(100, 101, 97, 50, 6)
+	if math.Abs(pTarget-0.25) > 1e-6 || math.Abs(pStop-0.75) > 1e-6 {
+		t.Errorf("short pStop/pTarget = %.4f/%.4f, want 0.75/0.25", pStop, pTarget)
+	}
+}
+
+func TestEstimateHitProbabilitiesShortHorizon(t *testing.T) {
+	// 止损止盈各10个ATR，6根K线内基本都不会触及
+	pStop, pTarget := EstimateHitProbabilities(100, 90, 110, 1, 6)
+	if pStop > 0.01 || pTarget > 0.01 {
+		t.Errorf("pStop/pTarget = %.4f/%.4f, want both near 0", pStop, pTarget)
+	}
+
+	// 对称距离时两者相等，且合计不超过1
+	pStop, pTarget = EstimateHitProbabilities(100, 98, 102, 1, 6)
+	if math.Abs(pStop-pTarget) > 1e-9 || pStop+pTarget > 1 || pStop <= 0 {
+		t.Errorf("symmetric pStop/pTarget = %.4f/%.4f, want equal, positive and summing to <= 1", pStop, pTarget)
+	}
+}
+
+func TestEstimateHitProbabilitiesInvalidInputs(t *testing.T) {
+	cases := []struct {
+		name                     string
+		entry, stop, target, atr float64
+		horizon                  int
+	}{
+		{"stop and target on the same side", 100, 98, 99, 1, 6},
+		{"stop at entry", 100, 100, 105, 1, 6},
+		{"no atr", 100, 98, 102, 0, 6},
+		{"no horizon", 100, 98, 102, 1, 0},
+	}
+	for _, tc := range cases {
+		if pStop, pTarget := EstimateHitProbabilities(tc.entry, tc.stop, tc.target, tc.atr, tc.horizon); pStop != 0 || pTarget != 0 {
+			t.Errorf("%s: got %.4f/%.4f, want 0/0", tc.name, pStop, pTarget)
+		}
+	}
+}
+
+func TestAnnotateHitProbabilitiesModelEV(t *testing.T) {
+	decisions := []Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 99, TakeProfit: 104, PositionSizeUSD: 1000},
+		{Symbol: "BTCUSDT", Action: "close_long", StopLoss: 99, TakeProfit: 104},
+	}
+	data := map[string]*market.Data{
+		"BTCUSDT": {CurrentPrice: 100, LongerTermContext: &market.LongerTermData{ATR14: 50}},
+	}
+	annotateHitProbabilities(decisions, data)
+
+	d := decisions[0]
+	if math.Abs(d.PTarget-0.2) > 1e-6 || math.Abs(d.PStop-0.8) > 1e-6 {
+		t.Errorf("pStop/pTarget = %.4f/%.4f, want 0.8/0.2", d.PStop, d.PTarget)
+	}
+	// 无漂移模型下 0.2×40 - 0.8×10 = 0
+	if math.Abs(d.ModelEV) > 1e-3 {
+		t.Errorf("ModelEV = %.4f, want 0", d.ModelEV)
+	}
+	if decisions[1].PStop != 0 || decisions[1].PTarget != 0 {
+		t.Error("close decision annotated")
+	}
+}
@@ -0,0 +1,30 @@
+package decision
+
+import (
+	"nofx/market"
+	"testing"
+)
+
+func TestBelowMinATR(t *testing.T) {
+	withATR := func(atr float64) *market.Data {
+		return &market.Data{CurrentPrice: 100, LongerTermContext: &market.LongerTermData{ATR14: atr}}
+	}
+
+	cases := []struct {
+		name     string
+		data     *market.Data
+		minRatio float64
+		want     bool
+	}{
+		{"below the threshold", withATR(0.2), 0.003, true},
+		{"at the threshold", withATR(0.3), 0.003, false},
+		{"above the threshold", withATR(1.5), 0.003, false},
+		{"filter disabled", withATR(0.01), 0, false},
+		{"no longer-term data", &market.Data{CurrentPrice: 100}, 0.003, true},
+	}
+	for _, tc := range cases {
+		if got := belowMinATR(tc.data, tc.minRatio); got != tc.want {
+			t.Errorf("%s: belowMinATR = %v, want %v (ratio %.4f)", tc.name, got, tc.want, tc.data.ATRRatio())
+		}
+	}
+}
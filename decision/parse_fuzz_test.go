@@ -0,0 +1,15 @@
+package decision
+
+import "testing"
+
+// FuzzParseFullDecisionResponse AI响应来自外部，任意畸形输入都只能返回错误，不能panic
+// 种子语料（合法响应、截断JSON、多余字段、越界信心度、空字节、超长字符串、嵌套对象等）见 testdata/fuzz/FuzzParseFullDecisionResponse；
+// repeated_reasoning 是模型重复输出同一个字的响应，曾让每次解析耗时数毫秒、模糊测试停滞
+func FuzzParseFullDecisionResponse(f *testing.F) {
+	f.Fuzz(func(t *testing.T, response string) {
+		decision, err := parseFullDecisionResponse(response, 1000, 10, 5)
+		if err == nil && decision == nil {
+			t.Fatal("nil decision without error")
+		}
+	})
+}
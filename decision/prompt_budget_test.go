@@ -0,0 +1,70 @@
+package decision
+
+import (
+	"nofx/market"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFitPromptBudgetTruncatesEachSection(t *testing.T) {
+	sections := []string{strings.Repeat("a", 600), strings.Repeat("b", 600), strings.Repeat("c", 600)}
+	fitted, allocation := fitPromptBudget(sections, 1000)
+
+	for i, want := range []int{400, 300, 300} {
+		if n := utf8.RuneCountInString(fitted[i]); n != want {
+			t.Errorf("section %d length = %d, want %d", i, n, want)
+		}
+		if allocation[i] != want {
+			t.Errorf("allocation[%d] = %d, want %d", i, allocation[i], want)
+		}
+	}
+}
+
+func TestFitPromptBudgetRedistributesUnusedBudget(t *testing.T) {
+	// 概况只用了100，剩余300按权重平分给账户持仓和候选币种
+	sections := []string{strings.Repeat("a", 100), strings.Repeat("b", 500), strings.Repeat("c", 800)}
+	fitted, allocation := fitPromptBudget(sections, 1000)
+
+	if fitted[0] != sections[0] {
+		t.Error("section within budget was truncated")
+	}
+	if n := utf8.RuneCountInString(fitted[1]); n != 450 {
+		t.Errorf("positions length = %d, want 450", n)
+	}
+	// 候选币种同样得到自己的300加上概况让出的150
+	if n := utf8.RuneCountInString(fitted[2]); n != 450 {
+		t.Errorf("candidates length = %d, want 450", n)
+	}
+	if allocation[0] != 100 || allocation[1] != 450 || allocation[2] != 450 {
+		t.Errorf("allocation = %v, want [100 450 450]", allocation)
+	}
+}
+
+func TestBuildUserPromptRespectsBudget(t *testing.T) {
+	ctx := &Context{
+		Account:        AccountInfo{TotalEquity: 1000, AvailableBalance: 800},
+		CandidateCoins: []CandidateCoin{{Symbol: "BTCUSDT"}},
+		MarketDataMap:  map[string]*market.Data{"BTCUSDT": {Symbol: "BTCUSDT", CurrentPrice: 100}},
+	}
+	full := buildUserPrompt(ctx)
+	if ctx.PromptBudgetAllocation != nil {
+		t.Errorf("allocation without a budget = %v", ctx.PromptBudgetAllocation)
+	}
+
+	ctx.PromptBudgetChars = utf8.RuneCountInString(full) / 2
+	limited := buildUserPrompt(ctx)
+	if utf8.RuneCountInString(limited) >= utf8.RuneCountInString(full) {
+		t.Error("budgeted prompt was not shorter")
+	}
+	if !strings.HasSuffix(limited, "现在请分析并输出决策（思维链 + JSON）\n") {
+		t.Error("closing instruction was truncated")
+	}
+	total := 0
+	for _, n := range ctx.PromptBudgetAllocation {
+		total += n
+	}
+	if len(ctx.PromptBudgetAllocation) != 3 || total > ctx.PromptBudgetChars {
+		t.Errorf("allocation = %v, want 3 sections within %d", ctx.PromptBudgetAllocation, ctx.PromptBudgetChars)
+	}
+}
@@ -0,0 +1,87 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 提示词质量评估参数
+const (
+	maxPromptTokens        = 60000 // User Prompt 估算token上限
+	promptTokenScale       = 1.3   // 按空白分词后的token放大系数
+	minPromptQualityScore  = 0.5   // 低于此分数不提交给AI
+	promptSectionWeight    = 0.7   // 必需章节在总分中的权重
+	promptLengthLimitScore = 0.3   // 长度未超限的得分
+)
+
+// promptSection User Prompt 中必须出现的章节（任一标记出现即视为存在）
+type promptSection struct {
+	Name    string
+	Markers []string
+}
+
+var requiredPromptSections = []promptSection{
+	{Name: "account", Markers: []string{"账户: "}},
+	{Name: "positions", Markers: []string{"## 当前持仓", "当前持仓: 无"}}, // 无持仓时也要明确告知
+	{Name: "candidate", Markers: []string{"### 1. "}},            // 至少一个候选币种
+}
+
+// PromptQualityScore 提示词质量评估结果
+type PromptQualityScore struct {
+	TokenEstimate       int      `json:"token_estimate"`
+	HasRequiredSections bool     `json:"has_required_sections"`
+	IsWithinLengthLimit bool     `json:"is_within_length_limit"`
+	MissingFields       []string `json:"missing_fields"`
+	Score               float64  `json:"score"` // 0-1，1=章节齐全且未超长
+}
+
+// LowQualityPromptError 提示词质量过低，不提交给AI
+type LowQualityPromptError struct {
+	Quality *PromptQualityScore
+}
+
+func (e *LowQualityPromptError) Error() string {
+	return fmt.Sprintf("提示词质量过低（%.2f < %.2f），缺少: %s，估算 %d tokens",
+		e.Quality.Score, minPromptQualityScore, strings.Join(e.Quality.MissingFields, ", "), e.Quality.TokenEstimate)
+}
+
+// ScorePromptQuality 提交前对 User Prompt 做启发式质量评估
+// token数按空白分词 × 1.3 估算；必需章节：账户状态、持仓信息（可为空）、至少一个候选币种
+func ScorePromptQuality(prompt string) *PromptQualityScore {
+	score := &PromptQualityScore{
+		TokenEstimate: int(float64(len(strings.Fields(prompt))) * promptTokenScale),
+		MissingFields: []string{},
+	}
+	score.IsWithinLengthLimit = score.TokenEstimate <= maxPromptTokens
+
+	for _, section := range requiredPromptSections {
+		found := false
+		for _, marker := range section.Markers {
+			if strings.Contains(prompt, marker) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			score.MissingFields = append(score.MissingFields, section.Name)
+		}
+	}
+	score.HasRequiredSections = len(score.MissingFields) == 0
+
+	present := len(requiredPromptSections) - len(score.MissingFields)
+	score.Score = promptSectionWeight * float64(present) / float64(len(requiredPromptSections))
+	if score.IsWithinLengthLimit {
+		score.Score += promptLengthLimitScore
+	}
+	return score
+}
+
+// buildCheckedUserPrompt 构建 User Prompt 并检查质量，过低时返回 LowQualityPromptError
+func buildCheckedUserPrompt(ctx *Context) (string, error) {
+	prompt := buildUserPrompt(ctx)
+	quality := ScorePromptQuality(prompt)
+	if quality.Score < minPromptQualityScore {
+		return prompt, &LowQualityPromptError{Quality: quality}
+	}
+	return prompt, nil
+}
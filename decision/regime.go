@@ -0,0 +1,91 @@
+package decision
+
+import (
+	"math"
+	"nofx/logger"
+	"nofx/market"
+	"sync"
+)
+
+// 市场状态记忆参数
+const (
+	regimeMaxAge        = 3    // 记住的状态最多沿用的周期数
+	regimeMinConfidence = 0.85 // 置信度高于此值才沿用
+	regimeDecay         = 0.95 // 每沿用一个周期置信度的衰减系数
+)
+
+// MarketRegimeMemory 跨周期记住市场状态
+// 趋势在几个周期内不会突变：上次判断置信度足够高时直接沿用（置信度按 0.95^周期数 衰减），
+// 避免每个周期都重新判断导致状态在临界点来回跳动
+type MarketRegimeMemory struct {
+	LastRegime       string  `json:"last_regime"`
+	RegimeAge        int     `json:"regime_age"`        // 沿用的周期数（0=本周期重新判断）
+	RegimeConfidence float64 `json:"regime_confidence"` // 最近一次判断的置信度
+
+	mu sync.Mutex
+}
+
+// Resolve 返回本周期的市场状态和置信度；满足沿用条件时不调用 classify
+func (m *MarketRegimeMemory) Resolve(classify func() (string, float64)) (string, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.LastRegime != "" && m.RegimeAge < regimeMaxAge && m.RegimeConfidence > regimeMinConfidence {
+		m.RegimeAge++
+		return m.LastRegime, m.RegimeConfidence * math.Pow(regimeDecay, float64(m.RegimeAge))
+	}
+
+	regime, confidence := classify()
+	if regime != m.LastRegime && m.LastRegime != "" && regime != "" {
+		logger.Infof("🧭 市场状态变化: %s → %s（置信度 %.2f）", m.LastRegime, regime, confidence)
+	}
+	m.LastRegime = regime
+	m.RegimeAge = 0
+	m.RegimeConfidence = confidence
+	return regime, confidence
+}
+
+// Status 当前记住的市场状态及已沿用的周期数
+func (m *MarketRegimeMemory) Status() (string, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.LastRegime, m.RegimeAge
+}
+
+// resolveMarketRegime 用BTC行情判断本周期的市场状态，并用候选币种计算市场广度交叉验证（写入 ctx）
+func resolveMarketRegime(ctx *Context) {
+	candidates := make([]*market.Data, 0, len(ctx.CandidateCoins))
+	for _, coin := range ctx.CandidateCoins {
+		if data, ok := ctx.MarketDataMap[coin.Symbol]; ok {
+			candidates = append(candidates, data)
+		}
+	}
+	ctx.MarketBreadth = market.ComputeMarketBreadth(candidates)
+
+	btcData, hasBTC := ctx.MarketDataMap["BTCUSDT"]
+	if !hasBTC {
+		return
+	}
+	classify := func() (string, float64) { return market.ClassifyRegime(btcData) }
+	if ctx.RegimeMemory == nil {
+		ctx.MarketRegime, ctx.RegimeConfidence = classify()
+		return
+	}
+	ctx.MarketRegime, ctx.RegimeConfidence = ctx.RegimeMemory.Resolve(classify)
+}
+
+// regimeName 市场状态的中文名称
+func regimeName(regime string) string {
+	switch regime {
+	case market.RegimeTrendingUp:
+		return "上升趋势"
+	case market.RegimeTrendingDown:
+		return "下降趋势"
+	case market.RegimeRanging:
+		return "震荡"
+	case market.RegimeVolatile:
+		return "剧烈波动"
+	default:
+		return regime
+	}
+}
@@ -0,0 +1,69 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 风险摘要参数
+const (
+	maxRiskContextLen          = 50   // 风险摘要最大长度（字符）
+	defensiveDrawdownPct       = 15.0 // 回撤超过此值进入防御模式
+	defensiveModeFlag          = "[DEFENSIVE_MODE]"
+	riskContextEquityThreshold = 10000 // 净值达到此值时以k为单位显示
+)
+
+// BuildRiskContext 生成一行紧凑的风险摘要（不超过50个字符），放在 User Prompt 第一行
+// 如 "Equity:$9500(↓5%) DD:8% DailyLoss:5% Heat:HOT"；
+// 持仓热度为 hot/critical 或回撤超过15%时追加 [DEFENSIVE_MODE]，提示AI采取保守操作
+func BuildRiskContext(ctx *Context) string {
+	equity := ctx.Account.TotalEquity
+
+	drawdownPct := 0.0
+	if ctx.PeakEquity > 0 && equity < ctx.PeakEquity {
+		drawdownPct = (ctx.PeakEquity - equity) / ctx.PeakEquity * 100
+	}
+	dailyLossPct := 0.0
+	if ctx.DailyStartEquity > 0 && equity < ctx.DailyStartEquity {
+		dailyLossPct = (ctx.DailyStartEquity - equity) / ctx.DailyStartEquity * 100
+	}
+	heat := strings.ToUpper(ctx.PortfolioHeat)
+	if heat == "" {
+		heat = "COOL"
+	}
+
+	// 按重要性从低到高排列，超长时从前往后丢弃
+	parts := []string{
+		fmt.Sprintf("Equity:%s(%s)", formatRiskEquity(equity), formatSignedPct(ctx.Account.TotalPnLPct)),
+		fmt.Sprintf("DD:%.0f%%", drawdownPct),
+		fmt.Sprintf("DailyLoss:%.0f%%", dailyLossPct),
+		"Heat:" + heat,
+	}
+	if heat == "HOT" || heat == "CRITICAL" || drawdownPct > defensiveDrawdownPct {
+		parts = append(parts, defensiveModeFlag)
+	}
+
+	line := strings.Join(parts, " ")
+	for utf8.RuneCountInString(line) > maxRiskContextLen && len(parts) > 1 {
+		parts = parts[1:]
+		line = strings.Join(parts, " ")
+	}
+	return line
+}
+
+// formatRiskEquity 净值显示（大额以k为单位，控制长度）
+func formatRiskEquity(equity float64) string {
+	if equity >= riskContextEquityThreshold {
+		return fmt.Sprintf("$%.0fk", equity/1000)
+	}
+	return fmt.Sprintf("$%.0f", equity)
+}
+
+// formatSignedPct 以箭头表示涨跌的百分比
+func formatSignedPct(pct float64) string {
+	if pct < 0 {
+		return fmt.Sprintf("↓%.0f%%", -pct)
+	}
+	return fmt.Sprintf("↑%.0f%%", pct)
+}
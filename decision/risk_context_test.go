@@ -0,0 +1,38 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBuildRiskContextFitsFiftyCharacters(t *testing.T) {
+	cases := []struct {
+		name      string
+		ctx       *Context
+		defensive bool
+	}{
+		{"calm", &Context{Account: AccountInfo{TotalEquity: 9500, TotalPnLPct: -5}, PeakEquity: 10300, DailyStartEquity: 10000, PortfolioHeat: "warm"}, false},
+		{"empty", &Context{}, false},
+		{"hot heat", &Context{Account: AccountInfo{TotalEquity: 9500}, PortfolioHeat: "hot"}, true},
+		{"deep drawdown", &Context{Account: AccountInfo{TotalEquity: 8000, TotalPnLPct: -20}, PeakEquity: 10000}, true},
+		{"large numbers", &Context{Account: AccountInfo{TotalEquity: 123456789, TotalPnLPct: 123456}, PeakEquity: 987654321, DailyStartEquity: 555555555, PortfolioHeat: "critical"}, true},
+		{"huge loss", &Context{Account: AccountInfo{TotalEquity: 1, TotalPnLPct: -99.99}, PeakEquity: 1e9, DailyStartEquity: 1e9, PortfolioHeat: "critical"}, true},
+	}
+	for _, tc := range cases {
+		line := BuildRiskContext(tc.ctx)
+		if n := utf8.RuneCountInString(line); n > maxRiskContextLen {
+			t.Errorf("%s: %q has %d characters, want <= %d", tc.name, line, n, maxRiskContextLen)
+		}
+		if got := strings.Contains(line, defensiveModeFlag); got != tc.defensive {
+			t.Errorf("%s: %q defensive = %v, want %v", tc.name, line, got, tc.defensive)
+		}
+	}
+}
+
+func TestBuildRiskContextFormat(t *testing.T) {
+	ctx := &Context{Account: AccountInfo{TotalEquity: 9500, TotalPnLPct: -5}, PeakEquity: 10300, DailyStartEquity: 10000, PortfolioHeat: "warm"}
+	if got, want := BuildRiskContext(ctx), "Equity:$9500(↓5%) DD:8% DailyLoss:5% Heat:WARM"; got != want {
+		t.Errorf("risk context = %q, want %q", got, want)
+	}
+}
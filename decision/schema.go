@@ -0,0 +1,193 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//go:generate go run ./schemagen schemas/ai_decision.json ai_decision_schema_gen.go
+
+// schemaField AI决策输出的字段约束（由 schemas/ai_decision.json 生成，见 ai_decision_schema_gen.go）
+type schemaField struct {
+	Name     string
+	Type     string // string / number / integer
+	Required string // always / open（仅开仓必填）/ 空（可选）
+	Enum     []string
+	Desc     string
+}
+
+// ValidationError 字段级校验错误
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// DecisionSchemaPrompt 生成紧凑的JSON Schema描述，附加到System Prompt中约束AI输出结构
+func DecisionSchemaPrompt() string {
+	properties := make(map[string]interface{}, len(decisionSchema))
+	var required, openRequired []string
+	for _, f := range decisionSchema {
+		prop := map[string]interface{}{"type": f.Type}
+		if len(f.Enum) > 0 {
+			prop["enum"] = f.Enum
+		}
+		if f.Desc != "" {
+			prop["description"] = f.Desc
+		}
+		properties[f.Name] = prop
+		switch f.Required {
+		case "always":
+			required = append(required, f.Name)
+		case "open":
+			openRequired = append(openRequired, f.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		},
+	}
+	data, _ := json.Marshal(schema)
+
+	var sb strings.Builder
+	sb.WriteString("JSON Schema（必须严格符合，字段类型错误或缺失会导致整个决策被拒绝）:\n")
+	sb.Write(data)
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("- action 为 open_long/open_short 时额外必填: %s\n", strings.Join(openRequired, ", ")))
+	sb.WriteString("- 数值字段直接输出数字，不要加引号或单位\n")
+	return sb.String()
+}
+
+// ParseDecisionsStrict 按 decisionSchema 严格校验并解析决策数组
+// 不符合Schema的决策逐条丢弃，invalid 中记录带精确路径（如 [1].stop_loss）的原因，其余决策照常返回；
+// 只有整体不是JSON数组时才返回 error。
+// 整个数组只解码一次：超长的 reasoning 等字符串字段重复解码会让每次解析的耗时成倍增加
+func ParseDecisionsStrict(jsonContent string) (decisions []Decision, invalid []ValidationError, err error) {
+	var items []interface{}
+	if err := json.Unmarshal([]byte(jsonContent), &items); err != nil {
+		return nil, nil, fmt.Errorf("$: 必须是JSON数组: %w", err)
+	}
+
+	decisions = make([]Decision, 0, len(items))
+	for i, item := range items {
+		path := fmt.Sprintf("[%d]", i)
+
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			invalid = append(invalid, ValidationError{Path: path, Message: "必须是JSON对象"})
+			continue
+		}
+		if verr := validateSchemaObject(obj, path); verr != nil {
+			invalid = append(invalid, *verr)
+			continue
+		}
+		decisions = append(decisions, decisionFromObject(obj))
+	}
+	return decisions, invalid, nil
+}
+
+// decisionFromObject 由已通过Schema校验的对象构造决策
+// 只读取AI可以输出的字段（Schema字段和计划模板），风控计算的字段不接受AI的值
+func decisionFromObject(obj map[string]interface{}) Decision {
+	str := func(name string) string {
+		s, _ := obj[name].(string)
+		return s
+	}
+	num := func(name string) float64 {
+		n, _ := obj[name].(float64)
+		return n
+	}
+	return Decision{
+		Symbol:          str("symbol"),
+		Action:          str("action"),
+		Leverage:        int(num("leverage")),
+		PositionSizeUSD: num("position_size_usd"),
+		StopLoss:        num("stop_loss"),
+		TakeProfit:      num("take_profit"),
+		Confidence:      int(num("confidence")),
+		RiskUSD:         num("risk_usd"),
+		Reasoning:       str("reasoning"),
+		PlanTemplate:    str("plan_template"),
+	}
+}
+
+// validateSchemaObject 校验单个决策对象的必填字段、类型和枚举值
+func validateSchemaObject(obj map[string]interface{}, path string) *ValidationError {
+	action, _ := obj["action"].(string)
+	isOpen := action == "open_long" || action == "open_short"
+
+	for _, f := range decisionSchema {
+		fieldPath := path + "." + f.Name
+		value, ok := obj[f.Name]
+		if !ok || value == nil {
+			if f.Required == "always" || (f.Required == "open" && isOpen) {
+				return &ValidationError{Path: fieldPath, Message: "缺少必填字段"}
+			}
+			continue
+		}
+
+		switch f.Type {
+		case "string":
+			s, ok := value.(string)
+			if !ok {
+				return &ValidationError{Path: fieldPath, Message: fmt.Sprintf("类型应为string，实际为%s", jsonTypeName(value))}
+			}
+			if len(f.Enum) > 0 && !containsString(f.Enum, s) {
+				return &ValidationError{Path: fieldPath, Message: fmt.Sprintf("无效值 %q（可选: %s）", s, strings.Join(f.Enum, " | "))}
+			}
+		case "number", "integer":
+			n, ok := value.(float64)
+			if !ok {
+				return &ValidationError{Path: fieldPath, Message: fmt.Sprintf("类型应为%s，实际为%s", f.Type, jsonTypeName(value))}
+			}
+			if f.Type == "integer" && n != float64(int64(n)) {
+				return &ValidationError{Path: fieldPath, Message: fmt.Sprintf("类型应为integer，实际为小数 %v", n)}
+			}
+		}
+	}
+
+	// 计划模板不在Schema中（只在配置了模板时提示AI输出），类型同样必须是string
+	if value, ok := obj["plan_template"]; ok && value != nil {
+		if _, ok := value.(string); !ok {
+			return &ValidationError{Path: path + ".plan_template", Message: fmt.Sprintf("类型应为string，实际为%s", jsonTypeName(value))}
+		}
+	}
+	return nil
+}
+
+// jsonTypeName 返回JSON解码值的类型名（用于错误信息）
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "null"
+	}
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
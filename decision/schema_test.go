@@ -0,0 +1,75 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDecisionsStrictMixedBatch(t *testing.T) {
+	input := `[
+		{"symbol": "BTCUSDT", "action": "close_long", "reasoning": "趋势反转"},
+		{"symbol": "ETHUSDT", "action": "open_long", "leverage": 5, "position_size_usd": "100", "stop_loss": 3000, "take_profit": 3500, "confidence": 80, "risk_usd": 20, "reasoning": "突破"},
+		{"symbol": "SOLUSDT", "action": "moon", "reasoning": "?"},
+		"not an object",
+		{"symbol": "BNBUSDT", "action": "close_short", "reasoning": "止盈"}
+	]`
+
+	decisions, invalid, err := ParseDecisionsStrict(input)
+	if err != nil {
+		t.Fatalf("ParseDecisionsStrict: %v", err)
+	}
+
+	if len(decisions) != 2 || decisions[0].Symbol != "BTCUSDT" || decisions[1].Symbol != "BNBUSDT" {
+		t.Fatalf("decisions = %+v, want the two valid closes", decisions)
+	}
+
+	wantPaths := []string{"[1].position_size_usd", "[2].action", "[3]"}
+	if len(invalid) != len(wantPaths) {
+		t.Fatalf("invalid = %v, want %d entries", invalid, len(wantPaths))
+	}
+	for i, path := range wantPaths {
+		if invalid[i].Path != path {
+			t.Errorf("invalid[%d].Path = %s, want %s", i, invalid[i].Path, path)
+		}
+	}
+}
+
+func TestParseDecisionsStrictRejectsNonArray(t *testing.T) {
+	if _, _, err := ParseDecisionsStrict(`{"symbol": "BTCUSDT"}`); err == nil {
+		t.Fatal("expected error for non-array input")
+	}
+}
+
+func TestExtractDecisionsAllInvalid(t *testing.T) {
+	_, err := extractDecisions(`思考过程 [{"symbol": "BTCUSDT", "action": "open_long", "reasoning": "x"}]`)
+	if err == nil || !strings.Contains(err.Error(), "[0].leverage") {
+		t.Fatalf("err = %v, want path-qualified error", err)
+	}
+}
+
+func TestParseDecisionsStrictReadsOnlyAIFields(t *testing.T) {
+	input := `[{"symbol": "ETHUSDT", "action": "open_long", "leverage": 5, "position_size_usd": 100, "stop_loss": 3000,
+		"take_profit": 3500, "confidence": 80, "risk_usd": 20, "reasoning": "突破", "plan_template": "scale_in",
+		"close_ratio": 0.5, "break_even_win_rate": 0.1}]`
+
+	decisions, invalid, err := ParseDecisionsStrict(input)
+	if err != nil || len(invalid) != 0 || len(decisions) != 1 {
+		t.Fatalf("decisions = %+v invalid = %v err = %v, want one decision", decisions, invalid, err)
+	}
+	want := Decision{Symbol: "ETHUSDT", Action: "open_long", Leverage: 5, PositionSizeUSD: 100, StopLoss: 3000,
+		TakeProfit: 3500, Confidence: 80, RiskUSD: 20, Reasoning: "突破", PlanTemplate: "scale_in"}
+	// 风控计算的字段（平仓比例、盈亏平衡胜率）不接受AI的值
+	if got := decisions[0]; got.Symbol != want.Symbol || got.Action != want.Action || got.Leverage != want.Leverage ||
+		got.PositionSizeUSD != want.PositionSizeUSD || got.StopLoss != want.StopLoss || got.TakeProfit != want.TakeProfit ||
+		got.Confidence != want.Confidence || got.RiskUSD != want.RiskUSD || got.Reasoning != want.Reasoning ||
+		got.PlanTemplate != want.PlanTemplate || got.CloseRatio != 0 || got.BreakEvenWinRate != 0 {
+		t.Errorf("decision = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDecisionsStrictRejectsNonStringPlanTemplate(t *testing.T) {
+	_, invalid, err := ParseDecisionsStrict(`[{"symbol": "BTCUSDT", "action": "wait", "reasoning": "x", "plan_template": 1}]`)
+	if err != nil || len(invalid) != 1 || invalid[0].Path != "[0].plan_template" {
+		t.Errorf("invalid = %v err = %v, want [0].plan_template rejected", invalid, err)
+	}
+}
@@ -0,0 +1,238 @@
+// 根据AI决策的JSON Schema生成Go类型和校验函数: go run ./decision/schemagen [schema文件] [输出文件]
+// 一般通过 decision 包中的 go:generate 指令调用（make generate）
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// property Schema中的单个字段
+type property struct {
+	Name        string
+	Type        string   `json:"type"`
+	Enum        []string `json:"enum"`
+	Description string   `json:"description"`
+	Required    string   // always / open / 空
+}
+
+// schema 支持的JSON Schema子集（单层对象）
+type schema struct {
+	Title          string                     `json:"title"`
+	Description    string                     `json:"description"`
+	Properties     json.RawMessage            `json:"properties"`
+	Required       []string                   `json:"required"`
+	RequiredOnOpen []string                   `json:"x-required-when-open"`
+	fields         []*property                `json:"-"`
+	byName         map[string]json.RawMessage `json:"-"`
+}
+
+func main() {
+	input, output := "schemas/ai_decision.json", "ai_decision_schema_gen.go"
+	if len(os.Args) > 1 {
+		input = os.Args[1]
+	}
+	if len(os.Args) > 2 {
+		output = os.Args[2]
+	}
+
+	s, err := loadSchema(input)
+	if err != nil {
+		log.Fatalf("❌ 读取Schema失败: %v", err)
+	}
+	code, err := generate(s, input)
+	if err != nil {
+		log.Fatalf("❌ 生成代码失败: %v", err)
+	}
+	if err := os.WriteFile(output, code, 0644); err != nil {
+		log.Fatalf("❌ 写入生成文件失败: %v", err)
+	}
+	log.Printf("✓ AI决策Schema代码已生成: %s", output)
+}
+
+// loadSchema 读取Schema，字段按文件中的顺序保留
+func loadSchema(path string) (*schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	}
+	if s.Title == "" {
+		return nil, fmt.Errorf("缺少 title（用作Go类型名）")
+	}
+
+	names, err := orderedKeys(s.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("解析 properties 失败: %w", err)
+	}
+	if err := json.Unmarshal(s.Properties, &s.byName); err != nil {
+		return nil, fmt.Errorf("解析 properties 失败: %w", err)
+	}
+
+	required := make(map[string]string)
+	for _, name := range s.RequiredOnOpen {
+		required[name] = "open"
+	}
+	for _, name := range s.Required {
+		required[name] = "always"
+	}
+
+	for _, name := range names {
+		p := &property{Name: name, Required: required[name]}
+		if err := json.Unmarshal(s.byName[name], p); err != nil {
+			return nil, fmt.Errorf("解析字段 %s 失败: %w", name, err)
+		}
+		if goType(p.Type) == "" {
+			return nil, fmt.Errorf("字段 %s 的类型 %q 不受支持", name, p.Type)
+		}
+		s.fields = append(s.fields, p)
+		delete(required, name)
+	}
+	for name := range required {
+		return nil, fmt.Errorf("必填字段 %s 未在 properties 中定义", name)
+	}
+	return &s, nil
+}
+
+// orderedKeys 按出现顺序返回JSON对象的键
+func orderedKeys(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("应为JSON对象")
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// goType JSON Schema类型对应的Go类型
+func goType(t string) string {
+	switch t {
+	case "string":
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	}
+	return ""
+}
+
+// goName snake_case 转为导出的Go字段名
+func goName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		switch part {
+		case "usd", "id":
+			sb.WriteString(strings.ToUpper(part))
+		default:
+			if part != "" {
+				sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			}
+		}
+	}
+	return sb.String()
+}
+
+// zeroValue 字段类型的零值（用于判断必填字段是否缺失）
+func zeroValue(t string) string {
+	if t == "string" {
+		return `""`
+	}
+	return "0"
+}
+
+var codeTemplate = template.Must(template.New("schema").Funcs(template.FuncMap{
+	"goType":    goType,
+	"goName":    goName,
+	"zeroValue": zeroValue,
+	"quote":     func(s string) string { return fmt.Sprintf("%q", s) },
+	"quoteList": func(list []string) string {
+		quoted := make([]string, len(list))
+		for i, s := range list {
+			quoted[i] = fmt.Sprintf("%q", s)
+		}
+		return strings.Join(quoted, ", ")
+	},
+	"join": strings.Join,
+}).Parse(`// Code generated by decision/schemagen from {{.Source}}; DO NOT EDIT.
+
+package decision
+
+// {{.Schema.Title}} {{.Schema.Description}}
+type {{.Schema.Title}} struct {
+{{- range .Schema.Fields}}
+	{{goName .Name}} {{goType .Type}} ` + "`json:\"{{.Name}}\"`" + `{{if .Description}} // {{.Description}}{{end}}
+{{- end}}
+}
+
+// decisionSchema AI决策数组中单个元素的字段约束
+var decisionSchema = []schemaField{
+{{- range .Schema.Fields}}
+	{Name: {{quote .Name}}, Type: {{quote .Type}}{{if .Required}}, Required: {{quote .Required}}{{end}}{{if .Enum}}, Enum: []string{ {{- quoteList .Enum -}} }{{end}}{{if .Description}}, Desc: {{quote .Description}}{{end}}},
+{{- end}}
+}
+
+// Validate{{.Schema.Title}} 按Schema校验AI决策的必填字段和枚举值
+func Validate{{.Schema.Title}}(d *{{.Schema.Title}}) []ValidationError {
+	var errs []ValidationError
+{{- if .Schema.HasOpen}}
+	isOpen := d.Action == "open_long" || d.Action == "open_short"
+{{- end}}
+{{range .Schema.Fields}}
+{{- if eq .Required "always"}}
+	if d.{{goName .Name}} == {{zeroValue .Type}} {
+		errs = append(errs, ValidationError{Path: {{quote .Name}}, Message: "缺少必填字段"})
+	}
+{{- else if eq .Required "open"}}
+	if isOpen && d.{{goName .Name}} == {{zeroValue .Type}} {
+		errs = append(errs, ValidationError{Path: {{quote .Name}}, Message: "开仓时必填"})
+	}
+{{- end}}
+{{- if .Enum}}
+	switch d.{{goName .Name}} {
+	case {{quoteList .Enum}}, "": // 缺失由必填检查报告
+	default:
+		errs = append(errs, ValidationError{Path: {{quote .Name}}, Message: "无效值: " + d.{{goName .Name}}})
+	}
+{{- end}}
+{{- end}}
+	return errs
+}
+`))
+
+// generate 生成格式化后的Go代码
+func generate(s *schema, source string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := codeTemplate.Execute(&buf, map[string]interface{}{
+		"Source": source,
+		"Schema": struct {
+			Title, Description string
+			Fields             []*property
+			HasOpen            bool
+		}{s.Title, s.Description, s.fields, len(s.RequiredOnOpen) > 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
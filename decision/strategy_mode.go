@@ -0,0 +1,82 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/market"
+)
+
+// 策略模式：限定允许的开仓信号类型
+const (
+	StrategyModeMixed         = "mixed"          // 趋势跟随和均值回归信号都允许（默认）
+	StrategyModeTrend         = "trend"          // 只做顺势开仓
+	StrategyModeMeanReversion = "mean_reversion" // 只做超买超卖后的反转开仓
+)
+
+// 均值回归信号的RSI(7)阈值
+const (
+	meanReversionOversoldRSI   = 30.0
+	meanReversionOverboughtRSI = 70.0
+)
+
+// NormalizeStrategyMode 未知的策略模式按 mixed 处理
+func NormalizeStrategyMode(mode string) string {
+	switch mode {
+	case StrategyModeTrend, StrategyModeMeanReversion:
+		return mode
+	default:
+		return StrategyModeMixed
+	}
+}
+
+// IsTrendEntry 顺势开仓：技术指标独立判断的方向与开仓方向一致（side 为 "long"/"short"）
+func IsTrendEntry(side string, data *market.Data) bool {
+	direction, _, _ := TechnicalDirection(data)
+	return direction != "" && direction == side
+}
+
+// IsMeanReversionEntry 均值回归开仓：RSI(7) 超卖时做多、超买时做空
+func IsMeanReversionEntry(side string, data *market.Data) bool {
+	if data == nil {
+		return false
+	}
+	if side == "long" {
+		return data.CurrentRSI7 <= meanReversionOversoldRSI
+	}
+	return data.CurrentRSI7 >= meanReversionOverboughtRSI
+}
+
+// CheckStrategyMode 检查开仓信号是否属于策略模式允许的类型，不允许时返回原因
+func CheckStrategyMode(mode, side string, data *market.Data) error {
+	switch NormalizeStrategyMode(mode) {
+	case StrategyModeTrend:
+		if !IsTrendEntry(side, data) {
+			return fmt.Errorf("趋势跟随模式：技术面未确认%s方向的趋势", sideLabel(side))
+		}
+	case StrategyModeMeanReversion:
+		if !IsMeanReversionEntry(side, data) {
+			return fmt.Errorf("均值回归模式：RSI(7)未处于%s的超买超卖区间", sideLabel(side))
+		}
+	}
+	return nil
+}
+
+// strategyModeGuidance 提示词中的策略模式说明（mixed 不提示）
+func strategyModeGuidance(mode string) string {
+	switch NormalizeStrategyMode(mode) {
+	case StrategyModeTrend:
+		return "策略模式: 趋势跟随，只在技术面确认的趋势方向开仓，不做区间内的超买超卖反转"
+	case StrategyModeMeanReversion:
+		return fmt.Sprintf("策略模式: 均值回归，只在RSI(7)≤%.0f时做多、≥%.0f时做空，不追趋势",
+			meanReversionOversoldRSI, meanReversionOverboughtRSI)
+	default:
+		return ""
+	}
+}
+
+// sideLabel 持仓方向的中文名称
+func sideLabel(side string) string {
+	if side == "short" {
+		return "空仓"
+	}
+	return "多仓"
+}
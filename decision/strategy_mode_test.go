@@ -0,0 +1,49 @@
+package decision
+
+import (
+	"nofx/market"
+	"testing"
+)
+
+// oversoldInRange 区间下沿的超卖反弹信号：技术面偏空，RSI(7) 超卖
+func oversoldInRange() *market.Data {
+	return &market.Data{CurrentPrice: 98, CurrentEMA20: 100, CurrentMACD: -0.5, CurrentRSI7: 22}
+}
+
+// uptrend 顺势信号：价格在EMA20之上、MACD为正，RSI(7) 居中
+func uptrend() *market.Data {
+	return &market.Data{CurrentPrice: 105, CurrentEMA20: 100, CurrentMACD: 1, CurrentRSI7: 58}
+}
+
+func TestCheckStrategyModeTrendRejectsOversoldRangeSignal(t *testing.T) {
+	if err := CheckStrategyMode(StrategyModeTrend, "long", oversoldInRange()); err == nil {
+		t.Error("trend mode accepted an RSI-oversold long against the trend")
+	}
+	if err := CheckStrategyMode(StrategyModeTrend, "long", uptrend()); err != nil {
+		t.Errorf("trend mode rejected a trend-confirmed long: %v", err)
+	}
+}
+
+func TestCheckStrategyModeMeanReversionAcceptsOversoldRangeSignal(t *testing.T) {
+	if err := CheckStrategyMode(StrategyModeMeanReversion, "long", oversoldInRange()); err != nil {
+		t.Errorf("mean reversion mode rejected an RSI-oversold long: %v", err)
+	}
+	if err := CheckStrategyMode(StrategyModeMeanReversion, "long", uptrend()); err == nil {
+		t.Error("mean reversion mode accepted a trend long without an oversold RSI")
+	}
+	overbought := &market.Data{CurrentPrice: 105, CurrentEMA20: 100, CurrentMACD: 1, CurrentRSI7: 78}
+	if err := CheckStrategyMode(StrategyModeMeanReversion, "short", overbought); err != nil {
+		t.Errorf("mean reversion mode rejected an RSI-overbought short: %v", err)
+	}
+}
+
+func TestCheckStrategyModeMixedAllowsBoth(t *testing.T) {
+	for _, mode := range []string{StrategyModeMixed, "", "unknown"} {
+		if err := CheckStrategyMode(mode, "long", oversoldInRange()); err != nil {
+			t.Errorf("mode %q rejected the range signal: %v", mode, err)
+		}
+		if err := CheckStrategyMode(mode, "long", uptrend()); err != nil {
+			t.Errorf("mode %q rejected the trend signal: %v", mode, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package decision
+
+import (
+	"context"
+	"nofx/logger"
+	"nofx/mcp"
+	"strings"
+	"time"
+)
+
+// callStreaming 以流式方式调用AI，决策JSON到达时立即返回已收到的文本（思维链+决策）
+// 剩余文本在后台继续接收，流结束后才计入token用量和熔断器；流中没有决策JSON时返回完整响应
+func callStreaming(mcpClient *mcp.Client, systemPrompt, userPrompt string) (string, error) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if mcpClient.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, mcpClient.Timeout)
+	}
+
+	start := time.Now()
+	stream, err := mcpClient.StreamAIDecision(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	var text strings.Builder
+	chunks, decisions := stream.Chunks, stream.Decisions
+	for chunks != nil || decisions != nil {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				chunks = nil
+				continue
+			}
+			text.WriteString(chunk)
+		case _, ok := <-decisions:
+			if !ok {
+				decisions = nil
+				continue
+			}
+			// 决策所在的分片在决策推送之前已写入 Chunks，读空缓冲即可得到到决策为止的全部文本
+			for drained := false; !drained; {
+				select {
+				case chunk, ok := <-chunks:
+					if !ok {
+						chunks = nil
+						drained = true
+						continue
+					}
+					text.WriteString(chunk)
+				default:
+					drained = true
+				}
+			}
+			logger.Infof("⚡ 流式响应：决策JSON已到达（%dms），剩余文本在后台接收", time.Since(start).Milliseconds())
+			go drainStream(stream, chunks, decisions, cancel)
+			return text.String(), nil
+		}
+	}
+	defer cancel()
+	return stream.Wait()
+}
+
+// drainStream 读空流的剩余内容并等待结束（流式调用提前返回后在后台执行）
+func drainStream(stream *mcp.DecisionStream, chunks, decisions <-chan string, cancel context.CancelFunc) {
+	defer cancel()
+	for chunks != nil || decisions != nil {
+		select {
+		case _, ok := <-chunks:
+			if !ok {
+				chunks = nil
+			}
+		case _, ok := <-decisions:
+			if !ok {
+				decisions = nil
+			}
+		}
+	}
+	if _, err := stream.Wait(); err != nil {
+		logger.Warnf("⚠️ 流式响应：决策之后的文本接收失败: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"nofx/mcp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseServer 按给定分片返回SSE流式响应
+func sseServer(t *testing.T, pieces []string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["stream"] != true {
+			t.Errorf("request body = %v (%v), want stream=true", body, err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range pieces {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []map[string]interface{}{{"delta": map[string]string{"content": piece}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCallStreamingReturnsResponseThroughDecision(t *testing.T) {
+	pieces := []string{
+		"BTC 多周期看涨，",
+		"继续持有。\n[{\"symbol\": \"BTCUSDT\", ",
+		"\"action\": \"hold\", \"reasoning\": \"持有\"}]",
+		"\n补充说明",
+	}
+	client := mcp.New()
+	client.APIKey = "test"
+	client.BaseURL = sseServer(t, pieces).URL
+
+	text, err := callStreaming(client, "system", "user")
+	if err != nil {
+		t.Fatalf("callStreaming: %v", err)
+	}
+	// 决策到达即返回，之后的文本可能还没收到
+	full := strings.Join(pieces, "")
+	throughDecision := strings.Join(pieces[:3], "")
+	if !strings.HasPrefix(text, throughDecision) || !strings.HasPrefix(full, text) {
+		t.Errorf("text = %q, want the response through the decision array", text)
+	}
+
+	decisions, err := extractDecisions(text)
+	if err != nil || len(decisions) != 1 || decisions[0].Action != "hold" {
+		t.Errorf("decisions = %+v (%v), want one hold", decisions, err)
+	}
+}
+
+func TestCallStreamingReportsHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := mcp.New()
+	client.APIKey = "test"
+	client.BaseURL = srv.URL
+	if _, err := callStreaming(client, "system", "user"); err == nil {
+		t.Fatal("expected error for non-200 response")
+	}
+}
+
+func TestCallStreamingReturnsBeforeStreamEnds(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(finished)
+		w.Header().Set("Content-Type", "text/event-stream")
+		send := func(content string) {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []map[string]interface{}{{"delta": map[string]string{"content": content}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		send("趋势向上。\n")
+		send(`[{"symbol": "BTCUSDT", "action": "hold", "reasoning": "持有"}]`)
+		// 决策之后的文本要等测试放行才发送
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		send("\n后续说明")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client := mcp.New()
+	client.APIKey = "test"
+	client.BaseURL = srv.URL
+
+	type result struct {
+		text string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		text, err := callStreaming(client, "system", "user")
+		done <- result{text, err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			t.Fatalf("callStreaming: %v", res.err)
+		}
+		decisions, err := extractDecisions(res.text)
+		if err != nil || len(decisions) != 1 || decisions[0].Action != "hold" {
+			t.Errorf("decisions = %+v (%v), want one hold", decisions, err)
+		}
+		if strings.Contains(res.text, "后续说明") {
+			t.Errorf("text = %q includes text sent after the decision", res.text)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callStreaming waited for the stream to end")
+	}
+
+	// 放行后后台继续读完剩余文本
+	close(release)
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream was not drained after the early return")
+	}
+}
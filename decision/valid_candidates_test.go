@@ -0,0 +1,58 @@
+package decision
+
+import (
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+func TestCheckValidCandidatesBlocksEntriesBelowMinimum(t *testing.T) {
+	ctx := &Context{
+		CandidateCoins:     []CandidateCoin{{Symbol: "BTCUSDT"}, {Symbol: "ETHUSDT"}, {Symbol: "SOLUSDT"}},
+		MarketDataMap:      map[string]*market.Data{"BTCUSDT": {}},
+		MinValidCandidates: 2,
+	}
+	checkValidCandidates(ctx)
+	if !ctx.EntriesBlocked {
+		t.Fatal("entries not blocked with 1 valid candidate (min 2)")
+	}
+
+	ctx.EntriesBlocked = false
+	ctx.MarketDataMap["ETHUSDT"] = &market.Data{}
+	checkValidCandidates(ctx)
+	if ctx.EntriesBlocked {
+		t.Error("entries blocked with 2 valid candidates (min 2)")
+	}
+
+	// 0 表示不限制
+	ctx.MarketDataMap = map[string]*market.Data{}
+	ctx.MinValidCandidates = 0
+	checkValidCandidates(ctx)
+	if ctx.EntriesBlocked {
+		t.Error("entries blocked with MinValidCandidates disabled")
+	}
+}
+
+func TestBlockEntryDecisionsKeepsClosesAndHolds(t *testing.T) {
+	decisions := []Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Reasoning: "突破"},
+		{Symbol: "ETHUSDT", Action: "open_short"},
+		{Symbol: "SOLUSDT", Action: "close_long"},
+		{Symbol: "BNBUSDT", Action: "close_short"},
+		{Symbol: "XRPUSDT", Action: "hold"},
+	}
+	blockEntryDecisions(decisions)
+
+	want := []string{"wait", "wait", "close_long", "close_short", "hold"}
+	for i, d := range decisions {
+		if d.Action != want[i] {
+			t.Errorf("%s action = %s, want %s", d.Symbol, d.Action, want[i])
+		}
+	}
+	if !strings.HasPrefix(decisions[0].Reasoning, "[有效数据不足，禁止开仓]") || !strings.HasSuffix(decisions[0].Reasoning, "突破") {
+		t.Errorf("blocked reasoning = %q, want the block note prepended", decisions[0].Reasoning)
+	}
+	if decisions[2].Reasoning != "" {
+		t.Errorf("close reasoning changed to %q", decisions[2].Reasoning)
+	}
+}
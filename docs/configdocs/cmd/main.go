@@ -0,0 +1,26 @@
+// 生成风控配置说明文档: go run ./docs/configdocs/cmd [输出文件]
+package main
+
+import (
+	"log"
+	"nofx/docs/configdocs"
+	"nofx/trader"
+	"os"
+)
+
+func main() {
+	output := "docs/CONFIG.md"
+	if len(os.Args) > 1 {
+		output = os.Args[1]
+	}
+
+	content := "# 风控配置说明\n\n" +
+		"> 本文件由 `make docs` 根据 `trader.RiskConfig` 的 `doc` 标签自动生成，请勿手动修改。\n\n" +
+		"以下参数填写在 `config.json` 的 `risk_config` 中，未填写的参数使用默认值（`-` 表示默认不启用）。\n\n" +
+		configdocs.GenerateConfigDocs(trader.DefaultRiskConfig())
+
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		log.Fatalf("❌ 写入配置文档失败: %v", err)
+	}
+	log.Printf("✓ 配置文档已生成: %s", output)
+}
@@ -0,0 +1,71 @@
+// Package configdocs 根据配置结构体的 json/doc 标签生成Markdown配置说明
+package configdocs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GenerateConfigDocs 遍历配置结构体的导出字段，生成Markdown表格
+// 列: 字段、JSON键、类型、默认值、说明（取自 doc 标签）
+// config 应为填充了默认值的结构体（或其指针），默认值列即取自该实例；嵌套结构体展开为 父键.子键
+func GenerateConfigDocs(config interface{}) string {
+	v := reflect.ValueOf(config)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| 字段 | JSON键 | 类型 | 默认值 | 说明 |\n")
+	sb.WriteString("|------|--------|------|--------|------|\n")
+	writeFields(&sb, v, "", "")
+	return sb.String()
+}
+
+// writeFields 逐个写入结构体字段（嵌套结构体递归展开）
+func writeFields(sb *strings.Builder, v reflect.Value, fieldPrefix, keyPrefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct {
+			writeFields(sb, value, fieldPrefix+field.Name+".", keyPrefix+key+".")
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s | %s |\n",
+			fieldPrefix+field.Name, keyPrefix+key, field.Type.String(), formatDefault(value), escapeCell(field.Tag.Get("doc"))))
+	}
+}
+
+// formatDefault 格式化默认值（零值显示为 -）
+func formatDefault(v reflect.Value) string {
+	if v.IsZero() {
+		return "-"
+	}
+	if v.Kind() == reflect.String {
+		return fmt.Sprintf("`%q`", v.String())
+	}
+	return fmt.Sprintf("`%v`", v.Interface())
+}
+
+// escapeCell 转义Markdown表格中的竖线
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
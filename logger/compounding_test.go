@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCompoundReturnsConstantOnePercent(t *testing.T) {
+	pcts := make([]float64, 10)
+	for i := range pcts {
+		pcts[i] = 1
+	}
+	want := math.Pow(1.01, 10) - 1 // 10.46%，高于简单累加的10%
+	if got := CompoundReturns(pcts); !approx(got, want) {
+		t.Errorf("CompoundReturns = %.6f, want %.6f", got, want)
+	}
+
+	// 盈亏交替：+10% 后 -10% 净亏1%
+	if got := CompoundReturns([]float64{10, -10}); !approx(got, -0.01) {
+		t.Errorf("CompoundReturns(+10, -10) = %.6f, want -0.01", got)
+	}
+}
+
+func TestAnnualizedReturn(t *testing.T) {
+	if got := AnnualizedReturn(0.1, 365); !approx(got, 0.1) {
+		t.Errorf("one-year return = %.6f, want 0.1", got)
+	}
+	if got := AnnualizedReturn(0.21, 730); !approx(got, 0.1) {
+		t.Errorf("two-year 21%% = %.6f, want 0.1 per year", got)
+	}
+	if got := AnnualizedReturn(0.1, 0); got != 0 {
+		t.Errorf("zero days = %v, want 0", got)
+	}
+	if got := AnnualizedReturn(-1.5, 30); got != -1 {
+		t.Errorf("wiped out = %v, want -1", got)
+	}
+}
+
+func TestAnalyzePerformanceCompoundsEachTrade(t *testing.T) {
+	l := NewDecisionLogger(t.TempDir())
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// 三笔 1x 杠杆交易，每笔 +1%（相对保证金），每笔持仓一天
+	for i := 0; i < 3; i++ {
+		open := DecisionAction{Action: "open_long", Symbol: "BTCUSDT", Quantity: 1, Leverage: 1, Price: 100, Timestamp: start.Add(time.Duration(i) * day), Success: true}
+		closed := DecisionAction{Action: "close_long", Symbol: "BTCUSDT", Price: 101, Timestamp: start.Add(time.Duration(i+1) * day), Success: true}
+		for _, action := range []DecisionAction{open, closed} {
+			if err := l.LogDecision(&DecisionRecord{Success: true, Decisions: []DecisionAction{action}}); err != nil {
+				t.Fatalf("LogDecision: %v", err)
+			}
+		}
+	}
+
+	analysis, err := l.AnalyzePerformance(20)
+	if err != nil {
+		t.Fatalf("AnalyzePerformance: %v", err)
+	}
+	if analysis.TotalTrades != 3 {
+		t.Fatalf("trades = %d, want 3", analysis.TotalTrades)
+	}
+	compounded := math.Pow(1.01, 3) - 1
+	if !approx(analysis.ArithmeticReturn, 0.03) || !approx(analysis.CompoundedReturn, compounded) {
+		t.Errorf("arithmetic/compounded = %.6f/%.6f, want 0.03/%.6f", analysis.ArithmeticReturn, analysis.CompoundedReturn, compounded)
+	}
+	if want := math.Pow(1+compounded, 365.0/3) - 1; math.Abs(analysis.RealisedCAGR-want) > 1e-6*want {
+		t.Errorf("CAGR = %.4f, want %.4f over 3 days", analysis.RealisedCAGR, want)
+	}
+}
@@ -25,6 +25,22 @@ type DecisionRecord struct {
 	ExecutionLog   []string           `json:"execution_log"`   // 执行日志
 	Success        bool               `json:"success"`         // 是否成功
 	ErrorMessage   string             `json:"error_message"`   // 错误信息（如果有）
+	Timing         CycleTiming        `json:"timing"`          // 各阶段耗时
+}
+
+// CycleTiming 交易周期各阶段耗时（毫秒）
+type CycleTiming struct {
+	DataMs  int64 `json:"data_ms"`  // 账户和市场数据获取
+	AIMs    int64 `json:"ai_ms"`    // AI调用（含重试）
+	RiskMs  int64 `json:"risk_ms"`  // 开仓前风控检查
+	ExecMs  int64 `json:"exec_ms"`  // 下单执行（不含风控检查）
+	TotalMs int64 `json:"total_ms"` // 整个周期
+}
+
+// Summary 耗时摘要（用于日志）
+func (t CycleTiming) Summary() string {
+	return fmt.Sprintf("总计%dms | 数据%dms | AI %dms | 风控%dms | 执行%dms",
+		t.TotalMs, t.DataMs, t.AIMs, t.RiskMs, t.ExecMs)
 }
 
 // AccountSnapshot 账户状态快照
@@ -59,6 +75,12 @@ type DecisionAction struct {
 	Timestamp time.Time `json:"timestamp"` // 执行时间
 	Success   bool      `json:"success"`   // 是否成功
 	Error     string    `json:"error"`     // 错误信息
+
+	FilledQuantity float64 `json:"filled_quantity,omitempty"` // 实际成交数量（开仓时，部分成交时小于 Quantity）
+
+	Attribution  *PnLAttribution      `json:"attribution,omitempty"`   // 盈亏归因（平仓时）
+	EntrySignals []SignalContribution `json:"entry_signals,omitempty"` // 开仓时的技术指标信号（开仓时）
+	ReportCard   *TradeReportCard     `json:"report_card,omitempty"`   // 执行质量评估（平仓时）
 }
 
 // DecisionLogger 决策日志记录器
@@ -75,7 +97,7 @@ func NewDecisionLogger(logDir string) *DecisionLogger {
 
 	// 确保日志目录存在
 	if err := os.MkdirAll(logDir, 0755); err != nil {
-		fmt.Printf("⚠ 创建日志目录失败: %v\n", err)
+		Warnf("⚠ 创建日志目录失败: %v", err)
 	}
 
 	return &DecisionLogger{
@@ -108,7 +130,7 @@ func (l *DecisionLogger) LogDecision(record *DecisionRecord) error {
 		return fmt.Errorf("写入决策记录失败: %w", err)
 	}
 
-	fmt.Printf("📝 决策记录已保存: %s\n", filename)
+	Debugf("📝 决策记录已保存: %s", filename)
 	return nil
 }
 
@@ -197,7 +219,7 @@ func (l *DecisionLogger) CleanOldRecords(days int) error {
 		if file.ModTime().Before(cutoffTime) {
 			filepath := filepath.Join(l.logDir, file.Name())
 			if err := os.Remove(filepath); err != nil {
-				fmt.Printf("⚠ 删除旧记录失败 %s: %v\n", file.Name(), err)
+				Warnf("⚠ 删除旧记录失败 %s: %v", file.Name(), err)
 				continue
 			}
 			removedCount++
@@ -205,7 +227,7 @@ func (l *DecisionLogger) CleanOldRecords(days int) error {
 	}
 
 	if removedCount > 0 {
-		fmt.Printf("🗑️ 已清理 %d 条旧记录（%d天前）\n", removedCount, days)
+		Infof("🗑️ 已清理 %d 条旧记录（%d天前）", removedCount, days)
 	}
 
 	return nil
@@ -284,6 +306,10 @@ type TradeOutcome struct {
 	OpenTime      time.Time `json:"open_time"`      // 开仓时间
 	CloseTime     time.Time `json:"close_time"`     // 平仓时间
 	WasStopLoss   bool      `json:"was_stop_loss"`  // 是否止损
+
+	Attribution  *PnLAttribution      `json:"attribution,omitempty"`   // 盈亏归因（价格/资金费/手续费）
+	EntrySignals []SignalContribution `json:"entry_signals,omitempty"` // 开仓时的技术指标信号
+	ReportCard   *TradeReportCard     `json:"report_card,omitempty"`   // 执行质量评估
 }
 
 // PerformanceAnalysis 交易表现分析
@@ -295,11 +321,35 @@ type PerformanceAnalysis struct {
 	AvgWin        float64                       `json:"avg_win"`        // 平均盈利
 	AvgLoss       float64                       `json:"avg_loss"`       // 平均亏损
 	ProfitFactor  float64                       `json:"profit_factor"`  // 盈亏比
+	GrossProfit   float64                       `json:"gross_profit"`   // 总盈利（USDT）
+	GrossLoss     float64                       `json:"gross_loss"`     // 总亏损（USDT，正数）
+	Expectancy    float64                       `json:"expectancy"`     // 期望值：平均每笔交易盈亏（USDT）
 	SharpeRatio   float64                       `json:"sharpe_ratio"`   // 夏普比率（风险调整后收益）
 	RecentTrades  []TradeOutcome                `json:"recent_trades"`  // 最近N笔交易
 	SymbolStats   map[string]*SymbolPerformance `json:"symbol_stats"`   // 各币种表现
 	BestSymbol    string                        `json:"best_symbol"`    // 表现最好的币种
 	WorstSymbol   string                        `json:"worst_symbol"`   // 表现最差的币种
+
+	// 每承担1美元风险（以平均亏损衡量）的期望收益 = (胜率×平均盈利 - 败率×平均亏损) / 平均亏损
+	ExpectedValuePerDollarRisked float64 `json:"expected_value_per_dollar_risked"`
+
+	// 复利收益：每笔盈利全部投入下一笔时的收益（按每笔相对保证金的盈亏百分比连乘），与简单累加对比
+	ArithmeticReturn float64 `json:"arithmetic_return"` // Σ 盈亏百分比/100
+	CompoundedReturn float64 `json:"compounded_return"` // Π(1 + 盈亏百分比/100) - 1
+	RealisedCAGR     float64 `json:"realised_cagr"`     // (1 + 复利收益)^(365/交易天数) - 1
+
+	IndicatorStats map[string]*IndicatorStats `json:"indicator_stats"` // 按入场信号统计的胜率
+
+	// 执行质量（来自平仓时的 TradeReportCard）
+	ReportCards     int     `json:"report_cards"`      // 有执行质量评估的交易数
+	AvgSlippage     float64 `json:"avg_slippage"`      // 平均入场滑点（比例）
+	StopHits        int     `json:"stop_hits"`         // 触及止损的交易数
+	AvgStopAccuracy float64 `json:"avg_stop_accuracy"` // 触及止损时平均越过止损价的比例
+}
+
+// IsPublishableQuality 策略质量是否达标（盈亏比 > 1.5 且期望值为正）
+func (a *PerformanceAnalysis) IsPublishableQuality() bool {
+	return a.ProfitFactor > 1.5 && a.Expectancy > 0
 }
 
 // SymbolPerformance 币种表现统计
@@ -322,14 +372,16 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 
 	if len(records) == 0 {
 		return &PerformanceAnalysis{
-			RecentTrades: []TradeOutcome{},
-			SymbolStats:  make(map[string]*SymbolPerformance),
+			RecentTrades:   []TradeOutcome{},
+			SymbolStats:    make(map[string]*SymbolPerformance),
+			IndicatorStats: make(map[string]*IndicatorStats),
 		}, nil
 	}
 
 	analysis := &PerformanceAnalysis{
-		RecentTrades: []TradeOutcome{},
-		SymbolStats:  make(map[string]*SymbolPerformance),
+		RecentTrades:   []TradeOutcome{},
+		SymbolStats:    make(map[string]*SymbolPerformance),
+		IndicatorStats: make(map[string]*IndicatorStats),
 	}
 
 	// 追踪持仓状态：symbol_side -> {side, openPrice, openTime, quantity, leverage}
@@ -364,6 +416,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						"openTime":  action.Timestamp,
 						"quantity":  action.Quantity,
 						"leverage":  action.Leverage,
+						"signals":   action.EntrySignals,
 					}
 				case "close_long", "close_short":
 					// 移除已平仓记录
@@ -398,6 +451,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 					"openTime":  action.Timestamp,
 					"quantity":  action.Quantity,
 					"leverage":  action.Leverage,
+					"signals":   action.EntrySignals,
 				}
 
 			case "close_long", "close_short":
@@ -408,6 +462,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 					side := openPos["side"].(string)
 					quantity := openPos["quantity"].(float64)
 					leverage := openPos["leverage"].(int)
+					signals, _ := openPos["signals"].([]SignalContribution)
 
 					// 计算实际盈亏（USDT）
 					// 合约交易 PnL 计算：quantity × 价格差
@@ -442,6 +497,9 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						Duration:      action.Timestamp.Sub(openTime).String(),
 						OpenTime:      openTime,
 						CloseTime:     action.Timestamp,
+						Attribution:   action.Attribution,
+						EntrySignals:  signals,
+						ReportCard:    action.ReportCard,
 					}
 
 					analysis.RecentTrades = append(analysis.RecentTrades, outcome)
@@ -472,6 +530,9 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						stats.LosingTrades++
 					}
 
+					recordIndicatorOutcome(analysis.IndicatorStats, signals, pnl)
+					recordReportCard(analysis, action.ReportCard)
+
 					// 移除已平仓记录
 					delete(openPositions, posKey)
 				}
@@ -494,6 +555,18 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 			analysis.AvgLoss /= float64(analysis.LosingTrades)
 		}
 
+		analysis.GrossProfit = totalWinAmount
+		analysis.GrossLoss = -totalLossAmount
+
+		// 期望值 = 胜率×平均盈利 - 败率×平均亏损（持平交易计入分母，贡献为0）
+		winRate := float64(analysis.WinningTrades) / float64(analysis.TotalTrades)
+		lossRate := float64(analysis.LosingTrades) / float64(analysis.TotalTrades)
+		avgLossAbs := -analysis.AvgLoss
+		analysis.Expectancy = winRate*analysis.AvgWin - lossRate*avgLossAbs
+		if avgLossAbs > 0 {
+			analysis.ExpectedValuePerDollarRisked = analysis.Expectancy / avgLossAbs
+		}
+
 		// Profit Factor = 总盈利 / 总亏损（绝对值）
 		// 注意：totalLossAmount 是负数，所以取负号得到绝对值
 		if totalLossAmount != 0 {
@@ -523,6 +596,19 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 		}
 	}
 
+	// 复利收益（在截取最近交易之前，按全部交易计算）
+	if len(analysis.RecentTrades) > 0 {
+		pnlPcts := make([]float64, len(analysis.RecentTrades))
+		for i, trade := range analysis.RecentTrades {
+			pnlPcts[i] = trade.PnLPct
+			analysis.ArithmeticReturn += trade.PnLPct / 100
+		}
+		analysis.CompoundedReturn = CompoundReturns(pnlPcts)
+		first := analysis.RecentTrades[0].OpenTime
+		last := analysis.RecentTrades[len(analysis.RecentTrades)-1].CloseTime
+		analysis.RealisedCAGR = AnnualizedReturn(analysis.CompoundedReturn, last.Sub(first).Hours()/24)
+	}
+
 	// 只保留最近的交易（倒序：最新的在前）
 	if len(analysis.RecentTrades) > 10 {
 		// 反转数组，让最新的在前
@@ -543,6 +629,26 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 	return analysis, nil
 }
 
+// CompoundReturns 按顺序复利连乘每笔收益百分比：Π(1 + pct/100) - 1
+func CompoundReturns(pnlPcts []float64) float64 {
+	growth := 1.0
+	for _, pct := range pnlPcts {
+		growth *= 1 + pct/100
+	}
+	return growth - 1
+}
+
+// AnnualizedReturn 将 days 天内的总收益折算为年化收益（天数无效或本金归零时返回0/-1）
+func AnnualizedReturn(totalReturn, days float64) float64 {
+	if days <= 0 {
+		return 0
+	}
+	if totalReturn <= -1 {
+		return -1
+	}
+	return math.Pow(1+totalReturn, 365/days) - 1
+}
+
 // calculateSharpeRatio 计算夏普比率
 // 基于账户净值的变化计算风险调整后收益
 func (l *DecisionLogger) calculateSharpeRatio(records []*DecisionRecord) float64 {
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level 日志级别
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String 级别名称
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+// ParseLevel 解析日志级别（debug / info / warn / error，不区分大小写）
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("未知的日志级别: %s", s)
+}
+
+// Logger 分级日志接口（可注入自定义实现，如测试中捕获输出）
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// StdLogger 基于标准库 log 的分级日志，低于最低级别的日志被丢弃
+type StdLogger struct {
+	min    atomic.Int32
+	output func(calldepth int, s string) error
+}
+
+// NewStdLogger 创建分级日志（输出到标准库 log 的默认 Logger）
+func NewStdLogger(min Level) *StdLogger {
+	l := &StdLogger{output: log.Output}
+	l.min.Store(int32(min))
+	return l
+}
+
+// SetLevel 修改最低输出级别
+func (l *StdLogger) SetLevel(min Level) {
+	l.min.Store(int32(min))
+}
+
+// Level 当前最低输出级别
+func (l *StdLogger) Level() Level {
+	return Level(l.min.Load())
+}
+
+func (l *StdLogger) logf(level Level, format string, args ...interface{}) {
+	if level < l.Level() {
+		return
+	}
+	l.output(3, fmt.Sprintf(format, args...))
+}
+
+func (l *StdLogger) Debugf(format string, args ...interface{}) { l.logf(LevelDebug, format, args...) }
+func (l *StdLogger) Infof(format string, args ...interface{})  { l.logf(LevelInfo, format, args...) }
+func (l *StdLogger) Warnf(format string, args ...interface{})  { l.logf(LevelWarn, format, args...) }
+func (l *StdLogger) Errorf(format string, args ...interface{}) { l.logf(LevelError, format, args...) }
+
+// defaultLogger 全局默认日志（默认 info 级别，由系统配置 log_level 调整）
+var defaultLogger = NewStdLogger(LevelInfo)
+
+// Default 全局默认日志
+func Default() *StdLogger {
+	return defaultLogger
+}
+
+// SetLevel 设置全局默认日志的最低级别
+func SetLevel(min Level) {
+	defaultLogger.SetLevel(min)
+}
+
+// Debugf 使用全局默认日志输出 debug 日志
+func Debugf(format string, args ...interface{}) { defaultLogger.logf(LevelDebug, format, args...) }
+
+// Infof 使用全局默认日志输出 info 日志
+func Infof(format string, args ...interface{}) { defaultLogger.logf(LevelInfo, format, args...) }
+
+// Warnf 使用全局默认日志输出 warn 日志
+func Warnf(format string, args ...interface{}) { defaultLogger.logf(LevelWarn, format, args...) }
+
+// Errorf 使用全局默认日志输出 error 日志
+func Errorf(format string, args ...interface{}) { defaultLogger.logf(LevelError, format, args...) }
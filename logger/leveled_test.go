@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+// newCaptureLogger 创建输出到内存的分级日志
+func newCaptureLogger(min Level) (*StdLogger, *[]string) {
+	var lines []string
+	l := NewStdLogger(min)
+	l.output = func(calldepth int, s string) error {
+		lines = append(lines, s)
+		return nil
+	}
+	return l, &lines
+}
+
+func TestStdLoggerSuppressesDebugAtInfo(t *testing.T) {
+	l, lines := newCaptureLogger(LevelInfo)
+	l.Debugf("debug %d", 1)
+	l.Infof("info %d", 2)
+	l.Warnf("warn %d", 3)
+	l.Errorf("error %d", 4)
+
+	if got := strings.Join(*lines, ","); got != "info 2,warn 3,error 4" {
+		t.Fatalf("lines = %q, want debug suppressed", got)
+	}
+}
+
+func TestStdLoggerSetLevel(t *testing.T) {
+	l, lines := newCaptureLogger(LevelInfo)
+	l.SetLevel(LevelDebug)
+	l.Debugf("debug")
+	l.SetLevel(LevelError)
+	l.Warnf("warn")
+	l.Errorf("error")
+
+	if got := strings.Join(*lines, ","); got != "debug,error" {
+		t.Fatalf("lines = %q, want debug,error", got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{"": LevelInfo, "DEBUG": LevelDebug, " warning ": LevelWarn, "error": LevelError}
+	for in, want := range cases {
+		if got, err := ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) succeeded, want error")
+	}
+}
@@ -0,0 +1,24 @@
+package logger
+
+// PnLAttribution 已实现盈亏归因（价格变动 / 资金费 / 手续费）
+type PnLAttribution struct {
+	PricePnL   float64 `json:"price_pnl"`   // 价格变动盈亏
+	FundingPnL float64 `json:"funding_pnl"` // 持仓期间资金费（收取为正，支付为负）
+	FeePnL     float64 `json:"fee_pnl"`     // 开平仓手续费（≤0）
+	NetPnL     float64 `json:"net_pnl"`     // 合计
+}
+
+// NewPnLAttribution 计算盈亏归因
+// funding 为持仓期间资金费净收入（支付为负），fees 为手续费总额（正数）
+func NewPnLAttribution(side string, quantity, openPrice, closePrice, funding, fees float64) *PnLAttribution {
+	pricePnL := quantity * (closePrice - openPrice)
+	if side == "short" {
+		pricePnL = -pricePnL
+	}
+	return &PnLAttribution{
+		PricePnL:   pricePnL,
+		FundingPnL: funding,
+		FeePnL:     -fees,
+		NetPnL:     pricePnL + funding - fees,
+	}
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestNewPnLAttributionComponents(t *testing.T) {
+	// 空单: 价格 +20，收取资金费 +5，手续费 5.99
+	a := NewPnLAttribution("short", 2, 3000, 2990, 5, 5.99)
+	if !approx(a.PricePnL, 20) || !approx(a.FundingPnL, 5) || !approx(a.FeePnL, -5.99) || !approx(a.NetPnL, 19.01) {
+		t.Errorf("attribution = %+v, want 20/5/-5.99 net 19.01", a)
+	}
+}
+
+func TestAnalyzePerformanceAttributesEachTrade(t *testing.T) {
+	l := NewDecisionLogger(t.TempDir())
+	now := time.Now()
+	open := func(action, symbol string, qty, price float64) DecisionAction {
+		return DecisionAction{Action: action, Symbol: symbol, Quantity: qty, Leverage: 5, Price: price, Timestamp: now, Success: true}
+	}
+	closeWith := func(action, symbol string, price float64, attribution *PnLAttribution) DecisionAction {
+		return DecisionAction{Action: action, Symbol: symbol, Price: price, Timestamp: now.Add(time.Hour), Success: true, Attribution: attribution}
+	}
+
+	btc := NewPnLAttribution("long", 0.1, 60000, 61000, -30, 6.05) // 资金费抵掉近三成价格收益
+	eth := NewPnLAttribution("short", 2, 3000, 2990, 5, 5.99)
+	sol := NewPnLAttribution("long", 10, 100, 100.5, -4.5, 1.0025) // 价格小赚，资金费和手续费后净亏
+
+	records := []*DecisionRecord{
+		{Success: true, Decisions: []DecisionAction{open("open_long", "BTCUSDT", 0.1, 60000), open("open_short", "ETHUSDT", 2, 3000)}},
+		{Success: true, Decisions: []DecisionAction{open("open_long", "SOLUSDT", 10, 100)}},
+		{Success: true, Decisions: []DecisionAction{closeWith("close_long", "BTCUSDT", 61000, btc), closeWith("close_short", "ETHUSDT", 2990, eth)}},
+		{Success: true, Decisions: []DecisionAction{closeWith("close_long", "SOLUSDT", 100.5, sol)}},
+	}
+	for _, r := range records {
+		if err := l.LogDecision(r); err != nil {
+			t.Fatalf("LogDecision: %v", err)
+		}
+	}
+
+	analysis, err := l.AnalyzePerformance(10)
+	if err != nil {
+		t.Fatalf("AnalyzePerformance: %v", err)
+	}
+	if len(analysis.RecentTrades) != 3 {
+		t.Fatalf("trades = %d, want 3", len(analysis.RecentTrades))
+	}
+
+	want := map[string]PnLAttribution{
+		"BTCUSDT": {PricePnL: 100, FundingPnL: -30, FeePnL: -6.05, NetPnL: 63.95},
+		"ETHUSDT": {PricePnL: 20, FundingPnL: 5, FeePnL: -5.99, NetPnL: 19.01},
+		"SOLUSDT": {PricePnL: 5, FundingPnL: -4.5, FeePnL: -1.0025, NetPnL: -0.5025},
+	}
+	var total PnLAttribution
+	for _, trade := range analysis.RecentTrades {
+		a, w := trade.Attribution, want[trade.Symbol]
+		if a == nil {
+			t.Errorf("%s has no attribution", trade.Symbol)
+			continue
+		}
+		if !approx(a.PricePnL, w.PricePnL) || !approx(a.FundingPnL, w.FundingPnL) || !approx(a.FeePnL, w.FeePnL) || !approx(a.NetPnL, w.NetPnL) {
+			t.Errorf("%s attribution = %+v, want %+v", trade.Symbol, *a, w)
+		}
+		// 价格盈亏与交易记录的盈亏一致
+		if !approx(a.PricePnL, trade.PnL) {
+			t.Errorf("%s price PnL %.4f != trade PnL %.4f", trade.Symbol, a.PricePnL, trade.PnL)
+		}
+		total.PricePnL += a.PricePnL
+		total.FundingPnL += a.FundingPnL
+		total.FeePnL += a.FeePnL
+		total.NetPnL += a.NetPnL
+	}
+	if !approx(total.PricePnL+total.FundingPnL+total.FeePnL, total.NetPnL) || !approx(total.NetPnL, 82.4575) {
+		t.Errorf("total = %+v, want components summing to net 82.4575", total)
+	}
+}
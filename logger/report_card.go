@@ -0,0 +1,29 @@
+package logger
+
+import "time"
+
+// TradeReportCard 平仓交易的执行质量评估
+type TradeReportCard struct {
+	EntrySlippage            float64       `json:"entry_slippage"`              // 入场滑点：成交均价相对计划价格的不利偏离（比例，负数为有利）
+	StopHit                  bool          `json:"stop_hit"`                    // 平仓价是否已触及计划止损
+	StopAccuracy             float64       `json:"stop_accuracy"`               // 止损偏离：触及止损时平仓价越过止损价的比例（未触及为0）
+	TotalCommission          float64       `json:"total_commission"`            // 开平仓手续费（USDT）
+	HoldDuration             time.Duration `json:"hold_duration"`               // 持仓时长
+	EffectiveRiskRewardRatio float64       `json:"effective_risk_reward_ratio"` // 实际盈亏 / 按计划止损承担的风险
+	SignalDecayIndex         float64       `json:"signal_decay_index"`          // 信心度衰减：(开仓时信心度 - 平仓前信心度) / 开仓时信心度
+	MaxAdverseExcursionPct   float64       `json:"max_adverse_excursion_pct"`   // 最大不利偏移（MAE）：持仓期间最差价格相对入场价（%）
+	MaxFavorableExcursionPct float64       `json:"max_favorable_excursion_pct"` // 最大有利偏移（MFE）：持仓期间最好价格相对入场价（%）
+}
+
+// recordReportCard 将一笔交易的执行质量计入统计（止损偏离只统计触及止损的交易）
+func recordReportCard(analysis *PerformanceAnalysis, card *TradeReportCard) {
+	if card == nil {
+		return
+	}
+	analysis.AvgSlippage = (analysis.AvgSlippage*float64(analysis.ReportCards) + card.EntrySlippage) / float64(analysis.ReportCards+1)
+	analysis.ReportCards++
+	if card.StopHit {
+		analysis.AvgStopAccuracy = (analysis.AvgStopAccuracy*float64(analysis.StopHits) + card.StopAccuracy) / float64(analysis.StopHits+1)
+		analysis.StopHits++
+	}
+}
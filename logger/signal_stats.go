@@ -0,0 +1,37 @@
+package logger
+
+// SignalContribution 触发开仓的技术指标信号（Weight>0 支持开仓方向，<0 与开仓方向冲突）
+type SignalContribution struct {
+	Indicator string  `json:"indicator"`
+	Weight    float64 `json:"weight"`
+}
+
+// IndicatorStats 按入场信号统计的交易表现（只统计支持开仓方向的信号）
+// 样本足够多（如50笔以上）时可以看出哪些指标真正有预测力
+type IndicatorStats struct {
+	Indicator     string  `json:"indicator"`
+	TotalTrades   int     `json:"total_trades"`
+	WinningTrades int     `json:"winning_trades"`
+	WinRate       float64 `json:"win_rate"` // 百分比
+	TotalPnL      float64 `json:"total_pnl"`
+}
+
+// recordIndicatorOutcome 将一笔交易结果计入其入场信号的统计
+func recordIndicatorOutcome(stats map[string]*IndicatorStats, signals []SignalContribution, pnl float64) {
+	for _, signal := range signals {
+		if signal.Weight <= 0 {
+			continue
+		}
+		s, ok := stats[signal.Indicator]
+		if !ok {
+			s = &IndicatorStats{Indicator: signal.Indicator}
+			stats[signal.Indicator] = s
+		}
+		s.TotalTrades++
+		s.TotalPnL += pnl
+		if pnl > 0 {
+			s.WinningTrades++
+		}
+		s.WinRate = float64(s.WinningTrades) / float64(s.TotalTrades) * 100
+	}
+}
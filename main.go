@@ -7,9 +7,11 @@ import (
 	"nofx/api"
 	"nofx/auth"
 	"nofx/config"
+	"nofx/logger"
 	"nofx/manager"
 	"nofx/market"
 	"nofx/pool"
+	"nofx/trader"
 	"os"
 	"os/signal"
 	"strconv"
@@ -25,19 +27,25 @@ type LeverageConfig struct {
 
 // ConfigFile 配置文件结构，只包含需要同步到数据库的字段
 type ConfigFile struct {
-	AdminMode          bool           `json:"admin_mode"`
-	BetaMode           bool           `json:"beta_mode"`
-	APIServerPort      int            `json:"api_server_port"`
-	UseDefaultCoins    bool           `json:"use_default_coins"`
-	DefaultCoins       []string       `json:"default_coins"`
-	CoinPoolAPIURL     string         `json:"coin_pool_api_url"`
-	OITopAPIURL        string         `json:"oi_top_api_url"`
-	MaxDailyLoss       float64        `json:"max_daily_loss"`
-	MaxDrawdown        float64        `json:"max_drawdown"`
-	StopTradingMinutes int            `json:"stop_trading_minutes"`
-	Leverage           LeverageConfig `json:"leverage"`
-	JWTSecret          string         `json:"jwt_secret"`
-	DataKLineTime      string         `json:"data_k_line_time"`
+	AdminMode          bool            `json:"admin_mode"`
+	BetaMode           bool            `json:"beta_mode"`
+	APIServerPort      int             `json:"api_server_port"`
+	UseDefaultCoins    bool            `json:"use_default_coins"`
+	DefaultCoins       []string        `json:"default_coins"`
+	CoinPoolAPIURL     string          `json:"coin_pool_api_url"`
+	OITopAPIURL        string          `json:"oi_top_api_url"`
+	MaxDailyLoss       float64         `json:"max_daily_loss"`
+	MaxDrawdown        float64         `json:"max_drawdown"`
+	StopTradingMinutes int             `json:"stop_trading_minutes"`
+	Leverage           LeverageConfig  `json:"leverage"`
+	JWTSecret          string          `json:"jwt_secret"`
+	DataKLineTime      string          `json:"data_k_line_time"`
+	RiskConfig         json.RawMessage `json:"risk_config"`
+	DistributedLockURL string          `json:"distributed_lock_url"`
+	LogLevel           string          `json:"log_level"`
+	TraceSpans         bool            `json:"trace_spans"`
+	EntryBlackoutUTC   []string        `json:"entry_blackout_utc"`
+	DecisionPublishURL string          `json:"decision_publish_url"`
 }
 
 // syncConfigToDatabase 从config.json读取配置并同步到数据库
@@ -91,6 +99,37 @@ func syncConfigToDatabase(database *config.Database) error {
 		configs["altcoin_leverage"] = strconv.Itoa(configFile.Leverage.AltcoinLeverage)
 	}
 
+	// 同步风控规则配置（原样保存JSON）
+	if len(configFile.RiskConfig) > 0 {
+		configs["risk_config"] = string(configFile.RiskConfig)
+	}
+
+	// 同步分布式锁地址（多实例部署时配置）
+	if configFile.DistributedLockURL != "" {
+		configs["distributed_lock_url"] = configFile.DistributedLockURL
+	}
+
+	// 同步日志级别（debug / info / warn / error）
+	if configFile.LogLevel != "" {
+		configs["log_level"] = configFile.LogLevel
+	}
+
+	// 同步决策事件发布地址（Redis频道，供外部执行或审计）
+	if configFile.DecisionPublishURL != "" {
+		configs["decision_publish_url"] = configFile.DecisionPublishURL
+	}
+
+	// 同步链路追踪开关（交易周期各阶段耗时写入日志）
+	configs["trace_spans"] = fmt.Sprintf("%t", configFile.TraceSpans)
+
+	// 同步禁止开仓时段（UTC，转换为JSON字符串存储）
+	if configFile.EntryBlackoutUTC != nil {
+		blackoutJSON, err := json.Marshal(configFile.EntryBlackoutUTC)
+		if err == nil {
+			configs["entry_blackout_utc"] = string(blackoutJSON)
+		}
+	}
+
 	// 如果JWT密钥不为空，也同步
 	if configFile.JWTSecret != "" {
 		configs["jwt_secret"] = configFile.JWTSecret
@@ -173,6 +212,14 @@ func main() {
 		log.Printf("⚠️  加载内测码到数据库失败: %v", err)
 	}
 
+	// 设置日志级别（默认info）
+	logLevelStr, _ := database.GetSystemConfig("log_level")
+	logLevel, err := logger.ParseLevel(logLevelStr)
+	if err != nil {
+		log.Printf("⚠️  %v，使用 info 级别", err)
+	}
+	logger.SetLevel(logLevel)
+
 	// 获取系统配置
 	useDefaultCoinsStr, _ := database.GetSystemConfig("use_default_coins")
 	useDefaultCoins := useDefaultCoinsStr == "true"
@@ -245,6 +292,49 @@ func main() {
 	// 创建TraderManager
 	traderManager := manager.NewTraderManager()
 
+	// 配置分布式锁（多实例部署共用同一交易账户时需要配置Redis）
+	distributedLockURL, _ := database.GetSystemConfig("distributed_lock_url")
+	distributedLock, err := trader.NewDistributedLock(distributedLockURL)
+	if err != nil {
+		log.Fatalf("❌ 初始化分布式锁失败: %v", err)
+	}
+	if distributedLockURL != "" {
+		log.Printf("✓ 已启用Redis分布式锁")
+	}
+	traderManager.SetDistributedLock(distributedLock)
+
+	// 配置决策事件发布（AI决策、执行计划和执行结果发布到Redis频道）
+	if publishURL, _ := database.GetSystemConfig("decision_publish_url"); publishURL != "" {
+		publisher, err := trader.NewRedisDecisionPublisher(publishURL)
+		if err != nil {
+			log.Fatalf("❌ 初始化决策事件发布失败: %v", err)
+		}
+		traderManager.SetDecisionPublisher(publisher)
+		log.Printf("✓ 已启用决策事件发布")
+	}
+
+	// 配置链路追踪（交易周期各阶段的耗时和错误写入日志）
+	if traceSpans, _ := database.GetSystemConfig("trace_spans"); traceSpans == "true" {
+		traderManager.SetTracer(trader.NewLogTracer(nil))
+		log.Printf("✓ 已启用交易周期链路追踪")
+	}
+
+	// 配置禁止开仓时段（如CPI等重要数据公布前后），作为自定义风控规则追加到内置规则之后
+	if blackoutJSON, _ := database.GetSystemConfig("entry_blackout_utc"); blackoutJSON != "" {
+		var windows []string
+		if err := json.Unmarshal([]byte(blackoutJSON), &windows); err != nil {
+			log.Printf("⚠️  解析entry_blackout_utc配置失败: %v", err)
+		} else if len(windows) > 0 {
+			rule, err := trader.NewEntryBlackoutRule(windows)
+			if err != nil {
+				log.Printf("⚠️  禁止开仓时段配置无效: %v", err)
+			} else {
+				traderManager.AddRiskRule(rule)
+				log.Printf("✓ 已配置禁止开仓时段: %v (UTC)", windows)
+			}
+		}
+	}
+
 	// 从数据库加载所有交易员到内存
 	err = traderManager.LoadTradersFromDatabase(database)
 	if err != nil {
@@ -310,6 +400,15 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// SIGQUIT: 紧急平仓所有交易员（平掉全部持仓并暂停交易24小时，程序继续运行）
+	quitChan := make(chan os.Signal, 1)
+	signal.Notify(quitChan, syscall.SIGQUIT)
+	go func() {
+		for range quitChan {
+			traderManager.EmergencyExitAll("收到SIGQUIT信号")
+		}
+	}()
+
 	// TODO: 启动数据库中配置为运行状态的交易员
 	// traderManager.StartAll()
 
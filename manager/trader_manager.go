@@ -4,8 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"nofx/config"
+	"nofx/logger"
 	"nofx/trader"
 	"sort"
 	"strconv"
@@ -23,9 +23,13 @@ type CompetitionCache struct {
 
 // TraderManager 管理多个trader实例
 type TraderManager struct {
-	traders         map[string]*trader.AutoTrader // key: trader ID
+	traders          map[string]*trader.AutoTrader // key: trader ID
 	competitionCache *CompetitionCache
-	mu              sync.RWMutex
+	distributedLock  trader.DistributedLock   // 分布式锁（所有trader共用）
+	tracer           trader.Tracer            // 链路追踪（所有trader共用，nil=不追踪）
+	riskRules        []trader.RiskRule        // 自定义风控规则（追加在内置规则之后、AI二次验证之前）
+	publisher        trader.DecisionPublisher // 决策事件发布（所有trader共用，nil=不发布）
+	mu               sync.RWMutex
 }
 
 // NewTraderManager 创建trader管理器
@@ -38,6 +42,50 @@ func NewTraderManager() *TraderManager {
 	}
 }
 
+// SetDistributedLock 设置分布式锁，之后加载的trader都会使用该锁
+func (tm *TraderManager) SetDistributedLock(lock trader.DistributedLock) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.distributedLock = lock
+}
+
+// SetTracer 设置链路追踪器，之后加载的trader都会使用该追踪器
+func (tm *TraderManager) SetTracer(tracer trader.Tracer) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.tracer = tracer
+}
+
+// SetDecisionPublisher 设置决策事件发布器，之后加载的trader都会发布决策事件
+func (tm *TraderManager) SetDecisionPublisher(publisher trader.DecisionPublisher) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.publisher = publisher
+}
+
+// AddRiskRule 注册自定义风控规则，之后加载的trader都会在内置规则之后执行该规则
+func (tm *TraderManager) AddRiskRule(rule trader.RiskRule) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.riskRules = append(tm.riskRules, rule)
+}
+
+// configureTrader 为新建的trader设置所有trader共用的组件（调用方持有 tm.mu）
+func (tm *TraderManager) configureTrader(at *trader.AutoTrader) {
+	if tm.distributedLock != nil {
+		at.SetDistributedLock(tm.distributedLock)
+	}
+	if tm.tracer != nil {
+		at.SetTracer(tm.tracer)
+	}
+	if tm.publisher != nil {
+		at.SetDecisionPublisher(tm.publisher)
+	}
+	for _, rule := range tm.riskRules {
+		at.AddRiskRule(rule)
+	}
+}
+
 // LoadTradersFromDatabase 从数据库加载所有交易员到内存
 func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) error {
 	tm.mu.Lock()
@@ -49,21 +97,21 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 		return fmt.Errorf("获取用户列表失败: %w", err)
 	}
 
-	log.Printf("📋 发现 %d 个用户，开始加载所有交易员配置...", len(userIDs))
+	logger.Infof("📋 发现 %d 个用户，开始加载所有交易员配置...", len(userIDs))
 
 	var allTraders []*config.TraderRecord
 	for _, userID := range userIDs {
 		// 获取每个用户的交易员
 		traders, err := database.GetTraders(userID)
 		if err != nil {
-			log.Printf("⚠️ 获取用户 %s 的交易员失败: %v", userID, err)
+			logger.Warnf("⚠️ 获取用户 %s 的交易员失败: %v", userID, err)
 			continue
 		}
-		log.Printf("📋 用户 %s: %d 个交易员", userID, len(traders))
+		logger.Infof("📋 用户 %s: %d 个交易员", userID, len(traders))
 		allTraders = append(allTraders, traders...)
 	}
 
-	log.Printf("📋 总共加载 %d 个交易员配置", len(allTraders))
+	logger.Infof("📋 总共加载 %d 个交易员配置", len(allTraders))
 
 	// 获取系统配置（不包含信号源，信号源现在为用户级别）
 	maxDailyLossStr, _ := database.GetSystemConfig("max_daily_loss")
@@ -91,17 +139,20 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 	var defaultCoins []string
 	if defaultCoinsStr != "" {
 		if err := json.Unmarshal([]byte(defaultCoinsStr), &defaultCoins); err != nil {
-			log.Printf("⚠️ 解析默认币种配置失败: %v，使用空列表", err)
+			logger.Warnf("⚠️ 解析默认币种配置失败: %v，使用空列表", err)
 			defaultCoins = []string{}
 		}
 	}
 
+	// 解析风控规则配置
+	riskConfig := loadRiskConfig(database)
+
 	// 为每个交易员获取AI模型和交易所配置
 	for _, traderCfg := range allTraders {
 		// 获取AI模型配置（使用交易员所属的用户ID）
 		aiModels, err := database.GetAIModels(traderCfg.UserID)
 		if err != nil {
-			log.Printf("⚠️  获取AI模型配置失败: %v", err)
+			logger.Warnf("⚠️  获取AI模型配置失败: %v", err)
 			continue
 		}
 
@@ -118,26 +169,26 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 			for _, model := range aiModels {
 				if model.Provider == traderCfg.AIModelID {
 					aiModelCfg = model
-					log.Printf("⚠️  交易员 %s 使用旧版 provider 匹配: %s -> %s", traderCfg.Name, traderCfg.AIModelID, model.ID)
+					logger.Warnf("⚠️  交易员 %s 使用旧版 provider 匹配: %s -> %s", traderCfg.Name, traderCfg.AIModelID, model.ID)
 					break
 				}
 			}
 		}
 
 		if aiModelCfg == nil {
-			log.Printf("⚠️  交易员 %s 的AI模型 %s 不存在，跳过", traderCfg.Name, traderCfg.AIModelID)
+			logger.Warnf("⚠️  交易员 %s 的AI模型 %s 不存在，跳过", traderCfg.Name, traderCfg.AIModelID)
 			continue
 		}
 
 		if !aiModelCfg.Enabled {
-			log.Printf("⚠️  交易员 %s 的AI模型 %s 未启用，跳过", traderCfg.Name, traderCfg.AIModelID)
+			logger.Warnf("⚠️  交易员 %s 的AI模型 %s 未启用，跳过", traderCfg.Name, traderCfg.AIModelID)
 			continue
 		}
 
 		// 获取交易所配置（使用交易员所属的用户ID）
 		exchanges, err := database.GetExchanges(traderCfg.UserID)
 		if err != nil {
-			log.Printf("⚠️  获取交易所配置失败: %v", err)
+			logger.Warnf("⚠️  获取交易所配置失败: %v", err)
 			continue
 		}
 
@@ -150,12 +201,12 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 		}
 
 		if exchangeCfg == nil {
-			log.Printf("⚠️  交易员 %s 的交易所 %s 不存在，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			logger.Warnf("⚠️  交易员 %s 的交易所 %s 不存在，跳过", traderCfg.Name, traderCfg.ExchangeID)
 			continue
 		}
 
 		if !exchangeCfg.Enabled {
-			log.Printf("⚠️  交易员 %s 的交易所 %s 未启用，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			logger.Warnf("⚠️  交易员 %s 的交易所 %s 未启用，跳过", traderCfg.Name, traderCfg.ExchangeID)
 			continue
 		}
 
@@ -166,23 +217,23 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 			oiTopURL = userSignalSource.OITopURL
 		} else {
 			// 如果用户没有配置信号源，使用空字符串
-			log.Printf("🔍 用户 %s 暂未配置信号源", traderCfg.UserID)
+			logger.Infof("🔍 用户 %s 暂未配置信号源", traderCfg.UserID)
 		}
 
 		// 添加到TraderManager
-		err = tm.addTraderFromDB(traderCfg, aiModelCfg, exchangeCfg, coinPoolURL, oiTopURL, maxDailyLoss, maxDrawdown, stopTradingMinutes, defaultCoins)
+		err = tm.addTraderFromDB(traderCfg, aiModelCfg, exchangeCfg, coinPoolURL, oiTopURL, maxDailyLoss, maxDrawdown, stopTradingMinutes, defaultCoins, riskConfig)
 		if err != nil {
-			log.Printf("❌ 添加交易员 %s 失败: %v", traderCfg.Name, err)
+			logger.Errorf("❌ 添加交易员 %s 失败: %v", traderCfg.Name, err)
 			continue
 		}
 	}
 
-	log.Printf("✓ 成功加载 %d 个交易员到内存", len(tm.traders))
+	logger.Infof("✓ 成功加载 %d 个交易员到内存", len(tm.traders))
 	return nil
 }
 
 // addTraderFromConfig 内部方法：从配置添加交易员（不加锁，因为调用方已加锁）
-func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModelCfg *config.AIModelConfig, exchangeCfg *config.ExchangeConfig, coinPoolURL, oiTopURL string, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, defaultCoins []string) error {
+func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModelCfg *config.AIModelConfig, exchangeCfg *config.ExchangeConfig, coinPoolURL, oiTopURL string, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, defaultCoins []string, riskConfig trader.RiskConfig) error {
 	if _, exists := tm.traders[traderCfg.ID]; exists {
 		return fmt.Errorf("trader ID '%s' 已存在", traderCfg.ID)
 	}
@@ -209,7 +260,7 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 	var effectiveCoinPoolURL string
 	if traderCfg.UseCoinPool && coinPoolURL != "" {
 		effectiveCoinPoolURL = coinPoolURL
-		log.Printf("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
+		logger.Infof("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
 	}
 
 	// 构建AutoTraderConfig
@@ -239,6 +290,7 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		DefaultCoins:          defaultCoins,
 		TradingCoins:          tradingCoins,
 		SystemPromptTemplate:  traderCfg.SystemPromptTemplate, // 系统提示词模板
+		Risk:                  riskConfig,
 	}
 
 	// 根据交易所类型设置API密钥
@@ -267,26 +319,28 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		return fmt.Errorf("创建trader失败: %w", err)
 	}
 
+	tm.configureTrader(at)
+
 	// 设置自定义prompt（如果有）
 	if traderCfg.CustomPrompt != "" {
 		at.SetCustomPrompt(traderCfg.CustomPrompt)
 		at.SetOverrideBasePrompt(traderCfg.OverrideBasePrompt)
 		if traderCfg.OverrideBasePrompt {
-			log.Printf("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
+			logger.Infof("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
 		} else {
-			log.Printf("✓ 已设置自定义交易策略prompt (补充基础prompt)")
+			logger.Infof("✓ 已设置自定义交易策略prompt (补充基础prompt)")
 		}
 	}
 
 	tm.traders[traderCfg.ID] = at
-	log.Printf("✓ Trader '%s' (%s + %s) 已加载到内存", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
+	logger.Infof("✓ Trader '%s' (%s + %s) 已加载到内存", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
 	return nil
 }
 
 // AddTrader 从数据库配置添加trader (移除旧版兼容性)
 
 // AddTraderFromDB 从数据库配置添加trader
-func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModelCfg *config.AIModelConfig, exchangeCfg *config.ExchangeConfig, coinPoolURL, oiTopURL string, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, defaultCoins []string) error {
+func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModelCfg *config.AIModelConfig, exchangeCfg *config.ExchangeConfig, coinPoolURL, oiTopURL string, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, defaultCoins []string, riskConfig trader.RiskConfig) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -316,7 +370,7 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 	var effectiveCoinPoolURL string
 	if traderCfg.UseCoinPool && coinPoolURL != "" {
 		effectiveCoinPoolURL = coinPoolURL
-		log.Printf("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
+		logger.Infof("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
 	}
 
 	// 构建AutoTraderConfig
@@ -345,6 +399,7 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		IsCrossMargin:         traderCfg.IsCrossMargin,
 		DefaultCoins:          defaultCoins,
 		TradingCoins:          tradingCoins,
+		Risk:                  riskConfig,
 	}
 
 	// 根据交易所类型设置API密钥
@@ -373,19 +428,21 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		return fmt.Errorf("创建trader失败: %w", err)
 	}
 
+	tm.configureTrader(at)
+
 	// 设置自定义prompt（如果有）
 	if traderCfg.CustomPrompt != "" {
 		at.SetCustomPrompt(traderCfg.CustomPrompt)
 		at.SetOverrideBasePrompt(traderCfg.OverrideBasePrompt)
 		if traderCfg.OverrideBasePrompt {
-			log.Printf("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
+			logger.Infof("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
 		} else {
-			log.Printf("✓ 已设置自定义交易策略prompt (补充基础prompt)")
+			logger.Infof("✓ 已设置自定义交易策略prompt (补充基础prompt)")
 		}
 	}
 
 	tm.traders[traderCfg.ID] = at
-	log.Printf("✓ Trader '%s' (%s + %s) 已添加", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
+	logger.Infof("✓ Trader '%s' (%s + %s) 已添加", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
 	return nil
 }
 
@@ -430,12 +487,12 @@ func (tm *TraderManager) StartAll() {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	log.Println("🚀 启动所有Trader...")
+	logger.Infof("🚀 启动所有Trader...")
 	for id, t := range tm.traders {
 		go func(traderID string, at *trader.AutoTrader) {
-			log.Printf("▶️  启动 %s...", at.GetName())
+			logger.Infof("▶️  启动 %s...", at.GetName())
 			if err := at.Run(); err != nil {
-				log.Printf("❌ %s 运行错误: %v", at.GetName(), err)
+				logger.Errorf("❌ %s 运行错误: %v", at.GetName(), err)
 			}
 		}(id, t)
 	}
@@ -446,12 +503,25 @@ func (tm *TraderManager) StopAll() {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	log.Println("⏹  停止所有Trader...")
+	logger.Infof("⏹  停止所有Trader...")
 	for _, t := range tm.traders {
 		t.Stop()
 	}
 }
 
+// EmergencyExitAll 所有Trader紧急平仓
+func (tm *TraderManager) EmergencyExitAll(reason string) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	logger.Infof("🚨 所有Trader紧急平仓: %s", reason)
+	for _, t := range tm.traders {
+		if _, err := t.EmergencyExit(reason); err != nil {
+			logger.Errorf("❌ Trader %s 紧急平仓失败: %v", t.GetName(), err)
+		}
+	}
+}
+
 // GetComparisonData 获取对比数据
 func (tm *TraderManager) GetComparisonData() (map[string]interface{}, error) {
 	tm.mu.RLock()
@@ -500,7 +570,7 @@ func (tm *TraderManager) GetCompetitionData() (map[string]interface{}, error) {
 			cachedData[k] = v
 		}
 		tm.competitionCache.mu.RUnlock()
-		log.Printf("📋 返回竞赛数据缓存 (缓存时间: %.1fs)", time.Since(tm.competitionCache.timestamp).Seconds())
+		logger.Infof("📋 返回竞赛数据缓存 (缓存时间: %.1fs)", time.Since(tm.competitionCache.timestamp).Seconds())
 		return cachedData, nil
 	}
 	tm.competitionCache.mu.RUnlock()
@@ -514,7 +584,7 @@ func (tm *TraderManager) GetCompetitionData() (map[string]interface{}, error) {
 	}
 	tm.mu.RUnlock()
 	
-	log.Printf("🔄 重新获取竞赛数据，交易员数量: %d", len(allTraders))
+	logger.Infof("🔄 重新获取竞赛数据，交易员数量: %d", len(allTraders))
 	
 	// 并发获取交易员数据
 	traders := tm.getConcurrentTraderData(allTraders)
@@ -603,7 +673,7 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 				}
 			case err := <-errorChan:
 				// 获取账户信息失败
-				log.Printf("⚠️ 获取交易员 %s 账户信息失败: %v", trader.GetID(), err)
+				logger.Warnf("⚠️ 获取交易员 %s 账户信息失败: %v", trader.GetID(), err)
 				traderData = map[string]interface{}{
 					"trader_id":       trader.GetID(),
 					"trader_name":     trader.GetName(),
@@ -619,7 +689,7 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 				}
 			case <-ctx.Done():
 				// 超时
-				log.Printf("⏰ 获取交易员 %s 账户信息超时", trader.GetID())
+				logger.Infof("⏰ 获取交易员 %s 账户信息超时", trader.GetID())
 				traderData = map[string]interface{}{
 					"trader_id":       trader.GetID(),
 					"trader_name":     trader.GetName(),
@@ -719,7 +789,7 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 		return fmt.Errorf("获取用户 %s 的交易员列表失败: %w", userID, err)
 	}
 
-	log.Printf("📋 为用户 %s 加载交易员配置: %d 个", userID, len(traders))
+	logger.Infof("📋 为用户 %s 加载交易员配置: %d 个", userID, len(traders))
 
 	// 获取系统配置（不包含信号源，信号源现在为用户级别）
 	maxDailyLossStr, _ := database.GetSystemConfig("max_daily_loss")
@@ -732,9 +802,9 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 	if userSignalSource, err := database.GetUserSignalSource(userID); err == nil {
 		coinPoolURL = userSignalSource.CoinPoolURL
 		oiTopURL = userSignalSource.OITopURL
-		log.Printf("📡 加载用户 %s 的信号源配置: COIN POOL=%s, OI TOP=%s", userID, coinPoolURL, oiTopURL)
+		logger.Infof("📡 加载用户 %s 的信号源配置: COIN POOL=%s, OI TOP=%s", userID, coinPoolURL, oiTopURL)
 	} else {
-		log.Printf("🔍 用户 %s 暂未配置信号源", userID)
+		logger.Infof("🔍 用户 %s 暂未配置信号源", userID)
 	}
 
 	// 解析配置
@@ -757,23 +827,26 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 	var defaultCoins []string
 	if defaultCoinsStr != "" {
 		if err := json.Unmarshal([]byte(defaultCoinsStr), &defaultCoins); err != nil {
-			log.Printf("⚠️ 解析默认币种配置失败: %v，使用空列表", err)
+			logger.Warnf("⚠️ 解析默认币种配置失败: %v，使用空列表", err)
 			defaultCoins = []string{}
 		}
 	}
 
+	// 解析风控规则配置
+	riskConfig := loadRiskConfig(database)
+
 	// 为每个交易员获取AI模型和交易所配置
 	for _, traderCfg := range traders {
 		// 检查是否已经加载过这个交易员
 		if _, exists := tm.traders[traderCfg.ID]; exists {
-			log.Printf("⚠️ 交易员 %s 已经加载，跳过", traderCfg.Name)
+			logger.Warnf("⚠️ 交易员 %s 已经加载，跳过", traderCfg.Name)
 			continue
 		}
 
 		// 获取AI模型配置（使用该用户的配置）
 		aiModels, err := database.GetAIModels(userID)
 		if err != nil {
-			log.Printf("⚠️ 获取用户 %s 的AI模型配置失败: %v", userID, err)
+			logger.Warnf("⚠️ 获取用户 %s 的AI模型配置失败: %v", userID, err)
 			continue
 		}
 
@@ -790,26 +863,26 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 			for _, model := range aiModels {
 				if model.Provider == traderCfg.AIModelID {
 					aiModelCfg = model
-					log.Printf("⚠️  交易员 %s 使用旧版 provider 匹配: %s -> %s", traderCfg.Name, traderCfg.AIModelID, model.ID)
+					logger.Warnf("⚠️  交易员 %s 使用旧版 provider 匹配: %s -> %s", traderCfg.Name, traderCfg.AIModelID, model.ID)
 					break
 				}
 			}
 		}
 
 		if aiModelCfg == nil {
-			log.Printf("⚠️ 交易员 %s 的AI模型 %s 不存在，跳过", traderCfg.Name, traderCfg.AIModelID)
+			logger.Warnf("⚠️ 交易员 %s 的AI模型 %s 不存在，跳过", traderCfg.Name, traderCfg.AIModelID)
 			continue
 		}
 
 		if !aiModelCfg.Enabled {
-			log.Printf("⚠️ 交易员 %s 的AI模型 %s 未启用，跳过", traderCfg.Name, traderCfg.AIModelID)
+			logger.Warnf("⚠️ 交易员 %s 的AI模型 %s 未启用，跳过", traderCfg.Name, traderCfg.AIModelID)
 			continue
 		}
 
 		// 获取交易所配置（使用该用户的配置）
 		exchanges, err := database.GetExchanges(userID)
 		if err != nil {
-			log.Printf("⚠️ 获取用户 %s 的交易所配置失败: %v", userID, err)
+			logger.Warnf("⚠️ 获取用户 %s 的交易所配置失败: %v", userID, err)
 			continue
 		}
 
@@ -822,19 +895,19 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 		}
 
 		if exchangeCfg == nil {
-			log.Printf("⚠️ 交易员 %s 的交易所 %s 不存在，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			logger.Warnf("⚠️ 交易员 %s 的交易所 %s 不存在，跳过", traderCfg.Name, traderCfg.ExchangeID)
 			continue
 		}
 
 		if !exchangeCfg.Enabled {
-			log.Printf("⚠️ 交易员 %s 的交易所 %s 未启用，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			logger.Warnf("⚠️ 交易员 %s 的交易所 %s 未启用，跳过", traderCfg.Name, traderCfg.ExchangeID)
 			continue
 		}
 
 		// 使用现有的方法加载交易员
-		err = tm.loadSingleTrader(traderCfg, aiModelCfg, exchangeCfg, coinPoolURL, oiTopURL, maxDailyLoss, maxDrawdown, stopTradingMinutes, defaultCoins)
+		err = tm.loadSingleTrader(traderCfg, aiModelCfg, exchangeCfg, coinPoolURL, oiTopURL, maxDailyLoss, maxDrawdown, stopTradingMinutes, defaultCoins, riskConfig)
 		if err != nil {
-			log.Printf("⚠️ 加载交易员 %s 失败: %v", traderCfg.Name, err)
+			logger.Warnf("⚠️ 加载交易员 %s 失败: %v", traderCfg.Name, err)
 		}
 	}
 
@@ -842,7 +915,7 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 }
 
 // loadSingleTrader 加载单个交易员（从现有代码提取的公共逻辑）
-func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiModelCfg *config.AIModelConfig, exchangeCfg *config.ExchangeConfig, coinPoolURL, oiTopURL string, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, defaultCoins []string) error {
+func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiModelCfg *config.AIModelConfig, exchangeCfg *config.ExchangeConfig, coinPoolURL, oiTopURL string, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, defaultCoins []string, riskConfig trader.RiskConfig) error {
 	// 处理交易币种列表
 	var tradingCoins []string
 	if traderCfg.TradingSymbols != "" {
@@ -865,7 +938,7 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 	var effectiveCoinPoolURL string
 	if traderCfg.UseCoinPool && coinPoolURL != "" {
 		effectiveCoinPoolURL = coinPoolURL
-		log.Printf("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
+		logger.Infof("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
 	}
 
 	// 构建AutoTraderConfig
@@ -889,6 +962,7 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 		DefaultCoins:         defaultCoins,
 		TradingCoins:         tradingCoins,
 		SystemPromptTemplate: traderCfg.SystemPromptTemplate, // 系统提示词模板
+		Risk:                 riskConfig,
 	}
 
 	// 根据交易所类型设置API密钥
@@ -917,18 +991,33 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 		return fmt.Errorf("创建trader失败: %w", err)
 	}
 
+	tm.configureTrader(at)
+
 	// 设置自定义prompt（如果有）
 	if traderCfg.CustomPrompt != "" {
 		at.SetCustomPrompt(traderCfg.CustomPrompt)
 		at.SetOverrideBasePrompt(traderCfg.OverrideBasePrompt)
 		if traderCfg.OverrideBasePrompt {
-			log.Printf("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
+			logger.Infof("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
 		} else {
-			log.Printf("✓ 已设置自定义交易策略prompt (补充基础prompt)")
+			logger.Infof("✓ 已设置自定义交易策略prompt (补充基础prompt)")
 		}
 	}
 
 	tm.traders[traderCfg.ID] = at
-	log.Printf("✓ Trader '%s' (%s + %s) 已为用户加载到内存", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
+	logger.Infof("✓ Trader '%s' (%s + %s) 已为用户加载到内存", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
 	return nil
 }
+
+// loadRiskConfig 从系统配置读取风控规则（risk_config，JSON格式）
+func loadRiskConfig(database *config.Database) trader.RiskConfig {
+	var riskConfig trader.RiskConfig
+	riskConfigStr, _ := database.GetSystemConfig("risk_config")
+	if riskConfigStr != "" {
+		if err := json.Unmarshal([]byte(riskConfigStr), &riskConfig); err != nil {
+			logger.Warnf("⚠️ 解析风控规则配置失败: %v，使用默认值", err)
+			return trader.RiskConfig{}
+		}
+	}
+	return riskConfig
+}
@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"nofx/logger"
 	"strconv"
 	"time"
 )
@@ -81,7 +81,7 @@ func (c *APIClient) GetKlines(symbol, interval string, limit int) ([]Kline, erro
 	for _, kr := range klineResponses {
 		kline, err := parseKline(kr)
 		if err != nil {
-			log.Printf("解析K线数据失败: %v", err)
+			logger.Warnf("解析K线数据失败: %v", err)
 			continue
 		}
 		klines = append(klines, kline)
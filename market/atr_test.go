@@ -0,0 +1,51 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+// atrSeries 手工计算的K线序列（含一次向下跳空）
+var atrSeries = []Kline{
+	{High: 10, Low: 9, Close: 9.5},
+	{High: 11, Low: 9.5, Close: 10.5},  // TR 1.5
+	{High: 12, Low: 10, Close: 11},     // TR 2
+	{High: 11.5, Low: 10.5, Close: 11}, // TR 1
+	{High: 13, Low: 11, Close: 12.5},   // TR 2
+	{High: 10, Low: 9, Close: 9.5},     // 跳空: TR = |9 - 12.5| = 3.5
+}
+
+func TestCalculateATRKnownSeries(t *testing.T) {
+	cases := []struct {
+		period int
+		want   float64
+	}{
+		// 初始ATR = (1.5+2+1)/3 = 1.5，之后 Wilder 平滑: (1.5×2+2)/3 = 5/3，(5/3×2+3.5)/3 = 41/18
+		{3, 41.0 / 18},
+		// 初始ATR = (1.5+2+1+2)/4 = 1.625，(1.625×3+3.5)/4 = 2.09375
+		{4, 2.09375},
+		// 恰好 period+1 根K线时为TR的简单平均
+		{5, (1.5 + 2 + 1 + 2 + 3.5) / 5},
+	}
+	for _, tc := range cases {
+		got, err := CalculateATR(atrSeries, tc.period)
+		if err != nil {
+			t.Fatalf("CalculateATR(%d): %v", tc.period, err)
+		}
+		if math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("ATR%d = %.6f, want %.6f", tc.period, got, tc.want)
+		}
+	}
+}
+
+func TestCalculateATRErrors(t *testing.T) {
+	if _, err := CalculateATR(atrSeries, 6); err == nil {
+		t.Error("ATR6 with 6 klines succeeded, want insufficient klines")
+	}
+	if _, err := CalculateATR(atrSeries, 0); err == nil {
+		t.Error("ATR0 succeeded, want an invalid period error")
+	}
+	if got := calculateATR(atrSeries[:2], 3); got != 0 {
+		t.Errorf("calculateATR with too few klines = %v, want 0", got)
+	}
+}
@@ -0,0 +1,60 @@
+package market
+
+import "math"
+
+// 市场广度评分的权重
+const (
+	breadthTrendWeight      = 0.6 // 上涨币种占比
+	breadthRSIWeight        = 0.2 // RSI平稳程度（越接近50越健康）
+	breadthVolatilityWeight = 0.2 // 波动平稳程度
+)
+
+// ComputeMarketBreadth 根据多个币种的行情计算市场广度评分（0-100，50为中性）
+// 综合价格位于4小时EMA20上方的币种占比、RSI7偏离50的平均程度和平均ATR/价格，
+// 用于在代码侧交叉验证市场状态判断；没有有效数据时返回50
+func ComputeMarketBreadth(candidates []*Data) float64 {
+	up, down, valid := 0, 0, 0
+	rsiExtremity, volatility := 0.0, 0.0
+	for _, data := range candidates {
+		if data == nil || data.CurrentPrice <= 0 || data.LongerTermContext == nil || data.LongerTermContext.EMA20 <= 0 {
+			continue
+		}
+		valid++
+		if data.CurrentPrice > data.LongerTermContext.EMA20 {
+			up++
+		} else if data.CurrentPrice < data.LongerTermContext.EMA20 {
+			down++
+		}
+		rsiExtremity += math.Min(1, math.Abs(data.CurrentRSI7-50)/50)
+		volatility += math.Min(1, data.ATRRatio()/regimeVolatileATRRatio)
+	}
+	if valid == 0 {
+		return 50
+	}
+
+	trendScore := 0.5
+	if up+down > 0 {
+		trendScore = float64(up) / float64(up+down)
+	}
+	rsiScore := 1 - rsiExtremity/float64(valid)
+	volatilityScore := 1 - volatility/float64(valid)
+	return (breadthTrendWeight*trendScore + breadthRSIWeight*rsiScore + breadthVolatilityWeight*volatilityScore) * 100
+}
+
+// 市场状态与广度背离的阈值：上升趋势但广度低于下限，或下降趋势但广度高于上限
+const (
+	breadthDivergenceLow  = 35.0
+	breadthDivergenceHigh = 65.0
+)
+
+// BreadthDiverges 市场状态（来自BTC）与市场广度是否明显背离
+func BreadthDiverges(regime string, breadth float64) bool {
+	switch regime {
+	case RegimeTrendingUp:
+		return breadth < breadthDivergenceLow
+	case RegimeTrendingDown:
+		return breadth > breadthDivergenceHigh
+	default:
+		return false
+	}
+}
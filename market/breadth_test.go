@@ -0,0 +1,59 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+// breadthData 价格相对4小时EMA20、RSI7和ATR14构造的行情
+func breadthData(price, ema20, rsi7, atr14 float64) *Data {
+	return &Data{CurrentPrice: price, CurrentRSI7: rsi7, LongerTermContext: &LongerTermData{EMA20: ema20, ATR14: atr14}}
+}
+
+func TestComputeMarketBreadth(t *testing.T) {
+	cases := []struct {
+		name       string
+		candidates []*Data
+		want       float64
+	}{
+		{"all above ema, calm", []*Data{breadthData(110, 100, 50, 0), breadthData(110, 100, 50, 0)}, 100},
+		{"all below ema, calm", []*Data{breadthData(90, 100, 50, 0), breadthData(90, 100, 50, 0)}, 40},
+		// 趋势 0.75×0.6 + RSI (1-0.4)×0.2 + 波动 (1-0.02/0.04)×0.2 = 0.45 + 0.12 + 0.10
+		{"mixed", []*Data{
+			breadthData(100, 90, 70, 2), breadthData(100, 90, 70, 2),
+			breadthData(100, 90, 30, 2), breadthData(100, 110, 30, 2),
+		}, 67},
+		// 剧烈波动、RSI极端时广度偏低
+		{"extreme and volatile", []*Data{breadthData(90, 100, 0, 10), breadthData(90, 100, 100, 10)}, 0},
+		{"price on ema is neutral trend", []*Data{breadthData(100, 100, 50, 0)}, 70},
+		{"no valid data", []*Data{nil, {CurrentPrice: 100}, breadthData(100, 0, 50, 1)}, 50},
+		{"empty", nil, 50},
+	}
+	for _, tc := range cases {
+		if got := ComputeMarketBreadth(tc.candidates); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: breadth = %.4f, want %.4f", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBreadthDiverges(t *testing.T) {
+	cases := []struct {
+		regime  string
+		breadth float64
+		want    bool
+	}{
+		{RegimeTrendingUp, 30, true}, // BTC上涨但多数币种走弱
+		{RegimeTrendingUp, 35, false},
+		{RegimeTrendingUp, 80, false},
+		{RegimeTrendingDown, 70, true}, // BTC下跌但多数币种走强
+		{RegimeTrendingDown, 65, false},
+		{RegimeTrendingDown, 20, false},
+		{RegimeRanging, 10, false},
+		{RegimeVolatile, 90, false},
+	}
+	for _, tc := range cases {
+		if got := BreadthDiverges(tc.regime, tc.breadth); got != tc.want {
+			t.Errorf("BreadthDiverges(%s, %.0f) = %v, want %v", tc.regime, tc.breadth, got, tc.want)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package market
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"nofx/logger"
 	"strings"
 	"sync"
 	"time"
@@ -44,7 +44,7 @@ func (c *CombinedStreamsClient) Connect() error {
 	c.conn = conn
 	c.mu.Unlock()
 
-	log.Println("组合流WebSocket连接成功")
+	logger.Infof("组合流WebSocket连接成功")
 	go c.readMessages()
 
 	return nil
@@ -56,7 +56,7 @@ func (c *CombinedStreamsClient) BatchSubscribeKlines(symbols []string, interval
 	batches := c.splitIntoBatches(symbols, c.batchSize)
 
 	for i, batch := range batches {
-		log.Printf("订阅第 %d 批, 数量: %d", i+1, len(batch))
+		logger.Infof("订阅第 %d 批, 数量: %d", i+1, len(batch))
 
 		streams := make([]string, len(batch))
 		for j, symbol := range batch {
@@ -106,7 +106,7 @@ func (c *CombinedStreamsClient) subscribeStreams(streams []string) error {
 		return fmt.Errorf("WebSocket未连接")
 	}
 
-	log.Printf("订阅流: %v", streams)
+	logger.Infof("订阅流: %v", streams)
 	return c.conn.WriteJSON(subscribeMsg)
 }
 
@@ -127,7 +127,7 @@ func (c *CombinedStreamsClient) readMessages() {
 
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				log.Printf("读取组合流消息失败: %v", err)
+				logger.Warnf("读取组合流消息失败: %v", err)
 				c.handleReconnect()
 				return
 			}
@@ -144,7 +144,7 @@ func (c *CombinedStreamsClient) handleCombinedMessage(message []byte) {
 	}
 
 	if err := json.Unmarshal(message, &combinedMsg); err != nil {
-		log.Printf("解析组合消息失败: %v", err)
+		logger.Warnf("解析组合消息失败: %v", err)
 		return
 	}
 
@@ -156,7 +156,7 @@ func (c *CombinedStreamsClient) handleCombinedMessage(message []byte) {
 		select {
 		case ch <- combinedMsg.Data:
 		default:
-			log.Printf("订阅者通道已满: %s", combinedMsg.Stream)
+			logger.Infof("订阅者通道已满: %s", combinedMsg.Stream)
 		}
 	}
 }
@@ -174,11 +174,11 @@ func (c *CombinedStreamsClient) handleReconnect() {
 		return
 	}
 
-	log.Println("组合流尝试重新连接...")
+	logger.Infof("组合流尝试重新连接...")
 	time.Sleep(3 * time.Second)
 
 	if err := c.Connect(); err != nil {
-		log.Printf("组合流重新连接失败: %v", err)
+		logger.Warnf("组合流重新连接失败: %v", err)
 		go c.handleReconnect()
 	}
 }
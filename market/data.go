@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"math"
 	"net/http"
+	"nofx/logger"
 	"strconv"
 	"strings"
 )
@@ -30,17 +31,16 @@ func Get(symbol string) (*Data, error) {
 
 	// 计算当前指标 (基于3分钟最新数据)
 	currentPrice := klines3m[len(klines3m)-1].Close
-	currentEMA20 := calculateEMA(klines3m, 20)
-	currentMACD := calculateMACD(klines3m)
-	currentRSI7 := calculateRSI(klines3m, 7)
 
 	// 计算价格变化百分比
 	// 1小时价格变化 = 20个3分钟K线前的价格
+	var present DataField
 	priceChange1h := 0.0
 	if len(klines3m) >= 21 { // 至少需要21根K线 (当前 + 20根前)
 		price1hAgo := klines3m[len(klines3m)-21].Close
 		if price1hAgo > 0 {
 			priceChange1h = ((currentPrice - price1hAgo) / price1hAgo) * 100
+			present |= FieldPriceChange1h
 		}
 	}
 
@@ -50,6 +50,7 @@ func Get(symbol string) (*Data, error) {
 		price4hAgo := klines4h[len(klines4h)-2].Close
 		if price4hAgo > 0 {
 			priceChange4h = ((currentPrice - price4hAgo) / price4hAgo) * 100
+			present |= FieldPriceChange4h
 		}
 	}
 
@@ -58,30 +59,80 @@ func Get(symbol string) (*Data, error) {
 	if err != nil {
 		// OI失败不影响整体,使用默认值
 		oiData = &OIData{Latest: 0, Average: 0}
+	} else {
+		present |= FieldOpenInterest
 	}
 
-	// 获取Funding Rate
-	fundingRate, _ := getFundingRate(symbol)
+	// 获取Funding Rate、标记价格和指数价格
+	fundingRate, nextFundingTime, markPrice, indexPrice, err := getFundingRate(symbol)
+	if err == nil {
+		present |= FieldFundingRate
+		if markPrice > 0 {
+			present |= FieldMarkPrice
+		}
+	}
+
+	// 获取盘口中间价
+	midPrice, err := getMidPrice(symbol)
+	if err == nil && midPrice > 0 {
+		present |= FieldMidPrice
+	}
 
-	// 计算日内系列数据
-	intradayData := calculateIntradaySeries(klines3m)
+	// 获取多空比并计算市场情绪
+	longShortRatio, err := getLongShortRatio(symbol)
+	if err == nil {
+		present |= FieldLongShortRatio
+	}
+	oiChange := 0.0
+	if oiData.Average > 0 {
+		oiChange = (oiData.Latest - oiData.Average) / oiData.Average * 100
+	}
+	sentiment := ComputeSentimentScore(fundingRate, longShortRatio, oiChange)
 
-	// 计算长期数据
-	longerTermData := calculateLongerTermData(klines4h)
+	// 计算K线指标（K线未更新时复用缓存，仅实时字段重新获取）
+	indicators := computeIndicators(symbol, klines3m, klines4h)
 
-	return &Data{
+	// 1h/4h/1d 多周期RSI背离（单周期背离容易是假信号）
+	divergence := fetchDivergence(symbol, map[string][]Kline{"4h": klines4h})
+
+	data := &Data{
 		Symbol:            symbol,
 		CurrentPrice:      currentPrice,
 		PriceChange1h:     priceChange1h,
 		PriceChange4h:     priceChange4h,
-		CurrentEMA20:      currentEMA20,
-		CurrentMACD:       currentMACD,
-		CurrentRSI7:       currentRSI7,
+		CurrentEMA20:      indicators.CurrentEMA20,
+		CurrentMACD:       indicators.CurrentMACD,
+		CurrentRSI7:       indicators.CurrentRSI7,
 		OpenInterest:      oiData,
 		FundingRate:       fundingRate,
-		IntradaySeries:    intradayData,
-		LongerTermContext: longerTermData,
-	}, nil
+		NextFundingTime:   nextFundingTime,
+		LongShortRatio:    longShortRatio,
+		Sentiment:         sentiment,
+		IntradaySeries:    indicators.IntradaySeries,
+		LongerTermContext: indicators.LongerTermContext,
+
+		MTFRSIBullishConfluence: divergence.ConfluentDivergence && divergence.BullishCount >= 2,
+		MTFRSIBearishConfluence: divergence.ConfluentDivergence && divergence.BearishCount >= 2,
+
+		LastPrice:  currentPrice,
+		MarkPrice:  markPrice,
+		MidPrice:   midPrice,
+		IndexPrice: indexPrice,
+
+		Present: present | FieldIntradaySeries | FieldLongerTermContext,
+	}
+
+	// 当前价（3分钟K线）与4小时K线区间核对，防止行情源异常导致指标失真
+	if err := ValidatePriceConsistency(currentPrice, klines4h[len(klines4h)-1], DefaultMaxPriceDeviationPct); err != nil {
+		data.PriceError = err.Error()
+	} else if err := ValidatePriceSources(currentPrice, markPrice, indexPrice, DefaultMaxSourceDeviationPct); err != nil {
+		data.PriceError = err.Error()
+	}
+
+	// 推送给实时看板订阅者
+	IndicatorStreamCli.Publish(data)
+
+	return data, nil
 }
 
 // calculateEMA 计算EMA
@@ -164,10 +215,20 @@ func calculateRSI(klines []Kline, period int) float64 {
 	return rsi
 }
 
-// calculateATR 计算ATR
+// calculateATR 计算ATR（K线不足时返回0）
 func calculateATR(klines []Kline, period int) float64 {
+	atr, _ := CalculateATR(klines, period)
+	return atr
+}
+
+// CalculateATR 计算指定周期的ATR（真实波幅，Wilder平滑）
+// 需要至少 period+1 根K线（第一根只提供前收盘价）
+func CalculateATR(klines []Kline, period int) (float64, error) {
+	if period <= 0 {
+		return 0, fmt.Errorf("ATR周期必须大于0: %d", period)
+	}
 	if len(klines) <= period {
-		return 0
+		return 0, fmt.Errorf("K线数量不足: 计算ATR%d需要至少%d根，实际%d根", period, period+1, len(klines))
 	}
 
 	trs := make([]float64, len(klines))
@@ -195,7 +256,55 @@ func calculateATR(klines []Kline, period int) float64 {
 		atr = (atr*float64(period-1) + trs[i]) / float64(period)
 	}
 
-	return atr
+	return atr, nil
+}
+
+// atrPercentileLookback ATR分位的统计窗口（K线根数）
+const atrPercentileLookback = 50
+
+// atrRatioPercentile 最新一根K线的 ATR/收盘价 在最近 lookback 根K线中的分位（0-1）
+// 即不高于当前值的比例；K线不足 period+1 根时返回0
+func atrRatioPercentile(klines []Kline, period, lookback int) float64 {
+	if period <= 0 || len(klines) <= period {
+		return 0
+	}
+
+	// 与 CalculateATR 相同的Wilder平滑，逐根记录 ATR/收盘价
+	trs := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		prevClose := klines[i-1].Close
+		trs[i] = math.Max(klines[i].High-klines[i].Low, math.Max(math.Abs(klines[i].High-prevClose), math.Abs(klines[i].Low-prevClose)))
+	}
+	sum := 0.0
+	for i := 1; i <= period; i++ {
+		sum += trs[i]
+	}
+	atr := sum / float64(period)
+
+	ratios := make([]float64, 0, len(klines)-period)
+	for i := period; i < len(klines); i++ {
+		if i > period {
+			atr = (atr*float64(period-1) + trs[i]) / float64(period)
+		}
+		if klines[i].Close > 0 {
+			ratios = append(ratios, atr/klines[i].Close)
+		}
+	}
+	if len(ratios) == 0 {
+		return 0
+	}
+	if len(ratios) > lookback {
+		ratios = ratios[len(ratios)-lookback:]
+	}
+
+	current := ratios[len(ratios)-1]
+	below := 0
+	for _, r := range ratios {
+		if r <= current {
+			below++
+		}
+	}
+	return float64(below) / float64(len(ratios))
 }
 
 // calculateIntradaySeries 计算日内系列数据
@@ -257,6 +366,7 @@ func calculateLongerTermData(klines []Kline) *LongerTermData {
 	// 计算ATR
 	data.ATR3 = calculateATR(klines, 3)
 	data.ATR14 = calculateATR(klines, 14)
+	data.ATRPercentile = atrRatioPercentile(klines, 14, atrPercentileLookback)
 
 	// 计算成交量
 	if len(klines) > 0 {
@@ -316,15 +426,28 @@ func getOpenInterestData(symbol string) (*OIData, error) {
 
 	oi, _ := strconv.ParseFloat(result.OpenInterest, 64)
 
+	// 历史OI均值获取失败时 Average 为0，OI变化不参与情绪计算
+	average, err := getOpenInterestAverage(symbol)
+	if err != nil {
+		logger.Warnf("⚠️  %s 获取历史持仓量失败，OI变化不计入情绪: %v", symbol, err)
+	}
+
 	return &OIData{
 		Latest:  oi,
-		Average: oi * 0.999, // 近似平均值
+		Average: average,
 	}, nil
 }
 
-// getFundingRate 获取资金费率
-func getFundingRate(symbol string) (float64, error) {
-	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/premiumIndex?symbol=%s", symbol)
+// oiHistoryPeriod/oiHistoryLimit 计算OI均值使用的历史持仓量（最近24小时，每小时一个点）
+const (
+	oiHistoryPeriod = "1h"
+	oiHistoryLimit  = 24
+)
+
+// getOpenInterestAverage 获取最近 oiHistoryLimit 个周期的历史持仓量均值
+func getOpenInterestAverage(symbol string) (float64, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/futures/data/openInterestHist?symbol=%s&period=%s&limit=%d",
+		symbol, oiHistoryPeriod, oiHistoryLimit)
 
 	resp, err := http.Get(url)
 	if err != nil {
@@ -336,6 +459,48 @@ func getFundingRate(symbol string) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
+	return parseOpenInterestAverage(body)
+}
+
+// parseOpenInterestAverage 解析 openInterestHist 响应，返回 sumOpenInterest 的均值
+func parseOpenInterestAverage(body []byte) (float64, error) {
+	var result []struct {
+		SumOpenInterest string `json:"sumOpenInterest"`
+		Timestamp       int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, err
+	}
+
+	sum, count := 0.0, 0
+	for _, point := range result {
+		oi, err := strconv.ParseFloat(point.SumOpenInterest, 64)
+		if err != nil || oi <= 0 {
+			continue
+		}
+		sum += oi
+		count++
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("历史持仓量数据为空")
+	}
+	return sum / float64(count), nil
+}
+
+// getFundingRate 获取资金费率、下次结算时间、标记价格及指数价格
+func getFundingRate(symbol string) (float64, int64, float64, float64, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/premiumIndex?symbol=%s", symbol)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
 
 	var result struct {
 		Symbol          string `json:"symbol"`
@@ -348,11 +513,13 @@ func getFundingRate(symbol string) (float64, error) {
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, err
+		return 0, 0, 0, 0, err
 	}
 
 	rate, _ := strconv.ParseFloat(result.LastFundingRate, 64)
-	return rate, nil
+	markPrice, _ := strconv.ParseFloat(result.MarkPrice, 64)
+	indexPrice, _ := strconv.ParseFloat(result.IndexPrice, 64)
+	return rate, result.NextFundingTime, markPrice, indexPrice, nil
 }
 
 // Format 格式化输出市场数据
@@ -372,6 +539,17 @@ func Format(data *Data) string {
 
 	sb.WriteString(fmt.Sprintf("Funding Rate: %.2e\n\n", data.FundingRate))
 
+	if data.Sentiment != nil {
+		sb.WriteString(fmt.Sprintf("Sentiment: %s (net %.0f, long/short ratio %.2f)\n\n",
+			data.Sentiment.Label, data.Sentiment.NetSentiment, data.LongShortRatio))
+	}
+
+	if data.MTFRSIBullishConfluence {
+		sb.WriteString("RSI divergence: bullish on at least two of 1h/4h/1d (multi-timeframe confluence)\n\n")
+	} else if data.MTFRSIBearishConfluence {
+		sb.WriteString("RSI divergence: bearish on at least two of 1h/4h/1d (multi-timeframe confluence)\n\n")
+	}
+
 	if data.IntradaySeries != nil {
 		sb.WriteString("Intraday series (3‑minute intervals, oldest → latest):\n\n")
 
@@ -453,3 +631,11 @@ func parseFloat(v interface{}) (float64, error) {
 		return 0, fmt.Errorf("unsupported type: %T", v)
 	}
 }
+
+// ATRRatio 4小时ATR14占当前价格的比例（衡量波动是否足够，数据不足时返回0）
+func (d *Data) ATRRatio() float64 {
+	if d == nil || d.CurrentPrice <= 0 || d.LongerTermContext == nil {
+		return 0
+	}
+	return d.LongerTermContext.ATR14 / d.CurrentPrice
+}
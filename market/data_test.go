@@ -0,0 +1,25 @@
+package market
+
+import "testing"
+
+func TestParseOpenInterestAverage(t *testing.T) {
+	body := []byte(`[
+		{"symbol":"BTCUSDT","sumOpenInterest":"100.0","timestamp":1},
+		{"symbol":"BTCUSDT","sumOpenInterest":"110.0","timestamp":2},
+		{"symbol":"BTCUSDT","sumOpenInterest":"bad","timestamp":3},
+		{"symbol":"BTCUSDT","sumOpenInterest":"120.0","timestamp":4}
+	]`)
+	avg, err := parseOpenInterestAverage(body)
+	if err != nil {
+		t.Fatalf("parseOpenInterestAverage: %v", err)
+	}
+	if avg != 110 {
+		t.Errorf("average = %.2f, want 110", avg)
+	}
+}
+
+func TestParseOpenInterestAverageEmpty(t *testing.T) {
+	if _, err := parseOpenInterestAverage([]byte(`[]`)); err == nil {
+		t.Fatal("expected error for empty history")
+	}
+}
@@ -0,0 +1,142 @@
+package market
+
+import (
+	"sort"
+	"time"
+)
+
+// DivergenceTimeframes 检测RSI背离共振的周期
+var DivergenceTimeframes = []string{"1h", "4h", "1d"}
+
+// divergenceWindow 每个周期参与背离检测的RSI点数（按已收盘K线计算）
+const divergenceWindow = 20
+
+// MTFDivergenceResult 多周期RSI背离统计
+type MTFDivergenceResult struct {
+	BullishCount        int      // 出现底背离的周期数
+	BearishCount        int      // 出现顶背离的周期数
+	Timeframes          []string // 出现背离的周期（如 "4h:bullish"）
+	ConfluentDivergence bool     // 同一类型背离在 ≥2 个周期同时出现
+}
+
+// MultiTimeframeRSIDivergence 检测多个周期的RSI背离
+// rsiByTimeframe 为各周期的RSI序列（从旧到新），与 priceByTimeframe 中对应周期最后 len(rsi) 根K线对齐
+// 单周期背离容易是假信号，多个周期同时出现同类背离时可靠性更高
+func MultiTimeframeRSIDivergence(rsiByTimeframe map[string][]float64, priceByTimeframe map[string][]Kline) *MTFDivergenceResult {
+	result := &MTFDivergenceResult{}
+
+	timeframes := make([]string, 0, len(rsiByTimeframe))
+	for tf := range rsiByTimeframe {
+		timeframes = append(timeframes, tf)
+	}
+	sort.Strings(timeframes)
+
+	for _, tf := range timeframes {
+		switch detectRSIDivergence(rsiByTimeframe[tf], priceByTimeframe[tf]) {
+		case "bullish":
+			result.BullishCount++
+			result.Timeframes = append(result.Timeframes, tf+":bullish")
+		case "bearish":
+			result.BearishCount++
+			result.Timeframes = append(result.Timeframes, tf+":bearish")
+		}
+	}
+
+	result.ConfluentDivergence = result.BullishCount >= 2 || result.BearishCount >= 2
+	return result
+}
+
+// klineRSIDivergence 按各周期已收盘K线计算RSI14序列后检测多周期背离（缺少数据的周期不参与统计）
+func klineRSIDivergence(klinesByTimeframe map[string][]Kline, nowMs int64) *MTFDivergenceResult {
+	rsiByTimeframe := make(map[string][]float64, len(klinesByTimeframe))
+	priceByTimeframe := make(map[string][]Kline, len(klinesByTimeframe))
+	for tf, klines := range klinesByTimeframe {
+		closed := closedKlines(klines, nowMs)
+		if rsi := rsiSeries(closed, 14, divergenceWindow); len(rsi) > 0 {
+			rsiByTimeframe[tf] = rsi
+			priceByTimeframe[tf] = closed
+		}
+	}
+	return MultiTimeframeRSIDivergence(rsiByTimeframe, priceByTimeframe)
+}
+
+// rsiSeries 最近 n 根K线各自收盘时的RSI（从旧到新），K线不足时返回可计算的部分
+func rsiSeries(klines []Kline, period, n int) []float64 {
+	start := len(klines) - n + 1
+	if start < period+1 {
+		start = period + 1
+	}
+	if start > len(klines) {
+		return nil
+	}
+	series := make([]float64, 0, len(klines)-start)
+	for end := start; end <= len(klines); end++ {
+		series = append(series, calculateRSI(klines[:end], period))
+	}
+	return series
+}
+
+// fetchDivergence 获取背离检测周期的K线并检测多周期RSI背离（已有的周期K线直接复用）
+func fetchDivergence(symbol string, known map[string][]Kline) *MTFDivergenceResult {
+	klinesByTimeframe := make(map[string][]Kline, len(DivergenceTimeframes))
+	for _, tf := range DivergenceTimeframes {
+		if klines, ok := known[tf]; ok {
+			klinesByTimeframe[tf] = klines
+			continue
+		}
+		klines, err := WSMonitorCli.GetCurrentKlines(symbol, tf)
+		if err != nil || len(klines) == 0 {
+			continue
+		}
+		klinesByTimeframe[tf] = klines
+	}
+	return klineRSIDivergence(klinesByTimeframe, time.Now().UnixMilli())
+}
+
+// detectRSIDivergence 检测单周期RSI背离，返回 "bullish"、"bearish" 或空字符串
+// 将序列分为前后两半比较：
+//   - 底背离: 后半段收盘价创新低，但对应RSI高于前半段低点时的RSI
+//   - 顶背离: 后半段收盘价创新高，但对应RSI低于前半段高点时的RSI
+func detectRSIDivergence(rsi []float64, klines []Kline) string {
+	n := len(rsi)
+	if n < 4 || len(klines) < n {
+		return ""
+	}
+
+	closes := make([]float64, n)
+	for i, k := range klines[len(klines)-n:] {
+		closes[i] = k.Close
+	}
+
+	mid := n / 2
+	firstLow, secondLow := argMin(closes[:mid]), mid+argMin(closes[mid:])
+	if closes[secondLow] < closes[firstLow] && rsi[secondLow] > rsi[firstLow] {
+		return "bullish"
+	}
+
+	firstHigh, secondHigh := argMax(closes[:mid]), mid+argMax(closes[mid:])
+	if closes[secondHigh] > closes[firstHigh] && rsi[secondHigh] < rsi[firstHigh] {
+		return "bearish"
+	}
+	return ""
+}
+
+func argMin(values []float64) int {
+	idx := 0
+	for i, v := range values {
+		if v < values[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
+
+func argMax(values []float64) int {
+	idx := 0
+	for i, v := range values {
+		if v > values[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
@@ -0,0 +1,98 @@
+package market
+
+import "testing"
+
+// divergenceKlines 生成收盘价序列对应的K线（周期 periodMs，最后一根收盘时间为 lastClose）
+func divergenceKlines(closes []float64, periodMs, lastClose int64) []Kline {
+	klines := make([]Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = Kline{
+			OpenTime:  lastClose - int64(len(closes)-i)*periodMs + 1,
+			CloseTime: lastClose - int64(len(closes)-1-i)*periodMs,
+			Open:      c, High: c, Low: c, Close: c,
+		}
+	}
+	return klines
+}
+
+// bullishDivergenceCloses 急跌后反弹，再缓慢跌出新低：价格新低但RSI抬高
+func bullishDivergenceCloses() []float64 {
+	closes := []float64{}
+	price := 100.0
+	for i := 0; i < 20; i++ {
+		closes = append(closes, price)
+		price += 0.5
+	}
+	for i := 0; i < 8; i++ { // 急跌
+		price -= 3
+		closes = append(closes, price)
+	}
+	for i := 0; i < 6; i++ { // 反弹
+		price += 2
+		closes = append(closes, price)
+	}
+	for i := 0; i < 6; i++ { // 缓跌至新低
+		price -= 2.2
+		closes = append(closes, price)
+	}
+	return closes
+}
+
+func TestRSISeriesAlignsWithLastKlines(t *testing.T) {
+	closes := bullishDivergenceCloses()
+	klines := divergenceKlines(closes, 3_600_000, 1_000_000_000)
+	series := rsiSeries(klines, 14, divergenceWindow)
+	if len(series) != divergenceWindow {
+		t.Fatalf("len = %d, want %d", len(series), divergenceWindow)
+	}
+	if got, want := series[len(series)-1], calculateRSI(klines, 14); got != want {
+		t.Errorf("last RSI = %.4f, want %.4f (RSI at the last kline)", got, want)
+	}
+	if short := rsiSeries(klines[:15], 14, divergenceWindow); len(short) != 1 {
+		t.Errorf("len = %d with 15 klines, want 1", len(short))
+	}
+	if rsiSeries(klines[:14], 14, divergenceWindow) != nil {
+		t.Error("want nil when klines do not cover the RSI period")
+	}
+}
+
+func TestKlineRSIDivergenceUsesConfiguredTimeframes(t *testing.T) {
+	now := int64(10_000_000_000)
+	closes := bullishDivergenceCloses()
+	if detectRSIDivergence(rsiSeries(divergenceKlines(closes, 3_600_000, now), 14, divergenceWindow), divergenceKlines(closes, 3_600_000, now)) != "bullish" {
+		t.Fatal("fixture does not produce a bullish divergence")
+	}
+
+	flat := make([]float64, len(closes))
+	for i := range flat {
+		flat[i] = 100 + float64(i%2)
+	}
+	result := klineRSIDivergence(map[string][]Kline{
+		"1h": divergenceKlines(closes, 3_600_000, now),
+		"4h": divergenceKlines(closes, 14_400_000, now),
+		"1d": divergenceKlines(flat, 86_400_000, now),
+	}, now)
+	if result.BullishCount != 2 || !result.ConfluentDivergence {
+		t.Fatalf("result = %+v, want bullish confluence on 2 timeframes", result)
+	}
+	if len(result.Timeframes) != 2 || result.Timeframes[0] != "1h:bullish" || result.Timeframes[1] != "4h:bullish" {
+		t.Errorf("timeframes = %v, want [1h:bullish 4h:bullish]", result.Timeframes)
+	}
+
+	single := klineRSIDivergence(map[string][]Kline{"1h": divergenceKlines(closes, 3_600_000, now)}, now)
+	if single.ConfluentDivergence {
+		t.Error("a single timeframe must not count as confluence")
+	}
+}
+
+func TestDivergenceTimeframes(t *testing.T) {
+	want := []string{"1h", "4h", "1d"}
+	if len(DivergenceTimeframes) != len(want) {
+		t.Fatalf("DivergenceTimeframes = %v, want %v", DivergenceTimeframes, want)
+	}
+	for i := range want {
+		if DivergenceTimeframes[i] != want[i] {
+			t.Fatalf("DivergenceTimeframes = %v, want %v", DivergenceTimeframes, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package market
+
+import (
+	"nofx/logger"
+	"sync"
+	"time"
+)
+
+// IndicatorSet 基于K线计算的指标（K线未更新时可直接复用）
+type IndicatorSet struct {
+	CurrentEMA20      float64
+	CurrentMACD       float64
+	CurrentRSI7       float64
+	IntradaySeries    *IntradayData
+	LongerTermContext *LongerTermData
+}
+
+// IndicatorCache 单个币种的指标缓存
+type IndicatorCache struct {
+	lastComputedCandle   int64 // 计算时3分钟最新K线的收盘时间
+	lastComputedCandle4h int64 // 计算时4小时最新K线的收盘时间
+	cachedIndicators     *IndicatorSet
+}
+
+// cacheStatsInterval 每查询多少次输出一次缓存命中率
+const cacheStatsInterval = 100
+
+// indicatorCacheStore 按币种保存指标缓存
+type indicatorCacheStore struct {
+	mu     sync.Mutex
+	caches map[string]*IndicatorCache
+	hits   int
+	misses int
+}
+
+var indicatorCaches = &indicatorCacheStore{caches: make(map[string]*IndicatorCache)}
+
+// lookup 最新K线收盘时间与缓存一致时返回缓存的指标，否则返回nil
+func (s *indicatorCacheStore) lookup(symbol string, closeTime3m, closeTime4h int64) *IndicatorSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var set *IndicatorSet
+	if c, ok := s.caches[symbol]; ok && c.lastComputedCandle == closeTime3m && c.lastComputedCandle4h == closeTime4h {
+		set = c.cachedIndicators
+		s.hits++
+	} else {
+		s.misses++
+	}
+
+	if total := s.hits + s.misses; total%cacheStatsInterval == 0 {
+		logger.Infof("📊 指标缓存命中率: %.1f%% (命中 %d / 未命中 %d)", float64(s.hits)/float64(total)*100, s.hits, s.misses)
+	}
+	return set
+}
+
+// store 保存最新计算的指标
+func (s *indicatorCacheStore) store(symbol string, closeTime3m, closeTime4h int64, set *IndicatorSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caches[symbol] = &IndicatorCache{
+		lastComputedCandle:   closeTime3m,
+		lastComputedCandle4h: closeTime4h,
+		cachedIndicators:     set,
+	}
+}
+
+// IndicatorCacheStats 指标缓存累计命中/未命中次数
+func IndicatorCacheStats() (hits, misses int) {
+	indicatorCaches.mu.Lock()
+	defer indicatorCaches.mu.Unlock()
+	return indicatorCaches.hits, indicatorCaches.misses
+}
+
+// closedKlines 去掉尚未收盘的K线（收盘时间晚于 nowMs），至少保留一根
+// 未收盘K线的价格和成交量随时变化，但收盘时间不变，不能作为缓存键
+func closedKlines(klines []Kline, nowMs int64) []Kline {
+	end := len(klines)
+	for end > 1 && klines[end-1].CloseTime > nowMs {
+		end--
+	}
+	return klines[:end]
+}
+
+// computeIndicators 基于已收盘的K线计算指标；同一根已收盘K线内重复调用时复用缓存
+func computeIndicators(symbol string, klines3m, klines4h []Kline) *IndicatorSet {
+	return computeIndicatorsAt(symbol, klines3m, klines4h, time.Now().UnixMilli())
+}
+
+func computeIndicatorsAt(symbol string, klines3m, klines4h []Kline, nowMs int64) *IndicatorSet {
+	klines3m = closedKlines(klines3m, nowMs)
+	klines4h = closedKlines(klines4h, nowMs)
+	closeTime3m := klines3m[len(klines3m)-1].CloseTime
+	closeTime4h := klines4h[len(klines4h)-1].CloseTime
+	if set := indicatorCaches.lookup(symbol, closeTime3m, closeTime4h); set != nil {
+		return set
+	}
+
+	intradayData := calculateIntradaySeries(klines3m)
+	longerTermData := calculateLongerTermData(klines4h)
+	set := &IndicatorSet{
+		CurrentEMA20:      calculateEMA(klines3m, 20),
+		CurrentMACD:       calculateMACD(klines3m),
+		CurrentRSI7:       calculateRSI(klines3m, 7),
+		IntradaySeries:    intradayData,
+		LongerTermContext: longerTermData,
+	}
+	indicatorCaches.store(symbol, closeTime3m, closeTime4h, set)
+	return set
+}
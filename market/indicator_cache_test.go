@@ -0,0 +1,54 @@
+package market
+
+import "testing"
+
+// testKlines 生成 n 根周期为 periodMs 的K线，最后一根收盘时间为 lastClose
+func testKlines(n int, periodMs, lastClose int64, lastPrice float64) []Kline {
+	klines := make([]Kline, n)
+	for i := range klines {
+		price := 100 + float64(i%7)
+		klines[i] = Kline{
+			OpenTime:  lastClose - int64(n-i)*periodMs + 1,
+			CloseTime: lastClose - int64(n-1-i)*periodMs,
+			Open:      price, High: price + 1, Low: price - 1, Close: price, Volume: 10,
+		}
+	}
+	klines[n-1].Close = lastPrice
+	return klines
+}
+
+func TestIndicatorCacheIgnoresFormingCandle(t *testing.T) {
+	const period3m, period4h = int64(180_000), int64(14_400_000)
+	now := int64(1_000_000_000)
+	// 最后一根K线未收盘（收盘时间在 now 之后）
+	forming3m := testKlines(60, period3m, now+60_000, 100)
+	klines4h := testKlines(60, period4h, now-1, 100)
+
+	hits0, misses0 := IndicatorCacheStats()
+	first := computeIndicatorsAt("TESTUSDT", forming3m, klines4h, now)
+
+	// 未收盘K线价格变化：指标基于已收盘K线，应命中缓存且结果不变
+	moved := testKlines(60, period3m, now+60_000, 150)
+	second := computeIndicatorsAt("TESTUSDT", moved, klines4h, now+1_000)
+	if second != first {
+		t.Fatal("forming candle change invalidated the cache")
+	}
+
+	// 该K线收盘后按新数据重新计算
+	third := computeIndicatorsAt("TESTUSDT", moved, klines4h, now+60_000)
+	if third == first {
+		t.Fatal("cache not refreshed after the candle closed")
+	}
+
+	hits, misses := IndicatorCacheStats()
+	if hits-hits0 != 1 || misses-misses0 != 2 {
+		t.Errorf("hits/misses = %d/%d, want 1/2", hits-hits0, misses-misses0)
+	}
+}
+
+func TestClosedKlinesKeepsAtLeastOne(t *testing.T) {
+	klines := testKlines(1, 180_000, 500, 100)
+	if got := closedKlines(klines, 0); len(got) != 1 {
+		t.Fatalf("len = %d, want 1", len(got))
+	}
+}
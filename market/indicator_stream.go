@@ -0,0 +1,114 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IndicatorSnapshot 指标快照（推送给实时看板）
+type IndicatorSnapshot struct {
+	Symbol         string    `json:"symbol"`
+	Price          float64   `json:"price"`
+	EMA20          float64   `json:"ema20"`
+	MACD           float64   `json:"macd"`
+	RSI7           float64   `json:"rsi7"`
+	FundingRate    float64   `json:"funding_rate"`
+	SentimentLabel string    `json:"sentiment_label,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// DefaultMaxSubscribers 默认最大订阅者数量
+const DefaultMaxSubscribers = 64
+
+// subscriberBuffer 每个订阅者的缓冲大小（订阅者处理不及时则丢弃新快照）
+const subscriberBuffer = 16
+
+// IndicatorStream 指标实时推送（按币种订阅）
+type IndicatorStream struct {
+	MaxSubscribers int // 最大订阅者数量（防止资源泄漏）
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan *IndicatorSnapshot]struct{}
+	count       int
+}
+
+// IndicatorStreamCli 全局指标推送（Get 计算完指标后推送）
+var IndicatorStreamCli = NewIndicatorStream(DefaultMaxSubscribers)
+
+// NewIndicatorStream 创建指标推送
+func NewIndicatorStream(maxSubscribers int) *IndicatorStream {
+	if maxSubscribers <= 0 {
+		maxSubscribers = DefaultMaxSubscribers
+	}
+	return &IndicatorStream{
+		MaxSubscribers: maxSubscribers,
+		subscribers:    make(map[string]map[chan *IndicatorSnapshot]struct{}),
+	}
+}
+
+// Subscribe 订阅指定币种的指标快照，调用返回的 cancel 取消订阅（channel随之关闭）
+func (s *IndicatorStream) Subscribe(symbol string) (<-chan *IndicatorSnapshot, context.CancelFunc, error) {
+	symbol = Normalize(symbol)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count >= s.MaxSubscribers {
+		return nil, nil, fmt.Errorf("指标订阅数已达上限 %d", s.MaxSubscribers)
+	}
+
+	ch := make(chan *IndicatorSnapshot, subscriberBuffer)
+	if s.subscribers[symbol] == nil {
+		s.subscribers[symbol] = make(map[chan *IndicatorSnapshot]struct{})
+	}
+	s.subscribers[symbol][ch] = struct{}{}
+	s.count++
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.subscribers[symbol], ch)
+			if len(s.subscribers[symbol]) == 0 {
+				delete(s.subscribers, symbol)
+			}
+			s.count--
+			close(ch)
+		})
+	}
+	return ch, cancel, nil
+}
+
+// Publish 推送市场数据给该币种的所有订阅者（非阻塞，缓冲已满的订阅者会丢弃本次快照）
+func (s *IndicatorStream) Publish(data *Data) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.subscribers[data.Symbol]
+	if len(subs) == 0 {
+		return
+	}
+
+	snapshot := &IndicatorSnapshot{
+		Symbol:      data.Symbol,
+		Price:       data.CurrentPrice,
+		EMA20:       data.CurrentEMA20,
+		MACD:        data.CurrentMACD,
+		RSI7:        data.CurrentRSI7,
+		FundingRate: data.FundingRate,
+		Timestamp:   time.Now(),
+	}
+	if data.Sentiment != nil {
+		snapshot.SentimentLabel = data.Sentiment.Label
+	}
+
+	for ch := range subs {
+		select {
+		case ch <- snapshot:
+		default:
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package market
+
+import (
+	"math"
+	"sort"
+)
+
+// Pivot 摆动高/低点（K线结构中的局部极值）
+type Pivot struct {
+	Index int     // K线索引
+	Price float64 // 摆动高点取最高价，摆动低点取最低价
+}
+
+// FindPivots 识别摆动高点和低点
+// 某根K线的最高价（最低价）严格高于（低于）左右各 strength 根K线时，视为摆动高点（低点）
+// 最近 strength 根K线右侧数据不足，无法确认，不参与识别
+func FindPivots(klines []Kline, strength int) (highs, lows []Pivot) {
+	if strength <= 0 {
+		strength = 2
+	}
+
+	for i := strength; i < len(klines)-strength; i++ {
+		isHigh, isLow := true, true
+		for j := i - strength; j <= i+strength; j++ {
+			if j == i {
+				continue
+			}
+			if klines[j].High >= klines[i].High {
+				isHigh = false
+			}
+			if klines[j].Low <= klines[i].Low {
+				isLow = false
+			}
+		}
+		if isHigh {
+			highs = append(highs, Pivot{Index: i, Price: klines[i].High})
+		}
+		if isLow {
+			lows = append(lows, Pivot{Index: i, Price: klines[i].Low})
+		}
+	}
+	return highs, lows
+}
+
+// SwingStopPrice 根据最近的摆动点计算结构止损价
+// 多仓取当前价下方最近的摆动低点，空仓取当前价上方最近的摆动高点，再向外留 bufferPct（如0.002=0.2%）缓冲
+// 找不到合适的摆动点时返回 false
+func SwingStopPrice(klines []Kline, side string, currentPrice, bufferPct float64, strength int) (float64, bool) {
+	highs, lows := FindPivots(klines, strength)
+
+	if side == "short" {
+		for i := len(highs) - 1; i >= 0; i-- {
+			if highs[i].Price > currentPrice {
+				return highs[i].Price * (1 + bufferPct), true
+			}
+		}
+		return 0, false
+	}
+
+	for i := len(lows) - 1; i >= 0; i-- {
+		if lows[i].Price < currentPrice {
+			return lows[i].Price * (1 - bufferPct), true
+		}
+	}
+	return 0, false
+}
+
+// Level 支撑/阻力位
+type Level struct {
+	Price   float64 `json:"price"`
+	Touches int     `json:"touches"` // 触及次数（聚合的摆动点个数，越多越强）
+	Type    string  `json:"type"`    // "support" 或 "resistance"（相对最新收盘价）
+}
+
+// FindSupportResistance 由摆动高低点聚合出支撑/阻力位
+// 价格相差在 tolerancePct（如0.003=0.3%）以内的摆动点合并为同一价位，按触及次数从多到少排序
+func FindSupportResistance(klines []Kline, strength int, tolerancePct float64) []Level {
+	if len(klines) == 0 {
+		return nil
+	}
+
+	highs, lows := FindPivots(klines, strength)
+	pivots := append(append([]Pivot{}, highs...), lows...)
+	sort.Slice(pivots, func(i, j int) bool { return pivots[i].Price < pivots[j].Price })
+
+	var levels []Level
+	sum := 0.0
+	for _, p := range pivots {
+		if n := len(levels); n > 0 && p.Price <= levels[n-1].Price*(1+tolerancePct) {
+			// 合并到当前价位（取均价）
+			sum += p.Price
+			levels[n-1].Touches++
+			levels[n-1].Price = sum / float64(levels[n-1].Touches)
+			continue
+		}
+		sum = p.Price
+		levels = append(levels, Level{Price: p.Price, Touches: 1})
+	}
+
+	lastClose := klines[len(klines)-1].Close
+	for i := range levels {
+		if levels[i].Price < lastClose {
+			levels[i].Type = "support"
+		} else {
+			levels[i].Type = "resistance"
+		}
+	}
+
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].Touches > levels[j].Touches })
+	return levels
+}
+
+// NearestRoundNumber 距离价格最近的整数关口
+// 关口间隔取价格数量级的1/10（如 67350 → 67000，2.46 → 2.5）
+func NearestRoundNumber(price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	step := math.Pow(10, math.Floor(math.Log10(price))-1)
+	return math.Round(price/step) * step
+}
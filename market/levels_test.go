@@ -0,0 +1,45 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+// swingKlines 由 (high, low) 序列生成K线
+func swingKlines(points [][2]float64) []Kline {
+	klines := make([]Kline, len(points))
+	for i, p := range points {
+		mid := (p[0] + p[1]) / 2
+		klines[i] = Kline{Open: mid, Close: mid, High: p[0], Low: p[1]}
+	}
+	return klines
+}
+
+func TestSwingStopPriceUsesRecentSwingLow(t *testing.T) {
+	// 第3根K线是明显的摆动低点 95，之后上涨到 110
+	klines := swingKlines([][2]float64{
+		{102, 100}, {101, 98}, {99, 95}, {103, 99}, {106, 102}, {108, 105}, {110, 107},
+	})
+	stop, ok := SwingStopPrice(klines, "long", 109, 0.002, 2)
+	if !ok || math.Abs(stop-95*0.998) > 1e-9 {
+		t.Errorf("stop = %.4f (%v), want %.4f", stop, ok, 95*0.998)
+	}
+}
+
+func TestSwingStopPriceUsesRecentSwingHighForShort(t *testing.T) {
+	klines := swingKlines([][2]float64{
+		{100, 98}, {102, 99}, {106, 101}, {103, 100}, {101, 97}, {99, 95}, {97, 94},
+	})
+	stop, ok := SwingStopPrice(klines, "short", 96, 0.002, 2)
+	if !ok || math.Abs(stop-106*1.002) > 1e-9 {
+		t.Errorf("stop = %.4f (%v), want %.4f", stop, ok, 106*1.002)
+	}
+}
+
+func TestSwingStopPriceWithoutSwing(t *testing.T) {
+	// 单边上涨没有摆动低点，应回退到ATR
+	klines := swingKlines([][2]float64{{101, 100}, {102, 101}, {103, 102}, {104, 103}, {105, 104}, {106, 105}})
+	if _, ok := SwingStopPrice(klines, "long", 106, 0.002, 2); ok {
+		t.Error("found a swing low in a monotonic series")
+	}
+}
@@ -0,0 +1,128 @@
+package market
+
+// MergeMarketData 合并主、备两路数据源的市场数据
+//
+// 合并规则:
+//   - 主数据源的价格相关字段（CurrentPrice、PriceChange1h/4h）始终优先；主数据源价格无效（<=0）时才使用备用数据源
+//   - 其余数值字段：主数据源为0（缺失）时用备用数据源补全；有获取记录（Present）的字段按是否获取成功判断，
+//     获取成功的0值（如横盘时资金费率为0）不会被备用数据源覆盖
+//   - 指针字段（OI、日内序列、长期数据、情绪评分）：主数据源为nil时使用备用数据源；
+//     两者都存在时逐字段补全，序列字段主数据源为空时整体使用备用数据源
+//   - Symbol 取主数据源
+//
+// 任一参数为nil时直接返回另一个；返回值是新对象，不修改传入的数据
+func MergeMarketData(primary, secondary *Data) *Data {
+	if primary == nil {
+		return secondary
+	}
+	if secondary == nil {
+		return primary
+	}
+
+	merged := *primary
+	if merged.Symbol == "" {
+		merged.Symbol = secondary.Symbol
+	}
+	if merged.CurrentPrice <= 0 {
+		merged.CurrentPrice = secondary.CurrentPrice
+		merged.PriceChange1h = secondary.PriceChange1h
+		merged.PriceChange4h = secondary.PriceChange4h
+	}
+	merged.LastPrice = mergeFloat(merged.LastPrice, secondary.LastPrice)
+	mergeTracked(&merged, secondary, FieldMarkPrice, &merged.MarkPrice, secondary.MarkPrice)
+	mergeTracked(&merged, secondary, FieldMidPrice, &merged.MidPrice, secondary.MidPrice)
+	merged.IndexPrice = mergeFloat(merged.IndexPrice, secondary.IndexPrice)
+	merged.CurrentEMA20 = mergeFloat(merged.CurrentEMA20, secondary.CurrentEMA20)
+	merged.CurrentMACD = mergeFloat(merged.CurrentMACD, secondary.CurrentMACD)
+	merged.CurrentRSI7 = mergeFloat(merged.CurrentRSI7, secondary.CurrentRSI7)
+	mergeTracked(&merged, secondary, FieldFundingRate, &merged.FundingRate, secondary.FundingRate)
+	mergeTracked(&merged, secondary, FieldLongShortRatio, &merged.LongShortRatio, secondary.LongShortRatio)
+	if merged.NextFundingTime <= 0 {
+		merged.NextFundingTime = secondary.NextFundingTime
+	}
+	if merged.Sentiment == nil {
+		merged.Sentiment = secondary.Sentiment
+	}
+
+	// OI
+	switch {
+	case merged.OpenInterest == nil:
+		merged.OpenInterest = secondary.OpenInterest
+	case secondary.OpenInterest != nil:
+		oi := *merged.OpenInterest
+		oi.Latest = mergeFloat(oi.Latest, secondary.OpenInterest.Latest)
+		oi.Average = mergeFloat(oi.Average, secondary.OpenInterest.Average)
+		merged.OpenInterest = &oi
+	}
+
+	// 日内序列
+	switch {
+	case merged.IntradaySeries == nil:
+		merged.IntradaySeries = secondary.IntradaySeries
+	case secondary.IntradaySeries != nil:
+		series := *merged.IntradaySeries
+		series.MidPrices = mergeSeries(series.MidPrices, secondary.IntradaySeries.MidPrices)
+		series.EMA20Values = mergeSeries(series.EMA20Values, secondary.IntradaySeries.EMA20Values)
+		series.MACDValues = mergeSeries(series.MACDValues, secondary.IntradaySeries.MACDValues)
+		series.RSI7Values = mergeSeries(series.RSI7Values, secondary.IntradaySeries.RSI7Values)
+		series.RSI14Values = mergeSeries(series.RSI14Values, secondary.IntradaySeries.RSI14Values)
+		merged.IntradaySeries = &series
+	}
+
+	// 长期数据
+	switch {
+	case merged.LongerTermContext == nil:
+		merged.LongerTermContext = secondary.LongerTermContext
+	case secondary.LongerTermContext != nil:
+		longer := *merged.LongerTermContext
+		other := secondary.LongerTermContext
+		longer.EMA20 = mergeFloat(longer.EMA20, other.EMA20)
+		longer.EMA50 = mergeFloat(longer.EMA50, other.EMA50)
+		longer.ATR3 = mergeFloat(longer.ATR3, other.ATR3)
+		longer.ATR14 = mergeFloat(longer.ATR14, other.ATR14)
+		longer.CurrentVolume = mergeFloat(longer.CurrentVolume, other.CurrentVolume)
+		longer.AverageVolume = mergeFloat(longer.AverageVolume, other.AverageVolume)
+		longer.MACDValues = mergeSeries(longer.MACDValues, other.MACDValues)
+		longer.RSI14Values = mergeSeries(longer.RSI14Values, other.RSI14Values)
+		merged.LongerTermContext = &longer
+	}
+
+	merged.Present |= secondary.Present
+	return &merged
+}
+
+// hasValue 字段是否有值：有获取记录时按记录判断，否则按非0判断
+func (d *Data) hasValue(field DataField, v float64) bool {
+	if d.Present == 0 {
+		return v != 0
+	}
+	return d.Present&field != 0
+}
+
+// mergeTracked 主数据源缺失该字段而备用数据源有时，使用备用数据源的值
+func mergeTracked(merged, secondary *Data, field DataField, dst *float64, src float64) {
+	if !merged.hasValue(field, *dst) && secondary.hasValue(field, src) {
+		*dst = src
+	}
+}
+
+// IsValid 数据是否可用于决策（价格有效且一致、日内和长期指标齐全）
+func (d *Data) IsValid() bool {
+	return d != nil && d.CurrentPrice > 0 && d.PriceError == "" && d.IntradaySeries != nil && d.LongerTermContext != nil
+}
+
+// mergeFloat 主值为0（缺失）时取备用值
+func mergeFloat(primary, secondary float64) float64 {
+	if primary == 0 {
+		return secondary
+	}
+	return primary
+}
+
+// mergeSeries 主序列为空时取备用序列
+func mergeSeries(primary, secondary []float64) []float64 {
+	if len(primary) == 0 {
+		return secondary
+	}
+	return primary
+}
@@ -0,0 +1,90 @@
+package market
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeMarketDataFillsMissingFields(t *testing.T) {
+	primary := &Data{
+		Symbol:         "BTCUSDT",
+		CurrentPrice:   100,
+		PriceChange1h:  1.5,
+		CurrentEMA20:   99,
+		IntradaySeries: &IntradayData{MidPrices: []float64{99, 100}},
+	}
+	secondary := &Data{
+		Symbol:            "BTCUSDT",
+		CurrentPrice:      101,
+		PriceChange1h:     2,
+		CurrentEMA20:      98,
+		CurrentRSI7:       55,
+		FundingRate:       0.0001,
+		OpenInterest:      &OIData{Latest: 5000, Average: 4800},
+		IntradaySeries:    &IntradayData{MidPrices: []float64{1, 2}, RSI7Values: []float64{50, 55}},
+		LongerTermContext: &LongerTermData{ATR14: 2.5},
+	}
+
+	merged := MergeMarketData(primary, secondary)
+
+	// 冲突字段以主数据源为准
+	if merged.CurrentPrice != 100 || merged.PriceChange1h != 1.5 || merged.CurrentEMA20 != 99 {
+		t.Errorf("price fields = %.2f/%.2f/%.2f, want primary 100/1.5/99", merged.CurrentPrice, merged.PriceChange1h, merged.CurrentEMA20)
+	}
+	if !reflect.DeepEqual(merged.IntradaySeries.MidPrices, []float64{99, 100}) {
+		t.Errorf("MidPrices = %v, want primary series", merged.IntradaySeries.MidPrices)
+	}
+	// 缺失字段由备用数据源补全
+	if merged.CurrentRSI7 != 55 || merged.FundingRate != 0.0001 {
+		t.Errorf("RSI7/funding = %.2f/%.4f, want filled from secondary", merged.CurrentRSI7, merged.FundingRate)
+	}
+	if merged.OpenInterest == nil || merged.OpenInterest.Latest != 5000 {
+		t.Errorf("OpenInterest = %+v, want secondary OI", merged.OpenInterest)
+	}
+	if !reflect.DeepEqual(merged.IntradaySeries.RSI7Values, []float64{50, 55}) {
+		t.Errorf("RSI7Values = %v, want secondary series", merged.IntradaySeries.RSI7Values)
+	}
+	if merged.LongerTermContext == nil || merged.LongerTermContext.ATR14 != 2.5 {
+		t.Errorf("LongerTermContext = %+v, want secondary context", merged.LongerTermContext)
+	}
+
+	// 不修改传入的数据
+	if primary.CurrentRSI7 != 0 || primary.IntradaySeries.RSI7Values != nil {
+		t.Error("MergeMarketData modified the primary data")
+	}
+}
+
+func TestMergeMarketDataUsesSecondaryPriceWhenPrimaryInvalid(t *testing.T) {
+	primary := &Data{Symbol: "ETHUSDT", CurrentPrice: 0, PriceChange1h: 3}
+	secondary := &Data{Symbol: "ETHUSDT", CurrentPrice: 2500, PriceChange1h: -0.5, PriceChange4h: 1}
+
+	merged := MergeMarketData(primary, secondary)
+	if merged.CurrentPrice != 2500 || merged.PriceChange1h != -0.5 || merged.PriceChange4h != 1 {
+		t.Errorf("price fields = %.2f/%.2f/%.2f, want all from secondary", merged.CurrentPrice, merged.PriceChange1h, merged.PriceChange4h)
+	}
+}
+
+func TestMergeMarketDataKeepsFetchedZeroValues(t *testing.T) {
+	// 主数据源成功获取到0资金费率（横盘），备用数据源的非0值不应覆盖
+	primary := &Data{Symbol: "BTCUSDT", CurrentPrice: 100, FundingRate: 0, Present: FieldFundingRate}
+	secondary := &Data{Symbol: "BTCUSDT", CurrentPrice: 100, FundingRate: 0.0003, MarkPrice: 100.1, Present: FieldFundingRate | FieldMarkPrice}
+
+	merged := MergeMarketData(primary, secondary)
+	if merged.FundingRate != 0 {
+		t.Errorf("FundingRate = %v, want the fetched 0 kept", merged.FundingRate)
+	}
+	// 主数据源未获取到标记价格时使用备用数据源
+	if merged.MarkPrice != 100.1 {
+		t.Errorf("MarkPrice = %v, want 100.1 from secondary", merged.MarkPrice)
+	}
+	if merged.Present != FieldFundingRate|FieldMarkPrice {
+		t.Errorf("Present = %b, want the union of both sources", merged.Present)
+	}
+}
+
+func TestMergeMarketDataNilInputs(t *testing.T) {
+	d := &Data{Symbol: "BTCUSDT"}
+	if MergeMarketData(nil, d) != d || MergeMarketData(d, nil) != d {
+		t.Error("nil input should return the other data unchanged")
+	}
+}
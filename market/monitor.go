@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"nofx/logger"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ type WSMonitor struct {
 	alertsChan     chan Alert
 	klineDataMap3m sync.Map // 存储每个交易对的K线历史数据
 	klineDataMap4h sync.Map // 存储每个交易对的K线历史数据
+	klineDataMaps  sync.Map // 其他周期的K线历史数据（周期 → *sync.Map），首次请求时按需订阅
 	tickerDataMap  sync.Map // 存储每个交易对的ticker数据
 	batchSize      int
 	filterSymbols  sync.Map // 使用sync.Map来存储需要监控的币种和其状态
@@ -45,7 +47,7 @@ func NewWSMonitor(batchSize int) *WSMonitor {
 }
 
 func (m *WSMonitor) Initialize(coins []string) error {
-	log.Println("初始化WebSocket监控器...")
+	logger.Infof("初始化WebSocket监控器...")
 	// 获取交易对信息
 	apiClient := NewAPIClient()
 	// 如果不指定交易对，则使用market市场的所有交易对币种
@@ -66,10 +68,10 @@ func (m *WSMonitor) Initialize(coins []string) error {
 		m.symbols = coins
 	}
 
-	log.Printf("找到 %d 个交易对", len(m.symbols))
+	logger.Infof("找到 %d 个交易对", len(m.symbols))
 	// 初始化历史数据
 	if err := m.initializeHistoricalData(); err != nil {
-		log.Printf("初始化历史数据失败: %v", err)
+		logger.Warnf("初始化历史数据失败: %v", err)
 	}
 
 	return nil
@@ -92,22 +94,22 @@ func (m *WSMonitor) initializeHistoricalData() error {
 			// 获取历史K线数据
 			klines, err := apiClient.GetKlines(s, "3m", 100)
 			if err != nil {
-				log.Printf("获取 %s 历史数据失败: %v", s, err)
+				logger.Warnf("获取 %s 历史数据失败: %v", s, err)
 				return
 			}
 			if len(klines) > 0 {
 				m.klineDataMap3m.Store(s, klines)
-				log.Printf("已加载 %s 的历史K线数据-3m: %d 条", s, len(klines))
+				logger.Infof("已加载 %s 的历史K线数据-3m: %d 条", s, len(klines))
 			}
 			// 获取历史K线数据
 			klines4h, err := apiClient.GetKlines(s, "4h", 100)
 			if err != nil {
-				log.Printf("获取 %s 历史数据失败: %v", s, err)
+				logger.Warnf("获取 %s 历史数据失败: %v", s, err)
 				return
 			}
 			if len(klines4h) > 0 {
 				m.klineDataMap4h.Store(s, klines4h)
-				log.Printf("已加载 %s 的历史K线数据-4h: %d 条", s, len(klines4h))
+				logger.Infof("已加载 %s 的历史K线数据-4h: %d 条", s, len(klines4h))
 			}
 		}(symbol)
 	}
@@ -117,7 +119,7 @@ func (m *WSMonitor) initializeHistoricalData() error {
 }
 
 func (m *WSMonitor) Start(coins []string) {
-	log.Printf("启动WebSocket实时监控...")
+	logger.Infof("启动WebSocket实时监控...")
 	// 初始化交易对
 	err := m.Initialize(coins)
 	if err != nil {
@@ -150,7 +152,7 @@ func (m *WSMonitor) subscribeSymbol(symbol, st string) []string {
 }
 func (m *WSMonitor) subscribeAll() error {
 	// 执行批量订阅
-	log.Println("开始订阅所有交易对...")
+	logger.Infof("开始订阅所有交易对...")
 	for _, symbol := range m.symbols {
 		for _, st := range subKlineTime {
 			m.subscribeSymbol(symbol, st)
@@ -163,7 +165,7 @@ func (m *WSMonitor) subscribeAll() error {
 			return err
 		}
 	}
-	log.Println("所有交易对订阅完成")
+	logger.Infof("所有交易对订阅完成")
 	return nil
 }
 
@@ -171,7 +173,7 @@ func (m *WSMonitor) handleKlineData(symbol string, ch <-chan []byte, _time strin
 	for data := range ch {
 		var klineData KlineWSData
 		if err := json.Unmarshal(data, &klineData); err != nil {
-			log.Printf("解析Kline数据失败: %v", err)
+			logger.Warnf("解析Kline数据失败: %v", err)
 			continue
 		}
 		m.processKlineUpdate(symbol, klineData, _time)
@@ -185,7 +187,8 @@ func (m *WSMonitor) getKlineDataMap(_time string) *sync.Map {
 	} else if _time == "4h" {
 		klineDataMap = &m.klineDataMap4h
 	} else {
-		klineDataMap = &sync.Map{}
+		value, _ := m.klineDataMaps.LoadOrStore(_time, &sync.Map{})
+		klineDataMap = value.(*sync.Map)
 	}
 	return klineDataMap
 }
@@ -242,7 +245,7 @@ func (m *WSMonitor) GetCurrentKlines(symbol string, _time string) ([]Kline, erro
 		m.getKlineDataMap(_time).Store(strings.ToUpper(symbol), klines) //动态缓存进缓存
 		subStr := m.subscribeSymbol(symbol, _time)
 		subErr := m.combinedClient.subscribeStreams(subStr)
-		log.Printf("动态订阅流: %v", subStr)
+		logger.Infof("动态订阅流: %v", subStr)
 		if subErr != nil {
 			return nil, fmt.Errorf("动态订阅%v分钟K线失败: %v", _time, subErr)
 		}
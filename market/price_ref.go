@@ -0,0 +1,157 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// 价格参考（永续合约的最新成交价、标记价格、盘口中间价可能不同）
+const (
+	PriceRefLast = "last" // 最新成交价
+	PriceRefMark = "mark" // 标记价格（交易所用于计算强平和未实现盈亏）
+	PriceRefMid  = "mid"  // 买一卖一中间价
+)
+
+// ReferencePrice 按参考类型取价格；对应价格缺失时回退到 CurrentPrice
+func (d *Data) ReferencePrice(ref string) float64 {
+	if d == nil {
+		return 0
+	}
+	var price float64
+	switch ref {
+	case PriceRefLast:
+		price = d.LastPrice
+	case PriceRefMark:
+		price = d.MarkPrice
+	case PriceRefMid:
+		price = d.MidPrice
+	}
+	if price <= 0 {
+		return d.CurrentPrice
+	}
+	return price
+}
+
+// getMidPrice 获取买一卖一中间价
+func getMidPrice(symbol string) (float64, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/ticker/bookTicker?symbol=%s", symbol)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		BidPrice string `json:"bidPrice"`
+		AskPrice string `json:"askPrice"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, err
+	}
+
+	bid, _ := strconv.ParseFloat(result.BidPrice, 64)
+	ask, _ := strconv.ParseFloat(result.AskPrice, 64)
+	if bid <= 0 || ask <= 0 {
+		return 0, fmt.Errorf("盘口价格无效: bid=%s ask=%s", result.BidPrice, result.AskPrice)
+	}
+	return (bid + ask) / 2, nil
+}
+
+// FundingSettlement 一次资金费结算
+type FundingSettlement struct {
+	Time      int64   // 结算时间（毫秒时间戳）
+	Rate      float64 // 资金费率
+	MarkPrice float64 // 结算时的标记价格
+}
+
+// GetFundingHistory 获取时间段内的资金费结算记录
+func GetFundingHistory(symbol string, startMs, endMs int64) ([]FundingSettlement, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/fundingRate?symbol=%s&startTime=%d&endTime=%d&limit=1000",
+		Normalize(symbol), startMs, endMs)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []struct {
+		FundingTime int64  `json:"fundingTime"`
+		FundingRate string `json:"fundingRate"`
+		MarkPrice   string `json:"markPrice"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	settlements := make([]FundingSettlement, 0, len(result))
+	for _, r := range result {
+		rate, _ := strconv.ParseFloat(r.FundingRate, 64)
+		markPrice, _ := strconv.ParseFloat(r.MarkPrice, 64)
+		settlements = append(settlements, FundingSettlement{Time: r.FundingTime, Rate: rate, MarkPrice: markPrice})
+	}
+	return settlements, nil
+}
+
+// BookDepth 盘口深度（前N档挂单数量合计，单位为币）
+type BookDepth struct {
+	BidQty float64 // 买盘数量（卖出/平多时可吃的深度）
+	AskQty float64 // 卖盘数量（买入/平空时可吃的深度）
+}
+
+// GetBookDepth 获取前 limit 档盘口深度（limit 取交易所支持的 5/10/20/50/100/500/1000）
+func GetBookDepth(symbol string, limit int) (*BookDepth, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/depth?symbol=%s&limit=%d", Normalize(symbol), limit)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Bids [][]string `json:"bids"`
+		Asks [][]string `json:"asks"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	depth := &BookDepth{BidQty: sumDepthLevels(result.Bids), AskQty: sumDepthLevels(result.Asks)}
+	if depth.BidQty <= 0 && depth.AskQty <= 0 {
+		return nil, fmt.Errorf("%s 盘口深度为空", symbol)
+	}
+	return depth, nil
+}
+
+// sumDepthLevels 合计各档挂单数量（每档为 [价格, 数量]）
+func sumDepthLevels(levels [][]string) float64 {
+	total := 0.0
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		qty, _ := strconv.ParseFloat(level[1], 64)
+		total += qty
+	}
+	return total
+}
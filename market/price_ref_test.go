@@ -0,0 +1,37 @@
+package market
+
+import "testing"
+
+func TestReferencePriceSelectsEachReference(t *testing.T) {
+	d := &Data{CurrentPrice: 100, LastPrice: 100.2, MarkPrice: 99.9, MidPrice: 100.05}
+
+	cases := []struct {
+		ref  string
+		want float64
+	}{
+		{PriceRefLast, 100.2},
+		{PriceRefMark, 99.9},
+		{PriceRefMid, 100.05},
+		{"", 100},        // 未配置时使用 CurrentPrice
+		{"unknown", 100}, // 未知类型同样回退
+	}
+	for _, tc := range cases {
+		if got := d.ReferencePrice(tc.ref); got != tc.want {
+			t.Errorf("ReferencePrice(%q) = %v, want %v", tc.ref, got, tc.want)
+		}
+	}
+}
+
+func TestReferencePriceFallsBackWhenMissing(t *testing.T) {
+	d := &Data{CurrentPrice: 100}
+	for _, ref := range []string{PriceRefLast, PriceRefMark, PriceRefMid} {
+		if got := d.ReferencePrice(ref); got != 100 {
+			t.Errorf("ReferencePrice(%q) = %v, want CurrentPrice 100", ref, got)
+		}
+	}
+
+	var nilData *Data
+	if got := nilData.ReferencePrice(PriceRefMark); got != 0 {
+		t.Errorf("nil ReferencePrice = %v, want 0", got)
+	}
+}
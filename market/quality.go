@@ -0,0 +1,68 @@
+package market
+
+// DataField 行情数据中可能缺失的字段（位图，获取成功时置位）
+// 用于区分"获取到但为0"（如横盘时1小时涨跌幅为0）和"未获取到"
+type DataField uint16
+
+const (
+	FieldPriceChange1h DataField = 1 << iota
+	FieldPriceChange4h
+	FieldOpenInterest
+	FieldFundingRate
+	FieldMarkPrice
+	FieldMidPrice
+	FieldLongShortRatio
+	FieldIntradaySeries
+	FieldLongerTermContext
+)
+
+// trackedFields 参与完整度统计的字段及名称
+var trackedFields = []struct {
+	Field DataField
+	Name  string
+}{
+	{FieldPriceChange1h, "price_change_1h"},
+	{FieldPriceChange4h, "price_change_4h"},
+	{FieldOpenInterest, "open_interest"},
+	{FieldFundingRate, "funding_rate"},
+	{FieldMarkPrice, "mark_price"},
+	{FieldMidPrice, "mid_price"},
+	{FieldLongShortRatio, "long_short_ratio"},
+	{FieldIntradaySeries, "intraday_series"},
+	{FieldLongerTermContext, "longer_term_context"},
+}
+
+// DataQualityReport 行情数据完整度
+type DataQualityReport struct {
+	Symbol        string   `json:"symbol"`
+	Completeness  float64  `json:"completeness"` // 0-1，获取到的字段占比
+	MissingFields []string `json:"missing_fields,omitempty"`
+}
+
+// Has 字段是否获取成功（值可以为0）
+func (d *Data) Has(field DataField) bool {
+	return d != nil && d.Present&field != 0
+}
+
+// GetDataQualityReport 按获取时记录的字段位图计算数据完整度（值为0但获取成功的字段不算缺失）
+func GetDataQualityReport(d *Data) DataQualityReport {
+	report := DataQualityReport{}
+	if d == nil {
+		for _, f := range trackedFields {
+			report.MissingFields = append(report.MissingFields, f.Name)
+		}
+		return report
+	}
+
+	report.Symbol = d.Symbol
+	present := 0
+	for _, f := range trackedFields {
+		if d.Has(f.Field) {
+			present++
+		} else {
+			report.MissingFields = append(report.MissingFields, f.Name)
+		}
+	}
+	report.Completeness = float64(present) / float64(len(trackedFields))
+	return report
+}
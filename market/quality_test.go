@@ -0,0 +1,68 @@
+package market
+
+import (
+	"reflect"
+	"testing"
+)
+
+// allDataFields 所有参与完整度统计的字段
+func allDataFields() DataField {
+	var all DataField
+	for _, f := range trackedFields {
+		all |= f.Field
+	}
+	return all
+}
+
+// flatSnapshot 横盘行情：所有字段都获取成功，但涨跌幅、资金费率、OI变化等数值为0
+func flatSnapshot() *Data {
+	return &Data{
+		Symbol:            "BTCUSDT",
+		CurrentPrice:      100,
+		PriceChange1h:     0,
+		PriceChange4h:     0,
+		FundingRate:       0,
+		LongShortRatio:    0,
+		OpenInterest:      &OIData{Latest: 0, Average: 0},
+		IntradaySeries:    &IntradayData{},
+		LongerTermContext: &LongerTermData{},
+		Present:           allDataFields(),
+	}
+}
+
+func TestDataQualityFlatSnapshotIsComplete(t *testing.T) {
+	data := flatSnapshot()
+	for _, f := range trackedFields {
+		if !data.Has(f.Field) {
+			t.Errorf("flat snapshot: %s not populated", f.Name)
+		}
+	}
+
+	report := GetDataQualityReport(data)
+	if report.Completeness != 1 || len(report.MissingFields) != 0 {
+		t.Errorf("flat snapshot report = %+v, want completeness 1 with no missing fields", report)
+	}
+	if report.Symbol != "BTCUSDT" {
+		t.Errorf("Symbol = %q, want BTCUSDT", report.Symbol)
+	}
+}
+
+func TestDataQualityReportsEachMissingField(t *testing.T) {
+	want := float64(len(trackedFields)-1) / float64(len(trackedFields))
+	for _, f := range trackedFields {
+		data := flatSnapshot()
+		data.Present &^= f.Field
+
+		report := GetDataQualityReport(data)
+		if report.Completeness != want || !reflect.DeepEqual(report.MissingFields, []string{f.Name}) {
+			t.Errorf("without %s: report = %+v, want completeness %.4f missing [%s]", f.Name, report, want, f.Name)
+		}
+	}
+}
+
+func TestDataQualityNilData(t *testing.T) {
+	report := GetDataQualityReport(nil)
+	if report.Completeness != 0 || len(report.MissingFields) != len(trackedFields) {
+		t.Errorf("nil report = %+v, want every field missing", report)
+	}
+}
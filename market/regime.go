@@ -0,0 +1,43 @@
+package market
+
+import "math"
+
+// 市场状态
+const (
+	RegimeTrendingUp   = "trending_up"   // 上升趋势
+	RegimeTrendingDown = "trending_down" // 下降趋势
+	RegimeRanging      = "ranging"       // 震荡
+	RegimeVolatile     = "volatile"      // 剧烈波动
+)
+
+// regimeVolatileATRRatio 4小时ATR占价格比例超过此值视为剧烈波动
+const regimeVolatileATRRatio = 0.04
+
+// ClassifyRegime 根据4小时均线排列和波动率判断市场状态，返回状态和置信度（0-1）
+// 价格、EMA20、EMA50 依次排列为趋势，排列越分明置信度越高；均线缠绕为震荡
+func ClassifyRegime(data *Data) (string, float64) {
+	if data == nil || data.CurrentPrice <= 0 || data.LongerTermContext == nil {
+		return "", 0
+	}
+	ltc := data.LongerTermContext
+
+	if ratio := data.ATRRatio(); ratio >= regimeVolatileATRRatio {
+		return RegimeVolatile, math.Min(1, 0.6+(ratio-regimeVolatileATRRatio)*10)
+	}
+	if ltc.EMA20 <= 0 || ltc.EMA50 <= 0 {
+		return "", 0
+	}
+
+	// EMA20与EMA50的距离（占价格比例），2%以上视为趋势分明
+	spread := (ltc.EMA20 - ltc.EMA50) / data.CurrentPrice
+	strength := math.Min(1, math.Abs(spread)/0.02)
+
+	switch {
+	case data.CurrentPrice > ltc.EMA20 && ltc.EMA20 > ltc.EMA50:
+		return RegimeTrendingUp, 0.5 + 0.5*strength
+	case data.CurrentPrice < ltc.EMA20 && ltc.EMA20 < ltc.EMA50:
+		return RegimeTrendingDown, 0.5 + 0.5*strength
+	default:
+		return RegimeRanging, 0.5 + 0.5*(1-strength)
+	}
+}
@@ -0,0 +1,108 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// SentimentScore 市场情绪综合评分（资金费率 + 多空比 + OI变化）
+type SentimentScore struct {
+	BullishScore float64 // 看多（贪婪）分量 0-100
+	BearishScore float64 // 看空（恐惧）分量 0-100
+	NetSentiment float64 // 净情绪 -100(极度恐惧) ~ 100(极度贪婪)
+	Label        string  // extreme_greed / greed / neutral / fear / extreme_fear
+}
+
+// 各指标权重
+const (
+	sentimentFundingWeight = 0.4
+	sentimentRatioWeight   = 0.35
+	sentimentOIWeight      = 0.25
+)
+
+// ComputeSentimentScore 计算市场情绪综合评分
+// fundingRate: 资金费率（如0.0001=0.01%），正费率说明多头拥挤
+// longShortRatio: 多空账户比（1=多空均衡，<=0表示无数据）
+// oiChange: 持仓量变化百分比，持仓量上升说明杠杆资金在加仓
+func ComputeSentimentScore(fundingRate, longShortRatio, oiChange float64) *SentimentScore {
+	// 各指标归一化到 [-1, 1]
+	fundingSignal := clampUnit(fundingRate / 0.0005) // 0.05%视为极端费率
+	ratioSignal := 0.0
+	if longShortRatio > 0 {
+		ratioSignal = clampUnit(math.Log2(longShortRatio)) // 2:1 或 1:2 视为极端
+	}
+	oiSignal := clampUnit(oiChange / 5) // 5%视为极端变化
+
+	score := &SentimentScore{}
+	for _, c := range []float64{
+		fundingSignal * sentimentFundingWeight * 100,
+		ratioSignal * sentimentRatioWeight * 100,
+		oiSignal * sentimentOIWeight * 100,
+	} {
+		if c > 0 {
+			score.BullishScore += c
+		} else {
+			score.BearishScore -= c
+		}
+	}
+	score.NetSentiment = score.BullishScore - score.BearishScore
+	score.Label = sentimentLabel(score.NetSentiment)
+	return score
+}
+
+// sentimentLabel 净情绪分档：>=60 极度贪婪，>=20 贪婪，(-20, 20) 中性，(-60, -20] 恐惧，<=-60 极度恐惧
+func sentimentLabel(net float64) string {
+	switch {
+	case net >= 60:
+		return "extreme_greed"
+	case net >= 20:
+		return "greed"
+	case net > -20:
+		return "neutral"
+	case net > -60:
+		return "fear"
+	default:
+		return "extreme_fear"
+	}
+}
+
+// clampUnit 限制在 [-1, 1] 区间
+func clampUnit(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
+
+// getLongShortRatio 获取全市场多空账户比（最近5分钟）
+func getLongShortRatio(symbol string) (float64, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/futures/data/globalLongShortAccountRatio?symbol=%s&period=5m&limit=1", symbol)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var result []struct {
+		Symbol         string `json:"symbol"`
+		LongShortRatio string `json:"longShortRatio"`
+		Timestamp      int64  `json:"timestamp"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("多空比数据为空")
+	}
+
+	ratio, _ := strconv.ParseFloat(result[0].LongShortRatio, 64)
+	return ratio, nil
+}
@@ -0,0 +1,59 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSentimentLabelBoundaries(t *testing.T) {
+	cases := []struct {
+		net  float64
+		want string
+	}{
+		{100, "extreme_greed"},
+		{60, "extreme_greed"},
+		{59.99, "greed"},
+		{20, "greed"},
+		{19.99, "neutral"},
+		{0, "neutral"},
+		{-19.99, "neutral"},
+		{-20, "fear"},
+		{-59.99, "fear"},
+		{-60, "extreme_fear"},
+		{-100, "extreme_fear"},
+	}
+	for _, tc := range cases {
+		if got := sentimentLabel(tc.net); got != tc.want {
+			t.Errorf("sentimentLabel(%v) = %s, want %s", tc.net, got, tc.want)
+		}
+	}
+}
+
+func TestComputeSentimentScoreExtremes(t *testing.T) {
+	// 全部指标达到极端看多：费率0.05%、多空比2:1、OI +5%
+	greed := ComputeSentimentScore(0.0005, 2, 5)
+	if math.Abs(greed.NetSentiment-100) > 1e-9 || greed.BearishScore != 0 || greed.Label != "extreme_greed" {
+		t.Errorf("greed = %+v, want net 100 extreme_greed", greed)
+	}
+
+	fear := ComputeSentimentScore(-0.001, 0.25, -10)
+	if math.Abs(fear.NetSentiment+100) > 1e-9 || fear.BullishScore != 0 || fear.Label != "extreme_fear" {
+		t.Errorf("fear = %+v, want net -100 extreme_fear (signals clamped)", fear)
+	}
+}
+
+func TestComputeSentimentScoreMixedSignals(t *testing.T) {
+	// 费率极端看多(+40)，多空比极端看空(-35)，无OI变化
+	score := ComputeSentimentScore(0.0005, 0.5, 0)
+	if math.Abs(score.BullishScore-40) > 1e-9 || math.Abs(score.BearishScore-35) > 1e-9 {
+		t.Errorf("components = %.2f/%.2f, want 40/35", score.BullishScore, score.BearishScore)
+	}
+	if score.Label != "neutral" {
+		t.Errorf("label = %s, want neutral for net %.2f", score.Label, score.NetSentiment)
+	}
+
+	// 多空比无数据时不参与评分
+	if s := ComputeSentimentScore(0, 0, 0); s.NetSentiment != 0 || s.Label != "neutral" {
+		t.Errorf("no data = %+v, want neutral 0", s)
+	}
+}
@@ -0,0 +1,115 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"nofx/logger"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tickSizeTTL 价格步进值缓存有效期（交易规则很少变化，但上新币或调整精度后需要刷新）
+const tickSizeTTL = time.Hour
+
+// ErrTickSizeUnsupported 交易所没有固定的价格步进值（如 Hyperliquid 按有效数字限制价格精度）
+var ErrTickSizeUnsupported = errors.New("交易所不提供固定的价格步进值")
+
+// exchangeInfoURLs 各交易所的交易规则接口（返回 Binance 兼容的 exchangeInfo 格式）
+var exchangeInfoURLs = map[string]string{
+	"binance": "https://fapi.binance.com/fapi/v1/exchangeInfo",
+	"aster":   "https://fapi.asterdex.com/fapi/v3/exchangeInfo",
+}
+
+// tickSizeEntry 单个交易所的价格步进值缓存
+type tickSizeEntry struct {
+	sizes     map[string]float64
+	fetchedAt time.Time
+}
+
+// 交易对价格步进值缓存（按交易所，首次查询或过期时一次性加载全部交易对）
+var (
+	tickSizeMu    sync.Mutex
+	tickSizeCache = make(map[string]tickSizeEntry)
+)
+
+// GetTickSize 获取指定交易所交易对的最小价格变动单位（PRICE_FILTER.tickSize）
+func GetTickSize(exchange, symbol string) (float64, error) {
+	symbol = Normalize(symbol)
+	if exchange == "" {
+		exchange = "binance"
+	}
+	url, ok := exchangeInfoURLs[exchange]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", exchange, ErrTickSizeUnsupported)
+	}
+
+	tickSizeMu.Lock()
+	entry, cached := tickSizeCache[exchange]
+	tickSizeMu.Unlock()
+
+	// 请求交易所时不持锁，避免慢请求阻塞其他交易所和其他交易员的查询
+	if !cached || time.Since(entry.fetchedAt) > tickSizeTTL {
+		sizes, err := fetchTickSizes(url)
+		switch {
+		case err == nil:
+			entry = tickSizeEntry{sizes: sizes, fetchedAt: time.Now()}
+			tickSizeMu.Lock()
+			tickSizeCache[exchange] = entry
+			tickSizeMu.Unlock()
+		case !cached:
+			return 0, fmt.Errorf("获取 %s 交易规则失败: %w", exchange, err)
+		default:
+			logger.Warnf("⚠️  刷新 %s 交易规则失败，继续使用缓存: %v", exchange, err)
+		}
+	}
+
+	tickSize, ok := entry.sizes[symbol]
+	if !ok || tickSize <= 0 {
+		return 0, fmt.Errorf("未找到 %s 的价格步进值", symbol)
+	}
+	return tickSize, nil
+}
+
+// fetchTickSizes 从交易所规则中读取所有交易对的价格步进值
+func fetchTickSizes(url string) (map[string]float64, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Symbols []struct {
+			Symbol  string                   `json:"symbol"`
+			Filters []map[string]interface{} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, err
+	}
+
+	cache := make(map[string]float64, len(info.Symbols))
+	for _, s := range info.Symbols {
+		for _, filter := range s.Filters {
+			if filterType, _ := filter["filterType"].(string); filterType != "PRICE_FILTER" {
+				continue
+			}
+			if tickSizeStr, ok := filter["tickSize"].(string); ok {
+				cache[s.Symbol], _ = strconv.ParseFloat(tickSizeStr, 64)
+			}
+		}
+	}
+	return cache, nil
+}
@@ -0,0 +1,106 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serveTickSize 返回指定 tickSize 的 exchangeInfo，并记录请求次数
+func serveTickSize(t *testing.T, tickSize *atomic.Value, hits *atomic.Int32) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		size, _ := tickSize.Load().(string)
+		if size == "" {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"symbols":[{"symbol":"BTCUSDT","filters":[{"filterType":"PRICE_FILTER","tickSize":"%s"}]}]}`, size)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// useExchangeInfoURLs 测试期间替换交易规则接口并清空缓存
+func useExchangeInfoURLs(t *testing.T, urls map[string]string) {
+	t.Helper()
+	oldURLs := exchangeInfoURLs
+	exchangeInfoURLs = urls
+	tickSizeMu.Lock()
+	tickSizeCache = make(map[string]tickSizeEntry)
+	tickSizeMu.Unlock()
+	t.Cleanup(func() {
+		exchangeInfoURLs = oldURLs
+		tickSizeMu.Lock()
+		tickSizeCache = make(map[string]tickSizeEntry)
+		tickSizeMu.Unlock()
+	})
+}
+
+// expireTickSizes 让某交易所的缓存过期
+func expireTickSizes(exchange string) {
+	tickSizeMu.Lock()
+	defer tickSizeMu.Unlock()
+	entry := tickSizeCache[exchange]
+	entry.fetchedAt = time.Now().Add(-tickSizeTTL - time.Minute)
+	tickSizeCache[exchange] = entry
+}
+
+func TestGetTickSizeIsPerExchange(t *testing.T) {
+	var binanceSize, asterSize atomic.Value
+	var binanceHits, asterHits atomic.Int32
+	binanceSize.Store("0.10")
+	asterSize.Store("0.01")
+	useExchangeInfoURLs(t, map[string]string{
+		"binance": serveTickSize(t, &binanceSize, &binanceHits),
+		"aster":   serveTickSize(t, &asterSize, &asterHits),
+	})
+
+	if got, err := GetTickSize("binance", "btc"); err != nil || got != 0.1 {
+		t.Fatalf("binance tick = %v, %v, want 0.1", got, err)
+	}
+	if got, err := GetTickSize("aster", "BTCUSDT"); err != nil || got != 0.01 {
+		t.Fatalf("aster tick = %v, %v, want 0.01", got, err)
+	}
+	GetTickSize("binance", "BTCUSDT")
+	if binanceHits.Load() != 1 || asterHits.Load() != 1 {
+		t.Errorf("hits = %d/%d, want one fetch per exchange", binanceHits.Load(), asterHits.Load())
+	}
+
+	if _, err := GetTickSize("hyperliquid", "BTCUSDT"); !errors.Is(err, ErrTickSizeUnsupported) {
+		t.Errorf("hyperliquid err = %v, want ErrTickSizeUnsupported", err)
+	}
+}
+
+func TestGetTickSizeRefreshesAfterTTL(t *testing.T) {
+	var size atomic.Value
+	var hits atomic.Int32
+	size.Store("0.10")
+	useExchangeInfoURLs(t, map[string]string{"binance": serveTickSize(t, &size, &hits)})
+
+	GetTickSize("binance", "BTCUSDT")
+	size.Store("0.50")
+	if got, _ := GetTickSize("binance", "BTCUSDT"); got != 0.1 {
+		t.Fatalf("tick = %v before expiry, want cached 0.1", got)
+	}
+
+	expireTickSizes("binance")
+	if got, _ := GetTickSize("binance", "BTCUSDT"); got != 0.5 {
+		t.Fatalf("tick = %v after expiry, want refreshed 0.5", got)
+	}
+
+	// 刷新失败时继续使用旧数据
+	size.Store("")
+	expireTickSizes("binance")
+	if got, err := GetTickSize("binance", "BTCUSDT"); err != nil || got != 0.5 {
+		t.Fatalf("tick = %v, %v after failed refresh, want stale 0.5", got, err)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("hits = %d, want 3", hits.Load())
+	}
+}
@@ -0,0 +1,72 @@
+package market
+
+import "sort"
+
+// TickData 逐笔成交
+type TickData struct {
+	Timestamp int64   // 成交时间（毫秒时间戳）
+	Price     float64 // 成交价
+	Volume    float64 // 成交量
+}
+
+// AggregateTicksToCandles 将逐笔成交聚合为K线（按开盘时间升序，中间不留空缺）
+// 每笔成交归入开盘时间为 Timestamp 向下取整到周期整数倍的K线；没有成交的K线用上一根的收盘价
+// 作为开高低收、成交量为0。candleIntervalSec <= 0 或没有成交时返回nil
+func AggregateTicksToCandles(ticks []TickData, candleIntervalSec int64) []Kline {
+	if candleIntervalSec <= 0 || len(ticks) == 0 {
+		return nil
+	}
+	intervalMs := candleIntervalSec * 1000
+
+	sorted := make([]TickData, len(ticks))
+	copy(sorted, ticks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	var candles []Kline
+	for _, tick := range sorted {
+		openTime := tick.Timestamp / intervalMs * intervalMs
+
+		if n := len(candles); n > 0 && candles[n-1].OpenTime == openTime {
+			c := &candles[n-1]
+			if tick.Price > c.High {
+				c.High = tick.Price
+			}
+			if tick.Price < c.Low {
+				c.Low = tick.Price
+			}
+			c.Close = tick.Price
+			c.Volume += tick.Volume
+			c.QuoteVolume += tick.Price * tick.Volume
+			c.Trades++
+			continue
+		}
+
+		// 补齐与上一根K线之间没有成交的K线
+		if n := len(candles); n > 0 {
+			prevClose := candles[n-1].Close
+			for t := candles[n-1].OpenTime + intervalMs; t < openTime; t += intervalMs {
+				candles = append(candles, Kline{
+					OpenTime:  t,
+					Open:      prevClose,
+					High:      prevClose,
+					Low:       prevClose,
+					Close:     prevClose,
+					CloseTime: t + intervalMs - 1,
+				})
+			}
+		}
+
+		candles = append(candles, Kline{
+			OpenTime:    openTime,
+			Open:        tick.Price,
+			High:        tick.Price,
+			Low:         tick.Price,
+			Close:       tick.Price,
+			Volume:      tick.Volume,
+			CloseTime:   openTime + intervalMs - 1,
+			QuoteVolume: tick.Price * tick.Volume,
+			Trades:      1,
+		})
+	}
+	return candles
+}
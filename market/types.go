@@ -13,8 +13,26 @@ type Data struct {
 	CurrentRSI7       float64
 	OpenInterest      *OIData
 	FundingRate       float64
+	NextFundingTime   int64           // 下次资金费结算时间（毫秒时间戳）
+	LongShortRatio    float64         // 多空账户比（0=无数据）
+	Sentiment         *SentimentScore // 市场情绪综合评分
 	IntradaySeries    *IntradayData
 	LongerTermContext *LongerTermData
+
+	// 多周期RSI背离共振（1h/4h/1d 中至少两个周期同时出现同类背离）
+	MTFRSIBullishConfluence bool
+	MTFRSIBearishConfluence bool
+
+	// 价格参考（0=无数据，见 ReferencePrice）
+	LastPrice float64 // 最新成交价（与 CurrentPrice 相同）
+	MarkPrice float64 // 标记价格
+	MidPrice  float64 // 买一卖一中间价
+
+	IndexPrice float64 // 指数价格（现货加权，仅用于价格一致性检查，0=无数据）
+
+	PriceError string // 价格一致性检查失败的原因（非空时数据不可用于开仓决策）
+
+	Present DataField // 获取成功的字段（见 GetDataQualityReport）
 }
 
 // OIData Open Interest数据
@@ -38,6 +56,7 @@ type LongerTermData struct {
 	EMA50         float64
 	ATR3          float64
 	ATR14         float64
+	ATRPercentile float64 // 当前ATR14/价格在近期K线中的分位（0-1，数据不足时为0）
 	CurrentVolume float64
 	AverageVolume float64
 	MACDValues    []float64
@@ -0,0 +1,51 @@
+package market
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultMaxPriceDeviationPct 当前价允许超出最新K线高低点的默认幅度（%）
+const DefaultMaxPriceDeviationPct = 2.0
+
+// DefaultMaxSourceDeviationPct 标记价格、指数价格与最新成交价允许的默认偏差（%）
+const DefaultMaxSourceDeviationPct = 1.0
+
+// ValidatePriceConsistency 检查当前价是否落在最新K线的高低点范围内（允许 maxDeviationPct% 的偏差）
+// 当前价远超K线区间通常是行情源异常，用这样的数据计算指标会失真
+func ValidatePriceConsistency(currentPrice float64, latestKline Kline, maxDeviationPct float64) error {
+	if maxDeviationPct <= 0 {
+		maxDeviationPct = DefaultMaxPriceDeviationPct
+	}
+	upper := latestKline.High * (1 + maxDeviationPct/100)
+	lower := latestKline.Low * (1 - maxDeviationPct/100)
+	if currentPrice > upper || currentPrice < lower {
+		return fmt.Errorf("当前价 %.4f 超出最新K线区间 [%.4f, %.4f] 的 %.1f%% 容差",
+			currentPrice, latestKline.Low, latestKline.High, maxDeviationPct)
+	}
+	return nil
+}
+
+// ValidatePriceSources 检查标记价格、指数价格与最新成交价是否一致（偏差不超过 maxDeviationPct%）
+// 正常行情下三者相差极小，大幅背离说明某个数据源异常或市场剧烈插针；缺失的价格（0）不参与检查
+func ValidatePriceSources(lastPrice, markPrice, indexPrice, maxDeviationPct float64) error {
+	if lastPrice <= 0 {
+		return nil
+	}
+	if maxDeviationPct <= 0 {
+		maxDeviationPct = DefaultMaxSourceDeviationPct
+	}
+	for _, source := range []struct {
+		name  string
+		price float64
+	}{{"标记价格", markPrice}, {"指数价格", indexPrice}} {
+		if source.price <= 0 {
+			continue
+		}
+		if deviation := math.Abs(source.price-lastPrice) / lastPrice * 100; deviation > maxDeviationPct {
+			return fmt.Errorf("%s %.4f 与最新成交价 %.4f 偏离 %.2f%%，超过 %.1f%% 容差",
+				source.name, source.price, lastPrice, deviation, maxDeviationPct)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package market
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePriceConsistencyKlineRange(t *testing.T) {
+	kline := Kline{High: 102, Low: 98}
+	cases := []struct {
+		name  string
+		price float64
+		ok    bool
+	}{
+		{"inside the range", 100, true},
+		{"within tolerance above the high", 104, true},
+		{"beyond tolerance above the high", 104.1, false},
+		{"beyond tolerance below the low", 96, false},
+	}
+	for _, tc := range cases {
+		if err := ValidatePriceConsistency(tc.price, kline, 2); (err == nil) != tc.ok {
+			t.Errorf("%s: price %.2f err = %v, want ok=%v", tc.name, tc.price, err, tc.ok)
+		}
+	}
+}
+
+func TestValidatePriceSourcesDivergence(t *testing.T) {
+	cases := []struct {
+		name              string
+		last, mark, index float64
+		wantErr           string
+	}{
+		{"all agree", 100, 100.05, 99.98, ""},
+		{"mark diverges", 100, 101.5, 100, "标记价格"},
+		{"index diverges", 100, 100.1, 98.5, "指数价格"},
+		{"mark and index agree but last diverges", 103, 100, 100, "标记价格"},
+		{"missing mark and index", 100, 0, 0, ""},
+		{"missing last price", 0, 150, 50, ""},
+	}
+	for _, tc := range cases {
+		err := ValidatePriceSources(tc.last, tc.mark, tc.index, 1)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: err = %v, want nil", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: err = %v, want divergence of %s", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidatePriceSourcesDefaultTolerance(t *testing.T) {
+	if err := ValidatePriceSources(100, 100.9, 0, 0); err != nil {
+		t.Errorf("0.9%% deviation rejected with the default tolerance: %v", err)
+	}
+	if err := ValidatePriceSources(100, 101.2, 0, 0); err == nil {
+		t.Error("1.2% deviation accepted with the default tolerance")
+	}
+}
@@ -0,0 +1,44 @@
+package market
+
+// VolumeStats 成交量确认指标（基于最近一根已收盘K线）
+type VolumeStats struct {
+	Current   float64 // 最近一根已收盘K线的成交量
+	Previous  float64 // 上一根K线的成交量
+	Average   float64 // 之前 lookback 根K线的平均成交量
+	ChangePct float64 // 相对上一根K线的成交量变化百分比
+	Ratio     float64 // 相对平均成交量的倍数
+}
+
+// ComputeVolumeStats 计算最近一根已收盘K线的成交量指标
+// 最后一根K线尚未收盘、成交量不完整，因此不参与计算；K线不足时返回 false
+func ComputeVolumeStats(klines []Kline, lookback int) (VolumeStats, bool) {
+	if lookback <= 0 {
+		lookback = 20
+	}
+	closed := len(klines) - 1 // 排除未收盘K线
+	if closed < 2 {
+		return VolumeStats{}, false
+	}
+
+	current := klines[closed-1].Volume
+	previous := klines[closed-2].Volume
+
+	start := closed - 1 - lookback
+	if start < 0 {
+		start = 0
+	}
+	sum := 0.0
+	for _, k := range klines[start : closed-1] {
+		sum += k.Volume
+	}
+	average := sum / float64(closed-1-start)
+
+	stats := VolumeStats{Current: current, Previous: previous, Average: average}
+	if previous > 0 {
+		stats.ChangePct = (current - previous) / previous * 100
+	}
+	if average > 0 {
+		stats.Ratio = current / average
+	}
+	return stats, true
+}
@@ -3,7 +3,7 @@ package market
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"nofx/logger"
 	"sync"
 	"time"
 
@@ -90,7 +90,7 @@ func (w *WSClient) Connect() error {
 	w.conn = conn
 	w.mu.Unlock()
 
-	log.Println("WebSocket连接成功")
+	logger.Infof("WebSocket连接成功")
 
 	// 启动消息读取循环
 	go w.readMessages()
@@ -132,7 +132,7 @@ func (w *WSClient) subscribe(stream string) error {
 		return err
 	}
 
-	log.Printf("订阅流: %s", stream)
+	logger.Infof("订阅流: %s", stream)
 	return nil
 }
 
@@ -153,7 +153,7 @@ func (w *WSClient) readMessages() {
 
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				log.Printf("读取WebSocket消息失败: %v", err)
+				logger.Warnf("读取WebSocket消息失败: %v", err)
 				w.handleReconnect()
 				return
 			}
@@ -178,7 +178,7 @@ func (w *WSClient) handleMessage(message []byte) {
 		select {
 		case ch <- wsMsg.Data:
 		default:
-			log.Printf("订阅者通道已满: %s", wsMsg.Stream)
+			logger.Infof("订阅者通道已满: %s", wsMsg.Stream)
 		}
 	}
 }
@@ -188,11 +188,11 @@ func (w *WSClient) handleReconnect() {
 		return
 	}
 
-	log.Println("尝试重新连接...")
+	logger.Infof("尝试重新连接...")
 	time.Sleep(3 * time.Second)
 
 	if err := w.Connect(); err != nil {
-		log.Printf("重新连接失败: %v", err)
+		logger.Warnf("重新连接失败: %v", err)
 		go w.handleReconnect()
 	}
 }
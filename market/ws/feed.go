@@ -0,0 +1,224 @@
+// Package ws 基于WebSocket的实时行情推送
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"nofx/logger"
+	"nofx/market"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultStreamURL 币安合约组合流地址
+const DefaultStreamURL = "wss://fstream.binance.com/stream"
+
+// feedBuffer 订阅channel的缓冲大小（订阅者处理不及时则丢弃新数据）
+const feedBuffer = 16
+
+// WebSocketMarketDataFeed 实时行情推送
+// 指标（EMA/MACD/RSI等）仍按 SnapshotInterval 通过 market.Get 定期刷新；
+// 最新成交价、标记价格、盘口中间价和资金费率由WebSocket实时更新，标记价格每秒推送一次
+type WebSocketMarketDataFeed struct {
+	URL              string        // 组合流地址
+	SnapshotInterval time.Duration // 指标快照刷新间隔
+	ReconnectDelay   time.Duration // 断线重连间隔
+
+	// getSnapshot 获取指标快照（默认 market.Get）
+	getSnapshot func(symbol string) (*market.Data, error)
+}
+
+// NewWebSocketMarketDataFeed 创建实时行情推送
+func NewWebSocketMarketDataFeed() *WebSocketMarketDataFeed {
+	return &WebSocketMarketDataFeed{
+		URL:              DefaultStreamURL,
+		SnapshotInterval: 3 * time.Minute,
+		ReconnectDelay:   5 * time.Second,
+		getSnapshot:      market.Get,
+	}
+}
+
+// Subscribe 订阅指定币种的实时行情，调用返回的 cancel 取消订阅（channel随之关闭）
+func (f *WebSocketMarketDataFeed) Subscribe(symbol string) (<-chan *market.Data, context.CancelFunc, error) {
+	symbol = market.Normalize(symbol)
+
+	snapshot, err := f.getSnapshot(symbol)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取%s初始行情失败: %w", symbol, err)
+	}
+
+	conn, err := f.dial(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &subscription{
+		feed:   f,
+		symbol: symbol,
+		data:   snapshot,
+		out:    make(chan *market.Data, feedBuffer),
+	}
+	go sub.run(ctx, conn)
+
+	return sub.out, cancel, nil
+}
+
+// dial 连接该币种的成交、标记价格和盘口组合流
+func (f *WebSocketMarketDataFeed) dial(symbol string) (*websocket.Conn, error) {
+	lower := strings.ToLower(symbol)
+	streams := []string{lower + "@aggTrade", lower + "@markPrice@1s", lower + "@bookTicker"}
+	url := fmt.Sprintf("%s?streams=%s", f.URL, strings.Join(streams, "/"))
+
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("实时行情WebSocket连接失败: %w", err)
+	}
+	return conn, nil
+}
+
+// subscription 单个币种的订阅
+type subscription struct {
+	feed   *WebSocketMarketDataFeed
+	symbol string
+
+	mu   sync.Mutex
+	data *market.Data // 当前行情（指标快照 + 实时价格）
+	out  chan *market.Data
+}
+
+// run 读取消息直到取消订阅，断线后自动重连
+func (s *subscription) run(ctx context.Context, conn *websocket.Conn) {
+	defer close(s.out)
+
+	go s.refreshSnapshots(ctx)
+
+	for {
+		go func(conn *websocket.Conn) {
+			<-ctx.Done()
+			conn.Close()
+		}(conn)
+
+		s.read(conn)
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Warnf("⚠️ [%s] 实时行情连接断开，%v 后重连", s.symbol, s.feed.ReconnectDelay)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.feed.ReconnectDelay):
+			}
+			var err error
+			if conn, err = s.feed.dial(s.symbol); err == nil {
+				break
+			}
+			logger.Warnf("⚠️ [%s] 实时行情重连失败: %v", s.symbol, err)
+		}
+	}
+}
+
+// refreshSnapshots 定期刷新指标快照（价格随后由实时消息继续更新）
+func (s *subscription) refreshSnapshots(ctx context.Context) {
+	ticker := time.NewTicker(s.feed.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot, err := s.feed.getSnapshot(s.symbol)
+			if err != nil {
+				logger.Warnf("⚠️ [%s] 刷新指标快照失败: %v", s.symbol, err)
+				continue
+			}
+			s.mu.Lock()
+			s.data = snapshot
+			s.mu.Unlock()
+		}
+	}
+}
+
+// read 读取并处理消息，连接出错时返回
+func (s *subscription) read(conn *websocket.Conn) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := s.handle(message); err != nil {
+			logger.Warnf("⚠️ [%s] 解析实时行情失败: %v", s.symbol, err)
+		}
+	}
+}
+
+// streamMessage 组合流消息
+type streamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// handle 更新实时价格；标记价格事件（每秒一次）触发推送
+func (s *subscription) handle(message []byte) error {
+	var msg streamMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return err
+	}
+
+	var event struct {
+		Price           string `json:"p"` // aggTrade: 成交价；markPrice: 标记价格
+		FundingRate     string `json:"r"`
+		NextFundingTime int64  `json:"T"`
+		BidPrice        string `json:"b"`
+		AskPrice        string `json:"a"`
+	}
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(msg.Stream, "@aggTrade"):
+		if price := parseFloat(event.Price); price > 0 {
+			s.data.CurrentPrice = price
+			s.data.LastPrice = price
+		}
+	case strings.HasSuffix(msg.Stream, "@bookTicker"):
+		if bid, ask := parseFloat(event.BidPrice), parseFloat(event.AskPrice); bid > 0 && ask > 0 {
+			s.data.MidPrice = (bid + ask) / 2
+		}
+	case strings.Contains(msg.Stream, "@markPrice"):
+		if price := parseFloat(event.Price); price > 0 {
+			s.data.MarkPrice = price
+		}
+		if event.FundingRate != "" {
+			s.data.FundingRate = parseFloat(event.FundingRate)
+		}
+		if event.NextFundingTime > 0 {
+			s.data.NextFundingTime = event.NextFundingTime
+		}
+
+		copied := *s.data
+		select {
+		case s.out <- &copied:
+		default:
+		}
+	}
+	return nil
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
@@ -0,0 +1,142 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MockWebSocketServer 按顺序推送给定消息的组合流服务器
+type MockWebSocketServer struct {
+	*httptest.Server
+	streams chan string // 客户端请求的 streams 参数
+}
+
+func newMockWebSocketServer(t *testing.T, frames []string) *MockWebSocketServer {
+	t.Helper()
+	m := &MockWebSocketServer{streams: make(chan string, 1)}
+	upgrader := websocket.Upgrader{}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		select {
+		case m.streams <- r.URL.Query().Get("streams"):
+		default:
+		}
+		for _, frame := range frames {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+				return
+			}
+		}
+		// 保持连接直到客户端关闭
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(m.Server.Close)
+	return m
+}
+
+func (m *MockWebSocketServer) URL() string {
+	return "ws" + strings.TrimPrefix(m.Server.URL, "http") + "/stream"
+}
+
+func newTestFeed(url string) *WebSocketMarketDataFeed {
+	feed := NewWebSocketMarketDataFeed()
+	feed.URL = url
+	feed.getSnapshot = func(symbol string) (*market.Data, error) {
+		return &market.Data{Symbol: symbol, CurrentPrice: 100, CurrentRSI7: 55}, nil
+	}
+	return feed
+}
+
+func receive(t *testing.T, ch <-chan *market.Data) *market.Data {
+	t.Helper()
+	select {
+	case d, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return d
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a tick")
+	}
+	return nil
+}
+
+func TestSubscribePushesFramesFromServer(t *testing.T) {
+	srv := newMockWebSocketServer(t, []string{
+		`{"stream":"btcusdt@aggTrade","data":{"p":"101.5"}}`,
+		`{"stream":"btcusdt@bookTicker","data":{"b":"101.4","a":"101.6"}}`,
+		`{"stream":"btcusdt@markPrice@1s","data":{"p":"101.45","r":"0.0001","T":1700000000000}}`,
+		`{"stream":"btcusdt@aggTrade","data":{"p":"102"}}`,
+		`{"stream":"btcusdt@markPrice@1s","data":{"p":"101.9","r":"0.0001","T":1700000000000}}`,
+	})
+	feed := newTestFeed(srv.URL())
+
+	ch, cancel, err := feed.Subscribe("btc")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if streams := <-srv.streams; streams != "btcusdt@aggTrade/btcusdt@markPrice@1s/btcusdt@bookTicker" {
+		t.Errorf("streams = %q", streams)
+	}
+	first := receive(t, ch)
+	if first.CurrentPrice != 101.5 || first.MarkPrice != 101.45 || first.MidPrice != 101.5 ||
+		first.FundingRate != 0.0001 || first.NextFundingTime != 1700000000000 {
+		t.Errorf("first tick = %+v", first)
+	}
+	if first.CurrentRSI7 != 55 {
+		t.Errorf("indicators from the snapshot lost: RSI7 = %v", first.CurrentRSI7)
+	}
+	if second := receive(t, ch); second.CurrentPrice != 102 || second.MarkPrice != 101.9 {
+		t.Errorf("second tick = %+v", second)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestStartStreamProcessingDropsInvalidAndUnchangedTicks(t *testing.T) {
+	srv := newMockWebSocketServer(t, []string{
+		`{"stream":"ethusdt@markPrice@1s","data":{"p":"0"}}`, // 标记价无效
+		`{"stream":"ethusdt@markPrice@1s","data":{"p":"2000"}}`,
+		`{"stream":"ethusdt@markPrice@1s","data":{"p":"2000"}}`, // 价格未变化
+		`not json`,
+		`{"stream":"ethusdt@markPrice@1s","data":{"p":"2001"}}`,
+	})
+	feed := newTestFeed(srv.URL())
+
+	ch, cancel, err := StartStreamProcessing(feed, "ETHUSDT")
+	if err != nil {
+		t.Fatalf("StartStreamProcessing: %v", err)
+	}
+	defer cancel()
+
+	if d := receive(t, ch); d.MarkPrice != 2000 {
+		t.Errorf("first processed tick mark = %v, want 2000", d.MarkPrice)
+	}
+	if d := receive(t, ch); d.MarkPrice != 2001 {
+		t.Errorf("second processed tick mark = %v, want 2001 (duplicate dropped)", d.MarkPrice)
+	}
+}
+
+func TestSubscribeFailsWhenServerUnavailable(t *testing.T) {
+	feed := newTestFeed("ws://127.0.0.1:1/stream")
+	if _, _, err := feed.Subscribe("BTCUSDT"); err == nil {
+		t.Fatal("expected dial error")
+	}
+}
@@ -0,0 +1,49 @@
+package ws
+
+import (
+	"context"
+	"math"
+	"nofx/market"
+)
+
+// StartStreamProcessing 订阅实时行情并清洗后输出：价格无效（≤0 或非数字）的推送丢弃，
+// 与上一次输出相比价格均未变化的推送也丢弃，下游只处理有变化的有效行情
+func StartStreamProcessing(feed *WebSocketMarketDataFeed, symbol string) (<-chan *market.Data, context.CancelFunc, error) {
+	in, cancel, err := feed.Subscribe(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan *market.Data, feedBuffer)
+	go func() {
+		defer close(out)
+		var last *market.Data
+		for data := range in {
+			if !validTick(data) || (last != nil && samePrices(last, data)) {
+				continue
+			}
+			last = data
+			select {
+			case out <- data:
+			default:
+			}
+		}
+	}()
+	return out, cancel, nil
+}
+
+// validTick 推送中的价格是否可用
+func validTick(d *market.Data) bool {
+	for _, p := range []float64{d.CurrentPrice, d.MarkPrice} {
+		if p <= 0 || math.IsNaN(p) || math.IsInf(p, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// samePrices 两次推送的实时价格是否完全相同
+func samePrices(a, b *market.Data) bool {
+	return a.CurrentPrice == b.CurrentPrice && a.MarkPrice == b.MarkPrice &&
+		a.MidPrice == b.MidPrice && a.FundingRate == b.FundingRate
+}
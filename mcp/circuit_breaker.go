@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"fmt"
+	"nofx/logger"
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // 正常：请求直接放行
+	CircuitOpen     CircuitState = "open"      // 熔断：请求立即拒绝
+	CircuitHalfOpen CircuitState = "half_open" // 半开：只放行一个试探请求
+)
+
+// ErrCircuitOpen 熔断期间拒绝调用时返回的错误
+var ErrCircuitOpen = fmt.Errorf("AI API熔断中，暂停调用")
+
+// CircuitBreaker AI API调用熔断器
+// 避免API持续故障时每个周期都重复请求（每次请求还会重试多次）
+//
+// 状态转换:
+//
+//	          连续失败 ≥ FailureThreshold
+//	┌────────┐ ────────────────────────────▶ ┌──────┐
+//	│ Closed │                               │ Open │
+//	└────────┘ ◀──┐                          └──────┘
+//	             │ 试探成功          经过 ResetTimeout │
+//	             │                                    ▼
+//	             │                            ┌──────────┐
+//	             └─────────────────────────── │ HalfOpen │
+//	                                          └──────────┘
+//	                       试探失败 → 回到 Open（重新计时）
+type CircuitBreaker struct {
+	FailureThreshold int           // 连续失败多少次后熔断
+	ResetTimeout     time.Duration // 熔断持续时间，到期后进入半开状态
+
+	mu            sync.Mutex
+	state         CircuitState
+	failureCount  int       // 连续失败次数
+	openedAt      time.Time // 进入熔断的时间
+	probeInFlight bool      // 半开状态下是否已有试探请求
+	now           func() time.Time
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(failureThreshold int, resetTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 3
+	}
+	if resetTimeout <= 0 {
+		resetTimeout = 10 * time.Minute
+	}
+	return &CircuitBreaker{
+		FailureThreshold: failureThreshold,
+		ResetTimeout:     resetTimeout,
+		state:            CircuitClosed,
+		now:              time.Now,
+	}
+}
+
+// Allow 判断本次调用是否放行
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if cb.now().Sub(cb.openedAt) < cb.ResetTimeout {
+			return false
+		}
+		// 熔断到期，进入半开状态并放行一个试探请求
+		cb.state = CircuitHalfOpen
+		cb.probeInFlight = true
+		logger.Infof("🔌 [MCP] 熔断器进入半开状态，发送试探请求")
+		return true
+	case CircuitHalfOpen:
+		if cb.probeInFlight {
+			return false
+		}
+		cb.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess 记录调用成功
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != CircuitClosed {
+		logger.Infof("✓ [MCP] 试探请求成功，熔断器恢复正常")
+	}
+	cb.state = CircuitClosed
+	cb.failureCount = 0
+	cb.probeInFlight = false
+}
+
+// RecordFailure 记录调用失败
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failureCount++
+	cb.probeInFlight = false
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		cb.trip()
+		logger.Warnf("⚠️ [MCP] 试探请求失败，熔断器重新打开 %v", cb.ResetTimeout)
+	case CircuitClosed:
+		if cb.failureCount >= cb.FailureThreshold {
+			cb.trip()
+			logger.Warnf("⚠️ [MCP] AI API连续失败 %d 次，熔断 %v", cb.failureCount, cb.ResetTimeout)
+		}
+	}
+}
+
+// trip 进入熔断状态（调用方已加锁）
+func (cb *CircuitBreaker) trip() {
+	cb.state = CircuitOpen
+	cb.openedAt = cb.now()
+}
+
+// State 当前状态
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// GetStatus 获取熔断器状态（用于API）
+func (cb *CircuitBreaker) GetStatus() map[string]interface{} {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := map[string]interface{}{
+		"state":         string(cb.state),
+		"failure_count": cb.failureCount,
+	}
+	if cb.state == CircuitOpen {
+		status["retry_at"] = cb.openedAt.Add(cb.ResetTimeout).Format(time.RFC3339)
+	}
+	return status
+}
@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+)
+
+// testClock 可手动推进的时钟
+type testClock struct{ t time.Time }
+
+func (c *testClock) Now() time.Time          { return c.t }
+func (c *testClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(threshold int, reset time.Duration) (*CircuitBreaker, *testClock) {
+	clock := &testClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(threshold, reset)
+	cb.now = clock.Now
+	return cb, clock
+}
+
+func TestCircuitBreakerFullCycle(t *testing.T) {
+	cb, clock := newTestBreaker(3, time.Minute)
+
+	// Closed：失败未达到阈值时继续放行，成功会清零计数
+	cb.RecordFailure()
+	cb.RecordFailure()
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if cb.State() != CircuitClosed || !cb.Allow() {
+		t.Fatalf("state = %s, want closed below the threshold", cb.State())
+	}
+
+	// Closed → Open
+	cb.RecordFailure()
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s, want open after 3 consecutive failures", cb.State())
+	}
+	clock.Advance(59 * time.Second)
+	if cb.Allow() {
+		t.Fatal("open breaker allowed a call before the reset timeout")
+	}
+
+	// Open → HalfOpen：只放行一个试探请求
+	clock.Advance(time.Second)
+	if !cb.Allow() {
+		t.Fatal("breaker did not allow a probe after the reset timeout")
+	}
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s, want half_open", cb.State())
+	}
+	if cb.Allow() {
+		t.Fatal("half-open breaker allowed a second concurrent probe")
+	}
+
+	// HalfOpen → Closed
+	cb.RecordSuccess()
+	if cb.State() != CircuitClosed || !cb.Allow() {
+		t.Fatalf("state = %s, want closed after a successful probe", cb.State())
+	}
+	if status := cb.GetStatus(); status["failure_count"] != 0 {
+		t.Errorf("failure_count = %v, want 0", status["failure_count"])
+	}
+}
+
+func TestCircuitBreakerFailedProbeReopens(t *testing.T) {
+	cb, clock := newTestBreaker(1, time.Minute)
+	cb.RecordFailure()
+	clock.Advance(time.Minute)
+	if !cb.Allow() {
+		t.Fatal("probe not allowed")
+	}
+
+	cb.RecordFailure()
+	if cb.State() != CircuitOpen {
+		t.Fatalf("state = %s, want open after a failed probe", cb.State())
+	}
+	// 重新计时
+	clock.Advance(30 * time.Second)
+	if cb.Allow() {
+		t.Fatal("reopened breaker allowed a call before a fresh reset timeout")
+	}
+	want := clock.Now().Add(30 * time.Second).Format(time.RFC3339)
+	if status := cb.GetStatus(); status["retry_at"] != want {
+		t.Errorf("retry_at = %v, want %s", status["retry_at"], want)
+	}
+}
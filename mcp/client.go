@@ -5,8 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"nofx/logger"
 	"strings"
 	"time"
 )
@@ -28,15 +28,20 @@ type Client struct {
 	Model      string
 	Timeout    time.Duration
 	UseFullURL bool // 是否使用完整URL（不添加/chat/completions）
+
+	Breaker     *CircuitBreaker // 熔断器（API持续故障时暂停调用）
+	CostTracker *CostTracker    // 费用统计与每日预算
 }
 
 func New() *Client {
 	// 默认配置
 	return &Client{
-		Provider: ProviderDeepSeek,
-		BaseURL:  "https://api.deepseek.com/v1",
-		Model:    "deepseek-chat",
-		Timeout:  120 * time.Second, // 增加到120秒，因为AI需要分析大量数据
+		Provider:    ProviderDeepSeek,
+		BaseURL:     "https://api.deepseek.com/v1",
+		Model:       "deepseek-chat",
+		Timeout:     120 * time.Second, // 增加到120秒，因为AI需要分析大量数据
+		Breaker:     NewCircuitBreaker(3, 10*time.Minute),
+		CostTracker: NewCostTracker(),
 	}
 }
 
@@ -47,21 +52,21 @@ func (client *Client) SetDeepSeekAPIKey(apiKey string, customURL string, customM
 	client.APIKey = apiKey
 	if customURL != "" {
 		client.BaseURL = customURL
-		log.Printf("🔧 [MCP] DeepSeek 使用自定义 BaseURL: %s", customURL)
+		logger.Infof("🔧 [MCP] DeepSeek 使用自定义 BaseURL: %s", customURL)
 	} else {
 		client.BaseURL = "https://api.deepseek.com/v1"
-		log.Printf("🔧 [MCP] DeepSeek 使用默认 BaseURL: %s", client.BaseURL)
+		logger.Infof("🔧 [MCP] DeepSeek 使用默认 BaseURL: %s", client.BaseURL)
 	}
 	if customModel != "" {
 		client.Model = customModel
-		log.Printf("🔧 [MCP] DeepSeek 使用自定义 Model: %s", customModel)
+		logger.Infof("🔧 [MCP] DeepSeek 使用自定义 Model: %s", customModel)
 	} else {
 		client.Model = "deepseek-chat"
-		log.Printf("🔧 [MCP] DeepSeek 使用默认 Model: %s", client.Model)
+		logger.Infof("🔧 [MCP] DeepSeek 使用默认 Model: %s", client.Model)
 	}
 	// 打印 API Key 的前后各4位用于验证
 	if len(apiKey) > 8 {
-		log.Printf("🔧 [MCP] DeepSeek API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
+		logger.Infof("🔧 [MCP] DeepSeek API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
 	}
 }
 
@@ -72,21 +77,21 @@ func (client *Client) SetQwenAPIKey(apiKey string, customURL string, customModel
 	client.APIKey = apiKey
 	if customURL != "" {
 		client.BaseURL = customURL
-		log.Printf("🔧 [MCP] Qwen 使用自定义 BaseURL: %s", customURL)
+		logger.Infof("🔧 [MCP] Qwen 使用自定义 BaseURL: %s", customURL)
 	} else {
 		client.BaseURL = "https://dashscope.aliyuncs.com/compatible-mode/v1"
-		log.Printf("🔧 [MCP] Qwen 使用默认 BaseURL: %s", client.BaseURL)
+		logger.Infof("🔧 [MCP] Qwen 使用默认 BaseURL: %s", client.BaseURL)
 	}
 	if customModel != "" {
 		client.Model = customModel
-		log.Printf("🔧 [MCP] Qwen 使用自定义 Model: %s", customModel)
+		logger.Infof("🔧 [MCP] Qwen 使用自定义 Model: %s", customModel)
 	} else {
 		client.Model = "qwen-plus" // 可选: qwen-turbo, qwen-plus, qwen-max
-		log.Printf("🔧 [MCP] Qwen 使用默认 Model: %s", client.Model)
+		logger.Infof("🔧 [MCP] Qwen 使用默认 Model: %s", client.Model)
 	}
 	// 打印 API Key 的前后各4位用于验证
 	if len(apiKey) > 8 {
-		log.Printf("🔧 [MCP] Qwen API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
+		logger.Infof("🔧 [MCP] Qwen API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
 	}
 }
 
@@ -122,19 +127,44 @@ func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string,
 		return "", fmt.Errorf("AI API密钥未设置，请先调用 SetDeepSeekAPIKey() 或 SetQwenAPIKey()")
 	}
 
+	if client.CostTracker != nil {
+		if err := client.CostTracker.CheckBudget(); err != nil {
+			return "", err
+		}
+	}
+
+	if client.Breaker == nil {
+		return client.callWithRetry(systemPrompt, userPrompt)
+	}
+	if !client.Breaker.Allow() {
+		return "", ErrCircuitOpen
+	}
+
+	result, err := client.callWithRetry(systemPrompt, userPrompt)
+	if err != nil {
+		client.Breaker.RecordFailure()
+		return "", err
+	}
+	client.Breaker.RecordSuccess()
+	return result, nil
+}
+
+// callWithRetry 调用AI API，网络类错误自动重试
+func (client *Client) callWithRetry(systemPrompt, userPrompt string) (string, error) {
+
 	// 重试配置
 	maxRetries := 3
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			fmt.Printf("⚠️  AI API调用失败，正在重试 (%d/%d)...\n", attempt, maxRetries)
+			logger.Warnf("⚠️  AI API调用失败，正在重试 (%d/%d)...", attempt, maxRetries)
 		}
 
 		result, err := client.callOnce(systemPrompt, userPrompt)
 		if err == nil {
 			if attempt > 1 {
-				fmt.Printf("✓ AI API重试成功\n")
+				logger.Infof("✓ AI API重试成功")
 			}
 			return result, nil
 		}
@@ -148,7 +178,7 @@ func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string,
 		// 重试前等待
 		if attempt < maxRetries {
 			waitTime := time.Duration(attempt) * 2 * time.Second
-			fmt.Printf("⏳ 等待%v后重试...\n", waitTime)
+			logger.Debugf("⏳ 等待%v后重试...", waitTime)
 			time.Sleep(waitTime)
 		}
 	}
@@ -159,13 +189,13 @@ func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string,
 // callOnce 单次调用AI API（内部使用）
 func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 	// 打印当前 AI 配置
-	log.Printf("📡 [MCP] AI 请求配置:")
-	log.Printf("   Provider: %s", client.Provider)
-	log.Printf("   BaseURL: %s", client.BaseURL)
-	log.Printf("   Model: %s", client.Model)
-	log.Printf("   UseFullURL: %v", client.UseFullURL)
+	logger.Infof("📡 [MCP] AI 请求配置:")
+	logger.Infof("   Provider: %s", client.Provider)
+	logger.Infof("   BaseURL: %s", client.BaseURL)
+	logger.Infof("   Model: %s", client.Model)
+	logger.Infof("   UseFullURL: %v", client.UseFullURL)
 	if len(client.APIKey) > 8 {
-		log.Printf("   API Key: %s...%s", client.APIKey[:4], client.APIKey[len(client.APIKey)-4:])
+		logger.Infof("   API Key: %s...%s", client.APIKey[:4], client.APIKey[len(client.APIKey)-4:])
 	}
 
 	// 构建 messages 数组
@@ -210,7 +240,7 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 		// 默认行为：添加/chat/completions
 		url = fmt.Sprintf("%s/chat/completions", client.BaseURL)
 	}
-	log.Printf("📡 [MCP] 请求 URL: %s", url)
+	logger.Infof("📡 [MCP] 请求 URL: %s", url)
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
@@ -256,12 +286,23 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
 		return "", fmt.Errorf("解析响应失败: %w", err)
 	}
 
+	// 记录token用量和费用
+	if client.CostTracker != nil {
+		cost := client.CostTracker.RecordCall(client.Model, result.Usage.PromptTokens, result.Usage.CompletionTokens)
+		logger.Infof("💵 [MCP] Token用量: 输入%d 输出%d, 费用 $%.5f",
+			result.Usage.PromptTokens, result.Usage.CompletionTokens, cost)
+	}
+
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("API返回空响应")
 	}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PricePerToken 每个token的价格（美元）
+type PricePerToken struct {
+	Input  float64
+	Output float64
+}
+
+// DefaultModelPricing 默认模型价格表（按模型名匹配，未列出的模型不计费）
+var DefaultModelPricing = map[string]PricePerToken{
+	"deepseek-chat":     {Input: 0.14 / 1_000_000, Output: 0.28 / 1_000_000},
+	"deepseek-reasoner": {Input: 0.55 / 1_000_000, Output: 2.19 / 1_000_000},
+	"qwen-turbo":        {Input: 0.05 / 1_000_000, Output: 0.2 / 1_000_000},
+	"qwen-plus":         {Input: 0.4 / 1_000_000, Output: 1.2 / 1_000_000},
+	"qwen-max":          {Input: 1.6 / 1_000_000, Output: 6.4 / 1_000_000},
+}
+
+// CostTracker AI调用费用统计与每日预算控制
+type CostTracker struct {
+	ModelPricing   map[string]PricePerToken
+	DailyBudgetUSD float64 // 每日预算（0=不限制）
+
+	mu         sync.Mutex
+	totalCost  float64
+	todayCost  float64
+	todayCalls int
+	today      string // 当前统计日期（YYYY-MM-DD）
+	now        func() time.Time
+}
+
+// NewCostTracker 创建费用统计器（使用默认价格表）
+func NewCostTracker() *CostTracker {
+	pricing := make(map[string]PricePerToken, len(DefaultModelPricing))
+	for model, price := range DefaultModelPricing {
+		pricing[model] = price
+	}
+	return &CostTracker{
+		ModelPricing: pricing,
+		now:          time.Now,
+	}
+}
+
+// RecordCall 记录一次调用的token用量，返回本次费用
+func (t *CostTracker) RecordCall(model string, inputTokens, outputTokens int) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollDay()
+	price := t.ModelPricing[strings.ToLower(model)]
+	cost := float64(inputTokens)*price.Input + float64(outputTokens)*price.Output
+	t.totalCost += cost
+	t.todayCost += cost
+	t.todayCalls++
+	return cost
+}
+
+// CheckBudget 检查下一次调用是否会超出每日预算（按今日平均单次费用估算）
+func (t *CostTracker) CheckBudget() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.DailyBudgetUSD <= 0 {
+		return nil
+	}
+	t.rollDay()
+
+	estimatedNext := 0.0
+	if t.todayCalls > 0 {
+		estimatedNext = t.todayCost / float64(t.todayCalls)
+	}
+	if t.todayCost+estimatedNext > t.DailyBudgetUSD {
+		return fmt.Errorf("AI调用今日费用 $%.4f 即将超出每日预算 $%.2f", t.todayCost, t.DailyBudgetUSD)
+	}
+	return nil
+}
+
+// GetTotalCost 累计费用（美元）
+func (t *CostTracker) GetTotalCost() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.totalCost
+}
+
+// GetStatus 获取费用统计（用于API）
+func (t *CostTracker) GetStatus() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollDay()
+	status := map[string]interface{}{
+		"today_cost_usd":   t.todayCost,
+		"today_calls":      t.todayCalls,
+		"total_cost_usd":   t.totalCost,
+		"daily_budget_usd": t.DailyBudgetUSD,
+	}
+	if t.DailyBudgetUSD > 0 {
+		remaining := t.DailyBudgetUSD - t.todayCost
+		if remaining < 0 {
+			remaining = 0
+		}
+		status["daily_budget_remaining_usd"] = remaining
+	}
+	return status
+}
+
+// rollDay 跨日时重置当日统计（调用方已加锁）
+func (t *CostTracker) rollDay() {
+	today := t.now().Format("2006-01-02")
+	if t.today != today {
+		t.today = today
+		t.todayCost = 0
+		t.todayCalls = 0
+	}
+}
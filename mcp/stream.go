@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DecisionStream 流式AI响应
+// Chunks 按到达顺序推送增量文本；Decisions 在检测到完整的JSON决策数组时推送该数组，
+// 调用方可以在思维链后续文本仍在传输时提前处理决策。两个channel都会在流结束时关闭
+type DecisionStream struct {
+	Chunks    <-chan string
+	Decisions <-chan string
+
+	wg   sync.WaitGroup
+	text strings.Builder
+	err  error
+}
+
+// Wait 等待流结束，返回完整文本（即思维链+决策）
+// 调用方必须持续读取 Chunks 和 Decisions（或在不需要时读空），否则流会阻塞
+func (s *DecisionStream) Wait() (string, error) {
+	s.wg.Wait()
+	return s.text.String(), s.err
+}
+
+// StreamAIDecision 以流式（SSE）方式调用AI API
+// 与 CallWithMessages 共用预算检查和熔断器；流式调用不做自动重试
+func (client *Client) StreamAIDecision(ctx context.Context, systemPrompt, userPrompt string) (*DecisionStream, error) {
+	if client.APIKey == "" {
+		return nil, fmt.Errorf("AI API密钥未设置，请先调用 SetDeepSeekAPIKey() 或 SetQwenAPIKey()")
+	}
+	if client.CostTracker != nil {
+		if err := client.CostTracker.CheckBudget(); err != nil {
+			return nil, err
+		}
+	}
+	if client.Breaker != nil && !client.Breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := client.openStream(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		if client.Breaker != nil {
+			client.Breaker.RecordFailure()
+		}
+		return nil, err
+	}
+
+	chunks := make(chan string, 64)
+	decisions := make(chan string, 1)
+	stream := &DecisionStream{Chunks: chunks, Decisions: decisions}
+	stream.wg.Add(1)
+
+	go func() {
+		defer stream.wg.Done()
+		defer resp.Body.Close()
+		defer close(chunks)
+		defer close(decisions)
+
+		detector := &jsonArrayDetector{}
+		stream.err = client.readStream(resp.Body, func(content string) {
+			stream.text.WriteString(content)
+			chunks <- content
+			for _, r := range content {
+				if array, ok := detector.feed(r); ok {
+					decisions <- array
+				}
+			}
+		})
+
+		if client.Breaker != nil {
+			if stream.err != nil {
+				client.Breaker.RecordFailure()
+			} else {
+				client.Breaker.RecordSuccess()
+			}
+		}
+	}()
+
+	return stream, nil
+}
+
+// openStream 发送流式请求
+func (client *Client) openStream(ctx context.Context, systemPrompt, userPrompt string) (*http.Response, error) {
+	messages := []map[string]string{}
+	if systemPrompt != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": systemPrompt})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": userPrompt})
+
+	requestBody := map[string]interface{}{
+		"model":          client.Model,
+		"messages":       messages,
+		"temperature":    0.5,
+		"max_tokens":     2000,
+		"stream":         true,
+		"stream_options": map[string]bool{"include_usage": true}, // 最后一个事件附带token用量
+	}
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	url := client.BaseURL
+	if !client.UseFullURL {
+		url = fmt.Sprintf("%s/chat/completions", client.BaseURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", client.APIKey))
+
+	// 流式响应持续时间不定，超时由ctx控制
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("API返回错误 (status %d): %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+// readStream 逐行解析SSE事件，将增量文本交给 onContent
+func (client *Client) readStream(body io.Reader, onContent func(string)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var event struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("解析流式响应失败: %w", err)
+		}
+
+		if event.Usage != nil && client.CostTracker != nil {
+			client.CostTracker.RecordCall(client.Model, event.Usage.PromptTokens, event.Usage.CompletionTokens)
+		}
+		for _, choice := range event.Choices {
+			if choice.Delta.Content != "" {
+				onContent(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取流式响应失败: %w", err)
+	}
+	return nil
+}
+
+// jsonArrayDetector 在流式文本中检测完整的JSON数组（决策列表形如 [{...}, ...]）
+// 跳过字符串内的括号；只有包含对象的数组才视为决策，避免把思维链中的 [1] 之类误判
+type jsonArrayDetector struct {
+	buf      strings.Builder
+	depth    int
+	inString bool
+	escaped  bool
+	hasObj   bool
+}
+
+// feed 输入一个字符，检测到完整数组时返回该数组文本
+func (d *jsonArrayDetector) feed(r rune) (string, bool) {
+	if d.depth == 0 {
+		if r != '[' {
+			return "", false
+		}
+		d.buf.Reset()
+		d.hasObj = false
+	}
+	d.buf.WriteRune(r)
+
+	if d.inString {
+		switch {
+		case d.escaped:
+			d.escaped = false
+		case r == '\\':
+			d.escaped = true
+		case r == '"':
+			d.inString = false
+		}
+		return "", false
+	}
+
+	switch r {
+	case '"':
+		d.inString = true
+	case '{':
+		d.hasObj = true
+	case '[':
+		d.depth++
+	case ']':
+		d.depth--
+		if d.depth == 0 && d.hasObj {
+			return d.buf.String(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"sync"
+	"unicode/utf8"
+)
+
+// DefaultCallWeights 多次调用分析时各调用的预算权重（市场分析、机会发现、综合决策）
+var DefaultCallWeights = []float64{0.4, 0.3, 0.3}
+
+// TokenBudgetManager 在一轮多次AI调用之间分配提示词长度预算
+// 某次调用被跳过（提前结束）时，其预算按权重重新分给尚未执行的调用
+type TokenBudgetManager struct {
+	Weights []float64 // 各调用的权重（为空或数量不足时按均分）
+
+	mu         sync.Mutex
+	allocation []int
+	skipped    []bool
+}
+
+// NewTokenBudgetManager 创建预算管理器（使用默认权重）
+func NewTokenBudgetManager() *TokenBudgetManager {
+	return &TokenBudgetManager{Weights: append([]float64(nil), DefaultCallWeights...)}
+}
+
+// AllocateBudget 按权重将总预算分配给 numCalls 次调用，返回每次调用的预算
+// 舍入误差计入最后一次调用，保证总和等于 totalBudget
+func (m *TokenBudgetManager) AllocateBudget(totalBudget, numCalls int) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if numCalls <= 0 || totalBudget <= 0 {
+		m.allocation, m.skipped = nil, nil
+		return nil
+	}
+	weights := m.weightsFor(numCalls)
+
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+	m.allocation = make([]int, numCalls)
+	m.skipped = make([]bool, numCalls)
+	allocated := 0
+	for i := 0; i < numCalls-1; i++ {
+		m.allocation[i] = int(float64(totalBudget) * weights[i] / sum)
+		allocated += m.allocation[i]
+	}
+	m.allocation[numCalls-1] = totalBudget - allocated
+	return append([]int(nil), m.allocation...)
+}
+
+// Skip 第 index 次调用被跳过，其预算按权重分给之后尚未跳过的调用
+func (m *TokenBudgetManager) Skip(index int) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if index < 0 || index >= len(m.allocation) || m.skipped[index] {
+		return append([]int(nil), m.allocation...)
+	}
+	m.skipped[index] = true
+	m.releaseLocked(index, 0)
+	m.allocation[index] = 0
+	return append([]int(nil), m.allocation...)
+}
+
+// Release 第 index 次调用只用了 used 的预算，剩余部分按权重分给之后尚未跳过的调用
+func (m *TokenBudgetManager) Release(index, used int) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if index < 0 || index >= len(m.allocation) || m.skipped[index] {
+		return append([]int(nil), m.allocation...)
+	}
+	m.releaseLocked(index, used)
+	return append([]int(nil), m.allocation...)
+}
+
+// releaseLocked 把第 index 次调用超出 used 的预算按权重分给之后尚未跳过的调用（调用方已加锁）
+// 之后没有可分配的调用时预算保留在原处
+func (m *TokenBudgetManager) releaseLocked(index, used int) {
+	released := m.allocation[index] - used
+	if released <= 0 {
+		return
+	}
+
+	weights := m.weightsFor(len(m.allocation))
+	remaining := []int{}
+	sum := 0.0
+	for i := index + 1; i < len(m.allocation); i++ {
+		if !m.skipped[i] {
+			remaining = append(remaining, i)
+			sum += weights[i]
+		}
+	}
+	if len(remaining) == 0 || sum <= 0 {
+		return
+	}
+
+	m.allocation[index] = used
+	given := 0
+	for _, i := range remaining[:len(remaining)-1] {
+		share := int(float64(released) * weights[i] / sum)
+		m.allocation[i] += share
+		given += share
+	}
+	m.allocation[remaining[len(remaining)-1]] += released - given
+}
+
+// CurrentBudgetAllocation 当前各调用的预算（用于状态展示）
+func (m *TokenBudgetManager) CurrentBudgetAllocation() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int(nil), m.allocation...)
+}
+
+// weightsFor 返回 numCalls 个权重（配置的权重数量不足或无效时均分）
+func (m *TokenBudgetManager) weightsFor(numCalls int) []float64 {
+	if len(m.Weights) >= numCalls {
+		valid := true
+		for _, w := range m.Weights[:numCalls] {
+			if w <= 0 {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			return m.Weights[:numCalls]
+		}
+	}
+	weights := make([]float64, numCalls)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return weights
+}
+
+// TruncatePrompt 将提示词截断到预算内（按字符计，budget<=0 表示不限制）
+func TruncatePrompt(prompt string, budget int) string {
+	if budget <= 0 || utf8.RuneCountInString(prompt) <= budget {
+		return prompt
+	}
+	return string([]rune(prompt)[:budget])
+}
@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllocateBudgetByWeight(t *testing.T) {
+	m := NewTokenBudgetManager()
+	if got := m.AllocateBudget(1000, 3); !reflect.DeepEqual(got, []int{400, 300, 300}) {
+		t.Errorf("allocation = %v, want [400 300 300]", got)
+	}
+	// 舍入误差计入最后一次调用
+	if got := m.AllocateBudget(101, 3); !reflect.DeepEqual(got, []int{40, 30, 31}) {
+		t.Errorf("allocation = %v, want [40 30 31]", got)
+	}
+	// 权重数量不足时均分
+	if got := m.AllocateBudget(100, 4); !reflect.DeepEqual(got, []int{25, 25, 25, 25}) {
+		t.Errorf("allocation = %v, want an even split", got)
+	}
+	if got := m.AllocateBudget(0, 3); got != nil {
+		t.Errorf("allocation of an empty budget = %v, want nil", got)
+	}
+}
+
+func TestSkipRedistributesToRemainingCalls(t *testing.T) {
+	m := NewTokenBudgetManager()
+	m.AllocateBudget(1000, 3)
+
+	if got := m.Skip(0); !reflect.DeepEqual(got, []int{0, 500, 500}) {
+		t.Errorf("after skipping call 0 = %v, want [0 500 500]", got)
+	}
+	if got := m.Skip(1); !reflect.DeepEqual(got, []int{0, 0, 1000}) {
+		t.Errorf("after skipping call 1 = %v, want [0 0 1000]", got)
+	}
+	if got := m.Skip(1); !reflect.DeepEqual(got, []int{0, 0, 1000}) {
+		t.Errorf("skipping twice changed the allocation: %v", got)
+	}
+	if got := m.CurrentBudgetAllocation(); !reflect.DeepEqual(got, []int{0, 0, 1000}) {
+		t.Errorf("CurrentBudgetAllocation = %v", got)
+	}
+}
+
+func TestReleaseGivesUnusedBudgetToLaterCalls(t *testing.T) {
+	m := NewTokenBudgetManager()
+	m.AllocateBudget(1000, 3)
+
+	// 第一次调用只用了100，剩余300平分给后两次
+	if got := m.Release(0, 100); !reflect.DeepEqual(got, []int{100, 450, 450}) {
+		t.Errorf("after release = %v, want [100 450 450]", got)
+	}
+	// 用超预算不会从后面的调用扣除
+	if got := m.Release(1, 600); !reflect.DeepEqual(got, []int{100, 450, 450}) {
+		t.Errorf("overuse changed the allocation: %v", got)
+	}
+	// 最后一次调用没有可分配的对象，预算保留
+	if got := m.Release(2, 0); !reflect.DeepEqual(got, []int{100, 450, 450}) {
+		t.Errorf("release of the last call = %v", got)
+	}
+}
+
+func TestTruncatePromptCountsRunes(t *testing.T) {
+	tests := []struct {
+		prompt string
+		budget int
+		want   string
+	}{
+		{"账户净值1000", 4, "账户净值"},
+		{"abc", 5, "abc"},
+		{"abcdef", 0, "abcdef"}, // 不限制
+	}
+	for _, tt := range tests {
+		if got := TruncatePrompt(tt.prompt, tt.budget); got != tt.want {
+			t.Errorf("TruncatePrompt(%q, %d) = %q, want %q", tt.prompt, tt.budget, got, tt.want)
+		}
+	}
+}
@@ -1,15 +1,19 @@
 package trader
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"nofx/decision"
 	"nofx/logger"
 	"nofx/market"
+	"nofx/market/ws"
 	"nofx/mcp"
 	"nofx/pool"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -73,6 +77,9 @@ type AutoTraderConfig struct {
 
 	// 系统提示词模板
 	SystemPromptTemplate string // 系统提示词模板名称（如 "default", "aggressive"）
+
+	// 风控规则（硬性执行，不依赖AI）
+	Risk RiskConfig
 }
 
 // AutoTrader 自动交易器
@@ -95,9 +102,57 @@ type AutoTrader struct {
 	lastResetTime         time.Time
 	stopUntil             time.Time
 	isRunning             bool
-	startTime             time.Time        // 系统启动时间
-	callCount             int              // AI调用次数
-	positionFirstSeenTime map[string]int64 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	startTime             time.Time                 // 系统启动时间
+	callCount             int                       // AI调用次数
+	positionFirstSeenTime map[string]int64          // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	distributedLock       DistributedLock           // 分布式锁（多实例部署时防止重复开仓）
+	cycleRiskTime         time.Duration             // 本周期风控检查累计耗时
+	lastCycleTiming       logger.CycleTiming        // 上一周期各阶段耗时
+	lastDecision          *decision.FullDecision    // 上一次成功的AI决策（AI失败时可复用）
+	lastExecuted          map[string]bool           // 上一次AI决策中已成功执行的动作 (symbol:action，复用决策时跳过)
+	lastEquity            float64                   // 最近一次获取的账户净值
+	dailyStartEquity      float64                   // 当日起始净值（用于计算日盈亏）
+	peakEquity            float64                   // 历史最高净值
+	haltedAt              time.Time                 // 风控暂停开始时间
+	entryHaltUntil        time.Time                 // 暂停开新仓截止时间（只拦截开仓，持仓管理继续）
+	lastPositions         []decision.PositionInfo   // 最近一次获取的持仓
+	positionStops         map[string]*positionStop  // 持仓止损止盈价 (symbol_side -> 价格)
+	pendingExits          map[string]*pendingExit   // 拆单平仓未执行的部分 (symbol_side)
+	plans                 *PlanFactory              // 开仓计划模板
+	pendingPlans          map[string][]*pendingPlan // 等待价格到达入场价的计划 (symbol_side)
+	closedBySystem        map[string]bool           // 上次获取持仓后由本系统平掉的持仓 (symbol_side)
+	exchangeClosed        []logger.DecisionAction   // 本周期发现的交易所侧平仓（写入本周期决策记录）
+	fills                 *fillTracker              // 开仓成交记录（计算成交均价）
+	orderLimiter          *orderRateLimiter         // 下单频率限制
+	reversals             *reversalThrottle         // 反手频率限制
+	tracer                Tracer                    // 链路追踪（默认不追踪）
+	publisher             DecisionPublisher         // 决策事件发布（默认不发布）
+	priceOracle           PriceOracle               // 平仓价格来源（默认使用交易所最新价）
+	cycleMarketData       map[string]*market.Data   // 本周期已获取的行情数据（风控规则复用）
+	cycleCtx              context.Context           // 当前交易周期的追踪context
+	marginCallWarning     bool                      // 本周期是否处于追保预警（新开仓位减半）
+	riskRules             []RiskRule                // 开仓风控规则（按顺序执行）
+	verifierClient        *mcp.Client               // 大额开仓二次验证的AI客户端（未启用时为nil）
+	now                   func() time.Time          // 时钟（可替换，便于模拟跨日）
+	stopsMu               sync.RWMutex
+	cycleMu               sync.Mutex // 串行化交易周期、紧急平仓和兜底止损监控，防止同时下单或修改持仓状态
+	monitorMu             sync.Mutex
+	monitorStop           chan struct{} // 兜底止损监控的停止信号（nil 表示未运行，由 monitorMu 保护）
+	stateMu               sync.RWMutex  // 保护净值跟踪（lastEquity/peakEquity/dailyStartEquity/dailyPnL/lastResetTime）和暂停状态（stopUntil/entryHaltUntil/haltedAt），HTTP接口与交易周期并发访问
+
+	regimeMemory    *decision.MarketRegimeMemory                          // 跨周期的市场状态记忆
+	heldConfidence  map[string]int                                        // 上周期AI对各持仓的信心度 (symbol_side -> 信心度，写入时持有 stopsMu)
+	calendar        *EconomicCalendar                                     // 高波动经济事件日历（未配置时为nil）
+	equityDetector  *EquityAnomalyDetector                                // 净值曲线异常检测
+	streak          tradeStreak                                           // 连胜/连败统计
+	logger          logger.Logger                                         // 分级日志（默认使用全局日志，级别由 log_level 配置）
+	strategyBreaker *StrategyCircuitBreaker                               // 滚动表现恶化时停用策略
+	drawdownStop    *DrawdownHardStop                                     // 最大回撤硬止损（不自动恢复）
+	weeklyReset     *weeklySchedule                                       // 每周重置（未配置时为nil）
+	rejectionAlerts []RejectionAlert                                      // 上一周期按原因分组的拒绝告警
+	getMarketData   func(symbol string) (*market.Data, error)             // 开仓时的行情来源（默认 market.Get，测试中可替换）
+	getKlines       func(symbol, interval string) ([]market.Kline, error) // 风控检查的K线来源（默认WS缓存，测试中可替换）
+	alertsMu        sync.Mutex
 }
 
 // NewAutoTrader 创建自动交易器
@@ -123,25 +178,29 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	if config.AIModel == "custom" {
 		// 使用自定义API
 		mcpClient.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CustomModelName)
-		log.Printf("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+		logger.Infof("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
 	} else if config.UseQwen || config.AIModel == "qwen" {
 		// 使用Qwen (支持自定义URL和Model)
 		mcpClient.SetQwenAPIKey(config.QwenKey, config.CustomAPIURL, config.CustomModelName)
 		if config.CustomAPIURL != "" || config.CustomModelName != "" {
-			log.Printf("🤖 [%s] 使用阿里云Qwen AI (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+			logger.Infof("🤖 [%s] 使用阿里云Qwen AI (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
 		} else {
-			log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
+			logger.Infof("🤖 [%s] 使用阿里云Qwen AI", config.Name)
 		}
 	} else {
 		// 默认使用DeepSeek (支持自定义URL和Model)
 		mcpClient.SetDeepSeekAPIKey(config.DeepSeekKey, config.CustomAPIURL, config.CustomModelName)
 		if config.CustomAPIURL != "" || config.CustomModelName != "" {
-			log.Printf("🤖 [%s] 使用DeepSeek AI (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+			logger.Infof("🤖 [%s] 使用DeepSeek AI (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
 		} else {
-			log.Printf("🤖 [%s] 使用DeepSeek AI", config.Name)
+			logger.Infof("🤖 [%s] 使用DeepSeek AI", config.Name)
 		}
 	}
 
+	if mcpClient.CostTracker != nil {
+		mcpClient.CostTracker.DailyBudgetUSD = config.Risk.AIDailyBudgetUSD
+	}
+
 	// 初始化币种池API
 	if config.CoinPoolAPIURL != "" {
 		pool.SetCoinPoolAPI(config.CoinPoolAPIURL)
@@ -161,24 +220,28 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	if !config.IsCrossMargin {
 		marginModeStr = "逐仓"
 	}
-	log.Printf("📊 [%s] 仓位模式: %s", config.Name, marginModeStr)
+	logger.Infof("📊 [%s] 仓位模式: %s", config.Name, marginModeStr)
 
 	switch config.Exchange {
 	case "binance":
-		log.Printf("🏦 [%s] 使用币安合约交易", config.Name)
-		trader = NewFuturesTrader(config.BinanceAPIKey, config.BinanceSecretKey)
+		logger.Infof("🏦 [%s] 使用币安合约交易", config.Name)
+		futuresTrader := NewFuturesTrader(config.BinanceAPIKey, config.BinanceSecretKey)
+		futuresTrader.SetStopTriggerPriceRef(config.Risk.StopTriggerPriceRef)
+		trader = futuresTrader
 	case "hyperliquid":
-		log.Printf("🏦 [%s] 使用Hyperliquid交易", config.Name)
+		logger.Infof("🏦 [%s] 使用Hyperliquid交易", config.Name)
 		trader, err = NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
 		if err != nil {
 			return nil, fmt.Errorf("初始化Hyperliquid交易器失败: %w", err)
 		}
 	case "aster":
-		log.Printf("🏦 [%s] 使用Aster交易", config.Name)
-		trader, err = NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
+		logger.Infof("🏦 [%s] 使用Aster交易", config.Name)
+		asterTrader, err := NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
 		if err != nil {
 			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
 		}
+		asterTrader.SetStopTriggerPriceRef(config.Risk.StopTriggerPriceRef)
+		trader = asterTrader
 	default:
 		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
 	}
@@ -188,6 +251,8 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		return nil, fmt.Errorf("初始金额必须大于0，请在配置中设置InitialBalance")
 	}
 
+	config.Risk.applyDefaults()
+
 	// 初始化决策日志记录器（使用trader ID创建独立目录）
 	logDir := fmt.Sprintf("decision_logs/%s", config.ID)
 	decisionLogger := logger.NewDecisionLogger(logDir)
@@ -198,7 +263,7 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		systemPromptTemplate = "default" // 默认使用 default 模板
 	}
 
-	return &AutoTrader{
+	at := &AutoTrader{
 		id:                    config.ID,
 		name:                  config.Name,
 		aiModel:               config.AIModel,
@@ -211,35 +276,88 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		systemPromptTemplate:  systemPromptTemplate,
 		defaultCoins:          config.DefaultCoins,
 		tradingCoins:          config.TradingCoins,
-		lastResetTime:         time.Now(),
 		startTime:             time.Now(),
 		callCount:             0,
 		isRunning:             false,
 		positionFirstSeenTime: make(map[string]int64),
-	}, nil
+		positionStops:         make(map[string]*positionStop),
+		pendingExits:          make(map[string]*pendingExit),
+		lastExecuted:          make(map[string]bool),
+		plans:                 NewPlanFactory(config.Risk.PlanTemplates),
+		pendingPlans:          make(map[string][]*pendingPlan),
+		closedBySystem:        make(map[string]bool),
+		fills:                 newFillTracker(),
+		orderLimiter:          newOrderRateLimiter(config.Risk.MaxOrdersPerMinute, config.Risk.MaxOrdersPerHour, config.Risk.MaxDailyTrades, time.Duration(config.Risk.MinEntryIntervalSec)*time.Second),
+		reversals:             newReversalThrottle(config.Risk.MaxReversalsPerWindow, time.Duration(config.Risk.ReversalWindowMinutes)*time.Minute),
+		tracer:                NoopTracer{},
+		publisher:             NoopDecisionPublisher{},
+		priceOracle:           TraderPriceOracle{Trader: trader},
+		distributedLock:       NoopDistributedLock{},
+		now:                   time.Now,
+		getMarketData:         market.Get,
+		getKlines:             currentKlines,
+		regimeMemory:          &decision.MarketRegimeMemory{},
+		equityDetector:        NewEquityAnomalyDetector(),
+		logger:                logger.Default(),
+		strategyBreaker:       NewStrategyCircuitBreaker(config.Risk.StrategyRollingDays, config.Risk.StrategyMinRollingReturn, config.Risk.StrategyMaxLosingDays),
+		drawdownStop:          NewDrawdownHardStop(config.Risk.DrawdownHardStopThresholdPct),
+	}
+	at.lastResetTime = at.now()
+	if config.Risk.EnableWebSocketFeed {
+		at.priceOracle = NewStreamPriceOracle(ws.NewWebSocketMarketDataFeed(), TraderPriceOracle{Trader: trader})
+		logger.Infof("📡 [%s] 已启用WebSocket实时价格", config.Name)
+	}
+	if config.Risk.EconomicCalendarFile != "" {
+		calendar, err := LoadEconomicCalendar(config.Risk.EconomicCalendarFile)
+		if err != nil {
+			return nil, err
+		}
+		at.calendar = calendar
+		logger.Infof("📅 [%s] 已加载经济日历: %s", config.Name, config.Risk.EconomicCalendarFile)
+	}
+	if config.Risk.WeeklyReset != "" {
+		day, hour, minute, err := ParseWeeklySchedule(config.Risk.WeeklyReset)
+		if err != nil {
+			return nil, err
+		}
+		at.ScheduleWeeklyReset(day, hour, minute)
+	}
+	if config.Risk.SecondaryVerificationThresholdUSD > 0 {
+		at.verifierClient = newSecondaryVerifier(mcpClient, config.Risk.SecondaryVerificationModel)
+	}
+	at.riskRules = at.defaultRiskRules()
+
+	return at, nil
 }
 
 // Run 运行自动交易主循环
 func (at *AutoTrader) Run() error {
 	at.isRunning = true
-	log.Println("🚀 AI驱动自动交易系统启动")
-	log.Printf("💰 初始余额: %.2f USDT", at.initialBalance)
-	log.Printf("⚙️  扫描间隔: %v", at.config.ScanInterval)
-	log.Println("🤖 AI将全权决定杠杆、仓位大小、止损止盈等参数")
+	at.restoreStateSnapshot()
+	if err := at.ResumeMonitoring(); err != nil {
+		at.logger.Warnf("⚠️ 接管持仓监控失败: %v", err)
+	}
+	if at.config.Risk.SoftStopMonitor {
+		at.startPositionMonitor()
+	}
+	at.logger.Infof("🚀 AI驱动自动交易系统启动")
+	at.logger.Infof("💰 初始余额: %.2f USDT", at.initialBalance)
+	at.logger.Infof("⚙️  扫描间隔: %v", at.config.ScanInterval)
+	at.logger.Infof("🤖 AI将全权决定杠杆、仓位大小、止损止盈等参数")
 
 	ticker := time.NewTicker(at.config.ScanInterval)
 	defer ticker.Stop()
 
 	// 首次立即执行
 	if err := at.runCycle(); err != nil {
-		log.Printf("❌ 执行失败: %v", err)
+		at.logger.Errorf("❌ 执行失败: %v", err)
 	}
 
 	for at.isRunning {
 		select {
 		case <-ticker.C:
 			if err := at.runCycle(); err != nil {
-				log.Printf("❌ 执行失败: %v", err)
+				at.logger.Errorf("❌ 执行失败: %v", err)
 			}
 		}
 	}
@@ -250,16 +368,31 @@ func (at *AutoTrader) Run() error {
 // Stop 停止自动交易
 func (at *AutoTrader) Stop() {
 	at.isRunning = false
-	log.Println("⏹ 自动交易系统停止")
+	at.stopPositionMonitor()
+	if oracle, ok := at.priceOracle.(*StreamPriceOracle); ok {
+		oracle.Close()
+	}
+	at.logger.Infof("⏹ 自动交易系统停止")
 }
 
 // runCycle 运行一个交易周期（使用AI全权决策）
 func (at *AutoTrader) runCycle() error {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
 	at.callCount++
+	defer at.saveStateSnapshot()
 
-	log.Printf("\n" + strings.Repeat("=", 70))
-	log.Printf("⏰ %s - AI决策周期 #%d", time.Now().Format("2006-01-02 15:04:05"), at.callCount)
-	log.Printf(strings.Repeat("=", 70))
+	traceCtx, cycleSpan := at.tracer.Start(context.Background(), spanTradingCycle, map[string]interface{}{
+		"trader_id": at.id,
+		"cycle":     at.callCount,
+	})
+	at.cycleCtx = traceCtx
+	defer cycleSpan.End()
+
+	at.logger.Infof("%s", "\n"+strings.Repeat("=", 70))
+	at.logger.Infof("⏰ %s - AI决策周期 #%d", time.Now().Format("2006-01-02 15:04:05"), at.callCount)
+	at.logger.Infof("%s", strings.Repeat("=", 70))
 
 	// 创建决策记录
 	record := &logger.DecisionRecord{
@@ -268,31 +401,39 @@ func (at *AutoTrader) runCycle() error {
 	}
 
 	// 1. 检查是否需要停止交易
-	if time.Now().Before(at.stopUntil) {
-		remaining := at.stopUntil.Sub(time.Now())
-		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
+	at.checkWeeklyReset()
+	if halt := at.haltSnapshot(); at.now().Before(halt.StopUntil) {
+		remaining := halt.StopUntil.Sub(at.now())
+		at.logger.Infof("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("风险控制暂停中，剩余 %.0f 分钟", remaining.Minutes())
 		at.decisionLogger.LogDecision(record)
 		return nil
 	}
 
-	// 2. 重置日盈亏（每天重置）
-	if time.Since(at.lastResetTime) > 24*time.Hour {
-		at.dailyPnL = 0
-		at.lastResetTime = time.Now()
-		log.Println("📅 日盈亏已重置")
-	}
+	// 2. 每日维护（跨UTC日时重置日盈亏、每日计数）
+	at.DailyMaintenance(at.now())
 
 	// 3. 收集交易上下文
+	cycleStart := time.Now()
+	at.cycleRiskTime = 0
+	dataSpan := at.startSpan(spanDataProcessing, nil)
 	ctx, err := at.buildTradingContext()
+	contextMs := time.Since(cycleStart).Milliseconds()
 	if err != nil {
+		dataSpan.RecordError(err)
+		dataSpan.End()
+		cycleSpan.RecordError(err)
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("构建交易上下文失败: %v", err)
 		at.decisionLogger.LogDecision(record)
 		return fmt.Errorf("构建交易上下文失败: %w", err)
 	}
 
+	dataSpan.End()
+	cycleSpan.SetAttribute("equity", ctx.Account.TotalEquity)
+	record.Decisions = append(record.Decisions, at.exchangeClosed...)
+
 	// 保存账户状态快照
 	record.AccountState = logger.AccountSnapshot{
 		TotalBalance:          ctx.Account.TotalEquity,
@@ -321,18 +462,50 @@ func (at *AutoTrader) runCycle() error {
 		record.CandidateCoins = append(record.CandidateCoins, coin.Symbol)
 	}
 
-	log.Printf("📊 账户净值: %.2f USDT | 可用: %.2f USDT | 持仓: %d",
+	at.logger.Infof("📊 账户净值: %.2f USDT | 可用: %.2f USDT | 持仓: %d",
 		ctx.Account.TotalEquity, ctx.Account.AvailableBalance, ctx.Account.PositionCount)
 
+	// 获取交易周期锁（多实例共用同一账户时，只允许一个实例决策和下单）
+	lockKey := "cycle_lock_" + at.id
+	acquired, err := at.distributedLock.Acquire(lockKey, at.config.ScanInterval)
+	if err != nil {
+		record.Success = false
+		record.ErrorMessage = fmt.Sprintf("获取交易周期锁失败: %v", err)
+		at.decisionLogger.LogDecision(record)
+		return fmt.Errorf("获取交易周期锁失败: %w", err)
+	}
+	if !acquired {
+		at.logger.Infof("🔒 [%s] 交易周期锁被其他实例持有，跳过本周期", at.name)
+		return nil
+	}
+	defer func() {
+		if err := at.distributedLock.Release(lockKey); err != nil {
+			at.logger.Warnf("⚠️ 释放交易周期锁失败: %v", err)
+		}
+	}()
+
+	// 拆单平仓会下单，与开仓一样只由持有交易周期锁的实例执行
+	at.runPendingExits()
+
 	// 4. 调用AI获取完整决策
-	log.Printf("🤖 正在请求AI分析并决策... [模板: %s]", at.systemPromptTemplate)
+	at.logger.Debugf("🤖 正在请求AI分析并决策... [模板: %s]", at.systemPromptTemplate)
+	aiSpan := at.startSpan(spanAIDecision, map[string]interface{}{"template": at.systemPromptTemplate})
 	decision, err := decision.GetFullDecisionWithCustomPrompt(ctx, at.mcpClient, at.customPrompt, at.overrideBasePrompt, at.systemPromptTemplate)
+	if err != nil {
+		aiSpan.RecordError(err)
+	}
+	aiSpan.End()
 
 	// 即使有错误，也保存思维链、决策和输入prompt（用于debug）
 	if decision != nil {
+		record.Timing.DataMs = contextMs + decision.DataMs
+		record.Timing.AIMs = decision.AIMs
 		record.SystemPrompt = decision.SystemPrompt // 保存系统提示词
 		record.InputPrompt = decision.UserPrompt
 		record.CoTTrace = decision.CoTTrace
+		if len(decision.PromptBudget) > 0 {
+			at.logger.Debugf("📏 提示词预算分配（概况/账户持仓/候选币种）: %v", decision.PromptBudget)
+		}
 		if len(decision.Decisions) > 0 {
 			decisionJSON, _ := json.MarshalIndent(decision.Decisions, "", "  ")
 			record.DecisionJSON = string(decisionJSON)
@@ -346,25 +519,43 @@ func (at *AutoTrader) runCycle() error {
 		// 打印系统提示词和AI思维链（即使有错误，也要输出以便调试）
 		if decision != nil {
 			if decision.SystemPrompt != "" {
-				log.Printf("\n" + strings.Repeat("=", 70))
-				log.Printf("📋 系统提示词 [模板: %s] (错误情况)", at.systemPromptTemplate)
-				log.Println(strings.Repeat("=", 70))
-				log.Println(decision.SystemPrompt)
-				log.Printf(strings.Repeat("=", 70) + "\n")
+				at.logger.Infof("📋 系统提示词 [模板: %s] (错误情况)\n%s\n%s\n%s",
+					at.systemPromptTemplate, strings.Repeat("=", 70), decision.SystemPrompt, strings.Repeat("=", 70))
 			}
 
 			if decision.CoTTrace != "" {
-				log.Printf("\n" + strings.Repeat("-", 70))
-				log.Println("💭 AI思维链分析（错误情况）:")
-				log.Println(strings.Repeat("-", 70))
-				log.Println(decision.CoTTrace)
-				log.Printf(strings.Repeat("-", 70) + "\n")
+				at.logger.Infof("💭 AI思维链分析（错误情况）:\n%s\n%s\n%s",
+					strings.Repeat("-", 70), decision.CoTTrace, strings.Repeat("-", 70))
 			}
 		}
 
-		at.decisionLogger.LogDecision(record)
-		return fmt.Errorf("获取AI决策失败: %w", err)
+		reused := at.reuseLastDecision(err, ctx)
+		if reused == nil {
+			at.finishCycleTiming(record, cycleStart)
+			at.decisionLogger.LogDecision(record)
+			return fmt.Errorf("获取AI决策失败: %w", err)
+		}
+
+		age := at.now().Sub(at.lastDecision.Timestamp)
+		at.logger.Warnf("♻️  AI决策失败，复用 %.0f 分钟前的决策（信心度已衰减）", age.Minutes())
+		record.ErrorMessage += fmt.Sprintf("（已复用 %.0f 分钟前的决策）", age.Minutes())
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("♻️ 复用 %.0f 分钟前的决策", age.Minutes()))
+		decision = reused
+		decisionJSON, _ := json.MarshalIndent(decision.Decisions, "", "  ")
+		record.DecisionJSON = string(decisionJSON)
+	} else {
+		at.lastDecision = decision
+		at.lastExecuted = make(map[string]bool)
 	}
+	return at.executeCycleDecisions(decision, ctx, record, cycleStart)
+}
+
+// executeCycleDecisions 发布AI决策，排序、去重、限流后依次执行，并保存本周期的决策记录
+func (at *AutoTrader) executeCycleDecisions(decision *decision.FullDecision, ctx *decision.Context, record *logger.DecisionRecord, cycleStart time.Time) error {
+	at.cycleMarketData = ctx.MarketDataMap
+	defer func() { at.cycleMarketData = nil }()
+
+	at.publishEvent(DecisionEvent{Type: EventAIDecision, CoTTrace: decision.CoTTrace, Decisions: decision.Decisions})
 
 	// // 5. 打印系统提示词
 	// log.Printf("\n" + strings.Repeat("=", 70))
@@ -389,18 +580,56 @@ func (at *AutoTrader) runCycle() error {
 	// 			d.Leverage, d.PositionSizeUSD, d.StopLoss, d.TakeProfit)
 	// 	}
 	// }
-	log.Println()
+	at.logger.Infof("")
 
 	// 8. 对决策排序：确保先平仓后开仓（防止仓位叠加超限）
-	sortedDecisions := sortDecisionsByPriority(decision.Decisions)
+	// 持仓时长超限的持仓强制平仓（与AI决策一起按优先级排序）
+	ageExits := at.positionAgeExits(ctx.Positions, decision.Decisions)
+	for _, d := range ageExits {
+		at.logger.Infof("⏳ %s %s: %s", d.Symbol, d.Action, d.Reasoning)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏳ %s 持仓时长超限，强制平仓", d.Symbol))
+	}
+	withExits := append(ageExits, decision.Decisions...)
+
+	// 持仓信心度明显下降时按下降幅度减仓
+	trims := at.confidenceTrims(ctx.Positions, withExits)
+	for _, d := range trims {
+		at.logger.Infof("✂️  %s %s: %s", d.Symbol, d.Action, d.Reasoning)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✂️ %s %s", d.Symbol, d.Reasoning))
+	}
+	sortedDecisions := deduplicateDecisions(append(trims, withExits...))
+
+	// 结构变化后止盈不现实的持仓，把止盈移到新形成的支撑/阻力内侧
+	for _, plan := range at.takeProfitRetargets(ctx.Positions, sortedDecisions) {
+		if err := at.applyTakeProfitRetarget(plan); err != nil {
+			at.logger.Warnf("  ⚠️ %s 止盈重定位失败: %v", plan.Symbol, err)
+			continue
+		}
+		record.ExecutionLog = append(record.ExecutionLog,
+			fmt.Sprintf("🎯 %s 止盈 %.4f → %.4f", plan.Symbol, plan.OldTakeProfit, plan.NewTakeProfit))
+	}
+
+	// 展开计划模板开仓，加入价格已到达入场价的挂起计划
+	sortedDecisions = sortDecisionsByPriority(at.applyPlanTemplates(sortedDecisions, ctx))
+
+	// 限制单周期开仓数量，超出的开仓延迟到下个周期
+	sortedDecisions, deferred := limitNewEntries(sortedDecisions, at.config.Risk.MaxNewEntriesPerCycle)
+	for _, d := range deferred {
+		at.logger.Infof("⏭  %s %s 延迟到下个周期（本周期开仓数已达上限 %d，信心度 %d）",
+			d.Symbol, d.Action, at.config.Risk.MaxNewEntriesPerCycle, d.Confidence)
+		record.ExecutionLog = append(record.ExecutionLog,
+			fmt.Sprintf("⏭ %s %s 延迟（本周期开仓数已达上限 %d）", d.Symbol, d.Action, at.config.Risk.MaxNewEntriesPerCycle))
+	}
+
+	at.publishEvent(DecisionEvent{Type: EventExecutionPlan, Decisions: sortedDecisions})
 
-	log.Println("🔄 执行顺序（已优化）: 先平仓→后开仓")
+	at.logger.Debugf("🔄 执行顺序（已优化）: 先平仓→后开仓")
 	for i, d := range sortedDecisions {
-		log.Printf("  [%d] %s %s", i+1, d.Symbol, d.Action)
+		at.logger.Debugf("  [%d] %s %s", i+1, d.Symbol, d.Action)
 	}
-	log.Println()
 
 	// 执行决策并记录结果
+	execStart := time.Now()
 	for _, d := range sortedDecisions {
 		actionRecord := logger.DecisionAction{
 			Action:    d.Action,
@@ -412,12 +641,20 @@ func (at *AutoTrader) runCycle() error {
 			Success:   false,
 		}
 
-		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
-			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
+		execSpan := at.startSpan(spanOrderExecution, map[string]interface{}{"symbol": d.Symbol, "action": d.Action})
+		err := at.executeDecisionWithRecord(&d, &actionRecord)
+		if err != nil {
+			execSpan.RecordError(err)
+		}
+		execSpan.End()
+
+		if err != nil {
+			at.logger.Errorf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
 			actionRecord.Error = err.Error()
 			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err))
 		} else {
 			actionRecord.Success = true
+			at.lastExecuted[d.Symbol+":"+d.Action] = true
 			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
 			// 成功执行后短暂延迟
 			time.Sleep(1 * time.Second)
@@ -425,15 +662,102 @@ func (at *AutoTrader) runCycle() error {
 
 		record.Decisions = append(record.Decisions, actionRecord)
 	}
+	at.recordRejectionAlerts(record.Decisions)
+	at.publishEvent(DecisionEvent{Type: EventExecutionResult, Results: record.Decisions})
+	record.Timing.RiskMs = at.cycleRiskTime.Milliseconds()
+	record.Timing.ExecMs = (time.Since(execStart) - at.cycleRiskTime).Milliseconds()
+	at.finishCycleTiming(record, cycleStart)
 
 	// 9. 保存决策记录
 	if err := at.decisionLogger.LogDecision(record); err != nil {
-		log.Printf("⚠ 保存决策记录失败: %v", err)
+		at.logger.Warnf("⚠ 保存决策记录失败: %v", err)
 	}
 
 	return nil
 }
 
+// reuseLastDecision AI调用失败时，返回按年龄衰减后的上次决策（不可复用时返回nil）
+// 开仓决策按 ctx 中本周期的价格重新检查止损止盈，价格已越过的开仓改为观望；
+// 已执行过的动作、已持有同方向持仓的开仓和没有对应持仓的平仓也改为观望，避免复用时重复下单
+func (at *AutoTrader) reuseLastDecision(cause error, ctx *decision.Context) *decision.FullDecision {
+	cfg := at.config.Risk
+	if cfg.DecisionReuseMinutes <= 0 || at.lastDecision == nil {
+		return nil
+	}
+
+	// AI给出了新决策但违反约束时不复用旧决策（新信息优先于过期信息）
+	if errors.Is(cause, decision.ErrAISchema) {
+		return nil
+	}
+
+	age := at.now().Sub(at.lastDecision.Timestamp)
+	if age > time.Duration(cfg.DecisionReuseMinutes)*time.Minute {
+		return nil
+	}
+
+	// 开仓的止损止盈按本周期价格重新检查，价格已越过的开仓不再执行
+	prices := make(map[string]float64, len(ctx.MarketDataMap))
+	for symbol, data := range ctx.MarketDataMap {
+		if data != nil {
+			prices[symbol] = data.CurrentPrice
+		}
+	}
+	decayed := decision.DecayDecisions(at.lastDecision.Decisions, age, cfg.ConfidenceDecayPerMinute, cfg.MinConfidence)
+	return &decision.FullDecision{
+		CoTTrace:  at.lastDecision.CoTTrace,
+		Decisions: at.skipStaleActions(decision.RevalidateEntries(decayed, prices), ctx.Positions),
+		Timestamp: at.lastDecision.Timestamp,
+	}
+}
+
+// skipStaleActions 复用的决策中已不适用的开仓/平仓改为观望：
+// 上次已成功执行的动作、已持有同方向持仓的开仓（包括允许加仓时，复用不应再次加仓）、持仓已不存在的平仓
+func (at *AutoTrader) skipStaleActions(decisions []decision.Decision, positions []decision.PositionInfo) []decision.Decision {
+	held := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		held[pos.Symbol+"_"+pos.Side] = true
+	}
+
+	for i := range decisions {
+		d := &decisions[i]
+		var reason string
+		switch d.Action {
+		case "open_long", "open_short":
+			if held[d.Symbol+"_"+strings.TrimPrefix(d.Action, "open_")] {
+				reason = "已持有该方向持仓"
+			}
+		case "close_long", "close_short":
+			if !held[d.Symbol+"_"+strings.TrimPrefix(d.Action, "close_")] {
+				reason = "持仓已不存在"
+			}
+		default:
+			continue
+		}
+		if at.lastExecuted[d.Symbol+":"+d.Action] {
+			reason = "上次已执行"
+		}
+		if reason == "" {
+			continue
+		}
+		d.Action = "wait"
+		d.Confidence = 0
+		d.Reasoning = "[复用决策，" + reason + "] " + d.Reasoning
+	}
+	return decisions
+}
+
+// currentKlines 从WS监控缓存获取K线（symbol 自动标准化）
+func currentKlines(symbol, interval string) ([]market.Kline, error) {
+	return market.WSMonitorCli.GetCurrentKlines(market.Normalize(symbol), interval)
+}
+
+// finishCycleTiming 记录周期总耗时并输出耗时摘要
+func (at *AutoTrader) finishCycleTiming(record *logger.DecisionRecord, cycleStart time.Time) {
+	record.Timing.TotalMs = time.Since(cycleStart).Milliseconds()
+	at.lastCycleTiming = record.Timing
+	at.logger.Debugf("⏱️  周期耗时: %s", record.Timing.Summary())
+}
+
 // buildTradingContext 构建交易上下文
 func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 1. 获取账户信息
@@ -442,20 +766,8 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		return nil, fmt.Errorf("获取账户余额失败: %w", err)
 	}
 
-	// 获取账户字段
-	totalWalletBalance := 0.0
-	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
-
-	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
-		totalWalletBalance = wallet
-	}
-	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
-		totalUnrealizedProfit = unrealized
-	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	// 获取账户字段（非USDT计价的账户换算为USD）
+	totalWalletBalance, totalUnrealizedProfit, availableBalance, currency := at.accountBalance(balance)
 
 	// Total Equity = 钱包余额 + 未实现盈亏
 	totalEquity := totalWalletBalance + totalUnrealizedProfit
@@ -483,6 +795,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		}
 		unrealizedPnl := pos["unRealizedProfit"].(float64)
 		liquidationPrice := pos["liquidationPrice"].(float64)
+		at.updateExcursion(symbol, side, markPrice)
 
 		// 计算盈亏百分比
 		pnlPct := 0.0
@@ -497,7 +810,10 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		if lev, ok := pos["leverage"].(float64); ok {
 			leverage = int(lev)
 		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
+		// 保证金和盈亏以计价资产表示，换算为账户结算币种
+		rates := at.config.Risk.QuoteConversionRates
+		marginUsed := rates.ToSettlement(symbol, (quantity*markPrice)/float64(leverage))
+		unrealizedPnl = rates.ToSettlement(symbol, unrealizedPnl)
 		totalMarginUsed += marginUsed
 
 		// 跟踪持仓首次出现时间
@@ -505,7 +821,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		currentPositionKeys[posKey] = true
 		if _, exists := at.positionFirstSeenTime[posKey]; !exists {
 			// 新持仓，记录当前时间
-			at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+			at.positionFirstSeenTime[posKey] = at.now().UnixMilli()
 		}
 		updateTime := at.positionFirstSeenTime[posKey]
 
@@ -524,12 +840,19 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		})
 	}
 
+	// 交易所侧平仓的执行评估（依赖止损记录和首次出现时间，须在清理前生成）
+	at.exchangeClosed = at.exchangeCloses(currentPositionKeys)
+
 	// 清理已平仓的持仓记录
 	for key := range at.positionFirstSeenTime {
 		if !currentPositionKeys[key] {
 			delete(at.positionFirstSeenTime, key)
 		}
 	}
+	at.prunePositionStops(currentPositionKeys)
+	at.fills.Prune(currentPositionKeys)
+	at.lastPositions = positionInfos
+	at.updateEquityTracking(totalEquity)
 
 	// 3. 获取交易员的候选币种池
 	candidateCoins, err := at.getCandidateCoins()
@@ -553,14 +876,15 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 假设每3分钟一个周期，100个周期 = 5小时，足够覆盖大部分交易
 	performance, err := at.decisionLogger.AnalyzePerformance(100)
 	if err != nil {
-		log.Printf("⚠️  分析历史表现失败: %v", err)
+		at.logger.Warnf("⚠️  分析历史表现失败: %v", err)
 		// 不影响主流程，继续执行（但设置performance为nil以避免传递错误数据）
 		performance = nil
 	}
 
 	// 6. 构建上下文
+	equity := at.equitySnapshot()
 	ctx := &decision.Context{
-		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
+		CurrentTime:     at.now().Format("2006-01-02 15:04:05"),
 		RuntimeMinutes:  int(time.Since(at.startTime).Minutes()),
 		CallCount:       at.callCount,
 		BTCETHLeverage:  at.config.BTCETHLeverage,  // 使用配置的杠杆倍数
@@ -573,15 +897,109 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 			MarginUsed:       totalMarginUsed,
 			MarginUsedPct:    marginUsedPct,
 			PositionCount:    len(positionInfos),
+			Currency:         currency,
 		},
-		Positions:      positionInfos,
-		CandidateCoins: candidateCoins,
-		Performance:    performance, // 添加历史表现分析
+		Positions:           positionInfos,
+		CandidateCoins:      candidateCoins,
+		Performance:         performance, // 添加历史表现分析
+		MinValidCandidates:  at.config.Risk.MinValidCandidates,
+		MinConfidence:       at.config.Risk.MinConfidence,
+		MinATRRatio:         at.config.Risk.MinATRRatio,
+		ConfidencePerFactor: at.config.Risk.ConfidencePerFactor,
+		MaxConfidence:       at.config.Risk.MaxConfidence,
+		StrategyMode:        at.config.Risk.StrategyMode,
+		DecisionSamples:     at.config.Risk.DecisionSamples,
+		StreamAIResponse:    at.config.Risk.StreamAIResponse,
+		PlanTemplates:       at.plans.Names(),
+		PromptBudgetChars:   at.config.Risk.PromptBudgetChars,
+		RegimeMemory:        at.regimeMemory,
+		PeakEquity:          equity.Peak,
+		DailyStartEquity:    equity.DailyStart,
+		PortfolioHeat:       at.portfolioHeat(positionInfos),
+	}
+
+	// 追保预警（在AI分析之前）
+	at.checkMarginCallRisk(ctx)
+
+	// 经济事件期间提示AI不要开新仓（执行时由风控规则拦截）
+	if err := at.checkEconomicCalendar(); err != nil {
+		ctx.RiskWarnings = append(ctx.RiskWarnings, err.Error()+"，本周期只考虑持仓管理")
 	}
 
 	return ctx, nil
 }
 
+// ResetDailyStats 重置日盈亏统计（日起始净值在下次获取账户信息时重新记录）
+func (at *AutoTrader) ResetDailyStats() {
+	at.stateMu.Lock()
+	at.dailyPnL = 0
+	at.dailyStartEquity = 0
+	at.lastResetTime = at.now()
+	at.stateMu.Unlock()
+	at.logger.Infof("📅 日盈亏已重置")
+}
+
+// equityState 净值跟踪状态的副本
+type equityState struct {
+	Last       float64 // 最近一次交易周期获取的账户净值
+	Peak       float64 // 历史最高净值
+	DailyStart float64 // 当日起始净值
+	DailyPnL   float64 // 当日盈亏
+}
+
+// equitySnapshot 读取净值跟踪状态（只由交易周期写入，HTTP接口只读）
+func (at *AutoTrader) equitySnapshot() equityState {
+	at.stateMu.RLock()
+	defer at.stateMu.RUnlock()
+	return equityState{
+		Last:       at.lastEquity,
+		Peak:       at.peakEquity,
+		DailyStart: at.dailyStartEquity,
+		DailyPnL:   at.dailyPnL,
+	}
+}
+
+// haltState 暂停状态的副本
+type haltState struct {
+	HaltedAt       time.Time // 暂停开始时间
+	StopUntil      time.Time // 整个交易周期暂停的截止时间（紧急平仓）
+	EntryHaltUntil time.Time // 只暂停开新仓的截止时间
+	LastResetTime  time.Time // 上次日盈亏重置时间
+}
+
+// haltSnapshot 读取暂停状态
+func (at *AutoTrader) haltSnapshot() haltState {
+	at.stateMu.RLock()
+	defer at.stateMu.RUnlock()
+	return haltState{
+		HaltedAt:       at.haltedAt,
+		StopUntil:      at.stopUntil,
+		EntryHaltUntil: at.entryHaltUntil,
+		LastResetTime:  at.lastResetTime,
+	}
+}
+
+// updateEquityTracking 更新净值相关的跟踪状态（日起始净值、历史最高、日盈亏）
+func (at *AutoTrader) updateEquityTracking(totalEquity float64) {
+	if totalEquity <= 0 {
+		return
+	}
+	at.stateMu.Lock()
+	at.lastEquity = totalEquity
+	if at.dailyStartEquity <= 0 {
+		at.dailyStartEquity = totalEquity
+	}
+	if totalEquity > at.peakEquity {
+		at.peakEquity = totalEquity
+	}
+	at.dailyPnL = totalEquity - at.dailyStartEquity
+	at.stateMu.Unlock()
+
+	at.recordDrawdown(totalEquity)
+	at.checkEquityAnomaly(totalEquity)
+	at.recordStrategyEquity(totalEquity)
+}
+
 // executeDecisionWithRecord 执行AI决策并记录详细信息
 func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	switch decision.Action {
@@ -603,32 +1021,42 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 
 // executeOpenLongWithRecord 执行开多仓并记录详细信息
 func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📈 开多仓: %s", decision.Symbol)
+	at.logger.Infof("  📈 开多仓: %s", decision.Symbol)
 
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
+	var existingQty, existingEntry float64
 	positions, err := at.trader.GetPositions()
 	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "long" {
-				return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
-			}
+		existingQty, existingEntry = existingPosition(positions, decision.Symbol, "long")
+		if existingQty > 0 && !at.config.Risk.AllowPositionAdds {
+			return fmt.Errorf("❌ %s 已有多仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_long 决策", decision.Symbol)
 		}
 	}
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 
+	// 按配置的止损模式调整止损价（在风控检查之前，风控按最终止损价计算风险）
+	at.applyStopMode(decision, marketData, "long")
+
+	// 风控检查（可能拒绝开仓、调整止损或仓位）
+	if err := at.checkEntryRisk(decision, marketData, "long"); err != nil {
+		return err
+	}
+
 	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	entryPrice := marketData.ReferencePrice(at.config.Risk.EntryPriceRef)
+	quantity := decision.PositionSizeUSD / entryPrice
 	actionRecord.Quantity = quantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
+	actionRecord.Leverage = decision.Leverage // 风控可能下调杠杆
 
 	// 设置仓位模式
 	if err := at.trader.SetMarginMode(decision.Symbol, at.config.IsCrossMargin); err != nil {
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
+		at.logger.Warnf("  ⚠️ 设置仓位模式失败: %v", err)
 		// 继续执行，不影响交易
 	}
 
@@ -637,24 +1065,61 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	if err != nil {
 		return err
 	}
+	at.orderLimiter.Record(at.now())
+	at.reversals.Record(decision.Symbol, "long", at.now())
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.logger.Infof("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	filled := filledQuantity(order, quantity)
+	actionRecord.FilledQuantity = filled
+	if filled < quantity {
+		at.logger.Warnf("  ⚠️ 部分成交: %.4f / %.4f，止损止盈按成交数量设置", filled, quantity)
+	}
 
-	// 记录开仓时间
 	posKey := decision.Symbol + "_long"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	if existingQty > 0 {
+		// 加仓：合并到已有持仓记录（保留开仓时间、入场信号和持仓期间价格范围）
+		at.recordOrderFill(posKey, order)
+		fillPrice, _ := order["avgPrice"].(float64)
+		if fillPrice <= 0 {
+			fillPrice = entryPrice
+		}
+		actionRecord.Price = fillPrice
+		avgPrice := at.mergePositionAdd(decision.Symbol, "long", decision.StopLoss, decision.TakeProfit, existingQty, existingEntry, filled, fillPrice)
+		at.logger.Infof("  ➕ 加仓 %.4f（原持仓 %.4f），合并均价 %.4f", filled, existingQty, avgPrice)
+	} else {
+		// 记录开仓时间
+		at.positionFirstSeenTime[posKey] = at.now().UnixMilli()
+
+		// 使用实际成交均价（多笔成交按数量加权），止损止盈随成交滑点平移，保持计划的止损止盈距离
+		avgPrice, filledQty := at.recordOrderFill(posKey, order)
+		if avgPrice > 0 {
+			decision.StopLoss, decision.TakeProfit = rebaseStops(decision.StopLoss, decision.TakeProfit, entryPrice, avgPrice)
+		}
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+		// 设置止损止盈
+		at.recordPositionStop(decision.Symbol, "long", decision.StopLoss, decision.TakeProfit)
+		at.recordEntrySignals(decision, "long", marketData, actionRecord)
+		at.setPositionEntryPlan(decision.Symbol, "long", entryPrice, decision.Confidence)
+
+		if avgPrice > 0 {
+			actionRecord.Price = avgPrice
+			at.setPositionEntryPrice(decision.Symbol, "long", avgPrice)
+			at.logger.Debugf("  成交均价: %.4f, 成交数量: %.4f, 止损 %.4f, 止盈 %.4f", avgPrice, filledQty, decision.StopLoss, decision.TakeProfit)
+		}
+	}
+
+	// 开仓会撤销该币种全部挂单，止损止盈按合计持仓数量重新挂
+	stopQty := existingQty + filled
+	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", stopQty, decision.StopLoss); err != nil {
+		at.logger.Warnf("  ⚠ 设置止损失败: %v", err)
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", stopQty, decision.TakeProfit); err != nil {
+		at.logger.Warnf("  ⚠ 设置止盈失败: %v", err)
 	}
 
 	return nil
@@ -662,32 +1127,42 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 
 // executeOpenShortWithRecord 执行开空仓并记录详细信息
 func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📉 开空仓: %s", decision.Symbol)
+	at.logger.Infof("  📉 开空仓: %s", decision.Symbol)
 
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
+	var existingQty, existingEntry float64
 	positions, err := at.trader.GetPositions()
 	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == decision.Symbol && pos["side"] == "short" {
-				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
-			}
+		existingQty, existingEntry = existingPosition(positions, decision.Symbol, "short")
+		if existingQty > 0 && !at.config.Risk.AllowPositionAdds {
+			return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", decision.Symbol)
 		}
 	}
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	marketData, err := at.getMarketData(decision.Symbol)
 	if err != nil {
 		return err
 	}
 
+	// 按配置的止损模式调整止损价（在风控检查之前，风控按最终止损价计算风险）
+	at.applyStopMode(decision, marketData, "short")
+
+	// 风控检查（可能拒绝开仓、调整止损或仓位）
+	if err := at.checkEntryRisk(decision, marketData, "short"); err != nil {
+		return err
+	}
+
 	// 计算数量
-	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	entryPrice := marketData.ReferencePrice(at.config.Risk.EntryPriceRef)
+	quantity := decision.PositionSizeUSD / entryPrice
 	actionRecord.Quantity = quantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
+	actionRecord.Leverage = decision.Leverage // 风控可能下调杠杆
 
 	// 设置仓位模式
 	if err := at.trader.SetMarginMode(decision.Symbol, at.config.IsCrossMargin); err != nil {
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
+		at.logger.Warnf("  ⚠️ 设置仓位模式失败: %v", err)
 		// 继续执行，不影响交易
 	}
 
@@ -696,24 +1171,61 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	if err != nil {
 		return err
 	}
+	at.orderLimiter.Record(at.now())
+	at.reversals.Record(decision.Symbol, "short", at.now())
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.logger.Infof("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	filled := filledQuantity(order, quantity)
+	actionRecord.FilledQuantity = filled
+	if filled < quantity {
+		at.logger.Warnf("  ⚠️ 部分成交: %.4f / %.4f，止损止盈按成交数量设置", filled, quantity)
+	}
 
-	// 记录开仓时间
 	posKey := decision.Symbol + "_short"
-	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	if existingQty > 0 {
+		// 加仓：合并到已有持仓记录（保留开仓时间、入场信号和持仓期间价格范围）
+		at.recordOrderFill(posKey, order)
+		fillPrice, _ := order["avgPrice"].(float64)
+		if fillPrice <= 0 {
+			fillPrice = entryPrice
+		}
+		actionRecord.Price = fillPrice
+		avgPrice := at.mergePositionAdd(decision.Symbol, "short", decision.StopLoss, decision.TakeProfit, existingQty, existingEntry, filled, fillPrice)
+		at.logger.Infof("  ➕ 加仓 %.4f（原持仓 %.4f），合并均价 %.4f", filled, existingQty, avgPrice)
+	} else {
+		// 记录开仓时间
+		at.positionFirstSeenTime[posKey] = at.now().UnixMilli()
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+		// 使用实际成交均价（多笔成交按数量加权），止损止盈随成交滑点平移，保持计划的止损止盈距离
+		avgPrice, filledQty := at.recordOrderFill(posKey, order)
+		if avgPrice > 0 {
+			decision.StopLoss, decision.TakeProfit = rebaseStops(decision.StopLoss, decision.TakeProfit, entryPrice, avgPrice)
+		}
+
+		// 设置止损止盈
+		at.recordPositionStop(decision.Symbol, "short", decision.StopLoss, decision.TakeProfit)
+		at.recordEntrySignals(decision, "short", marketData, actionRecord)
+		at.setPositionEntryPlan(decision.Symbol, "short", entryPrice, decision.Confidence)
+
+		if avgPrice > 0 {
+			actionRecord.Price = avgPrice
+			at.setPositionEntryPrice(decision.Symbol, "short", avgPrice)
+			at.logger.Debugf("  成交均价: %.4f, 成交数量: %.4f, 止损 %.4f, 止盈 %.4f", avgPrice, filledQty, decision.StopLoss, decision.TakeProfit)
+		}
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+
+	// 开仓会撤销该币种全部挂单，止损止盈按合计持仓数量重新挂
+	stopQty := existingQty + filled
+	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", stopQty, decision.StopLoss); err != nil {
+		at.logger.Warnf("  ⚠ 设置止损失败: %v", err)
+	}
+	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", stopQty, decision.TakeProfit); err != nil {
+		at.logger.Warnf("  ⚠ 设置止盈失败: %v", err)
 	}
 
 	return nil
@@ -721,53 +1233,87 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 
 // executeCloseLongWithRecord 执行平多仓并记录详细信息
 func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  🔄 平多仓: %s", decision.Symbol)
+	at.logger.Infof("  🔄 平多仓: %s", decision.Symbol)
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	price, err := at.priceOracle.GetPrice(decision.Symbol)
 	if err != nil {
 		return err
 	}
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = price
 
 	// 平仓
-	order, err := at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
-	if err != nil {
-		return err
-	}
+	quantity := at.closeQuantity(decision.Symbol, "long", decision.CloseRatio)
+	closeRatio := decision.CloseRatio
+	held := at.positionQuantity(decision.Symbol, "long")
+	result, err := at.closePosition(decision.Symbol, "long", quantity) // 0 = 全部平仓
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
+	if orderID, ok := result.Order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
+	if err != nil {
+		return err
+	}
 
-	log.Printf("  ✓ 平仓成功")
+	at.logger.Infof("  ✓ 平仓成功")
+	if result.Pending > 0 && held > 0 {
+		closeRatio = result.ClosedQty / held // 拆单时本周期只平了第一笔
+		at.logger.Infof("  🧩 剩余 %d 笔将在后续周期平仓", result.Pending)
+	} else if closeRatio <= 0 || closeRatio >= 1 {
+		at.markClosedBySystem(decision.Symbol, "long")
+	}
+	if attribution := at.attributeClose(decision.Symbol, "long", actionRecord.Price, closeRatio); attribution != nil {
+		at.logEntrySignals(decision.Symbol, "long", attribution)
+		actionRecord.Attribution = attribution
+		actionRecord.ReportCard = at.tradeReportCard(decision.Symbol, "long", actionRecord.Price, closeRatio, attribution)
+		at.logger.Infof("  💰 盈亏归因: 价格 %+.2f | 资金费 %+.2f | 手续费 %+.2f | 合计 %+.2f USDT",
+			attribution.PricePnL, attribution.FundingPnL, attribution.FeePnL, attribution.NetPnL)
+		at.RecordTradeOutcome(attribution.NetPnL > 0)
+	}
 	return nil
 }
 
 // executeCloseShortWithRecord 执行平空仓并记录详细信息
 func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  🔄 平空仓: %s", decision.Symbol)
+	at.logger.Infof("  🔄 平空仓: %s", decision.Symbol)
 
 	// 获取当前价格
-	marketData, err := market.Get(decision.Symbol)
+	price, err := at.priceOracle.GetPrice(decision.Symbol)
 	if err != nil {
 		return err
 	}
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = price
 
 	// 平仓
-	order, err := at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
-	if err != nil {
-		return err
-	}
+	quantity := at.closeQuantity(decision.Symbol, "short", decision.CloseRatio)
+	closeRatio := decision.CloseRatio
+	held := at.positionQuantity(decision.Symbol, "short")
+	result, err := at.closePosition(decision.Symbol, "short", quantity) // 0 = 全部平仓
 
 	// 记录订单ID
-	if orderID, ok := order["orderId"].(int64); ok {
+	if orderID, ok := result.Order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
+	if err != nil {
+		return err
+	}
 
-	log.Printf("  ✓ 平仓成功")
+	at.logger.Infof("  ✓ 平仓成功")
+	if result.Pending > 0 && held > 0 {
+		closeRatio = result.ClosedQty / held // 拆单时本周期只平了第一笔
+		at.logger.Infof("  🧩 剩余 %d 笔将在后续周期平仓", result.Pending)
+	} else if closeRatio <= 0 || closeRatio >= 1 {
+		at.markClosedBySystem(decision.Symbol, "short")
+	}
+	if attribution := at.attributeClose(decision.Symbol, "short", actionRecord.Price, closeRatio); attribution != nil {
+		at.logEntrySignals(decision.Symbol, "short", attribution)
+		actionRecord.Attribution = attribution
+		actionRecord.ReportCard = at.tradeReportCard(decision.Symbol, "short", actionRecord.Price, closeRatio, attribution)
+		at.logger.Infof("  💰 盈亏归因: 价格 %+.2f | 资金费 %+.2f | 手续费 %+.2f | 合计 %+.2f USDT",
+			attribution.PricePnL, attribution.FundingPnL, attribution.FeePnL, attribution.NetPnL)
+		at.RecordTradeOutcome(attribution.NetPnL > 0)
+	}
 	return nil
 }
 
@@ -806,6 +1352,31 @@ func (at *AutoTrader) SetSystemPromptTemplate(templateName string) {
 	at.systemPromptTemplate = templateName
 }
 
+// SetDistributedLock 设置分布式锁（多实例部署时使用）
+func (at *AutoTrader) SetDistributedLock(lock DistributedLock) {
+	if lock == nil {
+		lock = NoopDistributedLock{}
+	}
+	at.distributedLock = lock
+}
+
+// SetClock 设置时钟（用于回放或模拟跨日，nil 恢复系统时钟）
+// 日统计的重置时间同时以新时钟的当前时间重新锚定
+func (at *AutoTrader) SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	at.now = now
+	at.stateMu.Lock()
+	at.lastResetTime = now()
+	at.stateMu.Unlock()
+}
+
+// SetLogger 注入分级日志（如测试中捕获输出）
+func (at *AutoTrader) SetLogger(l logger.Logger) {
+	at.logger = l
+}
+
 // GetSystemPromptTemplate 获取当前系统提示词模板名称
 func (at *AutoTrader) GetSystemPromptTemplate() string {
 	return at.systemPromptTemplate
@@ -823,21 +1394,47 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		aiProvider = "Qwen"
 	}
 
-	return map[string]interface{}{
-		"trader_id":       at.id,
-		"trader_name":     at.name,
-		"ai_model":        at.aiModel,
-		"exchange":        at.exchange,
-		"is_running":      at.isRunning,
-		"start_time":      at.startTime.Format(time.RFC3339),
-		"runtime_minutes": int(time.Since(at.startTime).Minutes()),
-		"call_count":      at.callCount,
-		"initial_balance": at.initialBalance,
-		"scan_interval":   at.config.ScanInterval.String(),
-		"stop_until":      at.stopUntil.Format(time.RFC3339),
-		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
-		"ai_provider":     aiProvider,
+	halt := at.haltSnapshot()
+	status := map[string]interface{}{
+		"trader_id":         at.id,
+		"trader_name":       at.name,
+		"ai_model":          at.aiModel,
+		"exchange":          at.exchange,
+		"is_running":        at.isRunning,
+		"start_time":        at.startTime.Format(time.RFC3339),
+		"runtime_minutes":   int(time.Since(at.startTime).Minutes()),
+		"call_count":        at.callCount,
+		"initial_balance":   at.initialBalance,
+		"scan_interval":     at.config.ScanInterval.String(),
+		"stop_until":        halt.StopUntil.Format(time.RFC3339),
+		"last_reset_time":   halt.LastResetTime.Format(time.RFC3339),
+		"ai_provider":       aiProvider,
+		"last_cycle_timing": at.lastCycleTiming,
+	}
+
+	regime, regimeAge := at.regimeMemory.Status()
+	status["market_regime"] = regime
+	status["regime_age"] = regimeAge
+
+	dailyTrades, remaining := at.orderLimiter.DailyStatus(at.now())
+	status["daily_trade_count"] = dailyTrades
+	status["daily_trades_remaining"] = remaining // -1=不限制
+	status["trade_streak"] = at.GetStreakInfo()
+	if tripped, reason := at.strategyBreaker.Status(); tripped {
+		status["strategy_disabled"] = reason
+	}
+	if triggered, reason := at.drawdownStop.Status(); triggered {
+		status["drawdown_hard_stop"] = reason
+	}
+
+	if at.mcpClient.Breaker != nil {
+		status["ai_circuit_breaker"] = at.mcpClient.Breaker.GetStatus()
 	}
+	if at.mcpClient.CostTracker != nil {
+		status["ai_cost"] = at.mcpClient.CostTracker.GetStatus()
+	}
+
+	return status
 }
 
 // GetAccountInfo 获取账户信息（用于API）
@@ -847,20 +1444,8 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		return nil, fmt.Errorf("获取余额失败: %w", err)
 	}
 
-	// 获取账户字段
-	totalWalletBalance := 0.0
-	totalUnrealizedProfit := 0.0
-	availableBalance := 0.0
-
-	if wallet, ok := balance["totalWalletBalance"].(float64); ok {
-		totalWalletBalance = wallet
-	}
-	if unrealized, ok := balance["totalUnrealizedProfit"].(float64); ok {
-		totalUnrealizedProfit = unrealized
-	}
-	if avail, ok := balance["availableBalance"].(float64); ok {
-		availableBalance = avail
-	}
+	// 获取账户字段（非USDT计价的账户换算为USD）
+	totalWalletBalance, totalUnrealizedProfit, availableBalance, _ := at.accountBalance(balance)
 
 	// Total Equity = 钱包余额 + 未实现盈亏
 	totalEquity := totalWalletBalance + totalUnrealizedProfit
@@ -873,20 +1458,21 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 
 	totalMarginUsed := 0.0
 	totalUnrealizedPnL := 0.0
+	rates := at.config.Risk.QuoteConversionRates // 保证金和盈亏换算为账户结算币种
 	for _, pos := range positions {
 		markPrice := pos["markPrice"].(float64)
 		quantity := pos["positionAmt"].(float64)
 		if quantity < 0 {
 			quantity = -quantity
 		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
+		unrealizedPnl := rates.ToSettlement(pos["symbol"].(string), pos["unRealizedProfit"].(float64))
 		totalUnrealizedPnL += unrealizedPnl
 
 		leverage := 10
 		if lev, ok := pos["leverage"].(float64); ok {
 			leverage = int(lev)
 		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
+		marginUsed := rates.ToSettlement(pos["symbol"].(string), (quantity*markPrice)/float64(leverage))
 		totalMarginUsed += marginUsed
 	}
 
@@ -909,11 +1495,11 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		"available_balance": availableBalance,      // 可用余额
 
 		// 盈亏统计
-		"total_pnl":            totalPnL,           // 总盈亏 = equity - initial
-		"total_pnl_pct":        totalPnLPct,        // 总盈亏百分比
-		"total_unrealized_pnl": totalUnrealizedPnL, // 未实现盈亏（从持仓计算）
-		"initial_balance":      at.initialBalance,  // 初始余额
-		"daily_pnl":            at.dailyPnL,        // 日盈亏
+		"total_pnl":            totalPnL,                     // 总盈亏 = equity - initial
+		"total_pnl_pct":        totalPnLPct,                  // 总盈亏百分比
+		"total_unrealized_pnl": totalUnrealizedPnL,           // 未实现盈亏（从持仓计算）
+		"initial_balance":      at.initialBalance,            // 初始余额
+		"daily_pnl":            at.equitySnapshot().DailyPnL, // 日盈亏
 
 		// 持仓信息
 		"position_count":  len(positions),  // 持仓数量
@@ -1011,6 +1597,108 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 	return sorted
 }
 
+// deduplicateDecisions 同一 (币种, 动作) 只保留信心度最高的决策（信心度相同时保留先出现的）
+// 同一币种同时出现 open_long 与 open_short 时只保留信心度更高的一方，信心度相同则两者都丢弃
+// AI偶尔会对同一机会重复输出，重复执行会导致仓位叠加；返回结果按 sortDecisionsByPriority 排序
+func deduplicateDecisions(decisions []decision.Decision) []decision.Decision {
+	best := make(map[string]int, len(decisions)) // key -> 在 unique 中的下标
+	unique := make([]decision.Decision, 0, len(decisions))
+	for _, d := range decisions {
+		key := d.Symbol + ":" + d.Action
+		if i, ok := best[key]; ok {
+			if d.Confidence > unique[i].Confidence {
+				logger.Infof("🔁 %s %s 重复决策，保留信心度更高的 %d（丢弃 %d）", d.Symbol, d.Action, d.Confidence, unique[i].Confidence)
+				unique[i] = d
+			} else {
+				logger.Infof("🔁 %s %s 重复决策，丢弃信心度 %d", d.Symbol, d.Action, d.Confidence)
+			}
+			continue
+		}
+		best[key] = len(unique)
+		unique = append(unique, d)
+	}
+	return sortDecisionsByPriority(resolveOpposingEntries(unique))
+}
+
+// resolveOpposingEntries 处理同一币种相互矛盾的开多/开空决策（输入已按 (币种, 动作) 去重）
+func resolveOpposingEntries(decisions []decision.Decision) []decision.Decision {
+	longs := make(map[string]int)
+	for i, d := range decisions {
+		if d.Action == "open_long" {
+			longs[d.Symbol] = i
+		}
+	}
+	drop := make(map[int]bool)
+	for i, d := range decisions {
+		if d.Action != "open_short" {
+			continue
+		}
+		j, ok := longs[d.Symbol]
+		if !ok {
+			continue
+		}
+		long := decisions[j]
+		switch {
+		case long.Confidence > d.Confidence:
+			logger.Warnf("⚠️  %s 同时开多(%d)和开空(%d)，保留开多", d.Symbol, long.Confidence, d.Confidence)
+			drop[i] = true
+		case d.Confidence > long.Confidence:
+			logger.Warnf("⚠️  %s 同时开多(%d)和开空(%d)，保留开空", d.Symbol, long.Confidence, d.Confidence)
+			drop[j] = true
+		default:
+			logger.Warnf("⚠️  %s 开多与开空信心度相同(%d)，两者都丢弃", d.Symbol, d.Confidence)
+			drop[i], drop[j] = true, true
+		}
+	}
+	if len(drop) == 0 {
+		return decisions
+	}
+	kept := make([]decision.Decision, 0, len(decisions)-len(drop))
+	for i, d := range decisions {
+		if !drop[i] {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// limitNewEntries 限制单周期的开仓数量
+// 开仓决策按信心度从高到低保留前 maxEntries 个，其余延迟（不执行，下个周期由AI重新考虑）
+// maxEntries <= 0 表示不限制；非开仓决策不受影响，返回的执行列表保持原有的先平后开顺序
+func limitNewEntries(decisions []decision.Decision, maxEntries int) (kept, deferred []decision.Decision) {
+	if maxEntries <= 0 {
+		return decisions, nil
+	}
+
+	var entries []decision.Decision
+	for _, d := range decisions {
+		if d.Action == "open_long" || d.Action == "open_short" {
+			entries = append(entries, d)
+		}
+	}
+	if len(entries) <= maxEntries {
+		return decisions, nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Confidence > entries[j].Confidence })
+	entries, deferred = entries[:maxEntries], entries[maxEntries:]
+
+	// 重新组装：开仓部分替换为按信心度排序后保留的决策
+	kept = make([]decision.Decision, 0, len(decisions)-len(deferred))
+	inserted := false
+	for _, d := range decisions {
+		if d.Action == "open_long" || d.Action == "open_short" {
+			if !inserted {
+				kept = append(kept, entries...)
+				inserted = true
+			}
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept, deferred
+}
+
 // getCandidateCoins 获取交易员的候选币种列表
 func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 	if len(at.tradingCoins) == 0 {
@@ -1026,7 +1714,7 @@ func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 					Sources: []string{"default"}, // 标记为数据库默认币种
 				})
 			}
-			log.Printf("📋 [%s] 使用数据库默认币种: %d个币种 %v",
+			at.logger.Infof("📋 [%s] 使用数据库默认币种: %d个币种 %v",
 				at.name, len(candidateCoins), at.defaultCoins)
 			return candidateCoins, nil
 		} else {
@@ -1047,7 +1735,7 @@ func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 				})
 			}
 
-			log.Printf("📋 [%s] 数据库无默认币种配置，使用AI500+OI Top: AI500前%d + OI_Top20 = 总计%d个候选币种",
+			at.logger.Infof("📋 [%s] 数据库无默认币种配置，使用AI500+OI Top: AI500前%d + OI_Top20 = 总计%d个候选币种",
 				at.name, ai500Limit, len(candidateCoins))
 			return candidateCoins, nil
 		}
@@ -1063,7 +1751,7 @@ func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 			})
 		}
 
-		log.Printf("📋 [%s] 使用自定义币种: %d个币种 %v",
+		at.logger.Infof("📋 [%s] 使用自定义币种: %d个币种 %v",
 			at.name, len(candidateCoins), at.tradingCoins)
 		return candidateCoins, nil
 	}
@@ -3,7 +3,8 @@ package trader
 import (
 	"context"
 	"fmt"
-	"log"
+	"nofx/logger"
+	"nofx/market"
 	"strconv"
 	"sync"
 	"time"
@@ -27,14 +28,27 @@ type FuturesTrader struct {
 
 	// 缓存有效期（15秒）
 	cacheDuration time.Duration
+
+	// 止损单触发价格类型（最新成交价或标记价格）
+	stopWorkingType futures.WorkingType
 }
 
 // NewFuturesTrader 创建合约交易器
 func NewFuturesTrader(apiKey, secretKey string) *FuturesTrader {
 	client := futures.NewClient(apiKey, secretKey)
 	return &FuturesTrader{
-		client:        client,
-		cacheDuration: 15 * time.Second, // 15秒缓存
+		client:          client,
+		cacheDuration:   15 * time.Second, // 15秒缓存
+		stopWorkingType: futures.WorkingTypeContractPrice,
+	}
+}
+
+// SetStopTriggerPriceRef 设置止损单的触发价格类型（market.PriceRefMark=标记价格，其他=最新成交价）
+func (t *FuturesTrader) SetStopTriggerPriceRef(ref string) {
+	if ref == market.PriceRefMark {
+		t.stopWorkingType = futures.WorkingTypeMarkPrice
+	} else {
+		t.stopWorkingType = futures.WorkingTypeContractPrice
 	}
 }
 
@@ -45,16 +59,16 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.balanceCacheTime)
 		t.balanceCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		logger.Infof("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
 		return t.cachedBalance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取账户余额...")
+	logger.Infof("🔄 缓存过期，正在调用币安API获取账户余额...")
 	account, err := t.client.NewGetAccountService().Do(context.Background())
 	if err != nil {
-		log.Printf("❌ 币安API调用失败: %v", err)
+		logger.Errorf("❌ 币安API调用失败: %v", err)
 		return nil, fmt.Errorf("获取账户信息失败: %w", err)
 	}
 
@@ -63,7 +77,7 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	result["availableBalance"], _ = strconv.ParseFloat(account.AvailableBalance, 64)
 	result["totalUnrealizedProfit"], _ = strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
 
-	log.Printf("✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
+	logger.Infof("✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
 		account.TotalWalletBalance,
 		account.AvailableBalance,
 		account.TotalUnrealizedProfit)
@@ -84,13 +98,13 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 	if t.cachedPositions != nil && time.Since(t.positionsCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.positionsCacheTime)
 		t.positionsCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		logger.Infof("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
 		return t.cachedPositions, nil
 	}
 	t.positionsCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取持仓信息...")
+	logger.Infof("🔄 缓存过期，正在调用币安API获取持仓信息...")
 	positions, err := t.client.NewGetPositionRiskService().Do(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
@@ -154,20 +168,20 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明仓位模式已经是目标值
 		if contains(err.Error(), "No need to change margin type") {
-			log.Printf("  ✓ %s 仓位模式已是 %s", symbol, marginModeStr)
+			logger.Infof("  ✓ %s 仓位模式已是 %s", symbol, marginModeStr)
 			return nil
 		}
 		// 如果有持仓，无法更改仓位模式，但不影响交易
 		if contains(err.Error(), "Margin type cannot be changed if there exists position") {
-			log.Printf("  ⚠️ %s 有持仓，无法更改仓位模式，继续使用当前模式", symbol)
+			logger.Warnf("  ⚠️ %s 有持仓，无法更改仓位模式，继续使用当前模式", symbol)
 			return nil
 		}
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
+		logger.Warnf("  ⚠️ 设置仓位模式失败: %v", err)
 		// 不返回错误，让交易继续
 		return nil
 	}
 
-	log.Printf("  ✓ %s 仓位模式已设置为 %s", symbol, marginModeStr)
+	logger.Infof("  ✓ %s 仓位模式已设置为 %s", symbol, marginModeStr)
 	return nil
 }
 
@@ -189,7 +203,7 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 
 	// 如果当前杠杆已经是目标杠杆，跳过
 	if currentLeverage == leverage && currentLeverage > 0 {
-		log.Printf("  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
+		logger.Infof("  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
 		return nil
 	}
 
@@ -202,16 +216,16 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明杠杆已经是目标值
 		if contains(err.Error(), "No need to change") {
-			log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
+			logger.Infof("  ✓ %s 杠杆已是 %dx", symbol, leverage)
 			return nil
 		}
 		return fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	logger.Infof("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
 
 	// 切换杠杆后等待5秒（避免冷却期错误）
-	log.Printf("  ⏱ 等待5秒冷却期...")
+	logger.Infof("  ⏱ 等待5秒冷却期...")
 	time.Sleep(5 * time.Second)
 
 	return nil
@@ -221,7 +235,7 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		logger.Warnf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
 	}
 
 	// 设置杠杆
@@ -243,6 +257,7 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 		Side(futures.SideTypeBuy).
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交结果（含成交均价）
 		Quantity(quantityStr).
 		Do(context.Background())
 
@@ -250,13 +265,15 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 		return nil, fmt.Errorf("开多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开多仓成功: %s 数量: %s", symbol, quantityStr)
-	log.Printf("  订单ID: %d", order.OrderID)
+	logger.Infof("✓ 开多仓成功: %s 数量: %s", symbol, quantityStr)
+	logger.Infof("  订单ID: %d", order.OrderID)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"], _ = strconv.ParseFloat(order.AvgPrice, 64)
+	result["executedQty"], _ = strconv.ParseFloat(order.ExecutedQuantity, 64)
 	return result, nil
 }
 
@@ -264,7 +281,7 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		logger.Warnf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
 	}
 
 	// 设置杠杆
@@ -286,6 +303,7 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 		Side(futures.SideTypeSell).
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交结果（含成交均价）
 		Quantity(quantityStr).
 		Do(context.Background())
 
@@ -293,13 +311,15 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 		return nil, fmt.Errorf("开空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开空仓成功: %s 数量: %s", symbol, quantityStr)
-	log.Printf("  订单ID: %d", order.OrderID)
+	logger.Infof("✓ 开空仓成功: %s 数量: %s", symbol, quantityStr)
+	logger.Infof("  订单ID: %d", order.OrderID)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"], _ = strconv.ParseFloat(order.AvgPrice, 64)
+	result["executedQty"], _ = strconv.ParseFloat(order.ExecutedQuantity, 64)
 	return result, nil
 }
 
@@ -336,6 +356,7 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 		Side(futures.SideTypeSell).
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交结果（含成交均价）
 		Quantity(quantityStr).
 		Do(context.Background())
 
@@ -343,17 +364,19 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 		return nil, fmt.Errorf("平多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %s", symbol, quantityStr)
+	logger.Infof("✓ 平多仓成功: %s 数量: %s", symbol, quantityStr)
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		logger.Warnf("  ⚠ 取消挂单失败: %v", err)
 	}
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"], _ = strconv.ParseFloat(order.AvgPrice, 64)
+	result["executedQty"], _ = strconv.ParseFloat(order.ExecutedQuantity, 64)
 	return result, nil
 }
 
@@ -390,6 +413,7 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 		Side(futures.SideTypeBuy).
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交结果（含成交均价）
 		Quantity(quantityStr).
 		Do(context.Background())
 
@@ -397,17 +421,19 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 		return nil, fmt.Errorf("平空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %s", symbol, quantityStr)
+	logger.Infof("✓ 平空仓成功: %s 数量: %s", symbol, quantityStr)
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		logger.Warnf("  ⚠ 取消挂单失败: %v", err)
 	}
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"], _ = strconv.ParseFloat(order.AvgPrice, 64)
+	result["executedQty"], _ = strconv.ParseFloat(order.ExecutedQuantity, 64)
 	return result, nil
 }
 
@@ -421,10 +447,55 @@ func (t *FuturesTrader) CancelAllOrders(symbol string) error {
 		return fmt.Errorf("取消挂单失败: %w", err)
 	}
 
-	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	logger.Infof("  ✓ 已取消 %s 的所有挂单", symbol)
 	return nil
 }
 
+// GetBookDepth 获取前 levels 档盘口深度（用于拆单平仓）
+func (t *FuturesTrader) GetBookDepth(symbol string, levels int) (*market.BookDepth, error) {
+	return market.GetBookDepth(symbol, levels)
+}
+
+// CancelOrder 取消单个挂单
+func (t *FuturesTrader) CancelOrder(symbol string, orderID int64) error {
+	_, err := t.client.NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf("取消订单失败 (orderId=%d): %w", orderID, err)
+	}
+	return nil
+}
+
+// GetOpenOrders 获取该币种的所有挂单
+func (t *FuturesTrader) GetOpenOrders(symbol string) ([]map[string]interface{}, error) {
+	orders, err := t.client.NewListOpenOrdersService().
+		Symbol(symbol).
+		Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	var result []map[string]interface{}
+	for _, order := range orders {
+		stopPrice, _ := strconv.ParseFloat(order.StopPrice, 64)
+		price, _ := strconv.ParseFloat(order.Price, 64)
+		quantity, _ := strconv.ParseFloat(order.OrigQuantity, 64)
+		result = append(result, map[string]interface{}{
+			"orderId":      order.OrderID,
+			"symbol":       order.Symbol,
+			"side":         string(order.Side),
+			"positionSide": string(order.PositionSide),
+			"type":         string(order.Type),
+			"stopPrice":    stopPrice,
+			"price":        price,
+			"quantity":     quantity,
+		})
+	}
+	return result, nil
+}
+
 // GetMarketPrice 获取市场价格
 func (t *FuturesTrader) GetMarketPrice(symbol string) (float64, error) {
 	prices, err := t.client.NewListPricesService().Symbol(symbol).Do(context.Background())
@@ -478,7 +549,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		Type(futures.OrderTypeStopMarket).
 		StopPrice(fmt.Sprintf("%.8f", stopPrice)).
 		Quantity(quantityStr).
-		WorkingType(futures.WorkingTypeContractPrice).
+		WorkingType(t.stopWorkingType).
 		ClosePosition(true).
 		Do(context.Background())
 
@@ -486,7 +557,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		return fmt.Errorf("设置止损失败: %w", err)
 	}
 
-	log.Printf("  止损价设置: %.4f", stopPrice)
+	logger.Infof("  止损价设置: %.4f", stopPrice)
 	return nil
 }
 
@@ -524,7 +595,7 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		return fmt.Errorf("设置止盈失败: %w", err)
 	}
 
-	log.Printf("  止盈价设置: %.4f", takeProfitPrice)
+	logger.Infof("  止盈价设置: %.4f", takeProfitPrice)
 	return nil
 }
 
@@ -542,14 +613,14 @@ func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
 				if filter["filterType"] == "LOT_SIZE" {
 					stepSize := filter["stepSize"].(string)
 					precision := calculatePrecision(stepSize)
-					log.Printf("  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
+					logger.Infof("  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
 					return precision, nil
 				}
 			}
 		}
 	}
 
-	log.Printf("  ⚠ %s 未找到精度信息，使用默认精度3", symbol)
+	logger.Warnf("  ⚠ %s 未找到精度信息，使用默认精度3", symbol)
 	return 3, nil // 默认精度为3
 }
 
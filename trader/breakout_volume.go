@@ -0,0 +1,38 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// breakoutVolumeLookback 计算平均成交量的K线数量
+const breakoutVolumeLookback = 20
+
+// checkBreakoutVolume 突破开仓的成交量确认
+// 缩量突破多为假突破：要求最近一根已收盘K线的成交量同时满足相对上一根的增幅和相对均量的倍数
+func (at *AutoTrader) checkBreakoutVolume(d *decision.Decision, data *market.Data) error {
+	cfg := at.config.Risk
+	if !isBreakoutPlan(d) || (cfg.BreakoutMinVolumeChangePct <= 0 && cfg.BreakoutVolumeMultiplier <= 0) {
+		return nil
+	}
+
+	klines, err := at.getKlines(d.Symbol, cfg.BreakoutVolumeInterval)
+	if err != nil {
+		return nil // 拿不到K线时不阻止开仓
+	}
+	stats, ok := market.ComputeVolumeStats(klines, breakoutVolumeLookback)
+	if !ok {
+		return nil
+	}
+
+	if cfg.BreakoutMinVolumeChangePct > 0 && stats.ChangePct < cfg.BreakoutMinVolumeChangePct {
+		return fmt.Errorf("%s 突破量能不足：成交量较上一根K线变化 %+.1f%% < %.1f%%（当前 %.2f，上一根 %.2f，均量 %.2f）",
+			d.Symbol, stats.ChangePct, cfg.BreakoutMinVolumeChangePct, stats.Current, stats.Previous, stats.Average)
+	}
+	if cfg.BreakoutVolumeMultiplier > 0 && stats.Ratio < cfg.BreakoutVolumeMultiplier {
+		return fmt.Errorf("%s 突破量能不足：成交量为均量的 %.2f 倍 < %.2f 倍（当前 %.2f，均量 %.2f）",
+			d.Symbol, stats.Ratio, cfg.BreakoutVolumeMultiplier, stats.Current, stats.Average)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+// volumeKlines 由成交量序列生成K线（最后一根视为未收盘）
+func volumeKlines(volumes ...float64) []market.Kline {
+	klines := make([]market.Kline, len(volumes))
+	for i, v := range volumes {
+		klines[i] = market.Kline{Open: 100, High: 101, Low: 99, Close: 100, Volume: v}
+	}
+	return klines
+}
+
+func breakoutTestTrader(t *testing.T) *AutoTrader {
+	at, _ := newTestAutoTrader(t, RiskConfig{BreakoutMinVolumeChangePct: 50, BreakoutVolumeMultiplier: 1.5, BreakoutVolumeInterval: "15m"})
+	return at
+}
+
+func TestBreakoutVolumeConfirmed(t *testing.T) {
+	at := breakoutTestTrader(t)
+	// 最近收盘K线放量到200（较上一根+100%，均量2倍），最后一根未收盘的小成交量不参与
+	withKlines(at, volumeKlines(100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 200, 5))
+
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Reasoning: "放量突破前高"}
+	if err := at.checkBreakoutVolume(d, &market.Data{Symbol: "BTCUSDT"}); err != nil {
+		t.Errorf("confirmed breakout rejected: %v", err)
+	}
+}
+
+func TestBreakoutVolumeUnconfirmed(t *testing.T) {
+	cases := []struct {
+		name    string
+		volumes []float64
+		reason  string
+	}{
+		{"no volume expansion", []float64{100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 110, 500}, "较上一根K线变化"},
+		{"expansion below the average multiple", []float64{130, 130, 130, 130, 130, 130, 130, 130, 130, 50, 120, 500}, "均量"},
+	}
+	for _, tc := range cases {
+		at := breakoutTestTrader(t)
+		withKlines(at, volumeKlines(tc.volumes...))
+		d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Reasoning: "breakout above range"}
+		err := at.checkBreakoutVolume(d, &market.Data{Symbol: "BTCUSDT"})
+		if err == nil || !strings.Contains(err.Error(), tc.reason) {
+			t.Errorf("%s: err = %v, want rejection mentioning %q", tc.name, err, tc.reason)
+		}
+	}
+}
+
+func TestBreakoutVolumeSkipsNonBreakoutsAndMissingKlines(t *testing.T) {
+	at := breakoutTestTrader(t)
+	withKlines(at, volumeKlines(100, 100, 100, 50, 5))
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Reasoning: "回踩支撑做多"}
+	if err := at.checkBreakoutVolume(d, &market.Data{Symbol: "BTCUSDT"}); err != nil {
+		t.Errorf("non-breakout entry checked for volume: %v", err)
+	}
+
+	// 拿不到K线时不阻止开仓（newTestAutoTrader 默认没有K线）
+	at = breakoutTestTrader(t)
+	d.Reasoning = "突破"
+	if err := at.checkBreakoutVolume(d, &market.Data{Symbol: "BTCUSDT"}); err != nil {
+		t.Errorf("breakout rejected without klines: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+)
+
+// ConfidenceTrimRatio 根据持仓币种信心度的下降幅度计算减仓比例
+// 下降不足 trimDrop 不操作；达到 closeDrop 全部平仓；介于两者之间按 下降幅度/closeDrop 减仓
+func ConfidenceTrimRatio(prior, current, trimDrop, closeDrop int) float64 {
+	drop := prior - current
+	if trimDrop <= 0 || closeDrop <= 0 || drop < trimDrop {
+		return 0
+	}
+	if drop >= closeDrop {
+		return 1
+	}
+	return float64(drop) / float64(closeDrop)
+}
+
+// confidenceTrims 持仓币种信心度较上周期明显下降时生成减仓/平仓决策
+// 信心度取AI本周期对该币种持仓方向的决策（hold 或同向开仓）；记录本周期信心度供下周期比较。
+// decisions 中已经平仓的持仓不重复生成
+func (at *AutoTrader) confidenceTrims(positions []decision.PositionInfo, decisions []decision.Decision) []decision.Decision {
+	cfg := at.config.Risk
+	current := make(map[string]int)
+	closing := make(map[string]bool)
+	for _, d := range decisions {
+		switch d.Action {
+		case "close_long", "close_short":
+			closing[d.Symbol+"_"+d.Action] = true
+		}
+	}
+
+	var trims []decision.Decision
+	for _, pos := range positions {
+		key := pos.Symbol + "_" + pos.Side
+		confidence := heldConfidence(decisions, pos.Symbol, pos.Side)
+		if confidence <= 0 {
+			continue
+		}
+		current[key] = confidence
+
+		prior, ok := at.heldConfidence[key]
+		if !ok || closing[pos.Symbol+"_close_"+pos.Side] {
+			continue
+		}
+		ratio := ConfidenceTrimRatio(prior, confidence, cfg.TrimConfidenceDrop, cfg.CloseConfidenceDrop)
+		if ratio <= 0 {
+			continue
+		}
+		trims = append(trims, decision.Decision{
+			Symbol:     pos.Symbol,
+			Action:     "close_" + pos.Side,
+			CloseRatio: ratio,
+			Reasoning:  fmt.Sprintf("信心度从 %d 降至 %d，减仓 %.0f%%", prior, confidence, ratio*100),
+		})
+	}
+
+	at.stopsMu.Lock()
+	at.heldConfidence = current
+	at.stopsMu.Unlock()
+	return trims
+}
+
+// heldConfidenceSnapshot 各持仓最近一次AI信心度的副本（symbol_side -> 信心度，可在周期外调用）
+func (at *AutoTrader) heldConfidenceSnapshot() map[string]int {
+	at.stopsMu.RLock()
+	defer at.stopsMu.RUnlock()
+	snapshot := make(map[string]int, len(at.heldConfidence))
+	for key, confidence := range at.heldConfidence {
+		snapshot[key] = confidence
+	}
+	return snapshot
+}
+
+// heldConfidence AI本周期对持仓方向给出的信心度（0=未给出）
+func heldConfidence(decisions []decision.Decision, symbol, side string) int {
+	for _, d := range decisions {
+		if d.Symbol != symbol {
+			continue
+		}
+		if d.Action == "hold" || d.Action == "open_"+side {
+			return d.Confidence
+		}
+	}
+	return 0
+}
+
+// closeQuantity 平仓数量：部分平仓时按最近持仓数量 × 比例计算，否则返回0（全部平仓）
+func (at *AutoTrader) closeQuantity(symbol, side string, closeRatio float64) float64 {
+	if closeRatio <= 0 || closeRatio >= 1 {
+		return 0
+	}
+	for _, pos := range at.lastPositions {
+		if pos.Symbol == symbol && pos.Side == side {
+			return pos.Quantity * closeRatio
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package trader
+
+import (
+	"nofx/decision"
+	"sync"
+	"testing"
+)
+
+func TestConfidenceTrimRatio(t *testing.T) {
+	tests := []struct {
+		prior, current int
+		want           float64
+	}{
+		{80, 75, 0},
+		{80, 70, 0.5},
+		{80, 60, 1},
+	}
+	for _, tt := range tests {
+		if got := ConfidenceTrimRatio(tt.prior, tt.current, 10, 20); got != tt.want {
+			t.Errorf("ConfidenceTrimRatio(%d, %d) = %.2f, want %.2f", tt.prior, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestHeldConfidenceKeyedBySide(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	positions := []decision.PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long"},
+		{Symbol: "ETHUSDT", Side: "short"},
+	}
+	decisions := []decision.Decision{
+		{Symbol: "BTCUSDT", Action: "hold", Confidence: 80},
+		{Symbol: "ETHUSDT", Action: "hold", Confidence: 70},
+	}
+
+	// 看板在HTTP请求中读取信心度，与交易周期并发
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			at.heldConfidenceSnapshot()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		at.confidenceTrims(positions, decisions)
+	}
+	wg.Wait()
+
+	snapshot := at.heldConfidenceSnapshot()
+	if snapshot["BTCUSDT_long"] != 80 || snapshot["ETHUSDT_short"] != 70 {
+		t.Fatalf("snapshot = %v, want keys by symbol_side", snapshot)
+	}
+}
@@ -0,0 +1,116 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ConfigSchemaVersion 策略配置导出格式的版本（格式变化时递增，并在 LoadConfig 中迁移旧版本）
+const ConfigSchemaVersion = 1
+
+// StrategyConfig 可导出的策略配置（不含API密钥、私钥等凭证）
+type StrategyConfig struct {
+	AIModel              string     `json:"ai_model"`
+	Exchange             string     `json:"exchange"`
+	ScanInterval         string     `json:"scan_interval"` // 如 "3m0s"
+	InitialBalance       float64    `json:"initial_balance"`
+	BTCETHLeverage       int        `json:"btc_eth_leverage"`
+	AltcoinLeverage      int        `json:"altcoin_leverage"`
+	MaxDailyLoss         float64    `json:"max_daily_loss"`
+	MaxDrawdown          float64    `json:"max_drawdown"`
+	StopTradingTime      string     `json:"stop_trading_time"`
+	IsCrossMargin        bool       `json:"is_cross_margin"`
+	DefaultCoins         []string   `json:"default_coins"`
+	TradingCoins         []string   `json:"trading_coins"`
+	SystemPromptTemplate string     `json:"system_prompt_template"`
+	Risk                 RiskConfig `json:"risk_config"`
+}
+
+// ConfigSnapshot 带版本号的策略配置快照
+type ConfigSnapshot struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Strategy   StrategyConfig `json:"strategy"`
+}
+
+// ExportConfig 导出当前生效的策略配置（JSON），用于复现同一套参数
+func (c AutoTraderConfig) ExportConfig() ([]byte, error) {
+	snapshot := ConfigSnapshot{
+		Version:    ConfigSchemaVersion,
+		ExportedAt: time.Now().UTC(),
+		Strategy: StrategyConfig{
+			AIModel:              c.AIModel,
+			Exchange:             c.Exchange,
+			ScanInterval:         c.ScanInterval.String(),
+			InitialBalance:       c.InitialBalance,
+			BTCETHLeverage:       c.BTCETHLeverage,
+			AltcoinLeverage:      c.AltcoinLeverage,
+			MaxDailyLoss:         c.MaxDailyLoss,
+			MaxDrawdown:          c.MaxDrawdown,
+			StopTradingTime:      c.StopTradingTime.String(),
+			IsCrossMargin:        c.IsCrossMargin,
+			DefaultCoins:         c.DefaultCoins,
+			TradingCoins:         c.TradingCoins,
+			SystemPromptTemplate: c.SystemPromptTemplate,
+			Risk:                 c.Risk,
+		},
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return data, nil
+}
+
+// LoadConfig 导入 ExportConfig 导出的策略配置（校验通过后才覆盖，凭证等未导出字段保持不变）
+func (c *AutoTraderConfig) LoadConfig(data []byte) error {
+	var snapshot ConfigSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("解析配置失败: %w", err)
+	}
+	if snapshot.Version != ConfigSchemaVersion {
+		return fmt.Errorf("不支持的配置版本 %d（当前版本 %d）", snapshot.Version, ConfigSchemaVersion)
+	}
+
+	s := snapshot.Strategy
+	scanInterval, err := time.ParseDuration(s.ScanInterval)
+	if err != nil || scanInterval <= 0 {
+		return fmt.Errorf("scan_interval 无效: %q", s.ScanInterval)
+	}
+	stopTradingTime, err := time.ParseDuration(s.StopTradingTime)
+	if err != nil || stopTradingTime < 0 {
+		return fmt.Errorf("stop_trading_time 无效: %q", s.StopTradingTime)
+	}
+	if s.BTCETHLeverage <= 0 || s.AltcoinLeverage <= 0 {
+		return fmt.Errorf("杠杆倍数必须大于0（BTC/ETH %d，山寨币 %d）", s.BTCETHLeverage, s.AltcoinLeverage)
+	}
+	if s.InitialBalance < 0 {
+		return fmt.Errorf("initial_balance 不能为负: %.2f", s.InitialBalance)
+	}
+	if s.Risk.MinConfidence < 0 || s.Risk.MinConfidence > 100 {
+		return fmt.Errorf("risk_config.min_confidence 必须在0-100之间: %d", s.Risk.MinConfidence)
+	}
+
+	s.Risk.applyDefaults()
+	c.AIModel = s.AIModel
+	c.Exchange = s.Exchange
+	c.ScanInterval = scanInterval
+	c.InitialBalance = s.InitialBalance
+	c.BTCETHLeverage = s.BTCETHLeverage
+	c.AltcoinLeverage = s.AltcoinLeverage
+	c.MaxDailyLoss = s.MaxDailyLoss
+	c.MaxDrawdown = s.MaxDrawdown
+	c.StopTradingTime = stopTradingTime
+	c.IsCrossMargin = s.IsCrossMargin
+	c.DefaultCoins = s.DefaultCoins
+	c.TradingCoins = s.TradingCoins
+	c.SystemPromptTemplate = s.SystemPromptTemplate
+	c.Risk = s.Risk
+	return nil
+}
+
+// ExportConfig 导出该交易员当前生效的策略配置
+func (at *AutoTrader) ExportConfig() ([]byte, error) {
+	return at.config.ExportConfig()
+}
@@ -0,0 +1,129 @@
+package trader
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fullTestConfig() AutoTraderConfig {
+	risk := RiskConfig{
+		MinConfidence:      72,
+		DecisionSamples:    3,
+		MaxDailyTrades:     6,
+		MaxOrdersPerMinute: 2,
+		StrategyMode:       "trend",
+		StreamAIResponse:   true,
+		MaxPositionHoursByClass: map[string]float64{
+			"crypto": 48,
+		},
+	}
+	risk.applyDefaults()
+	return AutoTraderConfig{
+		AIModel:              "deepseek",
+		Exchange:             "binance",
+		ScanInterval:         5 * time.Minute,
+		InitialBalance:       2500,
+		BTCETHLeverage:       10,
+		AltcoinLeverage:      5,
+		MaxDailyLoss:         8,
+		MaxDrawdown:          25,
+		StopTradingTime:      90 * time.Minute,
+		IsCrossMargin:        true,
+		DefaultCoins:         []string{"BTCUSDT", "ETHUSDT"},
+		TradingCoins:         []string{"SOLUSDT"},
+		SystemPromptTemplate: "adaptive",
+		Risk:                 risk,
+		BinanceAPIKey:        "secret",
+	}
+}
+
+func TestConfigExportRoundTrip(t *testing.T) {
+	original := fullTestConfig()
+	data, err := original.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig: %v", err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Fatal("exported config contains credentials")
+	}
+
+	var loaded AutoTraderConfig
+	loaded.BinanceAPIKey = "kept"
+	if err := loaded.LoadConfig(data); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if loaded.BinanceAPIKey != "kept" {
+		t.Error("LoadConfig overwrote credentials")
+	}
+
+	again, err := loaded.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig after load: %v", err)
+	}
+	var first, second ConfigSnapshot
+	if err := json.Unmarshal(data, &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(again, &second); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first.Strategy, second.Strategy) {
+		t.Errorf("round trip changed the strategy:\nfirst:  %+v\nsecond: %+v", first.Strategy, second.Strategy)
+	}
+	if loaded.ScanInterval != original.ScanInterval || loaded.StopTradingTime != original.StopTradingTime {
+		t.Errorf("durations = %v/%v, want %v/%v", loaded.ScanInterval, loaded.StopTradingTime, original.ScanInterval, original.StopTradingTime)
+	}
+	if !reflect.DeepEqual(loaded.Risk, original.Risk) {
+		t.Errorf("risk config changed:\ngot:  %+v\nwant: %+v", loaded.Risk, original.Risk)
+	}
+}
+
+func TestLoadConfigRejectsUnknownVersion(t *testing.T) {
+	cfg := fullTestConfig()
+	data, err := cfg.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig: %v", err)
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	snapshot["version"] = ConfigSchemaVersion + 1
+	data, _ = json.Marshal(snapshot)
+
+	target := AutoTraderConfig{BTCETHLeverage: 3}
+	err = target.LoadConfig(data)
+	if err == nil || !strings.Contains(err.Error(), "不支持的配置版本") {
+		t.Fatalf("err = %v, want unsupported version", err)
+	}
+	if target.BTCETHLeverage != 3 {
+		t.Error("rejected config was partially applied")
+	}
+}
+
+func TestLoadConfigValidates(t *testing.T) {
+	cases := map[string]func(s *StrategyConfig){
+		"scan_interval":  func(s *StrategyConfig) { s.ScanInterval = "soon" },
+		"leverage":       func(s *StrategyConfig) { s.AltcoinLeverage = 0 },
+		"balance":        func(s *StrategyConfig) { s.InitialBalance = -1 },
+		"min_confidence": func(s *StrategyConfig) { s.Risk.MinConfidence = 120 },
+	}
+	for name, mutate := range cases {
+		cfg := fullTestConfig()
+		data, _ := cfg.ExportConfig()
+		var snapshot ConfigSnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			t.Fatal(err)
+		}
+		mutate(&snapshot.Strategy)
+		data, _ = json.Marshal(snapshot)
+
+		var target AutoTraderConfig
+		if err := target.LoadConfig(data); err == nil {
+			t.Errorf("%s: invalid config accepted", name)
+		}
+	}
+}
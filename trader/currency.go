@@ -0,0 +1,92 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 账户计价币种（未返回币种的交易所按USDT处理）
+const (
+	CurrencyUSDT = "USDT"
+	CurrencyBTC  = "BTC"
+	CurrencyETH  = "ETH"
+	CurrencyBNB  = "BNB"
+)
+
+// NormaliseToUSD 将以 currency 计价的金额换算为USD
+// USDT按1:1换算；BTC/ETH/BNB按 prices 中的USD价格换算（键为币种名，如 "BTC"）
+func NormaliseToUSD(amount float64, currency string, prices map[string]float64) (float64, error) {
+	currency = strings.ToUpper(currency)
+	switch currency {
+	case "", CurrencyUSDT:
+		return amount, nil
+	case CurrencyBTC, CurrencyETH, CurrencyBNB:
+		price := prices[currency]
+		if price <= 0 {
+			return 0, fmt.Errorf("缺少 %s 的USD价格", currency)
+		}
+		return amount * price, nil
+	default:
+		return 0, fmt.Errorf("不支持的计价币种: %s", currency)
+	}
+}
+
+// usdRate 账户计价币种换算为USD的汇率（USDT为1，BTC/ETH/BNB取交易所 <币种>USDT 的最新价）
+func (at *AutoTrader) usdRate(currency string) (float64, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == CurrencyUSDT {
+		return 1, nil
+	}
+	price, err := at.trader.GetMarketPrice(currency + CurrencyUSDT)
+	if err != nil {
+		return 0, fmt.Errorf("获取 %s 价格失败: %w", currency, err)
+	}
+	return NormaliseToUSD(1, currency, map[string]float64{currency: price})
+}
+
+// accountBalance 从 GetBalance 结果读取钱包余额、未实现盈亏和可用余额，非USDT计价的账户统一换算为USD
+// （换算失败时按原值返回并告警）。之后的净值跟踪、风控和AI上下文都使用换算后的值
+func (at *AutoTrader) accountBalance(balance map[string]interface{}) (wallet, unrealized, available float64, currency string) {
+	wallet, _ = balance["totalWalletBalance"].(float64)
+	unrealized, _ = balance["totalUnrealizedProfit"].(float64)
+	available, _ = balance["availableBalance"].(float64)
+	currency, _ = balance["currency"].(string)
+
+	rate, err := at.usdRate(currency)
+	if err != nil {
+		at.logger.Warnf("⚠️  账户余额换算USD失败，按原值处理: %v", err)
+		return wallet, unrealized, available, currency
+	}
+	return wallet * rate, unrealized * rate, available * rate, currency
+}
+
+// usdQuoteAssets 视为与USD等值的计价资产（未配置换算汇率时按1:1）
+var usdQuoteAssets = []string{"USDT", "USDC", "BUSD", "FDUSD", "USD"}
+
+// QuoteAsset 交易对的计价资产（如 "ETHBTC" → "BTC"，无法识别时为 "USDT"）
+func QuoteAsset(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	for _, quote := range append(append([]string(nil), usdQuoteAssets...), CurrencyBTC, CurrencyETH, CurrencyBNB) {
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return quote
+		}
+	}
+	return CurrencyUSDT
+}
+
+// QuoteRates 计价资产到账户结算币种的换算汇率（1单位计价资产 = 多少结算币种，如 {"BTC": 65000}）
+// 未配置的计价资产按1:1换算，保持USD计价交易对的原有行为
+type QuoteRates map[string]float64
+
+// Rate 交易对的计价资产换算为结算币种的汇率
+func (r QuoteRates) Rate(symbol string) float64 {
+	if rate := r[QuoteAsset(symbol)]; rate > 0 {
+		return rate
+	}
+	return 1
+}
+
+// ToSettlement 将以交易对计价资产表示的金额（风险、保证金、盈亏）换算为结算币种
+func (r QuoteRates) ToSettlement(symbol string, amount float64) float64 {
+	return amount * r.Rate(symbol)
+}
@@ -0,0 +1,111 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+)
+
+func TestAccountBalanceConvertsToUSD(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.prices["BTCUSDT"] = 60000
+	balance := map[string]interface{}{
+		"currency":              "btc",
+		"totalWalletBalance":    0.1,
+		"totalUnrealizedProfit": -0.01,
+		"availableBalance":      0.05,
+	}
+
+	wallet, unrealized, available, currency := at.accountBalance(balance)
+	if wallet != 6000 || unrealized != -600 || available != 3000 || currency != "btc" {
+		t.Fatalf("got wallet=%.2f unrealized=%.2f available=%.2f currency=%s", wallet, unrealized, available, currency)
+	}
+	if n := mock.Count("GetMarketPrice"); n != 1 {
+		t.Errorf("GetMarketPrice called %d times, want 1", n)
+	}
+}
+
+func TestAccountBalanceUSDTUnchanged(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	wallet, _, available, _ := at.accountBalance(map[string]interface{}{"totalWalletBalance": 1000.0, "availableBalance": 800.0})
+	if wallet != 1000 || available != 800 {
+		t.Fatalf("got wallet=%.2f available=%.2f", wallet, available)
+	}
+	if n := mock.Count("GetMarketPrice"); n != 0 {
+		t.Errorf("GetMarketPrice called %d times for a USDT account", n)
+	}
+}
+
+func TestAccountBalanceKeepsRawValuesWithoutPrice(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	wallet, _, _, _ := at.accountBalance(map[string]interface{}{"currency": "ETH", "totalWalletBalance": 2.0})
+	if wallet != 2 {
+		t.Fatalf("wallet = %.2f, want raw value when the price lookup fails", wallet)
+	}
+}
+
+func TestQuoteRatesConvertNonUSDQuote(t *testing.T) {
+	rates := QuoteRates{"BTC": 65000, "ETH": 3000}
+	cases := []struct {
+		symbol string
+		quote  string
+		amount float64
+		want   float64
+	}{
+		{"ETHBTC", "BTC", 0.05, 3250},
+		{"SOLETH", "ETH", 0.5, 1500},
+		{"BTCUSDT", "USDT", 100, 100},
+		{"ETHUSDC", "USDC", 100, 100}, // 未配置汇率按1:1
+		{"BNBFDUSD", "FDUSD", 100, 100},
+	}
+	for _, tc := range cases {
+		if got := QuoteAsset(tc.symbol); got != tc.quote {
+			t.Errorf("QuoteAsset(%s) = %s, want %s", tc.symbol, got, tc.quote)
+		}
+		if got := rates.ToSettlement(tc.symbol, tc.amount); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("ToSettlement(%s, %v) = %v, want %v", tc.symbol, tc.amount, got, tc.want)
+		}
+	}
+	if got := QuoteRates(nil).ToSettlement("ETHBTC", 0.05); got != 0.05 {
+		t.Errorf("nil rates converted %v, want 1:1", got)
+	}
+}
+
+func TestPortfolioRiskConvertsBTCQuotedPositions(t *testing.T) {
+	rates := QuoteRates{"BTC": 65000}
+	// ETHBTC 多仓：(0.05 - 0.045) × 10 = 0.05 BTC = 3250 USDT
+	positions := []decision.PositionInfo{{Symbol: "ETHBTC", Side: "long", EntryPrice: 0.05, Quantity: 10, MarginUsed: 100}}
+	stops := map[string]float64{"ETHBTC_long": 0.045}
+	// SOLBTC 计划：0.2 BTC 仓位 × 5% 止损距离 = 0.01 BTC = 650 USDT
+	plan := &decision.Decision{Symbol: "SOLBTC", Action: "open_long", PositionSizeUSD: 0.2, StopLoss: 0.0019}
+
+	risk := CalculatePortfolioRisk(positions, stops, plan, 0.002, 100000, rates)
+	if math.Abs(risk.ExistingRisk-3250) > 1e-6 || math.Abs(risk.PlanRisk-650) > 1e-6 {
+		t.Errorf("existing/plan = %.4f/%.4f, want 3250/650", risk.ExistingRisk, risk.PlanRisk)
+	}
+	if math.Abs(risk.TotalRiskPct-0.039) > 1e-9 {
+		t.Errorf("total risk pct = %.6f, want 0.039", risk.TotalRiskPct)
+	}
+
+	// 不换算时风险被低估为BTC数量
+	if raw := CalculatePortfolioRisk(positions, stops, plan, 0.002, 100000, nil); raw.TotalRisk > 1 {
+		t.Errorf("unconverted total = %.4f, want the raw BTC amount", raw.TotalRisk)
+	}
+}
+
+func TestCheckPortfolioRiskUsesQuoteConversion(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MaxPortfolioRiskPct: 0.05})
+	account := RiskAccount{Equity: 10000}
+	data := &market.Data{Symbol: "ETHBTC", CurrentPrice: 0.05}
+	// 1 BTC 仓位 × 10% 止损距离 = 0.1 BTC
+	d := &decision.Decision{Symbol: "ETHBTC", Action: "open_long", PositionSizeUSD: 1, StopLoss: 0.045}
+
+	if ok, reason := at.checkPortfolioRisk(d, account, data); !ok {
+		t.Errorf("1:1 conversion rejected: %s", reason)
+	}
+	at.config.Risk.QuoteConversionRates = QuoteRates{"BTC": 65000} // 0.1 BTC = 6500 USDT > 500
+	if ok, _ := at.checkPortfolioRisk(d, account, data); ok {
+		t.Error("BTC-quoted risk of 6500 USDT accepted under a 500 USDT cap")
+	}
+}
@@ -0,0 +1,54 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"testing"
+	"time"
+)
+
+func TestCheckEntryRiskAccumulatesRiskTime(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	at.riskRules = []RiskRule{NewRiskRule("slow", func(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+		time.Sleep(5 * time.Millisecond)
+		return true, ""
+	})}
+
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		d := &decision.Decision{Symbol: symbol, Action: "open_long"}
+		if err := at.checkEntryRisk(d, &market.Data{Symbol: symbol}, "long"); err != nil {
+			t.Fatalf("checkEntryRisk(%s): %v", symbol, err)
+		}
+	}
+	// 同一周期内多次风控检查的耗时累加
+	if at.cycleRiskTime < 10*time.Millisecond {
+		t.Errorf("cycleRiskTime = %v, want at least 10ms across two checks", at.cycleRiskTime)
+	}
+}
+
+func TestFinishCycleTimingRecordsTotal(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	record := &logger.DecisionRecord{Timing: logger.CycleTiming{DataMs: 120, AIMs: 3400, RiskMs: 15, ExecMs: 800}}
+
+	at.finishCycleTiming(record, time.Now().Add(-5*time.Second))
+
+	if record.Timing.TotalMs < 5000 {
+		t.Errorf("TotalMs = %d, want at least 5000", record.Timing.TotalMs)
+	}
+	if at.lastCycleTiming != record.Timing {
+		t.Errorf("lastCycleTiming = %+v, want %+v", at.lastCycleTiming, record.Timing)
+	}
+	// 各阶段耗时保持不变
+	if got := at.lastCycleTiming; got.DataMs != 120 || got.AIMs != 3400 || got.RiskMs != 15 || got.ExecMs != 800 {
+		t.Errorf("stage timings = %+v, want them unchanged", got)
+	}
+}
+
+func TestCycleTimingSummary(t *testing.T) {
+	timing := logger.CycleTiming{DataMs: 120, AIMs: 3400, RiskMs: 15, ExecMs: 800, TotalMs: 4400}
+	want := "总计4400ms | 数据120ms | AI 3400ms | 风控15ms | 执行800ms"
+	if got := timing.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
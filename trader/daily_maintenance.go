@@ -0,0 +1,28 @@
+package trader
+
+import (
+	"time"
+)
+
+// DailyMaintenance 每日维护：跨UTC自然日时统一重置所有按日统计的状态
+// 包括日盈亏、日起始净值（下次获取账户信息时以当时净值重新锚定）和每日下单计数。
+// 同一UTC日内重复调用不会重复重置，返回本次是否执行了重置
+func (at *AutoTrader) DailyMaintenance(now time.Time) bool {
+	if sameUTCDay(at.haltSnapshot().LastResetTime, now) {
+		return false
+	}
+	at.logger.Infof("📅 [%s] 每日维护（UTC %s）", at.name, now.UTC().Format("2006-01-02"))
+	at.ResetDailyStats()
+	at.stateMu.Lock()
+	at.lastResetTime = now
+	at.stateMu.Unlock()
+	at.orderLimiter.rollDay(now)
+	return true
+}
+
+// sameUTCDay 两个时间是否在同一个UTC自然日
+func sameUTCDay(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
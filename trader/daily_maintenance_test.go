@@ -0,0 +1,51 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyMaintenanceRunsOncePerUTCDay(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	clock := &fixedClock{t: time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	at.stateMu.Lock()
+	at.dailyPnL = -50
+	at.stateMu.Unlock()
+
+	clock.Advance(2 * time.Hour)
+	if at.DailyMaintenance(clock.Now()) {
+		t.Fatal("maintenance ran within the same UTC day as the clock anchor")
+	}
+	clock.Advance(time.Hour)
+	if at.DailyMaintenance(clock.Now()) {
+		t.Fatal("second call on the same day reset again")
+	}
+	if got := at.equitySnapshot().DailyPnL; got != -50 {
+		t.Errorf("dailyPnL = %v, want -50 untouched", got)
+	}
+}
+
+func TestDailyMaintenanceResetsAcrossDayBoundary(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	clock := &fixedClock{t: time.Date(2025, 3, 10, 23, 30, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	at.stateMu.Lock()
+	at.dailyPnL = -50
+	at.dailyStartEquity = 1000
+	at.stateMu.Unlock()
+
+	clock.Advance(time.Hour) // 跨过UTC零点
+	if !at.DailyMaintenance(clock.Now()) {
+		t.Fatal("maintenance did not run after the UTC day boundary")
+	}
+	if got := at.equitySnapshot().DailyPnL; got != 0 {
+		t.Errorf("dailyPnL = %v, want reset to 0", got)
+	}
+	if got := at.haltSnapshot().LastResetTime; !got.Equal(clock.Now()) {
+		t.Errorf("lastResetTime = %v, want %v", got, clock.Now())
+	}
+	if at.DailyMaintenance(clock.Now().Add(time.Hour)) {
+		t.Error("maintenance ran twice on the new day")
+	}
+}
@@ -0,0 +1,126 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"nofx/decision"
+	"nofx/logger"
+	"sync"
+	"time"
+)
+
+// 发布的决策事件类型（每个交易周期依次发布）
+const (
+	EventAIDecision      = "ai_decision"      // AI给出的原始决策（含思维链）
+	EventExecutionPlan   = "execution_plan"   // 排序、去重、限流后实际要执行的决策
+	EventExecutionResult = "execution_result" // 各决策的执行结果
+)
+
+// DecisionEvent 发布到外部的决策事件
+type DecisionEvent struct {
+	Type      string                  `json:"type"`
+	TraderID  string                  `json:"trader_id"`
+	Cycle     int                     `json:"cycle"` // AI调用次数（同一周期的事件相同）
+	Timestamp time.Time               `json:"timestamp"`
+	CoTTrace  string                  `json:"cot_trace,omitempty"`
+	Decisions []decision.Decision     `json:"decisions,omitempty"`
+	Results   []logger.DecisionAction `json:"results,omitempty"`
+}
+
+// DecisionPublisher 决策发布接口
+// 接入消息队列（Kafka、NATS等）时实现此接口，由外部消费者执行或审计决策；
+// 默认使用 NoopDecisionPublisher。发布失败只记录警告，不影响本地执行
+type DecisionPublisher interface {
+	Publish(ctx context.Context, event DecisionEvent) error
+}
+
+// NoopDecisionPublisher 空实现（未配置发布时使用）
+type NoopDecisionPublisher struct{}
+
+// Publish 不做任何事
+func (NoopDecisionPublisher) Publish(ctx context.Context, event DecisionEvent) error {
+	return nil
+}
+
+// MemoryDecisionPublisher 内存实现：按顺序保存所有事件（用于调试或进程内消费）
+type MemoryDecisionPublisher struct {
+	mu     sync.Mutex
+	events []DecisionEvent
+}
+
+// Publish 追加事件
+func (p *MemoryDecisionPublisher) Publish(ctx context.Context, event DecisionEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events 已发布事件的副本
+func (p *MemoryDecisionPublisher) Events() []DecisionEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]DecisionEvent(nil), p.events...)
+}
+
+// DefaultDecisionChannel Redis发布决策事件的默认频道
+const DefaultDecisionChannel = "nofx:decisions"
+
+// RedisDecisionPublisher 通过Redis PUBLISH 发布决策事件（JSON），外部消费者订阅同一频道即可执行或审计
+type RedisDecisionPublisher struct {
+	client  *redisClient
+	channel string
+}
+
+// NewRedisDecisionPublisher 创建Redis决策发布器
+// publishURL 格式: redis://[:password@]host:port[/db][?channel=频道名]，未指定频道时使用 DefaultDecisionChannel
+func NewRedisDecisionPublisher(publishURL string) (*RedisDecisionPublisher, error) {
+	client, err := newRedisClient(publishURL)
+	if err != nil {
+		return nil, err
+	}
+	channel := DefaultDecisionChannel
+	if u, err := url.Parse(publishURL); err == nil && u.Query().Get("channel") != "" {
+		channel = u.Query().Get("channel")
+	}
+	return &RedisDecisionPublisher{client: client, channel: channel}, nil
+}
+
+// Publish 序列化事件并发布到频道
+func (p *RedisDecisionPublisher) Publish(ctx context.Context, event DecisionEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化决策事件失败: %w", err)
+	}
+	if _, err := p.client.do("PUBLISH", p.channel, string(data)); err != nil {
+		return fmt.Errorf("发布决策事件失败: %w", err)
+	}
+	return nil
+}
+
+// SetDecisionPublisher 设置决策发布器（nil 表示关闭发布）
+func (at *AutoTrader) SetDecisionPublisher(publisher DecisionPublisher) {
+	if publisher == nil {
+		publisher = NoopDecisionPublisher{}
+	}
+	at.publisher = publisher
+}
+
+// publishEvent 发布本周期的决策事件（失败时只记录警告）
+func (at *AutoTrader) publishEvent(event DecisionEvent) {
+	event.TraderID = at.id
+	event.Cycle = at.callCount
+	event.Timestamp = at.now()
+	ctx := at.cycleCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := at.publisher.Publish(ctx, event); err != nil {
+		at.logger.Warnf("⚠️ 发布决策事件失败 (%s): %v", event.Type, err)
+	}
+}
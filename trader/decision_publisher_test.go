@@ -0,0 +1,138 @@
+package trader
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"nofx/decision"
+	"nofx/logger"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecisionPublisherReceivesCycleSequence(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 105)
+	at.SetPriceOracle(staticPriceOracle{"BTCUSDT": 105})
+	publisher := &MemoryDecisionPublisher{}
+	at.SetDecisionPublisher(publisher)
+	at.callCount = 7
+
+	fd := &decision.FullDecision{
+		CoTTrace: "BTC 动能减弱，平多；ETH 观望",
+		Decisions: []decision.Decision{
+			{Symbol: "ETHUSDT", Action: "wait"},
+			{Symbol: "BTCUSDT", Action: "close_long"},
+		},
+	}
+	record := &logger.DecisionRecord{Success: true}
+	if err := at.executeCycleDecisions(fd, &decision.Context{}, record, time.Now()); err != nil {
+		t.Fatalf("executeCycleDecisions: %v", err)
+	}
+
+	events := publisher.Events()
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+		if e.TraderID != "test" || e.Cycle != 7 {
+			t.Errorf("%s event trader/cycle = %s/%d, want test/7", e.Type, e.TraderID, e.Cycle)
+		}
+	}
+	want := []string{EventAIDecision, EventExecutionPlan, EventExecutionResult}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+
+	if ai := events[0]; ai.CoTTrace != fd.CoTTrace || len(ai.Decisions) != 2 {
+		t.Errorf("ai_decision = %+v, want the raw AI decisions with CoT", ai)
+	}
+	plan := events[1].Decisions
+	if len(plan) != 2 || plan[0].Action != "close_long" {
+		t.Errorf("execution_plan = %+v, want close_long first", plan)
+	}
+	results := events[2].Results
+	if len(results) != 2 || results[0].Action != "close_long" || !results[0].Success {
+		t.Errorf("execution_result = %+v, want a successful close_long first", results)
+	}
+	if n := mock.Count("CloseLong"); n != 1 {
+		t.Errorf("CloseLong called %d times, want 1", n)
+	}
+}
+
+// readCommand 读取一条RESP数组命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil { // $len
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+func TestRedisDecisionPublisherPublishesJSON(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	commands := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		args, err := readCommand(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		commands <- args
+		conn.Write([]byte(":1\r\n"))
+	}()
+
+	publisher, err := NewRedisDecisionPublisher("redis://" + ln.Addr().String() + "?channel=audit")
+	if err != nil {
+		t.Fatalf("NewRedisDecisionPublisher: %v", err)
+	}
+	event := DecisionEvent{Type: EventExecutionPlan, TraderID: "t1", Decisions: []decision.Decision{{Symbol: "BTCUSDT", Action: "open_long"}}}
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	args := <-commands
+	if len(args) != 3 || args[0] != "PUBLISH" || args[1] != "audit" {
+		t.Fatalf("command = %v, want PUBLISH audit <json>", args)
+	}
+	var got DecisionEvent
+	if err := json.Unmarshal([]byte(args[2]), &got); err != nil || got.TraderID != "t1" || len(got.Decisions) != 1 {
+		t.Errorf("payload = %s (%v), want the published event", args[2], err)
+	}
+}
+
+func TestRedisDecisionPublisherDefaultChannel(t *testing.T) {
+	publisher, err := NewRedisDecisionPublisher("redis://localhost:6379/1")
+	if err != nil {
+		t.Fatalf("NewRedisDecisionPublisher: %v", err)
+	}
+	if publisher.channel != DefaultDecisionChannel || publisher.client.db != 1 {
+		t.Errorf("channel/db = %s/%d, want %s/1", publisher.channel, publisher.client.db, DefaultDecisionChannel)
+	}
+	if _, err := NewRedisDecisionPublisher("http://localhost"); err == nil {
+		t.Error("non-redis URL accepted")
+	}
+}
@@ -0,0 +1,102 @@
+package trader
+
+import (
+	"errors"
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+	"time"
+)
+
+func TestReuseLastDecisionRevalidatesStops(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{DecisionReuseMinutes: 30, ConfidenceDecayPerMinute: 1})
+	clock := &fixedClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	at.lastDecision = &decision.FullDecision{
+		Timestamp: clock.Now(),
+		Decisions: []decision.Decision{
+			{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 95, TakeProfit: 120, Confidence: 80, PositionSizeUSD: 100},
+			{Symbol: "ETHUSDT", Action: "open_short", StopLoss: 2100, TakeProfit: 1800, Confidence: 80, PositionSizeUSD: 100},
+			{Symbol: "SOLUSDT", Action: "open_long", StopLoss: 90, TakeProfit: 150, Confidence: 80, PositionSizeUSD: 100},
+			{Symbol: "BNBUSDT", Action: "close_long"},
+		},
+	}
+	clock.Advance(10 * time.Minute)
+
+	ctx := &decision.Context{
+		MarketDataMap: map[string]*market.Data{
+			"BTCUSDT": {CurrentPrice: 94},   // 已跌破止损
+			"ETHUSDT": {CurrentPrice: 2000}, // 仍在止损止盈之间
+		},
+		Positions: []decision.PositionInfo{{Symbol: "BNBUSDT", Side: "long", Quantity: 1}},
+	}
+	reused := at.reuseLastDecision(errors.New("timeout"), ctx)
+	if reused == nil {
+		t.Fatal("decision not reused")
+	}
+
+	want := []string{"wait", "open_short", "wait", "close_long"}
+	for i, d := range reused.Decisions {
+		if d.Action != want[i] {
+			t.Errorf("%s action = %s, want %s", d.Symbol, d.Action, want[i])
+		}
+	}
+	if c := reused.Decisions[1].Confidence; c != 70 {
+		t.Errorf("confidence = %d, want 70 after 10 minutes of decay", c)
+	}
+}
+
+func TestReuseLastDecisionUsesClockForAge(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{DecisionReuseMinutes: 30})
+	clock := &fixedClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	at.lastDecision = &decision.FullDecision{Timestamp: clock.Now(), Decisions: []decision.Decision{{Symbol: "BTCUSDT", Action: "hold"}}}
+
+	clock.Advance(31 * time.Minute)
+	if reused := at.reuseLastDecision(errors.New("timeout"), &decision.Context{}); reused != nil {
+		t.Fatal("decision older than DecisionReuseMinutes was reused")
+	}
+}
+
+func TestReuseLastDecisionSkipsExecutedAndHeldActions(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{DecisionReuseMinutes: 30, AllowPositionAdds: true})
+	clock := &fixedClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	at.lastDecision = &decision.FullDecision{
+		Timestamp: clock.Now(),
+		Decisions: []decision.Decision{
+			{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 95, TakeProfit: 120, Confidence: 80, PositionSizeUSD: 100},
+			{Symbol: "ETHUSDT", Action: "open_short", StopLoss: 2100, TakeProfit: 1800, Confidence: 80, PositionSizeUSD: 100},
+			{Symbol: "SOLUSDT", Action: "open_long", StopLoss: 90, TakeProfit: 150, Confidence: 80, PositionSizeUSD: 100},
+			{Symbol: "BNBUSDT", Action: "close_long"},
+			{Symbol: "XRPUSDT", Action: "hold"},
+		},
+	}
+	// 上次开了BTC多仓（仍持有）和SOL多仓（已被止损）
+	at.lastExecuted = map[string]bool{"BTCUSDT:open_long": true, "SOLUSDT:open_long": true}
+	clock.Advance(5 * time.Minute)
+
+	ctx := &decision.Context{
+		MarketDataMap: map[string]*market.Data{
+			"BTCUSDT": {CurrentPrice: 101},
+			"ETHUSDT": {CurrentPrice: 2000},
+			"SOLUSDT": {CurrentPrice: 100},
+		},
+		Positions: []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "long", Quantity: 1}},
+	}
+	reused := at.reuseLastDecision(errors.New("timeout"), ctx)
+	if reused == nil {
+		t.Fatal("decision not reused")
+	}
+
+	// 允许加仓时也不能因复用再次开BTC多仓；BNB已无持仓，平仓改为观望
+	want := []string{"wait", "open_short", "wait", "wait", "hold"}
+	for i, d := range reused.Decisions {
+		if d.Action != want[i] {
+			t.Errorf("%s action = %s, want %s", d.Symbol, d.Action, want[i])
+		}
+	}
+	if at.lastDecision.Decisions[0].Action != "open_long" {
+		t.Error("reuse modified the stored decision")
+	}
+}
@@ -0,0 +1,52 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+)
+
+func actions(ds []decision.Decision) []string {
+	out := make([]string, len(ds))
+	for i, d := range ds {
+		out[i] = d.Symbol + ":" + d.Action
+	}
+	return out
+}
+
+func TestDeduplicateDecisionsKeepsHighestConfidence(t *testing.T) {
+	got := deduplicateDecisions([]decision.Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70},
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 85},
+	})
+	if len(got) != 1 || got[0].Confidence != 85 {
+		t.Fatalf("got %+v, want a single open_long with confidence 85", got)
+	}
+}
+
+func TestDeduplicateDecisionsResolvesOpposingEntries(t *testing.T) {
+	got := deduplicateDecisions([]decision.Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70},
+		{Symbol: "BTCUSDT", Action: "open_short", Confidence: 80},
+		{Symbol: "ETHUSDT", Action: "open_long", Confidence: 90},
+		{Symbol: "ETHUSDT", Action: "open_short", Confidence: 60},
+	})
+	if len(got) != 2 {
+		t.Fatalf("got %v, want one entry per symbol", actions(got))
+	}
+	for _, d := range got {
+		if (d.Symbol == "BTCUSDT" && d.Action != "open_short") || (d.Symbol == "ETHUSDT" && d.Action != "open_long") {
+			t.Errorf("got %v, want the higher-confidence side for each symbol", actions(got))
+		}
+	}
+}
+
+func TestDeduplicateDecisionsDropsTiedOpposingEntries(t *testing.T) {
+	got := deduplicateDecisions([]decision.Decision{
+		{Symbol: "BTCUSDT", Action: "close_long", Confidence: 80},
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 75},
+		{Symbol: "BTCUSDT", Action: "open_short", Confidence: 75},
+	})
+	if len(got) != 1 || got[0].Action != "close_long" {
+		t.Fatalf("got %v, want only close_long", actions(got))
+	}
+}
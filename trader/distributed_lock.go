@@ -0,0 +1,91 @@
+package trader
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DistributedLock 分布式锁接口
+// 多实例部署（如主备）共用同一交易账户时，用于保证同一时刻只有一个实例执行交易周期
+type DistributedLock interface {
+	// Acquire 尝试获取锁，ttl 到期后自动释放（防止实例崩溃后死锁）
+	Acquire(key string, ttl time.Duration) (bool, error)
+	// Release 释放锁（只会释放本实例持有的锁）
+	Release(key string) error
+}
+
+// NoopDistributedLock 空实现（单实例部署时使用，总是获取成功）
+type NoopDistributedLock struct{}
+
+// Acquire 总是获取成功
+func (NoopDistributedLock) Acquire(key string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Release 无需释放
+func (NoopDistributedLock) Release(key string) error {
+	return nil
+}
+
+// releaseScript 仅当锁的值等于本实例token时才删除，避免误删其他实例的锁
+const releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// RedisDistributedLock 基于Redis的分布式锁（SET key value NX EX）
+type RedisDistributedLock struct {
+	client *redisClient
+	token  string // 本实例的唯一标识，作为锁的值
+}
+
+// NewRedisDistributedLock 创建Redis分布式锁
+// lockURL 格式: redis://[:password@]host:port[/db]
+func NewRedisDistributedLock(lockURL string) (*RedisDistributedLock, error) {
+	client, err := newRedisClient(lockURL)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	buf := make([]byte, 8)
+	rand.Read(buf)
+
+	return &RedisDistributedLock{
+		client: client,
+		token:  fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(buf)),
+	}, nil
+}
+
+// NewDistributedLock 根据配置创建分布式锁（未配置地址时返回空实现）
+func NewDistributedLock(lockURL string) (DistributedLock, error) {
+	if lockURL == "" {
+		return NoopDistributedLock{}, nil
+	}
+	return NewRedisDistributedLock(lockURL)
+}
+
+// Acquire 获取锁
+func (l *RedisDistributedLock) Acquire(key string, ttl time.Duration) (bool, error) {
+	seconds := int(ttl.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	reply, err := l.client.do("SET", key, l.token, "NX", "EX", strconv.Itoa(seconds))
+	if err != nil {
+		return false, fmt.Errorf("获取分布式锁失败: %w", err)
+	}
+
+	// 成功返回 +OK，锁已被占用返回空值
+	return reply == "OK", nil
+}
+
+// Release 释放锁
+func (l *RedisDistributedLock) Release(key string) error {
+	if _, err := l.client.do("EVAL", releaseScript, "1", key, l.token); err != nil {
+		return fmt.Errorf("释放分布式锁失败: %w", err)
+	}
+	return nil
+}
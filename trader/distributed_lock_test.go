@@ -0,0 +1,169 @@
+package trader
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 支持分布式锁所需命令（AUTH/SELECT/SET NX EX/EVAL 释放脚本）的内存Redis
+type fakeRedis struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	values   map[string]string
+	commands [][]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	r := &fakeRedis{ln: ln, values: make(map[string]string)}
+	t.Cleanup(func() { ln.Close() })
+	go r.serve()
+	return r
+}
+
+func (r *fakeRedis) URL() string {
+	return "redis://:secret@" + r.ln.Addr().String() + "/2"
+}
+
+func (r *fakeRedis) serve() {
+	for {
+		conn, err := r.ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				args, err := readCommand(reader)
+				if err != nil {
+					return
+				}
+				conn.Write([]byte(r.handle(args)))
+			}
+		}()
+	}
+}
+
+func (r *fakeRedis) handle(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, args)
+
+	switch args[0] {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "SET": // SET key value NX EX seconds
+		if _, held := r.values[args[1]]; held {
+			return "$-1\r\n"
+		}
+		r.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "EVAL": // EVAL releaseScript 1 key token
+		if r.values[args[3]] != args[4] {
+			return ":0\r\n"
+		}
+		delete(r.values, args[3])
+		return ":1\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func (r *fakeRedis) value(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.values[key]
+	return v, ok
+}
+
+func (r *fakeRedis) lastCommand(name string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.commands) - 1; i >= 0; i-- {
+		if r.commands[i][0] == name {
+			return r.commands[i]
+		}
+	}
+	return nil
+}
+
+func TestRedisDistributedLockAcquireAndContention(t *testing.T) {
+	redis := newFakeRedis(t)
+	a, err := NewRedisDistributedLock(redis.URL())
+	if err != nil {
+		t.Fatalf("NewRedisDistributedLock: %v", err)
+	}
+	b, _ := NewRedisDistributedLock(redis.URL())
+
+	ok, err := a.Acquire("cycle_lock_t1", 90*time.Second)
+	if err != nil || !ok {
+		t.Fatalf("first Acquire = %v, %v; want true", ok, err)
+	}
+	if cmd := redis.lastCommand("SET"); len(cmd) != 6 || cmd[2] != a.token || cmd[3] != "NX" || cmd[4] != "EX" || cmd[5] != "90" {
+		t.Errorf("SET command = %v, want SET key token NX EX 90", cmd)
+	}
+	if cmd := redis.lastCommand("SELECT"); len(cmd) != 2 || cmd[1] != "2" {
+		t.Errorf("SELECT command = %v, want database 2", cmd)
+	}
+
+	// 其他实例获取同一把锁失败
+	if ok, err := b.Acquire("cycle_lock_t1", time.Minute); err != nil || ok {
+		t.Fatalf("contended Acquire = %v, %v; want false", ok, err)
+	}
+	// 不同的锁互不影响
+	if ok, _ := b.Acquire("cycle_lock_t2", time.Minute); !ok {
+		t.Error("Acquire of another key failed")
+	}
+}
+
+func TestRedisDistributedLockReleaseChecksToken(t *testing.T) {
+	redis := newFakeRedis(t)
+	a, _ := NewRedisDistributedLock(redis.URL())
+	b, _ := NewRedisDistributedLock(redis.URL())
+
+	if ok, _ := a.Acquire("cycle_lock_t1", time.Minute); !ok {
+		t.Fatal("Acquire failed")
+	}
+
+	// 非持有者释放不会删除锁
+	if err := b.Release("cycle_lock_t1"); err != nil {
+		t.Fatalf("Release by other instance: %v", err)
+	}
+	if holder, ok := redis.value("cycle_lock_t1"); !ok || holder != a.token {
+		t.Fatalf("lock holder = %q (%v), want the first instance", holder, ok)
+	}
+
+	if err := a.Release("cycle_lock_t1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, ok := redis.value("cycle_lock_t1"); ok {
+		t.Fatal("lock still held after release by its owner")
+	}
+	if ok, _ := b.Acquire("cycle_lock_t1", time.Minute); !ok {
+		t.Error("lock not available after release")
+	}
+}
+
+func TestRedisDistributedLockReportsServerErrors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // 地址不可连接
+
+	lock, _ := NewRedisDistributedLock("redis://" + addr)
+	lock.client.timeout = 100 * time.Millisecond
+	if _, err := lock.Acquire("cycle_lock_t1", time.Minute); err == nil {
+		t.Error("Acquire succeeded without a server")
+	}
+}
@@ -0,0 +1,198 @@
+package trader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DrawdownHardStop 最大回撤硬止损
+// 净值相对历史最高回撤达到阈值后停止开新仓，且不会随冷却时间自动恢复，
+// 只能手动恢复（ManualResumeTrading）或由每周定时重置解除。
+// 解除后从当时的净值重新计算最高净值，避免未回升的回撤立即再次触发
+type DrawdownHardStop struct {
+	ThresholdPct float64 // 回撤阈值（如0.3=30%）
+
+	mu          sync.Mutex
+	peak        float64 // 自上次解除以来的最高净值
+	triggered   bool
+	triggeredAt time.Time
+	drawdown    float64
+}
+
+// NewDrawdownHardStop 创建回撤硬止损
+func NewDrawdownHardStop(thresholdPct float64) *DrawdownHardStop {
+	return &DrawdownHardStop{ThresholdPct: thresholdPct}
+}
+
+// Check 记录当前净值并检查相对最高净值的回撤，本次新触发时返回true
+func (s *DrawdownHardStop) Check(now time.Time, equity float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if equity > s.peak {
+		s.peak = equity
+	}
+	if s.triggered || s.ThresholdPct <= 0 || s.peak <= 0 || equity <= 0 {
+		return false
+	}
+	drawdown := (s.peak - equity) / s.peak
+	if drawdown < s.ThresholdPct {
+		return false
+	}
+	s.trigger(now, drawdown)
+	return true
+}
+
+// Trigger 直接触发硬止损（如从快照恢复）
+func (s *DrawdownHardStop) Trigger(at time.Time, drawdown float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trigger(at, drawdown)
+}
+
+func (s *DrawdownHardStop) trigger(at time.Time, drawdown float64) {
+	s.triggered = true
+	s.triggeredAt = at
+	s.drawdown = drawdown
+}
+
+// Drawdown 触发时的回撤比例（未触发为0）
+func (s *DrawdownHardStop) Drawdown() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.triggered {
+		return 0
+	}
+	return s.drawdown
+}
+
+// Status 是否已触发及原因
+func (s *DrawdownHardStop) Status() (triggered bool, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.triggered {
+		return false, ""
+	}
+	return true, fmt.Sprintf("回撤 %.1f%% ≥ %.1f%%（%s 触发）",
+		s.drawdown*100, s.ThresholdPct*100, s.triggeredAt.Format("2006-01-02 15:04"))
+}
+
+// Reset 解除硬止损
+func (s *DrawdownHardStop) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.triggered = false
+	s.triggeredAt = time.Time{}
+	s.drawdown = 0
+	s.peak = 0
+}
+
+// weeklySchedule 每周定时（UTC）
+type weeklySchedule struct {
+	day    time.Weekday
+	hour   int
+	minute int
+	last   time.Time // 上次触发（或开始计划）的时间
+}
+
+// lastOccurrence 不晚于 now 的最近一次计划时间
+func (w *weeklySchedule) lastOccurrence(now time.Time) time.Time {
+	now = now.UTC()
+	daysBack := (int(now.Weekday()) - int(w.day) + 7) % 7
+	t := time.Date(now.Year(), now.Month(), now.Day()-daysBack, w.hour, w.minute, 0, 0, time.UTC)
+	if t.After(now) {
+		t = t.AddDate(0, 0, -7)
+	}
+	return t
+}
+
+// weekdayNames 每周重置配置中的星期名称（不区分大小写）
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeeklySchedule 解析每周定时配置，如 "Mon 00:00"（UTC）
+func ParseWeeklySchedule(spec string) (day time.Weekday, hour, minute int, err error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 2 {
+		return 0, 0, 0, fmt.Errorf("每周定时格式应为 \"Mon 00:00\": %q", spec)
+	}
+	name := strings.ToLower(fields[0])
+	if len(name) > 3 {
+		name = name[:3]
+	}
+	day, ok := weekdayNames[name]
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("无效的星期: %q", fields[0])
+	}
+	parts := strings.Split(fields[1], ":")
+	if len(parts) != 2 {
+		return 0, 0, 0, fmt.Errorf("无效的时间: %q", fields[1])
+	}
+	hour, errH := strconv.Atoi(parts[0])
+	minute, errM := strconv.Atoi(parts[1])
+	if errH != nil || errM != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, 0, fmt.Errorf("无效的时间: %q", fields[1])
+	}
+	return day, hour, minute, nil
+}
+
+// ScheduleWeeklyReset 每周在指定时间（UTC）重置日统计并解除回撤硬止损
+func (at *AutoTrader) ScheduleWeeklyReset(dayOfWeek time.Weekday, hour, minute int) {
+	at.weeklyReset = &weeklySchedule{day: dayOfWeek, hour: hour, minute: minute, last: at.now()}
+	at.logger.Infof("📅 [%s] 每周重置: %s %02d:%02d UTC", at.name, dayOfWeek, hour, minute)
+}
+
+// checkWeeklyReset 到达每周重置时间时执行重置（每个交易周期开始时调用）
+func (at *AutoTrader) checkWeeklyReset() {
+	if at.weeklyReset == nil {
+		return
+	}
+	now := at.now()
+	if occurrence := at.weeklyReset.lastOccurrence(now); at.weeklyReset.last.Before(occurrence) {
+		at.weeklyReset.last = now
+		at.ResetDailyStats()
+		if triggered, _ := at.drawdownStop.Status(); triggered {
+			at.drawdownStop.Reset()
+			at.logger.Infof("✅ [%s] 每周重置：回撤硬止损已解除", at.name)
+		}
+	}
+}
+
+// checkDrawdownHardStop 回撤硬止损触发后拒绝所有开仓
+func (at *AutoTrader) checkDrawdownHardStop() error {
+	if triggered, reason := at.drawdownStop.Status(); triggered {
+		return fmt.Errorf("回撤硬止损已触发（%s），需手动恢复交易或等待每周重置", reason)
+	}
+	return nil
+}
+
+// recordDrawdown 检查回撤硬止损，触发时记录暂停时间并告警
+func (at *AutoTrader) recordDrawdown(equity float64) {
+	if !at.config.Risk.EnableDrawdownHardStop {
+		return
+	}
+	now := at.now()
+	if at.drawdownStop.Check(now, equity) {
+		at.stateMu.Lock()
+		at.haltedAt = now
+		at.stateMu.Unlock()
+		_, reason := at.drawdownStop.Status()
+		at.logger.Infof("🛑 [%s] 回撤硬止损: %s，停止开新仓直到手动恢复或每周重置", at.name, reason)
+	}
+}
+
+// ManualResumeTrading 手动恢复交易：解除回撤硬止损和风控暂停
+func (at *AutoTrader) ManualResumeTrading() {
+	at.drawdownStop.Reset()
+	at.stateMu.Lock()
+	at.stopUntil = time.Time{}
+	at.entryHaltUntil = time.Time{}
+	at.haltedAt = time.Time{}
+	at.stateMu.Unlock()
+	at.logger.Infof("✅ [%s] 已手动恢复交易", at.name)
+}
@@ -0,0 +1,219 @@
+package trader
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// calendarEvent 经济日历事件（单次或周期性，时间均为UTC）
+type calendarEvent struct {
+	description string
+	duration    time.Duration
+
+	start time.Time // 单次事件的开始时间
+
+	recurring    bool
+	weekday      time.Weekday
+	nthOfMonth   int // 每月第N个 weekday（1-4，-1=最后一个，0=每周）
+	hour, minute int
+}
+
+// EconomicCalendar 高波动经济事件日历（非农、FOMC、CPI等）
+// 事件期间暂停开新仓（平仓不受影响）
+type EconomicCalendar struct {
+	mu     sync.RWMutex
+	events []calendarEvent
+}
+
+// NewEconomicCalendar 创建空日历
+func NewEconomicCalendar() *EconomicCalendar {
+	return &EconomicCalendar{}
+}
+
+// AddEvent 添加单次事件
+func (c *EconomicCalendar) AddEvent(start time.Time, duration time.Duration, description string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, calendarEvent{description: description, duration: duration, start: start.UTC()})
+}
+
+// AddRecurringEvent 添加周期事件：nthOfMonth=0 为每周的 weekday，否则为每月第N个 weekday（-1=最后一个）
+func (c *EconomicCalendar) AddRecurringEvent(weekday time.Weekday, nthOfMonth, hour, minute int, duration time.Duration, description string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, calendarEvent{
+		description: description,
+		duration:    duration,
+		recurring:   true,
+		weekday:     weekday,
+		nthOfMonth:  nthOfMonth,
+		hour:        hour,
+		minute:      minute,
+	})
+}
+
+// IsHighVolatilityPeriod 判断时间点是否处于某个事件期间，返回事件说明
+func (c *EconomicCalendar) IsHighVolatilityPeriod(t time.Time) (bool, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	t = t.UTC()
+	for _, e := range c.events {
+		for _, start := range e.occurrencesAround(t) {
+			if !t.Before(start) && t.Before(start.Add(e.duration)) {
+				return true, e.description
+			}
+		}
+	}
+	return false, ""
+}
+
+// NextEvent 下一个尚未开始的事件（没有时返回空说明）
+func (c *EconomicCalendar) NextEvent(after time.Time) (time.Time, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	after = after.UTC()
+	var next time.Time
+	description := ""
+	for _, e := range c.events {
+		for _, start := range e.occurrencesAround(after) {
+			if start.After(after) && (next.IsZero() || start.Before(next)) {
+				next, description = start, e.description
+			}
+		}
+	}
+	return next, description
+}
+
+// occurrencesAround 事件在 t 前后的开始时间（周期事件取上一周期到下一周期）
+func (e calendarEvent) occurrencesAround(t time.Time) []time.Time {
+	if !e.recurring {
+		return []time.Time{e.start}
+	}
+
+	var starts []time.Time
+	if e.nthOfMonth == 0 {
+		day := time.Date(t.Year(), t.Month(), t.Day(), e.hour, e.minute, 0, 0, time.UTC)
+		offset := int(e.weekday - day.Weekday())
+		thisWeek := day.AddDate(0, 0, offset)
+		return append(starts, thisWeek.AddDate(0, 0, -7), thisWeek, thisWeek.AddDate(0, 0, 7))
+	}
+
+	for _, monthOffset := range []int{-1, 0, 1} {
+		first := time.Date(t.Year(), t.Month()+time.Month(monthOffset), 1, e.hour, e.minute, 0, 0, time.UTC)
+		if start, ok := nthWeekdayOfMonth(first, e.weekday, e.nthOfMonth); ok {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// nthWeekdayOfMonth 当月第N个 weekday（n=-1 为最后一个），first 为当月1日（含时分）
+func nthWeekdayOfMonth(first time.Time, weekday time.Weekday, n int) (time.Time, bool) {
+	if n < 0 {
+		lastDay := first.AddDate(0, 1, -1)
+		return lastDay.AddDate(0, 0, -((int(lastDay.Weekday()) - int(weekday) + 7) % 7)), true
+	}
+	day := first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+(n-1)*7)
+	if day.Month() != first.Month() {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// calendarFile 经济日历YAML文件格式
+//
+//	events:
+//	  - description: FOMC利率决议
+//	    time: 2026-12-16T19:00:00Z
+//	    duration: 60m
+//	  - description: 美国非农就业数据
+//	    recurring: first friday   # 每月第N个星期X（first/second/third/fourth/last），或 every friday
+//	    at: "13:30"               # UTC
+//	    duration: 30m
+type calendarFile struct {
+	Events []struct {
+		Description string    `yaml:"description"`
+		Time        time.Time `yaml:"time"`
+		Recurring   string    `yaml:"recurring"`
+		At          string    `yaml:"at"`
+		Duration    string    `yaml:"duration"`
+	} `yaml:"events"`
+}
+
+// LoadEconomicCalendar 从YAML文件加载经济日历
+func LoadEconomicCalendar(path string) (*EconomicCalendar, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取经济日历失败: %w", err)
+	}
+
+	var file calendarFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("解析经济日历失败: %w", err)
+	}
+
+	calendar := NewEconomicCalendar()
+	for i, e := range file.Events {
+		duration, err := time.ParseDuration(e.Duration)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("事件 #%d（%s）持续时间无效: %q", i+1, e.Description, e.Duration)
+		}
+
+		if e.Recurring == "" {
+			if e.Time.IsZero() {
+				return nil, fmt.Errorf("事件 #%d（%s）缺少时间", i+1, e.Description)
+			}
+			calendar.AddEvent(e.Time, duration, e.Description)
+			continue
+		}
+
+		weekday, nth, err := parseRecurrence(e.Recurring)
+		if err != nil {
+			return nil, fmt.Errorf("事件 #%d（%s）: %w", i+1, e.Description, err)
+		}
+		at, err := time.Parse("15:04", e.At)
+		if err != nil {
+			return nil, fmt.Errorf("事件 #%d（%s）时间无效: %q", i+1, e.Description, e.At)
+		}
+		calendar.AddRecurringEvent(weekday, nth, at.Hour(), at.Minute(), duration, e.Description)
+	}
+	return calendar, nil
+}
+
+// parseRecurrence 解析周期规则，如 "first friday"、"last wednesday"、"every thursday"
+func parseRecurrence(rule string) (time.Weekday, int, error) {
+	fields := strings.Fields(strings.ToLower(rule))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("无法识别的周期规则: %q", rule)
+	}
+
+	ordinals := map[string]int{"every": 0, "first": 1, "second": 2, "third": 3, "fourth": 4, "last": -1}
+	nth, ok := ordinals[fields[0]]
+	if !ok {
+		return 0, 0, fmt.Errorf("无法识别的周期规则: %q", rule)
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == fields[1] {
+			return d, nth, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("无法识别的星期: %q", fields[1])
+}
+
+// checkEconomicCalendar 经济事件期间暂停开新仓
+func (at *AutoTrader) checkEconomicCalendar() error {
+	if at.calendar == nil {
+		return nil
+	}
+	if active, description := at.calendar.IsHighVolatilityPeriod(at.now()); active {
+		return fmt.Errorf("经济事件期间（%s）暂停开新仓", description)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// emergencyHaltDuration 紧急平仓后暂停交易的时长
+const emergencyHaltDuration = 24 * time.Hour
+
+// 紧急平仓失败重试：第n次重试前等待 n × emergencyRetryBackoff
+const emergencyCloseAttempts = 3
+
+var emergencyRetryBackoff = 2 * time.Second
+
+// EmergencyExitReport 紧急平仓结果
+type EmergencyExitReport struct {
+	Reason          string   `json:"reason"`
+	PositionsClosed int      `json:"positions_closed"`
+	TotalPnLUSD     float64  `json:"total_pnl_usd"` // 平仓时的未实现盈亏合计
+	Errors          []string `json:"errors,omitempty"`
+
+	RemainingPositions []string `json:"remaining_positions,omitempty"` // 平仓后复查仍未平掉的持仓
+}
+
+// EmergencyExit 紧急平仓（一键清仓）
+// 无视止损止盈位，立即暂停交易24小时、撤销所有挂单并市价平掉全部持仓。
+// 单个持仓平仓失败会退避重试，且不会中断其他持仓的处理，失败原因记录在报告中。
+// 最后重新查询持仓确认已全部平掉，仍有持仓时返回错误。
+// 暂停在等待交易周期结束之前设置，平仓期间持有 cycleMu，交易周期不会同时开仓或执行未完成的拆单
+func (at *AutoTrader) EmergencyExit(reason string) (*EmergencyExitReport, error) {
+	at.logger.Infof("🚨 [%s] 紧急平仓: %s", at.name, reason)
+
+	// 1. 先暂停交易，防止平仓过程中交易周期再开新仓
+	now := at.now()
+	at.stateMu.Lock()
+	at.haltedAt = now
+	at.stopUntil = now.Add(emergencyHaltDuration)
+	at.stateMu.Unlock()
+
+	// 等待进行中的交易周期结束，并丢弃未完成的拆单（全部持仓将一次性平掉）
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+	at.pendingExits = make(map[string]*pendingExit)
+	at.pendingPlans = make(map[string][]*pendingPlan)
+
+	report := &EmergencyExitReport{Reason: reason}
+
+	// 2. 获取全部持仓
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return report, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	record := &logger.DecisionRecord{
+		ExecutionLog: []string{fmt.Sprintf("🚨 紧急平仓: %s", reason)},
+		Success:      true,
+	}
+
+	for _, pos := range positions {
+		info := parsePositionInfo(pos)
+		if info.Symbol == "" || info.Quantity == 0 {
+			continue
+		}
+
+		// 3. 撤销该币种所有挂单（止损止盈单）
+		if err := at.trader.CancelAllOrders(info.Symbol); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s 撤单失败: %v", info.Symbol, err))
+		}
+
+		// 4. 市价全部平仓
+		action := logger.DecisionAction{
+			Action:    "close_" + info.Side,
+			Symbol:    info.Symbol,
+			Quantity:  info.Quantity,
+			Price:     info.MarkPrice,
+			Timestamp: time.Now(),
+		}
+		order, err := at.closeWithRetry(info.Symbol, info.Side)
+
+		// 5. 记录结果
+		if err != nil {
+			msg := fmt.Sprintf("%s %s 平仓失败: %v", info.Symbol, sideName(info.Side), err)
+			at.logger.Errorf("  ❌ %s", msg)
+			report.Errors = append(report.Errors, msg)
+			action.Error = err.Error()
+			record.Success = false
+			record.ExecutionLog = append(record.ExecutionLog, "❌ "+msg)
+		} else {
+			if orderID, ok := order["orderId"].(int64); ok {
+				action.OrderID = orderID
+			}
+			action.Success = true
+			at.markClosedBySystem(info.Symbol, info.Side)
+			report.PositionsClosed++
+			report.TotalPnLUSD += info.UnrealizedPnL
+			at.logger.Infof("  ✓ %s %s 已平仓，盈亏 %+.2f USDT", info.Symbol, sideName(info.Side), info.UnrealizedPnL)
+			record.ExecutionLog = append(record.ExecutionLog,
+				fmt.Sprintf("✓ %s %s 紧急平仓成功", info.Symbol, sideName(info.Side)))
+		}
+		record.Decisions = append(record.Decisions, action)
+	}
+
+	if len(report.Errors) > 0 {
+		record.ErrorMessage = fmt.Sprintf("紧急平仓部分失败（%d 个错误）", len(report.Errors))
+	}
+
+	// 6. 复查持仓，确认已全部平掉
+	verifyErr := at.verifyFlat(report)
+	if verifyErr != nil {
+		record.Success = false
+		record.ExecutionLog = append(record.ExecutionLog, "❌ "+verifyErr.Error())
+	}
+	if err := at.decisionLogger.LogDecision(record); err != nil {
+		at.logger.Warnf("⚠ 保存紧急平仓记录失败: %v", err)
+	}
+
+	at.logger.Warnf("🚨 [%s] 紧急平仓完成: 平仓 %d 个，盈亏 %+.2f USDT，错误 %d 个，剩余持仓 %d 个，暂停交易至 %s",
+		at.name, report.PositionsClosed, report.TotalPnLUSD, len(report.Errors), len(report.RemainingPositions),
+		at.haltSnapshot().StopUntil.Format("2006-01-02 15:04:05"))
+	return report, verifyErr
+}
+
+// closeWithRetry 市价平掉指定持仓，失败时退避重试
+func (at *AutoTrader) closeWithRetry(symbol, side string) (map[string]interface{}, error) {
+	var lastErr error
+	for attempt := 1; attempt <= emergencyCloseAttempts; attempt++ {
+		var order map[string]interface{}
+		var err error
+		if side == "short" {
+			order, err = at.trader.CloseShort(symbol, 0)
+		} else {
+			order, err = at.trader.CloseLong(symbol, 0)
+		}
+		if err == nil {
+			return order, nil
+		}
+
+		lastErr = err
+		if attempt < emergencyCloseAttempts {
+			waitTime := time.Duration(attempt) * emergencyRetryBackoff
+			at.logger.Warnf("  ⚠️  %s %s 平仓失败，%v后重试 (%d/%d): %v", symbol, sideName(side), waitTime, attempt+1, emergencyCloseAttempts, err)
+			time.Sleep(waitTime)
+		}
+	}
+	return nil, fmt.Errorf("重试%d次后仍然失败: %w", emergencyCloseAttempts, lastErr)
+}
+
+// verifyFlat 重新查询持仓，仍有持仓时记录到报告并返回错误
+func (at *AutoTrader) verifyFlat(report *EmergencyExitReport) error {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("复查持仓失败，无法确认已全部平仓: %w", err)
+	}
+	for _, pos := range positions {
+		info := parsePositionInfo(pos)
+		if info.Symbol == "" || info.Quantity == 0 {
+			continue
+		}
+		report.RemainingPositions = append(report.RemainingPositions,
+			fmt.Sprintf("%s %s %.6f", info.Symbol, sideName(info.Side), info.Quantity))
+	}
+	if len(report.RemainingPositions) > 0 {
+		return fmt.Errorf("紧急平仓后仍有 %d 个持仓未平: %s",
+			len(report.RemainingPositions), strings.Join(report.RemainingPositions, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package trader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fastEmergencyRetry 测试中缩短紧急平仓的重试等待
+func fastEmergencyRetry(t *testing.T) {
+	t.Helper()
+	backoff := emergencyRetryBackoff
+	emergencyRetryBackoff = time.Millisecond
+	t.Cleanup(func() { emergencyRetryBackoff = backoff })
+}
+
+func TestEmergencyExitWaitsForCycleAndHaltsFirst(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	clock := &fixedClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 100)
+
+	// 模拟进行中的交易周期
+	at.cycleMu.Lock()
+	done := make(chan error, 1)
+	go func() {
+		_, err := at.EmergencyExit("test")
+		done <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for at.haltSnapshot().StopUntil.IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("halt was not set before waiting for the cycle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("EmergencyExit ran concurrently with the cycle")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if n := mock.Count("CloseLong"); n != 0 {
+		t.Fatalf("CloseLong called %d times while the cycle was running", n)
+	}
+
+	at.cycleMu.Unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("EmergencyExit: %v", err)
+	}
+	if want := clock.Now().Add(emergencyHaltDuration); !at.haltSnapshot().StopUntil.Equal(want) {
+		t.Errorf("stopUntil = %v, want %v", at.haltSnapshot().StopUntil, want)
+	}
+}
+
+func TestEmergencyExitDropsPendingSlices(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 100)
+	at.pendingExits["BTCUSDT_long"] = &pendingExit{Symbol: "BTCUSDT", Side: "long", Slices: []float64{0.5}}
+
+	if _, err := at.EmergencyExit("test"); err != nil {
+		t.Fatalf("EmergencyExit: %v", err)
+	}
+	if len(at.pendingExits) != 0 {
+		t.Fatalf("pendingExits = %v, want empty", at.pendingExits)
+	}
+
+	// 清仓后重新开出的同向持仓不应被旧拆单平掉
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 100)
+	at.runPendingExits()
+	if n := mock.Count("CloseLong"); n != 1 {
+		t.Errorf("CloseLong called %d times, want 1 (emergency exit only)", n)
+	}
+}
+
+func TestEmergencyExitRetriesFailedCloseAndVerifiesFlat(t *testing.T) {
+	fastEmergencyRetry(t)
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 100)
+	mock.errs["CloseLong"] = errors.New("timeout")
+	mock.failFirst["CloseLong"] = 1
+
+	report, err := at.EmergencyExit("test")
+	if err != nil {
+		t.Fatalf("EmergencyExit: %v", err)
+	}
+	if n := mock.Count("CloseLong"); n != 2 {
+		t.Errorf("CloseLong called %d times, want a failure then a retry", n)
+	}
+	if report.PositionsClosed != 1 || len(report.Errors) != 0 || len(report.RemainingPositions) != 0 {
+		t.Errorf("report = %+v, want one clean close", report)
+	}
+}
+
+func TestEmergencyExitReportsRemainingPositions(t *testing.T) {
+	fastEmergencyRetry(t)
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 100)
+	mock.SetPosition("ETHUSDT", "short", 2, 2000, 2000)
+	mock.errs["CloseShort"] = errors.New("rejected")
+	mock.failFirst["CloseShort"] = emergencyCloseAttempts
+
+	report, err := at.EmergencyExit("test")
+	if err == nil {
+		t.Fatal("expected an error for the position left open")
+	}
+	if n := mock.Count("CloseShort"); n != emergencyCloseAttempts {
+		t.Errorf("CloseShort called %d times, want %d attempts", n, emergencyCloseAttempts)
+	}
+	if report.PositionsClosed != 1 || len(report.Errors) != 1 {
+		t.Errorf("report = %+v, want BTC closed and one ETH error", report)
+	}
+	if len(report.RemainingPositions) != 1 || !strings.HasPrefix(report.RemainingPositions[0], "ETHUSDT") {
+		t.Errorf("remaining = %v, want the ETH short", report.RemainingPositions)
+	}
+}
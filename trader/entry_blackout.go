@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// blackoutWindow 每日禁止开仓的UTC时间段（分钟，[start, end)，支持跨零点）
+type blackoutWindow struct {
+	start, end int
+	label      string
+}
+
+func (w blackoutWindow) contains(minute int) bool {
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// entryBlackoutRule 自定义风控规则：指定的UTC时间段内不开新仓（如CPI公布前后）
+type entryBlackoutRule struct {
+	windows []blackoutWindow
+	now     func() time.Time
+}
+
+// NewEntryBlackoutRule 根据 "HH:MM-HH:MM"（UTC）格式的时间段创建禁止开仓规则
+func NewEntryBlackoutRule(windows []string) (RiskRule, error) {
+	rule := &entryBlackoutRule{now: time.Now}
+	for _, w := range windows {
+		parts := strings.Split(strings.TrimSpace(w), "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("禁止开仓时段格式错误: %q（应为 HH:MM-HH:MM）", w)
+		}
+		start, err := parseClockMinute(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("禁止开仓时段 %q 解析失败: %w", w, err)
+		}
+		end, err := parseClockMinute(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("禁止开仓时段 %q 解析失败: %w", w, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("禁止开仓时段 %q 起止时间相同", w)
+		}
+		rule.windows = append(rule.windows, blackoutWindow{start: start, end: end, label: strings.TrimSpace(w)})
+	}
+	return rule, nil
+}
+
+// parseClockMinute 解析 "HH:MM" 为当天的分钟数
+func parseClockMinute(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (r *entryBlackoutRule) Name() string { return "entry_blackout" }
+
+func (r *entryBlackoutRule) Check(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+	now := r.now().UTC()
+	minute := now.Hour()*60 + now.Minute()
+	for _, w := range r.windows {
+		if w.contains(minute) {
+			return false, fmt.Sprintf("处于禁止开仓时段 %s UTC", w.label)
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,57 @@
+package trader
+
+import (
+	"nofx/decision"
+	"reflect"
+	"testing"
+)
+
+func TestLimitNewEntriesKeepsTopConfidence(t *testing.T) {
+	decisions := []decision.Decision{
+		{Symbol: "SOLUSDT", Action: "close_long", Confidence: 50},
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70},
+		{Symbol: "ETHUSDT", Action: "open_short", Confidence: 90},
+		{Symbol: "BNBUSDT", Action: "open_long", Confidence: 60},
+		{Symbol: "XRPUSDT", Action: "open_long", Confidence: 80},
+		{Symbol: "ADAUSDT", Action: "hold"},
+	}
+
+	kept, deferred := limitNewEntries(decisions, 2)
+
+	// 平仓在前，保留的开仓按信心度排序，其余非开仓决策保持原位
+	want := []string{"SOLUSDT:close_long", "ETHUSDT:open_short", "XRPUSDT:open_long", "ADAUSDT:hold"}
+	if got := actions(kept); !reflect.DeepEqual(got, want) {
+		t.Errorf("kept = %v, want %v", got, want)
+	}
+	if got := actions(deferred); !reflect.DeepEqual(got, []string{"BTCUSDT:open_long", "BNBUSDT:open_long"}) {
+		t.Errorf("deferred = %v, want the two lowest-confidence entries", got)
+	}
+}
+
+func TestLimitNewEntriesWithinLimit(t *testing.T) {
+	decisions := []decision.Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 70},
+		{Symbol: "ETHUSDT", Action: "open_short", Confidence: 90},
+	}
+	for _, limit := range []int{0, 2, 3} {
+		kept, deferred := limitNewEntries(decisions, limit)
+		if len(deferred) != 0 || !reflect.DeepEqual(actions(kept), actions(decisions)) {
+			t.Errorf("limit %d: kept %v deferred %v, want everything kept in order", limit, actions(kept), actions(deferred))
+		}
+	}
+}
+
+func TestLimitNewEntriesTiesKeepOriginalOrder(t *testing.T) {
+	decisions := []decision.Decision{
+		{Symbol: "BTCUSDT", Action: "open_long", Confidence: 80},
+		{Symbol: "ETHUSDT", Action: "open_long", Confidence: 80},
+		{Symbol: "SOLUSDT", Action: "open_long", Confidence: 80},
+	}
+	kept, deferred := limitNewEntries(decisions, 1)
+	if got := actions(kept); !reflect.DeepEqual(got, []string{"BTCUSDT:open_long"}) {
+		t.Errorf("kept = %v, want the first of equal-confidence entries", got)
+	}
+	if len(deferred) != 2 {
+		t.Errorf("deferred = %v, want 2", actions(deferred))
+	}
+}
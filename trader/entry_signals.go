@@ -0,0 +1,37 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"strings"
+)
+
+// recordEntrySignals 记录开仓时的技术指标信号（写入决策日志并随持仓保存，平仓时输出）
+func (at *AutoTrader) recordEntrySignals(d *decision.Decision, side string, data *market.Data, actionRecord *logger.DecisionAction) {
+	signals := decision.EntrySignals(d, data)
+	if len(signals) == 0 {
+		return
+	}
+	actionRecord.EntrySignals = signals
+
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+	if stop, ok := at.positionStops[d.Symbol+"_"+side]; ok {
+		stop.EntrySignals = signals
+	}
+}
+
+// logEntrySignals 平仓时输出该持仓的入场信号和结果，便于按指标复盘
+func (at *AutoTrader) logEntrySignals(symbol, side string, attribution *logger.PnLAttribution) {
+	stop := at.getPositionStop(symbol, side)
+	if stop == nil || len(stop.EntrySignals) == 0 || attribution == nil {
+		return
+	}
+	parts := make([]string, 0, len(stop.EntrySignals))
+	for _, s := range stop.EntrySignals {
+		parts = append(parts, fmt.Sprintf("%s(%+.0f)", s.Indicator, s.Weight))
+	}
+	at.logger.Infof("  📊 入场信号: %s → 盈亏 %+.2f USDT", strings.Join(parts, " "), attribution.NetPnL)
+}
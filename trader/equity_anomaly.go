@@ -0,0 +1,122 @@
+package trader
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// 净值异常检测参数
+const (
+	equityHistorySize      = 100           // 滚动窗口大小
+	equityAnomalyMinPoints = 10            // 至少积累多少个历史点才开始检测
+	equityAnomalyZScore    = 3.0           // |Z| 超过此值视为异常
+	equityAnomalyHalt      = 2 * time.Hour // 检测到异常后的暂停时长
+	equityAnomalyCooldown  = 2 * time.Hour // 两次告警的最短间隔（避免暂停期间每个周期重复触发）
+
+	// equityAnomalyMinStdDevPct 标准差低于均值的该比例时不检测
+	// 净值长时间几乎不变（空仓）时标准差趋近0，正常的小额盈亏也会得到很大的Z分数
+	equityAnomalyMinStdDevPct = 0.001
+)
+
+// EquityAnomaly 净值异常检测结果
+type EquityAnomaly struct {
+	IsAnomaly bool    `json:"is_anomaly"`
+	ZScore    float64 `json:"z_score"`
+	Direction string  `json:"direction"` // up / down
+}
+
+// EquityAnomalyDetector 净值曲线异常检测（Z分数）
+// 净值突然偏离近期均值3个标准差以上，更可能是交易所数据错误或系统bug而非正常盈亏
+type EquityAnomalyDetector struct {
+	mu        sync.Mutex
+	history   []float64
+	lastAlert time.Time // 上次告警时间（冷却期内不再告警）
+}
+
+// NewEquityAnomalyDetector 创建检测器
+func NewEquityAnomalyDetector() *EquityAnomalyDetector {
+	return &EquityAnomalyDetector{}
+}
+
+// RecordEquity 记录一个净值点（保留最近100个）
+func (d *EquityAnomalyDetector) RecordEquity(equity float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.history = append(d.history, equity)
+	if len(d.history) > equityHistorySize {
+		d.history = d.history[len(d.history)-equityHistorySize:]
+	}
+}
+
+// DetectAnomaly 计算最新净值相对之前历史的Z分数（历史不足或波动过小时返回nil）
+func (d *EquityAnomalyDetector) DetectAnomaly() *EquityAnomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n := len(d.history) - 1
+	if n < equityAnomalyMinPoints {
+		return nil
+	}
+	previous, latest := d.history[:n], d.history[n]
+
+	mean := 0.0
+	for _, v := range previous {
+		mean += v
+	}
+	mean /= float64(n)
+
+	variance := 0.0
+	for _, v := range previous {
+		variance += (v - mean) * (v - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(n))
+	if stdDev == 0 || stdDev < math.Abs(mean)*equityAnomalyMinStdDevPct {
+		return nil
+	}
+
+	z := (latest - mean) / stdDev
+	anomaly := &EquityAnomaly{ZScore: z, IsAnomaly: math.Abs(z) > equityAnomalyZScore, Direction: "up"}
+	if z < 0 {
+		anomaly.Direction = "down"
+	}
+	return anomaly
+}
+
+// Observe 记录净值并检测异常，只在需要告警时返回结果（距上次告警不足冷却时间时返回nil）
+func (d *EquityAnomalyDetector) Observe(equity float64, now time.Time) *EquityAnomaly {
+	d.RecordEquity(equity)
+	anomaly := d.DetectAnomaly()
+	if anomaly == nil || !anomaly.IsAnomaly {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.lastAlert.IsZero() && now.Sub(d.lastAlert) < equityAnomalyCooldown {
+		return nil
+	}
+	d.lastAlert = now
+	return anomaly
+}
+
+// checkEquityAnomaly 记录净值并检测异常，异常时暂停开新仓
+func (at *AutoTrader) checkEquityAnomaly(equity float64) {
+	if anomaly := at.equityDetector.Observe(equity, at.now()); anomaly != nil {
+		at.logger.Warnf("⚠️ [WARN] [%s] 净值异常（%.2f USDT，Z=%.2f，方向 %s），可能是交易所数据错误或系统故障",
+			at.name, equity, anomaly.ZScore, anomaly.Direction)
+		at.ManualHaltTrading("equity anomaly detected", equityAnomalyHalt)
+	}
+}
+
+// ManualHaltTrading 暂停开新仓一段时间
+// 只由 trading_halt 规则拦截开仓，交易周期照常运行（平仓、止损调整等持仓管理继续）
+func (at *AutoTrader) ManualHaltTrading(reason string, duration time.Duration) {
+	now := at.now()
+	at.stateMu.Lock()
+	at.haltedAt = now
+	at.entryHaltUntil = now.Add(duration)
+	at.stateMu.Unlock()
+	at.logger.Infof("⏸ [%s] 暂停交易 %v: %s", at.name, duration, reason)
+}
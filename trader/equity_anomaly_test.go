@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"nofx/decision"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectAnomalyIgnoresFlatHistory(t *testing.T) {
+	d := NewEquityAnomalyDetector()
+	// 空仓时净值几乎不变：标准差远低于均值的0.1%
+	for i := 0; i < 20; i++ {
+		equity := 1000.0
+		if i%2 == 0 {
+			equity += 0.01
+		}
+		d.RecordEquity(equity)
+	}
+	d.RecordEquity(1002)
+
+	if anomaly := d.DetectAnomaly(); anomaly != nil {
+		t.Fatalf("flat history should not be scored, got z=%.2f", anomaly.ZScore)
+	}
+}
+
+func TestDetectAnomalyFlagsOutlier(t *testing.T) {
+	d := NewEquityAnomalyDetector()
+	for i := 0; i < 20; i++ {
+		d.RecordEquity(1000 + float64(i%5)*5)
+	}
+	d.RecordEquity(700)
+
+	anomaly := d.DetectAnomaly()
+	if anomaly == nil || !anomaly.IsAnomaly {
+		t.Fatalf("expected anomaly, got %+v", anomaly)
+	}
+	if anomaly.Direction != "down" {
+		t.Errorf("direction = %s, want down", anomaly.Direction)
+	}
+}
+
+func TestObserveCooldown(t *testing.T) {
+	d := NewEquityAnomalyDetector()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20; i++ {
+		if a := d.Observe(1000+float64(i%5)*5, now); a != nil {
+			t.Fatalf("unexpected alert at point %d", i)
+		}
+	}
+
+	if d.Observe(700, now) == nil {
+		t.Fatal("expected first alert")
+	}
+	if d.Observe(600, now.Add(3*time.Minute)) != nil {
+		t.Fatal("alert repeated within cooldown")
+	}
+	if d.Observe(500, now.Add(equityAnomalyCooldown+time.Minute)) == nil {
+		t.Fatal("expected alert after cooldown")
+	}
+}
+
+func TestManualHaltTradingBlocksEntriesOnly(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	clock := &fixedClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+
+	at.ManualHaltTrading("test", time.Hour)
+
+	if !at.stopUntil.IsZero() {
+		t.Fatalf("stopUntil = %v, halt must not stop the whole cycle", at.stopUntil)
+	}
+	halt := at.riskRules[0]
+	if halt.Name() != "trading_halt" {
+		t.Fatalf("first rule = %s, want trading_halt", halt.Name())
+	}
+	ok, reason := halt.Check(&decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}, RiskAccount{}, nil)
+	if ok || !strings.Contains(reason, "交易暂停") {
+		t.Fatalf("trading_halt should reject entries, got ok=%v reason=%q", ok, reason)
+	}
+
+	clock.Advance(time.Hour + time.Second)
+	if ok, reason := halt.Check(&decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}, RiskAccount{}, nil); !ok {
+		t.Fatalf("halt should expire, got %q", reason)
+	}
+}
@@ -0,0 +1,45 @@
+package trader
+
+import "math"
+
+// updateExcursion 用当前价格更新持仓期间到达的最差/最好价格（每个交易周期和兜底止损检查时调用）
+func (at *AutoTrader) updateExcursion(symbol, side string, price float64) {
+	if price <= 0 {
+		return
+	}
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+	stop, ok := at.positionStops[symbol+"_"+side]
+	if !ok {
+		return
+	}
+	if stop.WorstPrice <= 0 || isWorsePrice(side, price, stop.WorstPrice) {
+		stop.WorstPrice = price
+	}
+	if stop.BestPrice <= 0 || isWorsePrice(side, stop.BestPrice, price) {
+		stop.BestPrice = price
+	}
+}
+
+// isWorsePrice 对该方向的持仓而言 price 是否比 reference 更不利（多仓更低、空仓更高）
+func isWorsePrice(side string, price, reference float64) bool {
+	if side == "short" {
+		return price > reference
+	}
+	return price < reference
+}
+
+// ExcursionPct 相对入场价的最大不利偏移（MAE）和最大有利偏移（MFE），单位为百分比，均为非负数
+// 最差/最好价格未记录（<=0）或未越过入场价时对应值为0
+func ExcursionPct(side string, entryPrice, worstPrice, bestPrice float64) (mae, mfe float64) {
+	if entryPrice <= 0 {
+		return 0, 0
+	}
+	if worstPrice > 0 && isWorsePrice(side, worstPrice, entryPrice) {
+		mae = math.Abs(worstPrice-entryPrice) / entryPrice * 100
+	}
+	if bestPrice > 0 && isWorsePrice(side, entryPrice, bestPrice) {
+		mfe = math.Abs(bestPrice-entryPrice) / entryPrice * 100
+	}
+	return mae, mfe
+}
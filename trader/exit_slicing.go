@@ -0,0 +1,221 @@
+package trader
+
+import (
+	"math"
+	"nofx/market"
+	"strings"
+	"time"
+)
+
+// SliceCloseQuantity 按盘口深度拆分平仓数量
+// 平仓数量不超过 深度 × maxDepthFraction 时不拆分；否则每笔不超过该数量，
+// 笔数超过 maxSlices 时改为均分成 maxSlices 笔（depth、maxDepthFraction <= 0 时不拆分）
+func SliceCloseQuantity(quantity, depth, maxDepthFraction float64, maxSlices int) []float64 {
+	if quantity <= 0 {
+		return nil
+	}
+	sliceSize := depth * maxDepthFraction
+	if sliceSize <= 0 || quantity <= sliceSize {
+		return []float64{quantity}
+	}
+
+	n := int(math.Ceil(quantity / sliceSize))
+	if maxSlices > 0 && n > maxSlices {
+		n = maxSlices
+		sliceSize = quantity / float64(n)
+	}
+	slices := make([]float64, 0, n)
+	remaining := quantity
+	for i := 0; i < n-1; i++ {
+		slices = append(slices, sliceSize)
+		remaining -= sliceSize
+	}
+	return append(slices, remaining)
+}
+
+// BookDepthProvider 提供盘口深度的交易器（未实现时不按深度拆单平仓）
+type BookDepthProvider interface {
+	GetBookDepth(symbol string, levels int) (*market.BookDepth, error)
+}
+
+// pendingExit 拆单平仓中尚未执行的部分（后续交易周期按间隔继续平仓）
+type pendingExit struct {
+	Symbol string
+	Side   string
+	Slices []float64 // 剩余各笔数量（0 表示平掉全部剩余）
+	NextAt time.Time // 下一笔最早执行时间
+}
+
+// exitResult 平仓结果（出错时仍返回已成交的部分）
+type exitResult struct {
+	Order     map[string]interface{} // 最后一笔成交的订单
+	ClosedQty float64                // 本次已平仓数量（0=全部平仓）
+	Pending   int                    // 留到后续周期执行的笔数
+}
+
+// closePosition 平仓（quantity=0 表示全部平仓）
+// 启用流动性拆单且交易所提供盘口深度时，平仓数量超过盘口深度的一定比例则拆成多笔：
+// 本周期只平第一笔，剩余笔数按间隔在后续交易周期执行（见 runPendingExits），不在周期内等待。
+// 交易所平仓会撤销该币种全部挂单，部分平仓后按剩余数量重新挂止损止盈
+func (at *AutoTrader) closePosition(symbol, side string, quantity float64) (*exitResult, error) {
+	key := symbol + "_" + side
+	delete(at.pendingExits, key) // 新的平仓决策取代未完成的拆单
+
+	total := at.positionQuantity(symbol, side)
+	slices := []float64{quantity}
+	cfg := at.config.Risk
+	if cfg.ExitMaxDepthFraction > 0 && total > 0 {
+		slices = at.exitSlices(symbol, side, quantity, total)
+	}
+
+	order, err := at.closeSlice(symbol, side, slices[0], total)
+	if err != nil {
+		return &exitResult{}, err
+	}
+	result := &exitResult{Order: order, ClosedQty: slices[0], Pending: len(slices) - 1}
+	if result.Pending > 0 {
+		at.pendingExits[key] = &pendingExit{
+			Symbol: symbol,
+			Side:   side,
+			Slices: slices[1:],
+			NextAt: at.now().Add(time.Duration(cfg.ExitSliceIntervalSec) * time.Second),
+		}
+	}
+	return result, nil
+}
+
+// exitSlices 按盘口深度拆分平仓数量（获取深度失败或无需拆分时不拆）
+func (at *AutoTrader) exitSlices(symbol, side string, quantity, total float64) []float64 {
+	cfg := at.config.Risk
+	amount := quantity
+	if amount <= 0 {
+		amount = total
+	}
+
+	provider, ok := at.trader.(BookDepthProvider)
+	if !ok {
+		return []float64{quantity}
+	}
+	depth, err := provider.GetBookDepth(symbol, cfg.ExitDepthLevels)
+	if err != nil {
+		at.logger.Warnf("  ⚠️ %s 获取盘口深度失败，不拆单: %v", symbol, err)
+		return []float64{quantity}
+	}
+	available := depth.BidQty // 平多为卖出，吃买盘
+	if side == "short" {
+		available = depth.AskQty
+	}
+
+	slices := SliceCloseQuantity(amount, available, cfg.ExitMaxDepthFraction, cfg.ExitMaxSlices)
+	if len(slices) <= 1 {
+		return []float64{quantity}
+	}
+	if quantity <= 0 {
+		slices[len(slices)-1] = 0 // 全部平仓时最后一笔平掉剩余（避免精度误差留下零头）
+	}
+	at.logger.Infof("  🧩 %s 平仓数量 %.4f 超过盘口深度 %.4f 的 %.0f%%，拆成 %d 笔，每 %d 秒以上一笔",
+		symbol, amount, available, cfg.ExitMaxDepthFraction*100, len(slices), cfg.ExitSliceIntervalSec)
+	return slices
+}
+
+// closeSlice 平掉一笔（held 为平仓前的持仓数量），部分平仓后为剩余持仓重新挂止损止盈
+func (at *AutoTrader) closeSlice(symbol, side string, quantity, held float64) (map[string]interface{}, error) {
+	closeFn := at.trader.CloseLong
+	if side == "short" {
+		closeFn = at.trader.CloseShort
+	}
+	order, err := closeFn(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if quantity > 0 && held-quantity > 0 {
+		at.replaceStopOrders(symbol, side, held-quantity)
+	}
+	return order, nil
+}
+
+// replaceStopOrders 按数量重新挂持仓记录的止损止盈（交易所下单会撤销该币种全部挂单）
+func (at *AutoTrader) replaceStopOrders(symbol, side string, quantity float64) {
+	stop := at.getPositionStop(symbol, side)
+	if stop == nil || quantity <= 0 {
+		return
+	}
+	positionSide := strings.ToUpper(side)
+	if stop.StopLoss > 0 {
+		if err := at.trader.SetStopLoss(symbol, positionSide, quantity, stop.StopLoss); err != nil {
+			at.logger.Warnf("  ⚠ %s 重新设置止损失败: %v", symbol, err)
+		}
+	}
+	if stop.TakeProfit > 0 {
+		if err := at.trader.SetTakeProfit(symbol, positionSide, quantity, stop.TakeProfit); err != nil {
+			at.logger.Warnf("  ⚠ %s 重新设置止盈失败: %v", symbol, err)
+		}
+	}
+}
+
+// reduceLastPosition 拆单成交后扣减最近一次获取的持仓数量
+// 拆单在获取持仓之后执行，不扣减的话本周期的平仓和风控会按拆单前的数量计算
+func (at *AutoTrader) reduceLastPosition(symbol, side string, quantity float64) {
+	for i := range at.lastPositions {
+		if at.lastPositions[i].Symbol == symbol && at.lastPositions[i].Side == side {
+			at.lastPositions[i].Quantity = math.Max(at.lastPositions[i].Quantity-quantity, 0)
+			return
+		}
+	}
+}
+
+// positionQuantity 最近一次获取的持仓数量（无持仓为0）
+func (at *AutoTrader) positionQuantity(symbol, side string) float64 {
+	for _, pos := range at.lastPositions {
+		if pos.Symbol == symbol && pos.Side == side {
+			return pos.Quantity
+		}
+	}
+	return 0
+}
+
+// runPendingExits 执行到期的拆单平仓（每个持仓每周期最多一笔）
+// 持仓已不存在时丢弃；某笔失败时保留剩余笔数，下个周期重试
+func (at *AutoTrader) runPendingExits() {
+	if len(at.pendingExits) == 0 {
+		return
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		at.logger.Warnf("  ⚠️ 获取持仓失败，拆单平仓推迟到下个周期: %v", err)
+		return
+	}
+	held := make(map[string]float64, len(positions))
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		amount, _ := pos["positionAmt"].(float64)
+		held[symbol+"_"+side] = math.Abs(amount)
+	}
+
+	now := at.now()
+	for key, exit := range at.pendingExits {
+		if held[key] <= 0 {
+			delete(at.pendingExits, key)
+			continue
+		}
+		if now.Before(exit.NextAt) {
+			continue
+		}
+		if _, err := at.closeSlice(exit.Symbol, exit.Side, exit.Slices[0], held[key]); err != nil {
+			at.logger.Warnf("  ⚠️ %s %s 拆单平仓失败（剩余 %d 笔，下个周期重试）: %v",
+				exit.Symbol, sideName(exit.Side), len(exit.Slices), err)
+			continue
+		}
+		at.reduceLastPosition(exit.Symbol, exit.Side, exit.Slices[0])
+		exit.Slices = exit.Slices[1:]
+		at.logger.Infof("  🧩 %s %s 拆单平仓已执行一笔，剩余 %d 笔", exit.Symbol, sideName(exit.Side), len(exit.Slices))
+		if len(exit.Slices) == 0 {
+			delete(at.pendingExits, key)
+			at.markClosedBySystem(exit.Symbol, exit.Side) // 平仓动作已在第一笔时记录
+			continue
+		}
+		exit.NextAt = now.Add(time.Duration(at.config.Risk.ExitSliceIntervalSec) * time.Second)
+	}
+}
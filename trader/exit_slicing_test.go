@@ -0,0 +1,187 @@
+package trader
+
+import (
+	"errors"
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+	"time"
+)
+
+// depthMockTrader 提供盘口深度的 mockTrader
+type depthMockTrader struct {
+	*mockTrader
+	depth *market.BookDepth
+}
+
+func (m *depthMockTrader) GetBookDepth(symbol string, levels int) (*market.BookDepth, error) {
+	return m.depth, nil
+}
+
+func newSlicingTrader(t *testing.T) (*AutoTrader, *mockTrader, *fixedClock) {
+	at, mock := newTestAutoTrader(t, RiskConfig{ExitMaxDepthFraction: 0.1, ExitSliceIntervalSec: 5, ExitMaxSlices: 10})
+	at.trader = &depthMockTrader{mockTrader: mock, depth: &market.BookDepth{BidQty: 20, AskQty: 20}}
+	clock := &fixedClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+
+	mock.SetPosition("BTCUSDT", "long", 10, 100, 100)
+	mock.SetStopLoss("BTCUSDT", "LONG", 10, 95)
+	mock.SetTakeProfit("BTCUSDT", "LONG", 10, 120)
+	at.recordPositionStop("BTCUSDT", "long", 95, 120)
+	at.lastPositions = []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "long", Quantity: 10}}
+	return at, mock, clock
+}
+
+func TestSliceCloseQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity float64
+		depth    float64
+		max      int
+		want     []float64
+	}{
+		{"within depth", 1, 20, 10, []float64{1}},
+		{"split by depth", 5, 20, 10, []float64{2, 2, 1}},
+		{"capped slices", 10, 20, 2, []float64{5, 5}},
+		{"no depth", 5, 0, 10, []float64{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SliceCloseQuantity(tt.quantity, tt.depth, 0.1, tt.max)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if diff := got[i] - tt.want[i]; diff > 1e-9 || diff < -1e-9 {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestClosePositionSlicesAcrossCycles(t *testing.T) {
+	at, mock, clock := newSlicingTrader(t)
+
+	start := time.Now()
+	result, err := at.closePosition("BTCUSDT", "long", 0)
+	if err != nil {
+		t.Fatalf("closePosition: %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("closePosition must not wait between slices")
+	}
+	if result.ClosedQty != 2 || result.Pending != 4 {
+		t.Fatalf("result = %+v, want 2 closed and 4 pending", result)
+	}
+	if n := mock.Count("CloseLong"); n != 1 {
+		t.Fatalf("CloseLong called %d times, want 1", n)
+	}
+	assertStopOrders(t, mock, 8)
+
+	// 间隔未到不执行
+	at.runPendingExits()
+	if n := mock.Count("CloseLong"); n != 1 {
+		t.Fatalf("slice executed before interval, CloseLong = %d", n)
+	}
+
+	clock.Advance(5 * time.Second)
+	before := at.positionQuantity("BTCUSDT", "long")
+	at.runPendingExits()
+	if n := mock.Count("CloseLong"); n != 2 {
+		t.Fatalf("CloseLong called %d times, want 2", n)
+	}
+	// 拆单在获取持仓之后执行，本周期的持仓数量同步扣减
+	if after := at.positionQuantity("BTCUSDT", "long"); after != before-2 {
+		t.Errorf("position quantity = %v after the slice, want %v", after, before-2)
+	}
+	assertStopOrders(t, mock, 6)
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(5 * time.Second)
+		at.runPendingExits()
+	}
+	if len(at.pendingExits) != 0 {
+		t.Fatalf("pending exits = %+v, want none", at.pendingExits)
+	}
+	if positions, _ := mock.GetPositions(); len(positions) != 0 {
+		t.Fatalf("positions = %v, want fully closed", positions)
+	}
+}
+
+func TestPendingExitRetriesAfterError(t *testing.T) {
+	at, mock, clock := newSlicingTrader(t)
+	if _, err := at.closePosition("BTCUSDT", "long", 0); err != nil {
+		t.Fatalf("closePosition: %v", err)
+	}
+
+	mock.errs["CloseLong"] = errors.New("rejected")
+	mock.failAfter["CloseLong"] = 1
+	clock.Advance(5 * time.Second)
+	at.runPendingExits()
+
+	exit := at.pendingExits["BTCUSDT_long"]
+	if exit == nil || len(exit.Slices) != 4 {
+		t.Fatalf("pending exit = %+v, want 4 slices kept for retry", exit)
+	}
+}
+
+func TestClosePositionReturnsErrorWithResult(t *testing.T) {
+	at, mock, _ := newSlicingTrader(t)
+	mock.errs["CloseLong"] = errors.New("rejected")
+
+	result, err := at.closePosition("BTCUSDT", "long", 0)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if result == nil || result.ClosedQty != 0 {
+		t.Fatalf("result = %+v, want empty result", result)
+	}
+	if len(at.pendingExits) != 0 {
+		t.Fatal("failed first slice must not schedule the rest")
+	}
+}
+
+func TestClosePositionWithoutDepthProvider(t *testing.T) {
+	at, mock, _ := newSlicingTrader(t)
+	at.trader = mock // 交易器不提供盘口深度
+
+	result, err := at.closePosition("BTCUSDT", "long", 0)
+	if err != nil {
+		t.Fatalf("closePosition: %v", err)
+	}
+	if result.Pending != 0 {
+		t.Fatalf("pending = %d, want no slicing", result.Pending)
+	}
+}
+
+// assertStopOrders 检查止损止盈已按剩余数量重新挂单
+func assertStopOrders(t *testing.T, mock *mockTrader, quantity float64) {
+	t.Helper()
+	orders := mock.Orders()
+	if len(orders) != 2 {
+		t.Fatalf("orders = %+v, want stop loss and take profit", orders)
+	}
+	for _, order := range orders {
+		if diff := order.Quantity - quantity; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s quantity = %.4f, want %.4f", order.Type, order.Quantity, quantity)
+		}
+	}
+}
+
+func TestClosePositionSmallCloseIsSingleOrder(t *testing.T) {
+	at, mock, _ := newSlicingTrader(t)
+	mock.SetPosition("BTCUSDT", "long", 1.5, 100, 100) // 深度20的10%=2，一笔即可平完
+	at.lastPositions[0].Quantity = 1.5
+
+	result, err := at.closePosition("BTCUSDT", "long", 0)
+	if err != nil {
+		t.Fatalf("closePosition: %v", err)
+	}
+	if result.Pending != 0 || len(at.pendingExits) != 0 {
+		t.Fatalf("result = %+v, pending = %v, want no slices", result, at.pendingExits)
+	}
+	if n := mock.Count("CloseLong"); n != 1 {
+		t.Errorf("CloseLong called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,98 @@
+package trader
+
+import (
+	"sync"
+)
+
+// OrderFill 单笔成交
+type OrderFill struct {
+	Quantity float64
+	Price    float64
+}
+
+// WeightedAveragePrice 计算多笔成交的加权平均价（按成交数量加权）
+func WeightedAveragePrice(fills []OrderFill) (avgPrice, totalQty float64) {
+	notional := 0.0
+	for _, f := range fills {
+		if f.Quantity <= 0 || f.Price <= 0 {
+			continue
+		}
+		notional += f.Quantity * f.Price
+		totalQty += f.Quantity
+	}
+	if totalQty == 0 {
+		return 0, 0
+	}
+	return notional / totalQty, totalQty
+}
+
+// fillTracker 按持仓累计开仓成交（key: symbol_side）
+type fillTracker struct {
+	mu    sync.Mutex
+	fills map[string][]OrderFill
+}
+
+func newFillTracker() *fillTracker {
+	return &fillTracker{fills: make(map[string][]OrderFill)}
+}
+
+// Add 记录一笔成交
+func (f *fillTracker) Add(key string, quantity, price float64) {
+	if quantity <= 0 || price <= 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fills[key] = append(f.fills[key], OrderFill{Quantity: quantity, Price: price})
+}
+
+// AveragePrice 获取持仓的加权平均成交价和累计成交数量
+func (f *fillTracker) AveragePrice(key string) (avgPrice, totalQty float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return WeightedAveragePrice(f.fills[key])
+}
+
+// Prune 清理已平仓持仓的成交记录
+func (f *fillTracker) Prune(currentPositionKeys map[string]bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key := range f.fills {
+		if !currentPositionKeys[key] {
+			delete(f.fills, key)
+		}
+	}
+}
+
+// recordOrderFill 从下单结果中提取成交信息（交易所未返回成交均价时不记录）
+// 返回累计后的加权平均成交价和成交数量
+func (at *AutoTrader) recordOrderFill(posKey string, order map[string]interface{}) (avgPrice, totalQty float64) {
+	price, _ := order["avgPrice"].(float64)
+	qty, _ := order["executedQty"].(float64)
+	at.fills.Add(posKey, qty, price)
+	return at.fills.AveragePrice(posKey)
+}
+
+// filledQuantity 订单的实际成交数量（交易所未返回成交数量时按下单数量）
+func filledQuantity(order map[string]interface{}, quantity float64) float64 {
+	if qty, _ := order["executedQty"].(float64); qty > 0 && qty < quantity {
+		return qty
+	}
+	return quantity
+}
+
+// rebaseStops 按成交均价相对计划价格的偏差平移止损止盈（未设置的价位保持为0）
+// 计划按 plannedPrice 设定止损止盈距离，滑点成交后不平移会让实际风险和盈亏比偏离计划
+func rebaseStops(stopLoss, takeProfit, plannedPrice, fillPrice float64) (float64, float64) {
+	if plannedPrice <= 0 || fillPrice <= 0 {
+		return stopLoss, takeProfit
+	}
+	delta := fillPrice - plannedPrice
+	if stopLoss > 0 {
+		stopLoss += delta
+	}
+	if takeProfit > 0 {
+		takeProfit += delta
+	}
+	return stopLoss, takeProfit
+}
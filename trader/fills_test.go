@@ -0,0 +1,96 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"testing"
+)
+
+func TestWeightedAveragePriceThreeFills(t *testing.T) {
+	avg, qty := WeightedAveragePrice([]OrderFill{{Quantity: 1, Price: 100}, {Quantity: 2, Price: 103}, {Quantity: 1, Price: 106}})
+	if qty != 4 || avg != 103 {
+		t.Fatalf("avg=%.4f qty=%.4f, want 103/4", avg, qty)
+	}
+}
+
+func TestRebaseStopsKeepsPlannedDistances(t *testing.T) {
+	cases := []struct {
+		name           string
+		sl, tp         float64
+		planned, fill  float64
+		wantSL, wantTP float64
+	}{
+		{"long adverse fill", 95, 110, 100, 100.5, 95.5, 110.5},
+		{"short adverse fill", 105, 90, 100, 99.5, 104.5, 89.5},
+		{"no take profit", 95, 0, 100, 101, 96, 0},
+		{"fill unknown", 95, 110, 100, 0, 95, 110},
+	}
+	for _, tc := range cases {
+		sl, tp := rebaseStops(tc.sl, tc.tp, tc.planned, tc.fill)
+		if sl != tc.wantSL || tp != tc.wantTP {
+			t.Errorf("%s: got %.2f/%.2f, want %.2f/%.2f", tc.name, sl, tp, tc.wantSL, tc.wantTP)
+		}
+	}
+}
+
+// withMarketData 开仓使用固定行情（不请求交易所）
+func withMarketData(at *AutoTrader, data *market.Data) {
+	at.getMarketData = func(symbol string) (*market.Data, error) {
+		copied := *data
+		copied.Symbol = symbol
+		return &copied, nil
+	}
+}
+
+func TestOpenLongPartialFillWalksBook(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	withMarketData(at, &market.Data{CurrentPrice: 100})
+	mock.SetOrderBook("BTCUSDT", mockOrderBook{Asks: []bookLevel{{Price: 100, Qty: 3}, {Price: 100.5, Qty: 4}}})
+
+	var record logger.DecisionAction
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 5, PositionSizeUSD: 1000, StopLoss: 95, TakeProfit: 120}
+	if err := at.executeOpenLongWithRecord(d, &record); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	if record.Quantity != 10 {
+		t.Fatalf("requested quantity = %v, want 10", record.Quantity)
+	}
+	if record.FilledQuantity >= record.Quantity || record.FilledQuantity != 7 {
+		t.Errorf("filled = %v, want a partial fill of 7 of %v", record.FilledQuantity, record.Quantity)
+	}
+	wantAvg := (3*100 + 4*100.5) / 7.0
+	if math.Abs(record.Price-wantAvg) > 1e-9 {
+		t.Errorf("fill price = %.6f, want VWAP %.6f", record.Price, wantAvg)
+	}
+	orders := mock.Orders()
+	if len(orders) != 2 {
+		t.Fatalf("orders = %+v, want stop loss and take profit", orders)
+	}
+	for _, order := range orders {
+		if order.Quantity != 7 {
+			t.Errorf("%s quantity = %v, want the filled 7", order.Type, order.Quantity)
+		}
+	}
+}
+
+func TestOpenShortWithinBookDepthFillsFully(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	withMarketData(at, &market.Data{CurrentPrice: 100})
+	mock.SetOrderBook("ETHUSDT", mockOrderBook{Bids: []bookLevel{{Price: 99.9, Qty: 5}, {Price: 99.8, Qty: 20}}})
+
+	var record logger.DecisionAction
+	d := &decision.Decision{Symbol: "ETHUSDT", Action: "open_short", Leverage: 5, PositionSizeUSD: 1000, StopLoss: 105, TakeProfit: 80}
+	if err := at.executeOpenShortWithRecord(d, &record); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if record.FilledQuantity != record.Quantity {
+		t.Errorf("filled = %v, want the full %v", record.FilledQuantity, record.Quantity)
+	}
+	wantAvg := (5*99.9 + 5*99.8) / 10
+	if math.Abs(record.Price-wantAvg) > 1e-9 {
+		t.Errorf("fill price = %.6f, want VWAP %.6f", record.Price, wantAvg)
+	}
+}
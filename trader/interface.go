@@ -39,6 +39,14 @@ type Trader interface {
 	// CancelAllOrders 取消该币种的所有挂单
 	CancelAllOrders(symbol string) error
 
+	// CancelOrder 取消单个挂单（orderID 为 GetOpenOrders 返回的 orderId）
+	CancelOrder(symbol string, orderID int64) error
+
+	// GetOpenOrders 获取该币种的所有挂单（含止损止盈单）
+	// 每个挂单包含: orderId, symbol, side(BUY/SELL), positionSide(LONG/SHORT/BOTH),
+	// type(STOP_MARKET/TAKE_PROFIT_MARKET/LIMIT...), stopPrice, price, quantity
+	GetOpenOrders(symbol string) ([]map[string]interface{}, error)
+
 	// FormatQuantity 格式化数量到正确的精度
 	FormatQuantity(symbol string, quantity float64) (string, error)
 }
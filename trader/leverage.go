@@ -0,0 +1,140 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// CalculateSafeLeverageFloor 计算强平价与止损价保持足够距离时允许的最高杠杆
+// 强平价按 入场价 × (1 ∓ 1/杠杆) 估算（未计维持保证金，由 liquidationBufferPct 覆盖）：
+// 做多要求强平价 ≤ 止损价 × (1 - buffer)，做空要求强平价 ≥ 止损价 × (1 + buffer)。
+// liquidationBufferPct 为百分比（如 2 表示2%）；止损在入场价同侧等无效输入返回0
+func CalculateSafeLeverageFloor(entryPrice, stopLossPrice, liquidationBufferPct float64) int {
+	if entryPrice <= 0 || stopLossPrice <= 0 || entryPrice == stopLossPrice {
+		return 0
+	}
+	buffer := liquidationBufferPct / 100
+
+	// 强平距离（占入场价比例）至少为 minLiqDistance
+	var minLiqDistance float64
+	if stopLossPrice < entryPrice {
+		minLiqDistance = 1 - stopLossPrice*(1-buffer)/entryPrice
+	} else {
+		minLiqDistance = stopLossPrice*(1+buffer)/entryPrice - 1
+	}
+	if minLiqDistance <= 0 {
+		return 0
+	}
+	return int(math.Floor(1 / minLiqDistance))
+}
+
+// 交易所允许的杠杆范围
+const (
+	minExchangeLeverage = 1
+	maxExchangeLeverage = 125
+)
+
+// LeverageForLiquidationDistance 反推使强平价距入场价至少 distancePercent 的最高杠杆
+// 强平价按 入场价 × (1 ∓ 1/杠杆 ± 维持保证金率) 估算，做多做空的强平距离都是 1/杠杆 - 维持保证金率，
+// 因此 杠杆 = 1 / (距离 + 维持保证金率)。distancePercent 为百分比（如 30 表示30%），
+// maintenanceMarginRate 为比例（如 0.005）；结果限制在 1-125 倍，direction 无效时返回0
+func LeverageForLiquidationDistance(direction string, distancePercent, maintenanceMarginRate float64) int {
+	if direction != "long" && direction != "short" {
+		return 0
+	}
+	required := distancePercent/100 + math.Max(maintenanceMarginRate, 0)
+	if required <= 0 {
+		return maxExchangeLeverage
+	}
+
+	leverage := int(math.Floor(1 / required))
+	if leverage < minExchangeLeverage {
+		return minExchangeLeverage
+	}
+	if leverage > maxExchangeLeverage {
+		return maxExchangeLeverage
+	}
+	return leverage
+}
+
+// applyLiquidationDistanceLeverage 按目标强平距离选择杠杆（不超过该币种配置的杠杆上限）
+func (at *AutoTrader) applyLiquidationDistanceLeverage(d *decision.Decision, data *market.Data) error {
+	distance := at.config.Risk.TargetLiquidationDistancePct
+	if distance <= 0 {
+		return nil
+	}
+
+	leverage := LeverageForLiquidationDistance(entrySide(d), distance*100, at.config.Risk.MaintenanceMarginRate)
+	maxLeverage := at.config.AltcoinLeverage
+	if AssetClass(d.Symbol) == AssetClassBTCETH {
+		maxLeverage = at.config.BTCETHLeverage
+	}
+	if maxLeverage > 0 && leverage > maxLeverage {
+		leverage = maxLeverage
+	}
+	if leverage != d.Leverage {
+		at.logger.Infof("  🎯 %s 按强平距离 %.0f%% 选择杠杆: %dx → %dx", d.Symbol, distance*100, d.Leverage, leverage)
+		d.Leverage = leverage
+	}
+	return nil
+}
+
+// RecommendLeverage 按已实现波动的分位推荐杠杆：分位0（近期最平静）用满 assetCap，分位1（近期最剧烈）为1倍，
+// 中间线性插值。realizedVolPercentile 超出[0,1]时按边界处理
+func RecommendLeverage(realizedVolPercentile float64, assetCap int) int {
+	if assetCap <= minExchangeLeverage {
+		return minExchangeLeverage
+	}
+	p := clamp01(realizedVolPercentile)
+	leverage := int(math.Round(float64(assetCap) - p*float64(assetCap-minExchangeLeverage)))
+	if leverage < minExchangeLeverage {
+		return minExchangeLeverage
+	}
+	if leverage > assetCap {
+		return assetCap
+	}
+	return leverage
+}
+
+// applyVolatilityLeverage 按4小时ATR分位计算杠杆上限（不超过该币种配置的杠杆上限）
+// 只会下调AI选择的杠杆，不会上调；AI未给出杠杆时使用推荐值
+func (at *AutoTrader) applyVolatilityLeverage(d *decision.Decision, data *market.Data) error {
+	if !at.config.Risk.VolatilityLeverage || data == nil || data.LongerTermContext == nil {
+		return nil
+	}
+
+	maxLeverage := at.config.AltcoinLeverage
+	if AssetClass(d.Symbol) == AssetClassBTCETH {
+		maxLeverage = at.config.BTCETHLeverage
+	}
+	percentile := data.LongerTermContext.ATRPercentile
+	leverage := RecommendLeverage(percentile, maxLeverage)
+	if d.Leverage > 0 && d.Leverage <= leverage {
+		return nil
+	}
+	if leverage != d.Leverage {
+		at.logger.Infof("  🌡 %s 按波动分位 %.0f%% 下调杠杆: %dx → %dx", d.Symbol, percentile*100, d.Leverage, leverage)
+		d.Leverage = leverage
+	}
+	return nil
+}
+
+// applySafeLeverage 杠杆过高导致强平价贴近止损时下调杠杆
+// 山寨币波动大、插针多，强平价离止损太近时可能先被强平，止损形同虚设
+func (at *AutoTrader) applySafeLeverage(d *decision.Decision, data *market.Data) error {
+	buffer := at.config.Risk.LiquidationBufferPct
+	if buffer <= 0 || d.Leverage <= 1 {
+		return nil
+	}
+
+	maxLeverage := CalculateSafeLeverageFloor(data.ReferencePrice(at.config.Risk.EntryPriceRef), d.StopLoss, buffer*100)
+	if maxLeverage < 1 {
+		maxLeverage = 1
+	}
+	if d.Leverage > maxLeverage {
+		at.logger.Infof("  🛡 %s 杠杆 %dx 的强平价距止损不足 %.1f%%，下调为 %dx", d.Symbol, d.Leverage, buffer*100, maxLeverage)
+		d.Leverage = maxLeverage
+	}
+	return nil
+}
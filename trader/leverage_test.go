@@ -0,0 +1,105 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+)
+
+func TestApplyVolatilityLeverageOnlyLowers(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{VolatilityLeverage: true})
+	at.config.BTCETHLeverage = 20
+	calm := &market.Data{LongerTermContext: &market.LongerTermData{ATRPercentile: 0}}
+	wild := &market.Data{LongerTermContext: &market.LongerTermData{ATRPercentile: 1}}
+
+	cases := []struct {
+		name string
+		ai   int
+		data *market.Data
+		want int
+	}{
+		{"calm market keeps AI leverage", 5, calm, 5},
+		{"volatile market lowers AI leverage", 10, wild, 1},
+		{"AI leverage below recommendation is kept", 3, &market.Data{LongerTermContext: &market.LongerTermData{ATRPercentile: 0.5}}, 3},
+		{"AI leverage above recommendation is capped", 15, &market.Data{LongerTermContext: &market.LongerTermData{ATRPercentile: 0.5}}, 11},
+		{"missing AI leverage uses recommendation", 0, calm, 20},
+	}
+	for _, c := range cases {
+		d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: c.ai}
+		if err := at.applyVolatilityLeverage(d, c.data); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if d.Leverage != c.want {
+			t.Errorf("%s: leverage = %d, want %d", c.name, d.Leverage, c.want)
+		}
+	}
+}
+
+func TestApplyVolatilityLeverageDisabled(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	at.config.BTCETHLeverage = 20
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 10}
+	if err := at.applyVolatilityLeverage(d, &market.Data{LongerTermContext: &market.LongerTermData{ATRPercentile: 1}}); err != nil || d.Leverage != 10 {
+		t.Fatalf("leverage = %d, err = %v, want 10 unchanged", d.Leverage, err)
+	}
+}
+
+// liquidationDistance 杠杆对应的强平距离（占入场价比例）
+func liquidationDistance(leverage int, mmr float64) float64 {
+	return 1/float64(leverage) - mmr
+}
+
+func TestLeverageForLiquidationDistanceKeepsTarget(t *testing.T) {
+	const mmr = 0.005
+	for _, target := range []float64{2, 5, 9.5, 15, 30, 50, 80} {
+		for _, side := range []string{"long", "short"} {
+			leverage := LeverageForLiquidationDistance(side, target, mmr)
+			if got := liquidationDistance(leverage, mmr); got < target/100-1e-12 {
+				t.Errorf("%s %.1f%%: %dx gives distance %.4f, want >= %.4f", side, target, leverage, got, target/100)
+			}
+			// 是满足目标的最高杠杆
+			if leverage < maxExchangeLeverage && liquidationDistance(leverage+1, mmr) >= target/100 {
+				t.Errorf("%s %.1f%%: %dx is not the highest leverage keeping the target", side, target, leverage)
+			}
+		}
+	}
+}
+
+func TestLeverageForLiquidationDistanceKnownPairs(t *testing.T) {
+	cases := []struct {
+		distance, mmr float64
+		want          int
+	}{
+		{30, 0.005, 3},   // 1/0.305 = 3.28
+		{19.5, 0.005, 5}, // 1/0.2 = 5
+		{0.1, 0, 125},    // 1000倍，限制为交易所上限
+		{150, 0.005, 1},  // 不足1倍，限制为1倍
+		{0, 0, 125},
+	}
+	for _, tc := range cases {
+		if got := LeverageForLiquidationDistance("long", tc.distance, tc.mmr); got != tc.want {
+			t.Errorf("LeverageForLiquidationDistance(%.1f%%, %.3f) = %d, want %d", tc.distance, tc.mmr, got, tc.want)
+		}
+	}
+	if got := LeverageForLiquidationDistance("wait", 30, 0.005); got != 0 {
+		t.Errorf("invalid direction = %d, want 0", got)
+	}
+}
+
+func TestApplyLiquidationDistanceLeverageRespectsConfiguredCap(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{TargetLiquidationDistancePct: 0.02, MaintenanceMarginRate: 0.005})
+	at.config.AltcoinLeverage = 5
+	at.config.BTCETHLeverage = 20
+
+	// 2% 强平距离允许40倍，分别受山寨币5倍和BTC 20倍上限约束
+	alt := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", Leverage: 3}
+	btc := &decision.Decision{Symbol: "BTCUSDT", Action: "open_short", Leverage: 3}
+	for _, d := range []*decision.Decision{alt, btc} {
+		if err := at.applyLiquidationDistanceLeverage(d, &market.Data{Symbol: d.Symbol, CurrentPrice: 100}); err != nil {
+			t.Fatalf("%s: %v", d.Symbol, err)
+		}
+	}
+	if alt.Leverage != 5 || btc.Leverage != 20 {
+		t.Errorf("leverage = %d/%d, want 5/20", alt.Leverage, btc.Leverage)
+	}
+}
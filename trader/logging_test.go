@@ -0,0 +1,19 @@
+package trader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetLoggerRoutesTraderLogs(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	out := &captureLogger{}
+	at.SetLogger(out)
+
+	at.ResetDailyStats()
+
+	lines := out.Lines()
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "INFO") || !strings.Contains(lines[0], "日盈亏已重置") {
+		t.Fatalf("lines = %q, want the reset message at info", lines)
+	}
+}
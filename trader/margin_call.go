@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"sort"
+)
+
+// marginCallEquityBuffer 预警范围：净值再下跌该比例即触发追保时提前减仓
+const marginCallEquityBuffer = 0.10
+
+// marginCallSizeRatio 追保预警期间新开仓位的缩减比例
+const marginCallSizeRatio = 0.5
+
+// MarginCallSimulation 追保模拟结果
+type MarginCallSimulation struct {
+	IsMarginCall        bool     `json:"is_margin_call"`
+	TotalMarginRequired float64  `json:"total_margin_required"` // 维持保证金合计
+	CurrentMargin       float64  `json:"current_margin"`        // 当前净值
+	Shortfall           float64  `json:"shortfall"`             // 保证金缺口（无缺口为0）
+	PositionsToReduce   []string `json:"positions_to_reduce"`   // 补足缺口需平掉的持仓（按未实现亏损从大到小）
+}
+
+// SimulateMarginCall 模拟账户是否触发追保
+// 维持保证金 = Σ 持仓名义价值 × maintenanceMarginRate；净值低于维持保证金即视为追保。
+// 有缺口时按未实现亏损从大到小选出需要平仓的持仓，直到释放的维持保证金足以覆盖缺口
+func SimulateMarginCall(positions []decision.PositionInfo, currentEquity float64, maintenanceMarginRate float64) *MarginCallSimulation {
+	sim := &MarginCallSimulation{CurrentMargin: currentEquity}
+
+	for _, pos := range positions {
+		sim.TotalMarginRequired += pos.Quantity * pos.MarkPrice * maintenanceMarginRate
+	}
+	if currentEquity >= sim.TotalMarginRequired {
+		return sim
+	}
+
+	sim.IsMarginCall = true
+	sim.Shortfall = sim.TotalMarginRequired - currentEquity
+
+	sorted := make([]decision.PositionInfo, len(positions))
+	copy(sorted, positions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].UnrealizedPnL < sorted[j].UnrealizedPnL })
+
+	freed := 0.0
+	for _, pos := range sorted {
+		if freed >= sim.Shortfall {
+			break
+		}
+		freed += pos.Quantity * pos.MarkPrice * maintenanceMarginRate
+		sim.PositionsToReduce = append(sim.PositionsToReduce, pos.Symbol)
+	}
+	return sim
+}
+
+// checkMarginCallRisk 决策前检查净值再下跌10%是否会触发追保
+// 会触发时在提示词中加入风险警告，并将本周期新开仓位减半
+func (at *AutoTrader) checkMarginCallRisk(ctx *decision.Context) {
+	at.marginCallWarning = false
+	rate := at.config.Risk.MaintenanceMarginRate
+	if rate <= 0 || len(ctx.Positions) == 0 {
+		return
+	}
+
+	projected := SimulateMarginCall(ctx.Positions, ctx.Account.TotalEquity*(1-marginCallEquityBuffer), rate)
+	if !projected.IsMarginCall {
+		return
+	}
+
+	at.marginCallWarning = true
+	warning := fmt.Sprintf("净值再下跌%.0f%%将触发追保（维持保证金 %.2f USDT），建议优先减仓: %v，本周期新开仓位减半",
+		marginCallEquityBuffer*100, projected.TotalMarginRequired, projected.PositionsToReduce)
+	ctx.RiskWarnings = append(ctx.RiskWarnings, warning)
+	at.logger.Infof("🚨 %s", warning)
+}
+
+// applyMarginCallSizing 追保预警期间缩减新开仓位
+func (at *AutoTrader) applyMarginCallSizing(d *decision.Decision) {
+	if !at.marginCallWarning {
+		return
+	}
+	original := d.PositionSizeUSD
+	d.PositionSizeUSD = original * marginCallSizeRatio
+	at.logger.Infof("  🚨 %s 追保预警，仓位 %.2f → %.2f USDT", d.Symbol, original, d.PositionSizeUSD)
+}
@@ -0,0 +1,419 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockOrder mockTrader 上的挂单
+type mockOrder struct {
+	ID           int64
+	Symbol       string
+	PositionSide string
+	Type         string // STOP_MARKET / TAKE_PROFIT_MARKET
+	Quantity     float64
+	StopPrice    float64
+}
+
+// bookLevel 盘口中的一档
+type bookLevel struct {
+	Price float64
+	Qty   float64
+}
+
+// mockOrderBook 模拟盘口（开多吃卖盘，开空吃买盘，按价格优先排列）
+type mockOrderBook struct {
+	Bids []bookLevel
+	Asks []bookLevel
+}
+
+// mockTrader 内存中的交易器（实现 Trader 接口，用于测试）
+type mockTrader struct {
+	mu        sync.Mutex
+	balance   map[string]interface{}
+	positions []map[string]interface{}
+	prices    map[string]float64
+	orders    []mockOrder
+	books     map[string]*mockOrderBook // 设置了盘口的币种开仓时逐档成交
+	calls     []string
+	nextID    int64
+
+	// errs 方法名 -> 返回的错误
+	// failAfter 方法名 -> 前 N 次调用成功，之后返回 errs 中的错误；failFirst 方法名 -> 只有前 N 次调用返回错误
+	errs      map[string]error
+	failAfter map[string]int
+	failFirst map[string]int
+	counts    map[string]int
+}
+
+func newMockTrader() *mockTrader {
+	return &mockTrader{
+		balance: map[string]interface{}{
+			"totalWalletBalance":    1000.0,
+			"availableBalance":      1000.0,
+			"totalUnrealizedProfit": 0.0,
+		},
+		prices:    make(map[string]float64),
+		books:     make(map[string]*mockOrderBook),
+		errs:      make(map[string]error),
+		failAfter: make(map[string]int),
+		failFirst: make(map[string]int),
+		counts:    make(map[string]int),
+	}
+}
+
+// call 记录调用，返回该调用应返回的错误
+func (m *mockTrader) call(name string, args ...interface{}) error {
+	m.calls = append(m.calls, fmt.Sprintf("%s%v", name, args))
+	m.counts[name]++
+	err := m.errs[name]
+	if err == nil {
+		return nil
+	}
+	if n, ok := m.failAfter[name]; ok && m.counts[name] <= n {
+		return nil
+	}
+	if n, ok := m.failFirst[name]; ok && m.counts[name] > n {
+		return nil
+	}
+	return err
+}
+
+// Calls 已调用的方法（按顺序）
+func (m *mockTrader) Calls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+// Count 某方法的调用次数
+func (m *mockTrader) Count(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[name]
+}
+
+// Orders 当前挂单
+func (m *mockTrader) Orders() []mockOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mockOrder(nil), m.orders...)
+}
+
+// SetOrderBook 设置币种的盘口，之后的开仓按盘口逐档成交
+func (m *mockTrader) SetOrderBook(symbol string, book mockOrderBook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.books[symbol] = &book
+}
+
+// walkBook 按盘口逐档成交，返回成交均价（按数量加权）和成交数量，深度不足时部分成交
+// 成交的数量从盘口中扣除
+func (m *mockTrader) walkBook(symbol, side string, quantity float64) (avgPrice, filled float64) {
+	book := m.books[symbol]
+	levels := &book.Asks
+	if side == "short" {
+		levels = &book.Bids
+	}
+	notional := 0.0
+	for len(*levels) > 0 && filled < quantity {
+		level := &(*levels)[0]
+		qty := math.Min(level.Qty, quantity-filled)
+		notional += qty * level.Price
+		filled += qty
+		level.Qty -= qty
+		if level.Qty <= 1e-12 {
+			*levels = (*levels)[1:]
+		}
+	}
+	if filled == 0 {
+		return 0, 0
+	}
+	return notional / filled, filled
+}
+
+// SetPosition 设置持仓（quantity<=0 时移除）
+func (m *mockTrader) SetPosition(symbol, side string, quantity, entryPrice, markPrice float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prices[symbol] = markPrice
+	for i, pos := range m.positions {
+		if pos["symbol"] == symbol && pos["side"] == side {
+			m.positions = append(m.positions[:i], m.positions[i+1:]...)
+			break
+		}
+	}
+	if quantity <= 0 {
+		return
+	}
+	m.positions = append(m.positions, map[string]interface{}{
+		"symbol":           symbol,
+		"side":             side,
+		"positionAmt":      quantity,
+		"entryPrice":       entryPrice,
+		"markPrice":        markPrice,
+		"unRealizedProfit": 0.0,
+		"leverage":         5.0,
+		"liquidationPrice": 0.0,
+	})
+}
+
+func (m *mockTrader) GetBalance() (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call("GetBalance"); err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(m.balance))
+	for k, v := range m.balance {
+		result[k] = v
+	}
+	return result, nil
+}
+
+func (m *mockTrader) GetPositions() ([]map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call("GetPositions"); err != nil {
+		return nil, err
+	}
+	result := make([]map[string]interface{}, 0, len(m.positions))
+	for _, pos := range m.positions {
+		copied := make(map[string]interface{}, len(pos))
+		for k, v := range pos {
+			copied[k] = v
+		}
+		result = append(result, copied)
+	}
+	return result, nil
+}
+
+func (m *mockTrader) open(name, symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call(name, symbol, quantity, leverage); err != nil {
+		return nil, err
+	}
+	avgPrice, filled := m.prices[symbol], quantity
+	if _, ok := m.books[symbol]; ok {
+		if avgPrice, filled = m.walkBook(symbol, side, quantity); filled == 0 {
+			return nil, fmt.Errorf("%s 盘口深度不足，无法成交", symbol)
+		}
+	}
+	m.nextID++
+	return map[string]interface{}{"orderId": m.nextID, "symbol": symbol, "avgPrice": avgPrice, "executedQty": filled}, nil
+}
+
+func (m *mockTrader) close(name, symbol, side string, quantity float64) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call(name, symbol, quantity); err != nil {
+		return nil, err
+	}
+	for i, pos := range m.positions {
+		if pos["symbol"] != symbol || pos["side"] != side {
+			continue
+		}
+		remaining := pos["positionAmt"].(float64) - quantity
+		if quantity <= 0 || remaining <= 1e-9 {
+			m.positions = append(m.positions[:i], m.positions[i+1:]...)
+		} else {
+			pos["positionAmt"] = remaining
+		}
+		break
+	}
+	// 与交易所实现一致：平仓后撤销该币种全部挂单
+	kept := m.orders[:0]
+	for _, order := range m.orders {
+		if order.Symbol != symbol {
+			kept = append(kept, order)
+		}
+	}
+	m.orders = kept
+	m.nextID++
+	return map[string]interface{}{"orderId": m.nextID, "symbol": symbol, "avgPrice": m.prices[symbol], "executedQty": quantity}, nil
+}
+
+func (m *mockTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return m.open("OpenLong", symbol, "long", quantity, leverage)
+}
+
+func (m *mockTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return m.open("OpenShort", symbol, "short", quantity, leverage)
+}
+
+func (m *mockTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return m.close("CloseLong", symbol, "long", quantity)
+}
+
+func (m *mockTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return m.close("CloseShort", symbol, "short", quantity)
+}
+
+func (m *mockTrader) SetLeverage(symbol string, leverage int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.call("SetLeverage", symbol, leverage)
+}
+
+func (m *mockTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.call("SetMarginMode", symbol, isCrossMargin)
+}
+
+func (m *mockTrader) GetMarketPrice(symbol string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call("GetMarketPrice", symbol); err != nil {
+		return 0, err
+	}
+	price, ok := m.prices[symbol]
+	if !ok {
+		return 0, fmt.Errorf("no price for %s", symbol)
+	}
+	return price, nil
+}
+
+func (m *mockTrader) placeOrder(name, orderType, symbol, positionSide string, quantity, price float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call(name, symbol, positionSide, quantity, price); err != nil {
+		return err
+	}
+	m.nextID++
+	m.orders = append(m.orders, mockOrder{ID: m.nextID, Symbol: symbol, PositionSide: positionSide, Type: orderType, Quantity: quantity, StopPrice: price})
+	return nil
+}
+
+func (m *mockTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return m.placeOrder("SetStopLoss", "STOP_MARKET", symbol, positionSide, quantity, stopPrice)
+}
+
+func (m *mockTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return m.placeOrder("SetTakeProfit", "TAKE_PROFIT_MARKET", symbol, positionSide, quantity, takeProfitPrice)
+}
+
+func (m *mockTrader) CancelAllOrders(symbol string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call("CancelAllOrders", symbol); err != nil {
+		return err
+	}
+	kept := m.orders[:0]
+	for _, order := range m.orders {
+		if order.Symbol != symbol {
+			kept = append(kept, order)
+		}
+	}
+	m.orders = kept
+	return nil
+}
+
+func (m *mockTrader) CancelOrder(symbol string, orderID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call("CancelOrder", symbol, orderID); err != nil {
+		return err
+	}
+	for i, order := range m.orders {
+		if order.ID == orderID {
+			m.orders = append(m.orders[:i], m.orders[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("order %d not found", orderID)
+}
+
+func (m *mockTrader) GetOpenOrders(symbol string) ([]map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.call("GetOpenOrders", symbol); err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for _, order := range m.orders {
+		if order.Symbol != symbol {
+			continue
+		}
+		side := "SELL"
+		if order.PositionSide == "SHORT" {
+			side = "BUY"
+		}
+		result = append(result, map[string]interface{}{
+			"orderId":      order.ID,
+			"symbol":       order.Symbol,
+			"side":         side,
+			"positionSide": order.PositionSide,
+			"type":         order.Type,
+			"stopPrice":    order.StopPrice,
+			"quantity":     order.Quantity,
+		})
+	}
+	return result, nil
+}
+
+func (m *mockTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return strconv.FormatFloat(quantity, 'f', 4, 64), nil
+}
+
+// newTestAutoTrader 创建使用 mockTrader 的 AutoTrader（工作目录切到临时目录，决策日志不落在源码目录）
+func newTestAutoTrader(t *testing.T, risk RiskConfig) (*AutoTrader, *mockTrader) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	at, err := NewAutoTrader(AutoTraderConfig{
+		ID:             "test",
+		Name:           "test",
+		InitialBalance: 1000,
+		ScanInterval:   time.Minute,
+		Risk:           risk,
+	})
+	if err != nil {
+		t.Fatalf("NewAutoTrader: %v", err)
+	}
+	mock := newMockTrader()
+	at.trader = mock
+	at.SetPriceOracle(nil)
+	at.getMarketData = func(symbol string) (*market.Data, error) {
+		return nil, fmt.Errorf("测试中没有 %s 行情", symbol)
+	}
+	at.getKlines = func(symbol, interval string) ([]market.Kline, error) {
+		return nil, fmt.Errorf("测试中没有 %s %s K线", symbol, interval)
+	}
+	return at, mock
+}
+
+// fixedClock 可手动推进的时钟
+type fixedClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fixedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// staticPriceOracle 返回固定价格的价格来源
+type staticPriceOracle map[string]float64
+
+func (o staticPriceOracle) GetPrice(symbol string) (float64, error) {
+	price, ok := o[symbol]
+	if !ok {
+		return 0, fmt.Errorf("no price for %s", symbol)
+	}
+	return price, nil
+}
@@ -0,0 +1,81 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// NetTargetReward 止盈价成交时扣除双边手续费后的净收益
+// 返回净收益（USDT）及其占仓位价值的基点数；止盈价不在盈利方向时为负
+func NetTargetReward(side string, entryPrice, takeProfit, positionUSD, feeRate float64) (netUSD, netBps float64) {
+	if entryPrice <= 0 || takeProfit <= 0 || positionUSD <= 0 {
+		return 0, 0
+	}
+	quantity := positionUSD / entryPrice
+	gross := quantity * (takeProfit - entryPrice)
+	if side == "short" {
+		gross = -gross
+	}
+	netUSD = gross - EstimateTradingFees(positionUSD, quantity*takeProfit, feeRate)
+	return netUSD, netUSD / positionUSD * 10000
+}
+
+// checkNetReward 净收益过滤：目标过近、手续费吃掉大部分收益的开仓（如低波动币种的短线）不执行
+func (at *AutoTrader) checkNetReward(d *decision.Decision, data *market.Data) error {
+	cfg := at.config.Risk
+	if (cfg.MinNetRewardBps <= 0 && cfg.MinNetRewardUSD <= 0) || d.TakeProfit <= 0 {
+		return nil
+	}
+
+	entryPrice := data.ReferencePrice(cfg.EntryPriceRef)
+	netUSD, netBps := NetTargetReward(entrySide(d), entryPrice, d.TakeProfit, d.PositionSizeUSD, cfg.FeeRate)
+	if cfg.MinNetRewardBps > 0 && netBps < cfg.MinNetRewardBps {
+		return fmt.Errorf("%s 扣除手续费后目标净收益 %.1f bps 低于下限 %.1f bps，不开仓", d.Symbol, netBps, cfg.MinNetRewardBps)
+	}
+	if cfg.MinNetRewardUSD > 0 && netUSD < cfg.MinNetRewardUSD {
+		return fmt.Errorf("%s 扣除手续费后目标净收益 %.2f USDT 低于下限 %.2f USDT，不开仓", d.Symbol, netUSD, cfg.MinNetRewardUSD)
+	}
+	return nil
+}
+
+// BreakEvenWinRate 盈亏平衡胜率：按此胜率交易的期望收益为0
+// rewardRisk 为盈亏比（止盈距离/止损距离），feeCost 为一笔交易的手续费占止损风险的比例（以R计）；
+// 盈利 rewardRisk-feeCost、亏损 1+feeCost，解得 p = (1+feeCost)/(rewardRisk+1)，结果限制在 [0, 1]
+func BreakEvenWinRate(rewardRisk, feeCost float64) float64 {
+	if rewardRisk <= 0 {
+		return 1
+	}
+	return math.Max(0, math.Min(1, (1+feeCost)/(rewardRisk+1)))
+}
+
+// checkBreakEvenWinRate 盈亏平衡胜率检查：信心度（视为胜率）低于盈亏平衡胜率加安全边际时不开仓
+// 使用的是经 decision.AdjustConfidence 按技术面因子调整后的信心度；系统没有按历史胜率校准信心度，
+// 调整后的信心度是目前最接近校准胜率的值，AI信心度普遍偏高时应调大 break_even_win_rate_margin
+// 盈亏平衡胜率始终写入决策（便于复盘），只有启用时才拒绝
+func (at *AutoTrader) checkBreakEvenWinRate(d *decision.Decision, data *market.Data) error {
+	cfg := at.config.Risk
+	entryPrice := data.ReferencePrice(cfg.EntryPriceRef)
+	if entryPrice <= 0 || d.StopLoss <= 0 || d.TakeProfit <= 0 || d.PositionSizeUSD <= 0 {
+		return nil
+	}
+	riskDistance := math.Abs(entryPrice - d.StopLoss)
+	if riskDistance == 0 {
+		return nil
+	}
+	rewardRisk := math.Abs(d.TakeProfit-entryPrice) / riskDistance
+	riskUSD := d.PositionSizeUSD * riskDistance / entryPrice
+	feeCost := EstimateTradingFees(d.PositionSizeUSD, d.PositionSizeUSD, cfg.FeeRate) / riskUSD
+	d.BreakEvenWinRate = BreakEvenWinRate(rewardRisk, feeCost)
+
+	if !cfg.EnableBreakEvenCheck {
+		return nil
+	}
+	required := d.BreakEvenWinRate + cfg.BreakEvenWinRateMargin
+	if float64(d.Confidence)/100 < required {
+		return fmt.Errorf("%s 信心度 %d%% 低于盈亏平衡胜率 %.1f%% + 边际 %.1f%%（盈亏比 %.2f），不开仓",
+			d.Symbol, d.Confidence, d.BreakEvenWinRate*100, cfg.BreakEvenWinRateMargin*100, rewardRisk)
+	}
+	return nil
+}
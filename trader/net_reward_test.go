@@ -0,0 +1,117 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+)
+
+func TestBreakEvenWinRate(t *testing.T) {
+	cases := []struct {
+		rewardRisk, feeCost, want float64
+	}{
+		{1, 0, 0.5},
+		{2, 0, 1.0 / 3},
+		{3, 0, 0.25},
+		{0.5, 0, 2.0 / 3},
+		{1, 0.1, 0.55},
+		{3, 0.2, 0.3},
+		{0, 0, 1},
+		{0.1, 1, 1}, // 手续费过高时无论胜率多少都不盈利
+	}
+	for _, c := range cases {
+		if got := BreakEvenWinRate(c.rewardRisk, c.feeCost); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("BreakEvenWinRate(%v, %v) = %v, want %v", c.rewardRisk, c.feeCost, got, c.want)
+		}
+	}
+}
+
+func TestCheckBreakEvenWinRateBoundary(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{EnableBreakEvenCheck: true, BreakEvenWinRateMargin: 0.05})
+	data := &market.Data{CurrentPrice: 100}
+	// 盈亏比 2，手续费 1 USDT / 风险 100 USDT = 0.01R：盈亏平衡胜率 1.01/3 ≈ 33.7%，加边际后要求 ≈ 38.7%
+	plan := func(confidence int) *decision.Decision {
+		return &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Confidence: confidence,
+			StopLoss: 90, TakeProfit: 120, PositionSizeUSD: 1000}
+	}
+
+	accepted := plan(39)
+	if err := at.checkBreakEvenWinRate(accepted, data); err != nil {
+		t.Fatalf("confidence 39 rejected: %v", err)
+	}
+	if math.Abs(accepted.BreakEvenWinRate-1.01/3) > 1e-9 {
+		t.Errorf("BreakEvenWinRate = %v, want %v", accepted.BreakEvenWinRate, 1.01/3)
+	}
+	if err := at.checkBreakEvenWinRate(plan(38), data); err == nil {
+		t.Fatal("confidence 38 accepted below the break-even win rate plus margin")
+	}
+}
+
+func TestCheckBreakEvenWinRateDisabledOnlyAnnotates(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_short", Confidence: 10,
+		StopLoss: 110, TakeProfit: 90, PositionSizeUSD: 1000}
+	if err := at.checkBreakEvenWinRate(d, &market.Data{CurrentPrice: 100}); err != nil {
+		t.Fatalf("disabled check rejected: %v", err)
+	}
+	if d.BreakEvenWinRate <= 0.5 {
+		t.Errorf("BreakEvenWinRate = %v, want just above 50%% for 1:1 with fees", d.BreakEvenWinRate)
+	}
+}
+
+func TestNetTargetReward(t *testing.T) {
+	// 1000 USDT 仓位，目标 0.15%：毛收益 1.5，双边手续费 (1000+1001.5)×0.05% = 1.00075
+	netUSD, netBps := NetTargetReward("long", 100, 100.15, 1000, 0.0005)
+	if math.Abs(netUSD-0.49925) > 1e-9 || math.Abs(netBps-4.9925) > 1e-9 {
+		t.Errorf("long net = %.5f USDT / %.4f bps, want 0.49925 / 4.9925", netUSD, netBps)
+	}
+	// 空头：平仓价值 998.5，手续费 0.99925
+	netUSD, _ = NetTargetReward("short", 100, 99.85, 1000, 0.0005)
+	if math.Abs(netUSD-0.50075) > 1e-9 {
+		t.Errorf("short net = %.5f USDT, want 0.50075", netUSD)
+	}
+	// 止盈价在亏损方向时为负
+	if netUSD, _ := NetTargetReward("long", 100, 99, 1000, 0); netUSD >= 0 {
+		t.Errorf("target on the losing side net = %.4f, want negative", netUSD)
+	}
+}
+
+func TestCheckNetRewardRejectsScalpEatenByFees(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MinNetRewardBps: 10, FeeRate: 0.0005})
+	data := &market.Data{Symbol: "XRPUSDT", CurrentPrice: 100}
+
+	// 低波动币种短线：目标 15 bps，扣除约 10 bps 手续费后只剩 5 bps
+	scalp := &decision.Decision{Symbol: "XRPUSDT", Action: "open_long", PositionSizeUSD: 1000, StopLoss: 99.9, TakeProfit: 100.15}
+	if err := at.checkNetReward(scalp, data); err == nil {
+		t.Fatal("scalp accepted although fees leave only 5 bps")
+	}
+
+	// 不计手续费时同样的目标满足下限
+	at.config.Risk.FeeRate = 0
+	if err := at.checkNetReward(scalp, data); err != nil {
+		t.Errorf("scalp without fees rejected: %v", err)
+	}
+
+	// 目标足够远时扣除手续费仍满足下限（约 40 bps）
+	at.config.Risk.FeeRate = 0.0005
+	wide := &decision.Decision{Symbol: "XRPUSDT", Action: "open_long", PositionSizeUSD: 1000, StopLoss: 99, TakeProfit: 100.5}
+	if err := at.checkNetReward(wide, data); err != nil {
+		t.Errorf("wide target rejected: %v", err)
+	}
+}
+
+func TestCheckNetRewardMinUSD(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MinNetRewardUSD: 1, FeeRate: 0.0005})
+	data := &market.Data{Symbol: "XRPUSDT", CurrentPrice: 100}
+
+	// 净收益约 0.5 USDT < 1 USDT
+	d := &decision.Decision{Symbol: "XRPUSDT", Action: "open_short", PositionSizeUSD: 1000, StopLoss: 100.1, TakeProfit: 99.85}
+	if err := at.checkNetReward(d, data); err == nil {
+		t.Fatal("scalp accepted below the USD minimum")
+	}
+	d.PositionSizeUSD = 3000 // 净收益约 1.5 USDT
+	if err := at.checkNetReward(d, data); err != nil {
+		t.Errorf("larger scalp rejected: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+)
+
+// 禁止开仓区的支撑阻力识别参数
+const (
+	noTradeZonePivotStrength = 2     // 摆动点识别强度（左右各N根K线）
+	noTradeZoneLevelMerge    = 0.003 // 合并为同一价位的价格容差（0.3%）
+)
+
+// checkNoTradeZone 禁止开仓区检查
+// 价格贴近强支撑/阻力位或整数关口时容易来回震荡，入场质量差；AI明确以突破该价位为开仓理由时放行（由 checkBreakoutVolume 确认量能）
+func (at *AutoTrader) checkNoTradeZone(d *decision.Decision, data *market.Data) error {
+	cfg := at.config.Risk
+	if cfg.NoTradeZonePct <= 0 || data.CurrentPrice <= 0 || isBreakoutPlan(d) {
+		return nil
+	}
+
+	price := data.CurrentPrice
+
+	if round := market.NearestRoundNumber(price); withinPct(price, round, cfg.NoTradeZonePct) {
+		return fmt.Errorf("%s 当前价 %.4f 贴近整数关口 %.4f（%.2f%%以内），处于禁止开仓区",
+			d.Symbol, price, round, cfg.NoTradeZonePct*100)
+	}
+
+	klines, err := at.getKlines(d.Symbol, cfg.NoTradeZoneInterval)
+	if err != nil {
+		return nil // 拿不到K线时不阻止开仓
+	}
+	for _, level := range market.FindSupportResistance(klines, noTradeZonePivotStrength, noTradeZoneLevelMerge) {
+		if level.Touches < cfg.NoTradeZoneMinTouches {
+			break // 已按触及次数降序排列
+		}
+		if withinPct(price, level.Price, cfg.NoTradeZonePct) {
+			return fmt.Errorf("%s 当前价 %.4f 贴近强%s位 %.4f（触及%d次），处于禁止开仓区",
+				d.Symbol, price, levelTypeName(level.Type), level.Price, level.Touches)
+		}
+	}
+	return nil
+}
+
+// isBreakoutPlan AI的开仓理由是否为突破交易
+func isBreakoutPlan(d *decision.Decision) bool {
+	reasoning := strings.ToLower(d.Reasoning)
+	return strings.Contains(reasoning, "突破") || strings.Contains(reasoning, "breakout")
+}
+
+// withinPct 两个价格的差距是否在 pct 比例以内
+func withinPct(price, level, pct float64) bool {
+	return level > 0 && math.Abs(price-level)/level <= pct
+}
+
+// levelTypeName 支撑阻力类型的中文名称
+func levelTypeName(levelType string) string {
+	if levelType == "support" {
+		return "支撑"
+	}
+	return "阻力"
+}
@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+)
+
+// withKlines 让风控检查使用固定的K线
+func withKlines(at *AutoTrader, klines []market.Kline) {
+	at.getKlines = func(symbol, interval string) ([]market.Kline, error) {
+		return klines, nil
+	}
+}
+
+// hlKlines 由 (high, low) 序列生成K线
+func hlKlines(points [][2]float64) []market.Kline {
+	klines := make([]market.Kline, len(points))
+	for i, p := range points {
+		mid := (p[0] + p[1]) / 2
+		klines[i] = market.Kline{Open: mid, Close: mid, High: p[0], Low: p[1]}
+	}
+	return klines
+}
+
+func TestNoTradeZoneRoundNumber(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{NoTradeZonePct: 0.005, NoTradeZoneMinTouches: 2, NoTradeZoneInterval: "4h"})
+
+	cases := []struct {
+		name    string
+		price   float64
+		blocked bool
+	}{
+		{"inside the zone", 100.3, true},
+		{"on the zone edge", 100.5, true},
+		{"outside the zone", 100.6, false},
+		{"below the round number", 99.7, true},
+	}
+	for _, tc := range cases {
+		d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", Reasoning: "回踩做多"}
+		err := at.checkNoTradeZone(d, &market.Data{Symbol: "SOLUSDT", CurrentPrice: tc.price})
+		if (err != nil) != tc.blocked {
+			t.Errorf("%s: price %.2f err = %v, want blocked=%v", tc.name, tc.price, err, tc.blocked)
+		}
+	}
+}
+
+func TestNoTradeZoneStrongLevel(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{NoTradeZonePct: 0.002, NoTradeZoneMinTouches: 2, NoTradeZoneInterval: "4h"})
+	// 113.7 被触及两次的阻力位
+	withKlines(at, hlKlines([][2]float64{
+		{112, 111}, {113, 111.5}, {113.7, 112}, {113, 111.5}, {112, 110.5},
+		{113, 111.5}, {113.7, 112}, {113, 112}, {112.5, 111.5},
+	}))
+
+	cases := []struct {
+		name    string
+		price   float64
+		blocked bool
+	}{
+		{"inside the zone", 113.6, true},
+		{"outside the zone", 115, false},
+	}
+	for _, tc := range cases {
+		d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", Reasoning: "趋势延续"}
+		err := at.checkNoTradeZone(d, &market.Data{Symbol: "SOLUSDT", CurrentPrice: tc.price})
+		if (err != nil) != tc.blocked {
+			t.Errorf("%s: price %.2f err = %v, want blocked=%v", tc.name, tc.price, err, tc.blocked)
+		}
+	}
+
+	// 触及次数不足的价位不构成禁止开仓区
+	at.config.Risk.NoTradeZoneMinTouches = 3
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long"}
+	if err := at.checkNoTradeZone(d, &market.Data{Symbol: "SOLUSDT", CurrentPrice: 113.6}); err != nil {
+		t.Errorf("weak level blocked the entry: %v", err)
+	}
+}
+
+func TestNoTradeZoneAllowsBreakoutPlans(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{NoTradeZonePct: 0.005, NoTradeZoneMinTouches: 2, NoTradeZoneInterval: "4h"})
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", Reasoning: "放量突破100整数关口"}
+	if err := at.checkNoTradeZone(d, &market.Data{Symbol: "SOLUSDT", CurrentPrice: 100.3}); err != nil {
+		t.Errorf("breakout plan blocked: %v", err)
+	}
+}
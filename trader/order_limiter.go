@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// orderRateLimiter 下单频率限制（所有币种共用，滑动时间窗口）
+// 防止一次AI决策包含大量开仓机会时集中下单；每日上限按UTC自然日计数，防止过度交易
+type orderRateLimiter struct {
+	maxPerMinute int           // 每分钟最多下单数（0=不限制）
+	maxPerHour   int           // 每小时最多下单数（0=不限制）
+	maxPerDay    int           // 每个UTC自然日最多下单数（0=不限制）
+	minInterval  time.Duration // 相邻两次下单的最小间隔（0=不限制）
+
+	mu         sync.Mutex
+	orders     []time.Time // 最近一小时内的下单时间
+	lastOrder  time.Time   // 最近一次下单时间（不随一小时窗口清理，最小间隔可超过1小时）
+	day        string      // 当前计数的UTC日期（YYYY-MM-DD）
+	dailyCount int         // 当日下单数
+}
+
+func newOrderRateLimiter(maxPerMinute, maxPerHour, maxPerDay int, minInterval time.Duration) *orderRateLimiter {
+	return &orderRateLimiter{
+		maxPerMinute: maxPerMinute,
+		maxPerHour:   maxPerHour,
+		maxPerDay:    maxPerDay,
+		minInterval:  minInterval,
+	}
+}
+
+// Check 检查当前是否还能下单（超出限制时返回原因）
+func (l *orderRateLimiter) Check(now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+
+	if l.minInterval > 0 && !l.lastOrder.IsZero() {
+		if elapsed := now.Sub(l.lastOrder); elapsed < l.minInterval {
+			return fmt.Errorf("距上次开仓仅 %.0f 秒，未达最小间隔 %.0f 秒，延迟开仓", elapsed.Seconds(), l.minInterval.Seconds())
+		}
+	}
+	if l.maxPerMinute > 0 {
+		if n := l.countSince(now.Add(-time.Minute)); n >= l.maxPerMinute {
+			return fmt.Errorf("最近1分钟已下单 %d 次，达到上限 %d，延迟开仓", n, l.maxPerMinute)
+		}
+	}
+	if l.maxPerHour > 0 {
+		if n := len(l.orders); n >= l.maxPerHour {
+			return fmt.Errorf("最近1小时已下单 %d 次，达到上限 %d，延迟开仓", n, l.maxPerHour)
+		}
+	}
+	if l.maxPerDay > 0 {
+		if l.dailyCount >= l.maxPerDay {
+			return fmt.Errorf("今日（UTC）已开仓 %d 次，达到每日上限 %d，今日不再开仓", l.dailyCount, l.maxPerDay)
+		}
+	}
+	return nil
+}
+
+// Record 记录一次成功下单
+func (l *orderRateLimiter) Record(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+	l.orders = append(l.orders, now)
+	l.lastOrder = now
+	l.dailyCount++
+}
+
+// DailyStatus 当日（UTC）下单数和剩余次数（未设置每日上限时剩余为-1）
+func (l *orderRateLimiter) DailyStatus(now time.Time) (count, remaining int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+	if l.maxPerDay <= 0 {
+		return l.dailyCount, -1
+	}
+	remaining = l.maxPerDay - l.dailyCount
+	if remaining < 0 {
+		remaining = 0
+	}
+	return l.dailyCount, remaining
+}
+
+// countSince 统计指定时间之后的下单次数（调用方已加锁）
+func (l *orderRateLimiter) countSince(since time.Time) int {
+	count := 0
+	for _, t := range l.orders {
+		if t.After(since) {
+			count++
+		}
+	}
+	return count
+}
+
+// prune 清理一小时之前的记录，跨UTC日时重置当日计数（调用方已加锁）
+func (l *orderRateLimiter) prune(now time.Time) {
+	if day := now.UTC().Format("2006-01-02"); day != l.day {
+		l.day = day
+		l.dailyCount = 0
+	}
+
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(l.orders) && !l.orders[i].After(cutoff) {
+		i++
+	}
+	l.orders = l.orders[i:]
+}
+
+// rollDay 跨UTC日时重置当日计数（与每日维护对齐，否则在下次下单检查时才重置）
+func (l *orderRateLimiter) rollDay(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+}
@@ -0,0 +1,118 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+)
+
+// riskRule 按名称查找内置风控规则
+func riskRule(t *testing.T, at *AutoTrader, name string) RiskRule {
+	t.Helper()
+	for _, rule := range at.riskRules {
+		if rule.Name() == name {
+			return rule
+		}
+	}
+	t.Fatalf("risk rule %s not found", name)
+	return nil
+}
+
+func TestDailyTradeCapBlocksEntriesUntilUTCMidnight(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MaxDailyTrades: 2})
+	clock := &fixedClock{t: time.Date(2025, 3, 10, 21, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	rule := riskRule(t, at, "order_rate_limit")
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}
+	data := &market.Data{Symbol: "BTCUSDT"}
+
+	for i := 0; i < 2; i++ {
+		if ok, reason := rule.Check(d, RiskAccount{}, data); !ok {
+			t.Fatalf("entry %d rejected: %s", i+1, reason)
+		}
+		at.orderLimiter.Record(at.now())
+		clock.Advance(time.Hour)
+	}
+
+	ok, reason := rule.Check(d, RiskAccount{}, data)
+	if ok || !strings.Contains(reason, "每日上限") {
+		t.Fatalf("ok = %v (%s), want rejection at the daily cap", ok, reason)
+	}
+	status := at.GetStatus()
+	if status["daily_trade_count"] != 2 || status["daily_trades_remaining"] != 0 {
+		t.Errorf("status count/remaining = %v/%v, want 2/0", status["daily_trade_count"], status["daily_trades_remaining"])
+	}
+
+	// 23:59 仍在同一UTC日
+	clock.Advance(59 * time.Minute)
+	if ok, _ := rule.Check(d, RiskAccount{}, data); ok {
+		t.Fatal("entry allowed before the UTC day boundary")
+	}
+
+	// 跨过UTC零点后重置
+	clock.Advance(time.Minute)
+	if ok, reason := rule.Check(d, RiskAccount{}, data); !ok {
+		t.Fatalf("entry rejected after the UTC day boundary: %s", reason)
+	}
+	status = at.GetStatus()
+	if status["daily_trade_count"] != 0 || status["daily_trades_remaining"] != 2 {
+		t.Errorf("status count/remaining = %v/%v, want 0/2 after reset", status["daily_trade_count"], status["daily_trades_remaining"])
+	}
+}
+
+func TestDailyTradeCapUsesUTCDay(t *testing.T) {
+	// 北京时间 07:30 与 08:30 分属两个UTC日（UTC 23:30 和 00:30）
+	l := newOrderRateLimiter(0, 0, 1, 0)
+	cst := time.FixedZone("CST", 8*3600)
+	l.Record(time.Date(2025, 3, 11, 7, 30, 0, 0, cst))
+	if err := l.Check(time.Date(2025, 3, 11, 7, 45, 0, 0, cst)); err == nil {
+		t.Fatal("second entry allowed on the same UTC day")
+	}
+	if err := l.Check(time.Date(2025, 3, 11, 8, 30, 0, 0, cst)); err != nil {
+		t.Fatalf("entry rejected on the next UTC day: %v", err)
+	}
+}
+
+func TestDailyStatusUnlimited(t *testing.T) {
+	l := newOrderRateLimiter(0, 0, 0, 0)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	l.Record(now)
+	if count, remaining := l.DailyStatus(now); count != 1 || remaining != -1 {
+		t.Errorf("count/remaining = %d/%d, want 1/-1", count, remaining)
+	}
+}
+
+func TestMinEntryIntervalDefersEntriesInsideInterval(t *testing.T) {
+	for _, interval := range []time.Duration{30 * time.Second, 90 * time.Minute, 6 * time.Hour} {
+		l := newOrderRateLimiter(0, 0, 0, interval)
+		start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+		l.Record(start)
+
+		if err := l.Check(start.Add(interval - time.Second)); err == nil {
+			t.Errorf("interval %v: entry 1s before the interval elapsed was allowed", interval)
+		}
+		if err := l.Check(start.Add(interval)); err != nil {
+			t.Errorf("interval %v: entry right after the interval was deferred: %v", interval, err)
+		}
+	}
+}
+
+func TestMinEntryIntervalUsesTraderClock(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MinEntryIntervalSec: 7200})
+	clock := &fixedClock{t: time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)}
+	at.SetClock(clock.Now)
+	rule := riskRule(t, at, "order_rate_limit")
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}
+
+	at.orderLimiter.Record(at.now())
+	clock.Advance(2*time.Hour - time.Second)
+	if ok, _ := rule.Check(d, RiskAccount{}, nil); ok {
+		t.Fatal("entry allowed inside the 2h minimum interval")
+	}
+	clock.Advance(time.Second)
+	if ok, reason := rule.Check(d, RiskAccount{}, nil); !ok {
+		t.Fatalf("entry deferred after the 2h minimum interval: %s", reason)
+	}
+}
@@ -0,0 +1,295 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"sort"
+	"strings"
+)
+
+// 计划模板类型
+const (
+	PlanTemplateGrid = "grid" // 网格：以基准价为中心等距分布的入场价
+	PlanTemplateDCA  = "dca"  // 定投：从基准价开始按百分比逐级向不利方向加仓
+)
+
+// PlanTemplate 可复用的开仓计划模板（网格、DCA等重复性策略写在配置中而非代码里）
+// SymbolTemplate / ActionTemplate 支持 {symbol} 和 {action} 占位符
+type PlanTemplate struct {
+	Name           string  `json:"name"`
+	Kind           string  `json:"kind"` // grid / dca
+	SymbolTemplate string  `json:"symbol_template"`
+	ActionTemplate string  `json:"action_template"`
+	Levels         int     `json:"levels"`          // 生成的计划数
+	SpacingPct     float64 `json:"spacing_pct"`     // 相邻入场价间距（占基准价比例，如0.01=1%）
+	EquityPct      float64 `json:"equity_pct"`      // 所有计划合计仓位价值占净值比例，平均分配到每一级
+	LeverageRatio  float64 `json:"leverage_ratio"`  // 使用传入杠杆的比例（0=原样使用）
+	StopMultiplier float64 `json:"stop_multiplier"` // 止损距入场价的ATR倍数
+	TPMultiplier   float64 `json:"tp_multiplier"`   // 止盈距入场价的ATR倍数
+}
+
+// ExecutionPlan 模板生成的单级开仓计划（价格到达 EntryPrice 时按 Decision 开仓）
+type ExecutionPlan struct {
+	decision.Decision
+	Level      int     `json:"level"`
+	EntryPrice float64 `json:"entry_price"`
+}
+
+// PlanFactory 按名称管理计划模板
+type PlanFactory struct {
+	templates map[string]*PlanTemplate
+}
+
+// NewPlanFactory 创建计划工厂（模板名重复时后者覆盖前者）
+func NewPlanFactory(templates []PlanTemplate) *PlanFactory {
+	f := &PlanFactory{templates: make(map[string]*PlanTemplate, len(templates))}
+	for i := range templates {
+		f.templates[templates[i].Name] = &templates[i]
+	}
+	return f
+}
+
+// Template 按名称获取模板
+func (f *PlanFactory) Template(name string) (*PlanTemplate, bool) {
+	t, ok := f.templates[name]
+	return t, ok
+}
+
+// Names 按名称排序的模板列表
+func (f *PlanFactory) Names() []string {
+	names := make([]string, 0, len(f.templates))
+	for name := range f.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GeneratePlansFromTemplate 按模板生成一组开仓计划
+// basePrice 为当前价格，atr 用于计算止损止盈，leverage 为该币种可用杠杆
+func (f *PlanFactory) GeneratePlansFromTemplate(template *PlanTemplate, symbol, action string, basePrice, atr float64, leverage int, account decision.AccountInfo) ([]*ExecutionPlan, error) {
+	if template == nil {
+		return nil, fmt.Errorf("计划模板为空")
+	}
+	if template.Levels <= 0 {
+		return nil, fmt.Errorf("模板 %s 的 levels 必须大于0", template.Name)
+	}
+	if basePrice <= 0 {
+		return nil, fmt.Errorf("基准价格无效: %.4f", basePrice)
+	}
+	if atr <= 0 && (template.StopMultiplier > 0 || template.TPMultiplier > 0) {
+		return nil, fmt.Errorf("ATR无效，无法计算止损止盈: %.4f", atr)
+	}
+
+	symbol = expandPlanTemplate(template.SymbolTemplate, symbol, action, symbol)
+	action = expandPlanTemplate(template.ActionTemplate, symbol, action, action)
+	if action != "open_long" && action != "open_short" {
+		return nil, fmt.Errorf("模板 %s 只支持开仓动作，实际: %s", template.Name, action)
+	}
+
+	prices, err := planEntryPrices(template, action, basePrice)
+	if err != nil {
+		return nil, err
+	}
+
+	if template.LeverageRatio > 0 {
+		leverage = int(math.Round(float64(leverage) * template.LeverageRatio))
+	}
+	if leverage < 1 {
+		leverage = 1
+	}
+
+	sizePerLevel := account.TotalEquity * template.EquityPct / float64(template.Levels)
+	if sizePerLevel <= 0 {
+		return nil, fmt.Errorf("模板 %s 的单级仓位价值无效: %.2f", template.Name, sizePerLevel)
+	}
+
+	direction := 1.0
+	if action == "open_short" {
+		direction = -1
+	}
+
+	plans := make([]*ExecutionPlan, 0, len(prices))
+	for i, price := range prices {
+		plan := &ExecutionPlan{
+			Level:      i + 1,
+			EntryPrice: price,
+			Decision: decision.Decision{
+				Symbol:          symbol,
+				Action:          action,
+				Leverage:        leverage,
+				PositionSizeUSD: sizePerLevel,
+				Reasoning:       fmt.Sprintf("[%s模板 %s] 第%d/%d级 @ %.4f", template.Kind, template.Name, i+1, len(prices), price),
+			},
+		}
+		if template.StopMultiplier > 0 {
+			plan.StopLoss = price - direction*atr*template.StopMultiplier
+		}
+		if template.TPMultiplier > 0 {
+			plan.TakeProfit = price + direction*atr*template.TPMultiplier
+		}
+		if plan.StopLoss < 0 || plan.TakeProfit < 0 {
+			return nil, fmt.Errorf("第%d级止损/止盈为负，ATR倍数过大", i+1)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// planEntryPrices 按模板类型计算各级入场价
+func planEntryPrices(template *PlanTemplate, action string, basePrice float64) ([]float64, error) {
+	if template.SpacingPct <= 0 && template.Levels > 1 {
+		return nil, fmt.Errorf("模板 %s 的 spacing_pct 必须大于0", template.Name)
+	}
+
+	prices := make([]float64, template.Levels)
+	switch template.Kind {
+	case PlanTemplateGrid:
+		// 以基准价为中心对称分布
+		center := float64(template.Levels-1) / 2
+		for i := range prices {
+			prices[i] = basePrice * (1 + (float64(i)-center)*template.SpacingPct)
+		}
+	case PlanTemplateDCA:
+		// 做多逐级向下、做空逐级向上
+		direction := -1.0
+		if action == "open_short" {
+			direction = 1
+		}
+		for i := range prices {
+			prices[i] = basePrice * (1 + direction*float64(i)*template.SpacingPct)
+		}
+	default:
+		return nil, fmt.Errorf("未知的计划模板类型: %s", template.Kind)
+	}
+
+	for i, price := range prices {
+		if price <= 0 {
+			return nil, fmt.Errorf("第%d级入场价为负，spacing_pct 过大", i+1)
+		}
+	}
+	return prices, nil
+}
+
+// expandPlanTemplate 替换模板中的 {symbol} / {action} 占位符（模板为空时返回 fallback）
+func expandPlanTemplate(tmpl, symbol, action, fallback string) string {
+	if tmpl == "" {
+		return fallback
+	}
+	return strings.NewReplacer("{symbol}", symbol, "{action}", action).Replace(tmpl)
+}
+
+// pendingPlan 等待价格到达入场价的计划
+type pendingPlan struct {
+	*ExecutionPlan
+	Above bool // 生成计划时价格是否高于入场价（高于时价格跌到入场价触发，否则涨到入场价触发）
+}
+
+// reached 价格是否已到达计划的入场价
+func (p *pendingPlan) reached(price float64) bool {
+	if p.Above {
+		return price <= p.EntryPrice
+	}
+	return price >= p.EntryPrice
+}
+
+// applyPlanTemplates 展开指定了计划模板的开仓决策，并加入价格已到达入场价的挂起计划
+// 入场价为当前价的级别本周期执行，其余级别挂起；同一币种同方向的平仓或新模板开仓会取消挂起的计划
+// 同一币种的后续级别属于加仓，需要开启 allow_position_adds
+func (at *AutoTrader) applyPlanTemplates(decisions []decision.Decision, ctx *decision.Context) []decision.Decision {
+	result := make([]decision.Decision, 0, len(decisions))
+	created := make(map[string][]*pendingPlan)
+	for _, d := range decisions {
+		switch d.Action {
+		case "close_long", "close_short":
+			key := d.Symbol + "_" + strings.TrimPrefix(d.Action, "close_")
+			if n := len(at.pendingPlans[key]); n > 0 {
+				at.logger.Infof("  🗒  %s 平仓，取消 %d 个挂起的计划", d.Symbol, n)
+				delete(at.pendingPlans, key)
+			}
+		case "open_long", "open_short":
+			if d.PlanTemplate == "" {
+				break
+			}
+			immediate, pending, err := at.expandPlanDecision(d, ctx)
+			if err != nil {
+				at.logger.Warnf("  ⚠️ %s 按计划模板 %s 生成计划失败，不开仓: %v", d.Symbol, d.PlanTemplate, err)
+				continue
+			}
+			key := d.Symbol + "_" + strings.TrimPrefix(d.Action, "open_")
+			delete(at.pendingPlans, key) // 新模板开仓取代旧计划
+			created[key] = pending
+			result = append(result, immediate...)
+			at.logger.Infof("  🗒  %s 按模板 %s 生成 %d 级计划，挂起 %d 级",
+				d.Symbol, d.PlanTemplate, len(immediate)+len(pending), len(pending))
+			continue
+		}
+		result = append(result, d)
+	}
+
+	// 已挂起的计划：价格到达入场价后作为开仓决策执行（执行前仍经过全部风控检查）
+	for key, plans := range at.pendingPlans {
+		remaining := plans[:0]
+		for _, plan := range plans {
+			price := at.planPrice(plan.Symbol, ctx)
+			if price > 0 && plan.reached(price) {
+				at.logger.Infof("  🗒  %s 第%d级计划触发（入场价 %.4f，当前 %.4f）", plan.Symbol, plan.Level, plan.EntryPrice, price)
+				result = append(result, plan.Decision)
+				continue
+			}
+			remaining = append(remaining, plan)
+		}
+		if len(remaining) == 0 {
+			delete(at.pendingPlans, key)
+		} else {
+			at.pendingPlans[key] = remaining
+		}
+	}
+	for key, plans := range created {
+		at.pendingPlans[key] = plans
+	}
+	return result
+}
+
+// expandPlanDecision 按决策指定的模板生成分级计划（基准价为当前价，止损止盈按4小时ATR14计算）
+// 返回入场价即当前价、本周期执行的级别，以及需要挂起的级别
+func (at *AutoTrader) expandPlanDecision(d decision.Decision, ctx *decision.Context) ([]decision.Decision, []*pendingPlan, error) {
+	template, ok := at.plans.Template(d.PlanTemplate)
+	if !ok {
+		return nil, nil, fmt.Errorf("未配置的计划模板: %s", d.PlanTemplate)
+	}
+	price := at.planPrice(d.Symbol, ctx)
+	atr := 0.0
+	if data := ctx.MarketDataMap[d.Symbol]; data != nil && data.LongerTermContext != nil {
+		atr = data.LongerTermContext.ATR14
+	}
+
+	plans, err := at.plans.GeneratePlansFromTemplate(template, d.Symbol, d.Action, price, atr, d.Leverage, ctx.Account)
+	if err != nil {
+		return nil, nil, err
+	}
+	var immediate []decision.Decision
+	var pending []*pendingPlan
+	for _, plan := range plans {
+		plan.Confidence = d.Confidence
+		if plan.EntryPrice == price {
+			immediate = append(immediate, plan.Decision)
+			continue
+		}
+		pending = append(pending, &pendingPlan{ExecutionPlan: plan, Above: price > plan.EntryPrice})
+	}
+	return immediate, pending, nil
+}
+
+// planPrice 计划使用的当前价格（优先使用本周期行情，没有时查询价格来源，获取失败返回0）
+func (at *AutoTrader) planPrice(symbol string, ctx *decision.Context) float64 {
+	if data := ctx.MarketDataMap[symbol]; data != nil && data.CurrentPrice > 0 {
+		return data.CurrentPrice
+	}
+	price, err := at.priceOracle.GetPrice(symbol)
+	if err != nil {
+		return 0
+	}
+	return price
+}
@@ -0,0 +1,166 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestGeneratePlansFromGridTemplate(t *testing.T) {
+	template := PlanTemplate{Name: "grid5", Kind: PlanTemplateGrid, Levels: 5, SpacingPct: 0.01,
+		EquityPct: 0.5, LeverageRatio: 0.5, StopMultiplier: 2, TPMultiplier: 3}
+	factory := NewPlanFactory([]PlanTemplate{template})
+	tmpl, ok := factory.Template("grid5")
+	if !ok {
+		t.Fatal("template not found")
+	}
+
+	plans, err := factory.GeneratePlansFromTemplate(tmpl, "BTCUSDT", "open_long", 100, 1, 10, decision.AccountInfo{TotalEquity: 1000})
+	if err != nil {
+		t.Fatalf("GeneratePlansFromTemplate: %v", err)
+	}
+	wantPrices := []float64{98, 99, 100, 101, 102}
+	if len(plans) != len(wantPrices) {
+		t.Fatalf("got %d plans, want %d", len(plans), len(wantPrices))
+	}
+	for i, plan := range plans {
+		if plan.Level != i+1 || !approxEqual(plan.EntryPrice, wantPrices[i]) {
+			t.Errorf("plan %d: level %d entry %v, want level %d entry %v", i, plan.Level, plan.EntryPrice, i+1, wantPrices[i])
+		}
+		if plan.Symbol != "BTCUSDT" || plan.Action != "open_long" || plan.Leverage != 5 {
+			t.Errorf("plan %d: %s %s %dx", i, plan.Symbol, plan.Action, plan.Leverage)
+		}
+		if !approxEqual(plan.PositionSizeUSD, 100) {
+			t.Errorf("plan %d size = %v, want 100 (500 split over 5 levels)", i, plan.PositionSizeUSD)
+		}
+		if !approxEqual(plan.StopLoss, wantPrices[i]-2) || !approxEqual(plan.TakeProfit, wantPrices[i]+3) {
+			t.Errorf("plan %d stop/tp = %v/%v", i, plan.StopLoss, plan.TakeProfit)
+		}
+	}
+}
+
+func TestGeneratePlansFromDCATemplate(t *testing.T) {
+	template := &PlanTemplate{Name: "dca", Kind: PlanTemplateDCA, Levels: 3, SpacingPct: 0.02,
+		EquityPct: 0.3, StopMultiplier: 1, TPMultiplier: 2}
+	factory := NewPlanFactory(nil)
+
+	tests := []struct {
+		action     string
+		wantPrices []float64
+		direction  float64
+	}{
+		{"open_long", []float64{100, 98, 96}, 1},     // 做多逐级向下
+		{"open_short", []float64{100, 102, 104}, -1}, // 做空逐级向上
+	}
+	for _, tt := range tests {
+		plans, err := factory.GeneratePlansFromTemplate(template, "ETHUSDT", tt.action, 100, 5, 3, decision.AccountInfo{TotalEquity: 1000})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.action, err)
+		}
+		for i, plan := range plans {
+			if !approxEqual(plan.EntryPrice, tt.wantPrices[i]) {
+				t.Errorf("%s level %d entry = %v, want %v", tt.action, i+1, plan.EntryPrice, tt.wantPrices[i])
+			}
+			if !approxEqual(plan.StopLoss, tt.wantPrices[i]-tt.direction*5) || !approxEqual(plan.TakeProfit, tt.wantPrices[i]+tt.direction*10) {
+				t.Errorf("%s level %d stop/tp = %v/%v", tt.action, i+1, plan.StopLoss, plan.TakeProfit)
+			}
+			if plan.Leverage != 3 || !approxEqual(plan.PositionSizeUSD, 100) {
+				t.Errorf("%s level %d: %dx %v USDT", tt.action, i+1, plan.Leverage, plan.PositionSizeUSD)
+			}
+		}
+	}
+}
+
+func TestGeneratePlansRejectsInvalidTemplates(t *testing.T) {
+	factory := NewPlanFactory(nil)
+	account := decision.AccountInfo{TotalEquity: 1000}
+	tests := []struct {
+		name     string
+		template PlanTemplate
+		action   string
+	}{
+		{"no levels", PlanTemplate{Kind: PlanTemplateGrid, SpacingPct: 0.01, EquityPct: 0.1}, "open_long"},
+		{"no spacing", PlanTemplate{Kind: PlanTemplateDCA, Levels: 3, EquityPct: 0.1}, "open_long"},
+		{"unknown kind", PlanTemplate{Kind: "martingale", Levels: 2, SpacingPct: 0.01, EquityPct: 0.1}, "open_long"},
+		{"close action", PlanTemplate{Kind: PlanTemplateGrid, Levels: 2, SpacingPct: 0.01, EquityPct: 0.1}, "close_long"},
+		{"negative price", PlanTemplate{Kind: PlanTemplateDCA, Levels: 3, SpacingPct: 0.6, EquityPct: 0.1}, "open_long"},
+		{"no size", PlanTemplate{Kind: PlanTemplateGrid, Levels: 2, SpacingPct: 0.01}, "open_long"},
+	}
+	for _, tt := range tests {
+		if _, err := factory.GeneratePlansFromTemplate(&tt.template, "BTCUSDT", tt.action, 100, 1, 5, account); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestExpandPlanTemplatePlaceholders(t *testing.T) {
+	template := &PlanTemplate{Kind: PlanTemplateDCA, Levels: 1, EquityPct: 0.1,
+		SymbolTemplate: "{symbol}", ActionTemplate: "open_short"}
+	plans, err := NewPlanFactory(nil).GeneratePlansFromTemplate(template, "SOLUSDT", "open_long", 100, 1, 5, decision.AccountInfo{TotalEquity: 1000})
+	if err != nil {
+		t.Fatalf("GeneratePlansFromTemplate: %v", err)
+	}
+	if plans[0].Symbol != "SOLUSDT" || plans[0].Action != "open_short" {
+		t.Errorf("plan = %s %s, want SOLUSDT open_short", plans[0].Symbol, plans[0].Action)
+	}
+}
+
+func planContext(price float64) *decision.Context {
+	return &decision.Context{
+		Account: decision.AccountInfo{TotalEquity: 1000},
+		MarketDataMap: map[string]*market.Data{
+			"BTCUSDT": {Symbol: "BTCUSDT", CurrentPrice: price, LongerTermContext: &market.LongerTermData{ATR14: 1}},
+		},
+	}
+}
+
+func TestApplyPlanTemplatesQueuesAndTriggersLevels(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{PlanTemplates: []PlanTemplate{
+		{Name: "dca", Kind: PlanTemplateDCA, Levels: 3, SpacingPct: 0.01, EquityPct: 0.3, StopMultiplier: 2},
+	}})
+	open := decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 5, Confidence: 80, PlanTemplate: "dca"}
+
+	// 第1级入场价为当前价，本周期执行；其余两级挂起
+	got := at.applyPlanTemplates([]decision.Decision{open}, planContext(100))
+	if len(got) != 1 || got[0].Action != "open_long" || got[0].Confidence != 80 || !approxEqual(got[0].PositionSizeUSD, 100) {
+		t.Fatalf("first cycle decisions = %+v", got)
+	}
+	if n := len(at.pendingPlans["BTCUSDT_long"]); n != 2 {
+		t.Fatalf("pending plans = %d, want 2", n)
+	}
+
+	// 价格未到第2级入场价（99）
+	if got := at.applyPlanTemplates(nil, planContext(99.5)); len(got) != 0 {
+		t.Errorf("decisions above level 2 = %+v, want none", got)
+	}
+	// 跌到99触发第2级
+	got = at.applyPlanTemplates(nil, planContext(98.9))
+	if len(got) != 1 || !approxEqual(got[0].StopLoss, 99-2) {
+		t.Fatalf("level 2 decisions = %+v", got)
+	}
+	if n := len(at.pendingPlans["BTCUSDT_long"]); n != 1 {
+		t.Errorf("pending plans after trigger = %d, want 1", n)
+	}
+
+	// 平仓取消剩余计划
+	got = at.applyPlanTemplates([]decision.Decision{{Symbol: "BTCUSDT", Action: "close_long"}}, planContext(90))
+	if len(got) != 1 || got[0].Action != "close_long" {
+		t.Errorf("close cycle decisions = %+v, want the close only", got)
+	}
+	if _, ok := at.pendingPlans["BTCUSDT_long"]; ok {
+		t.Error("close did not cancel the pending plans")
+	}
+}
+
+func TestApplyPlanTemplatesUnknownTemplateSkipsEntry(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	open := decision.Decision{Symbol: "BTCUSDT", Action: "open_long", Leverage: 5, PlanTemplate: "missing"}
+	if got := at.applyPlanTemplates([]decision.Decision{open}, planContext(100)); len(got) != 0 {
+		t.Errorf("decisions = %+v, want the entry dropped", got)
+	}
+}
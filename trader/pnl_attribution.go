@@ -0,0 +1,70 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"time"
+)
+
+// EstimateTradingFees 手续费模型：开仓和平仓各按成交额 × feeRate 计费
+func EstimateTradingFees(openValue, closeValue, feeRate float64) float64 {
+	return (openValue + closeValue) * feeRate
+}
+
+// AccruedFunding 持仓期间的资金费净收入（支付为负）
+// 费率为正时多头付给空头，每次结算金额 = 数量 × 结算标记价 × 费率（标记价缺失时用 fallbackPrice）
+func AccruedFunding(side string, quantity, fallbackPrice float64, settlements []market.FundingSettlement) float64 {
+	total := 0.0
+	for _, s := range settlements {
+		price := s.MarkPrice
+		if price <= 0 {
+			price = fallbackPrice
+		}
+		payment := quantity * price * s.Rate
+		if side == "long" {
+			total -= payment
+		} else {
+			total += payment
+		}
+	}
+	return total
+}
+
+// attributeClose 计算平仓的盈亏归因（持仓未知时返回nil），closeRatio 为部分平仓比例
+// 入场价优先使用实际成交均价；资金费记录获取失败时按0计
+func (at *AutoTrader) attributeClose(symbol, side string, closePrice, closeRatio float64) *logger.PnLAttribution {
+	var pos *decision.PositionInfo
+	for i := range at.lastPositions {
+		if at.lastPositions[i].Symbol == symbol && at.lastPositions[i].Side == side {
+			pos = &at.lastPositions[i]
+			break
+		}
+	}
+	if pos == nil || pos.Quantity <= 0 || closePrice <= 0 {
+		return nil
+	}
+
+	entry := pos.EntryPrice
+	if stop := at.getPositionStop(symbol, side); stop != nil && stop.AvgEntryPrice > 0 {
+		entry = stop.AvgEntryPrice
+	}
+
+	funding := 0.0
+	if pos.UpdateTime > 0 {
+		settlements, err := market.GetFundingHistory(symbol, pos.UpdateTime, time.Now().UnixMilli())
+		if err != nil {
+			at.logger.Warnf("  ⚠️ %s 获取资金费记录失败，资金费按0计: %v", symbol, err)
+		} else {
+			funding = AccruedFunding(side, pos.Quantity, entry, settlements)
+		}
+	}
+
+	quantity := pos.Quantity
+	if closeRatio > 0 && closeRatio < 1 {
+		quantity *= closeRatio
+		funding *= closeRatio
+	}
+	fees := EstimateTradingFees(quantity*entry, quantity*closePrice, at.config.Risk.FeeRate)
+	return logger.NewPnLAttribution(side, quantity, entry, closePrice, funding, fees)
+}
@@ -0,0 +1,27 @@
+package trader
+
+import (
+	"nofx/market"
+	"testing"
+)
+
+func TestAccruedFundingAcrossSettlements(t *testing.T) {
+	settlements := []market.FundingSettlement{
+		{Rate: 0.0001, MarkPrice: 60000},
+		{Rate: 0.0003, MarkPrice: 0}, // 标记价缺失时用入场价
+		{Rate: -0.0001, MarkPrice: 61000},
+	}
+	// 0.1 × (60000×0.0001 + 60500×0.0003 - 61000×0.0001) = 0.1 × 18.05 = 1.805
+	if got := AccruedFunding("long", 0.1, 60500, settlements); !approxEqual(got, -1.805) {
+		t.Errorf("long funding = %.4f, want -1.805 (paid)", got)
+	}
+	if got := AccruedFunding("short", 0.1, 60500, settlements); !approxEqual(got, 1.805) {
+		t.Errorf("short funding = %.4f, want 1.805 (received)", got)
+	}
+}
+
+func TestEstimateTradingFees(t *testing.T) {
+	if got := EstimateTradingFees(6000, 6100, 0.0005); !approxEqual(got, 6.05) {
+		t.Errorf("fees = %.4f, want 6.05", got)
+	}
+}
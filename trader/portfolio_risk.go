@@ -0,0 +1,141 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// PortfolioRisk 组合风险（所有持仓打到止损时的合计亏损）
+type PortfolioRisk struct {
+	ExistingRisk float64 `json:"existing_risk"`  // 现有持仓风险（USDT）
+	PlanRisk     float64 `json:"plan_risk"`      // 待开仓位风险（USDT）
+	TotalRisk    float64 `json:"total_risk"`     // 合计风险（USDT）
+	TotalRiskPct float64 `json:"total_risk_pct"` // 合计风险占净值比例
+}
+
+// CalculatePortfolioRisk 计算现有持仓加上待开仓位的组合风险
+// 持仓风险 = |入场价 - 止损价| × 数量；stopLosses 未记录止损的持仓按已用保证金计（最坏亏完保证金）。
+// 待开仓位风险 = 仓位价值 × |入场价 - 止损价| / 入场价（plan 为 nil 时只计算现有持仓）
+// 以计价资产表示的风险按 rates 换算为账户结算币种
+func CalculatePortfolioRisk(positions []decision.PositionInfo, stopLosses map[string]float64, plan *decision.Decision, entryPrice, equity float64, rates QuoteRates) PortfolioRisk {
+	var risk PortfolioRisk
+
+	for _, pos := range positions {
+		risk.ExistingRisk += positionStopRisk(pos, stopLosses[pos.Symbol+"_"+pos.Side], rates)
+	}
+	risk.PlanRisk = planStopRisk(plan, entryPrice, rates)
+
+	risk.TotalRisk = risk.ExistingRisk + risk.PlanRisk
+	if equity > 0 {
+		risk.TotalRiskPct = risk.TotalRisk / equity
+	}
+	return risk
+}
+
+// positionStopRisk 单个持仓打到止损的亏损（结算币种，stop<=0 时按已用保证金计，保证金已是结算币种）
+func positionStopRisk(pos decision.PositionInfo, stop float64, rates QuoteRates) float64 {
+	if stop <= 0 {
+		return pos.MarginUsed
+	}
+	loss := (pos.EntryPrice - stop) * pos.Quantity
+	if pos.Side == "short" {
+		loss = -loss
+	}
+	return rates.ToSettlement(pos.Symbol, math.Max(loss, 0)) // 止损已移到保本之上的持仓不再有风险
+}
+
+// planStopRisk 待开仓位打到止损的亏损（结算币种）
+func planStopRisk(plan *decision.Decision, entryPrice float64, rates QuoteRates) float64 {
+	if plan == nil || entryPrice <= 0 || plan.StopLoss <= 0 {
+		return 0
+	}
+	return rates.ToSettlement(plan.Symbol, plan.PositionSizeUSD*math.Abs(entryPrice-plan.StopLoss)/entryPrice)
+}
+
+// CorrelationMatrix 币种间收益相关系数（如 {"BTCUSDT": {"ETHUSDT": 0.85}}）
+// 只需填写一个方向，查询时对称处理；未填写的币种对视为不相关，币种与自身相关系数为1
+type CorrelationMatrix map[string]map[string]float64
+
+// Correlation 查询两个币种的相关系数
+func (m CorrelationMatrix) Correlation(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if rho, ok := m[a][b]; ok {
+		return rho
+	}
+	return m[b][a]
+}
+
+// CorrelationAdjustedRisk 按相关系数矩阵合成组合风险：sqrt(Σi Σj ri·rj·ρij)
+// risks 为各币种的带方向风险（多头为正、空头为负，对冲仓位相互抵消）。
+// 全部完全正相关时等于各项绝对值之和，不相关时等于平方和开根号
+func CorrelationAdjustedRisk(risks map[string]float64, matrix CorrelationMatrix) float64 {
+	variance := 0.0
+	for a, ra := range risks {
+		for b, rb := range risks {
+			variance += ra * rb * matrix.Correlation(a, b)
+		}
+	}
+	return math.Sqrt(math.Max(variance, 0))
+}
+
+// checkCorrelatedRisk 相关性调整后的组合风险上限检查
+// 新仓位与现有持仓高度相关时，调整后风险比不相关时增长更快，更早触发拒绝
+func (at *AutoTrader) checkCorrelatedRisk(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+	maxPct := at.config.Risk.MaxCorrelatedRiskPct
+	if maxPct <= 0 || account.Equity <= 0 {
+		return true, ""
+	}
+
+	risks := make(map[string]float64, len(account.Positions)+1)
+	for _, pos := range account.Positions {
+		stop := 0.0
+		if s := at.getPositionStop(pos.Symbol, pos.Side); s != nil {
+			stop = s.StopLoss
+		}
+		risks[pos.Symbol] += signedRisk(pos.Side, positionStopRisk(pos, stop, at.config.Risk.QuoteConversionRates))
+	}
+	planRisk := planStopRisk(d, data.ReferencePrice(at.config.Risk.EntryPriceRef), at.config.Risk.QuoteConversionRates)
+	risks[d.Symbol] += signedRisk(entrySide(d), planRisk)
+
+	adjusted := CorrelationAdjustedRisk(risks, at.config.Risk.CorrelationMatrix)
+	if adjusted/account.Equity > maxPct {
+		return false, fmt.Sprintf("%s 开仓后相关性调整风险 %.2f USDT（%.2f%%）超过上限 %.2f%%（本次 %.2f）",
+			d.Symbol, adjusted, adjusted/account.Equity*100, maxPct*100, planRisk)
+	}
+	return true, ""
+}
+
+// signedRisk 多头风险为正、空头为负
+func signedRisk(side string, risk float64) float64 {
+	if side == "short" {
+		return -risk
+	}
+	return risk
+}
+
+// checkPortfolioRisk 组合风险上限检查
+// 单笔开仓风险合理，但与现有持仓合计超过上限时同样拒绝（使用规则执行时的止损价）
+func (at *AutoTrader) checkPortfolioRisk(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+	maxPct := at.config.Risk.MaxPortfolioRiskPct
+	if maxPct <= 0 || account.Equity <= 0 {
+		return true, ""
+	}
+
+	stopLosses := make(map[string]float64, len(account.Positions))
+	for _, pos := range account.Positions {
+		if stop := at.getPositionStop(pos.Symbol, pos.Side); stop != nil {
+			stopLosses[pos.Symbol+"_"+pos.Side] = stop.StopLoss
+		}
+	}
+
+	risk := CalculatePortfolioRisk(account.Positions, stopLosses, d, data.ReferencePrice(at.config.Risk.EntryPriceRef), account.Equity, at.config.Risk.QuoteConversionRates)
+	if risk.TotalRiskPct > maxPct {
+		return false, fmt.Sprintf("%s 开仓后组合风险 %.2f USDT（%.2f%%）超过上限 %.2f%%（现有持仓 %.2f + 本次 %.2f）",
+			d.Symbol, risk.TotalRisk, risk.TotalRiskPct*100, maxPct*100, risk.ExistingRisk, risk.PlanRisk)
+	}
+	return true, ""
+}
@@ -0,0 +1,135 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+// portfolioTestAccount BTC多仓止损风险20 + ETH空仓无止损按保证金15 = 现有风险35
+func portfolioTestAccount(at *AutoTrader) RiskAccount {
+	at.recordPositionStop("BTCUSDT", "long", 90, 120)
+	return RiskAccount{
+		Equity: 1000,
+		Positions: []decision.PositionInfo{
+			{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, Quantity: 2, MarginUsed: 40},
+			{Symbol: "ETHUSDT", Side: "short", EntryPrice: 200, Quantity: 1, MarginUsed: 15},
+		},
+	}
+}
+
+func TestCalculatePortfolioRisk(t *testing.T) {
+	positions := []decision.PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, Quantity: 2, MarginUsed: 40},
+		{Symbol: "ETHUSDT", Side: "short", EntryPrice: 200, Quantity: 1, MarginUsed: 15},
+		{Symbol: "BNBUSDT", Side: "long", EntryPrice: 300, Quantity: 1, MarginUsed: 30},
+	}
+	stops := map[string]float64{"BTCUSDT_long": 90, "BNBUSDT_long": 310} // BNB止损已移到保本之上
+	plan := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 500, StopLoss: 96}
+
+	risk := CalculatePortfolioRisk(positions, stops, plan, 100, 1000, nil)
+	if math.Abs(risk.ExistingRisk-35) > 1e-9 || math.Abs(risk.PlanRisk-20) > 1e-9 {
+		t.Errorf("existing/plan = %.2f/%.2f, want 35/20", risk.ExistingRisk, risk.PlanRisk)
+	}
+	if math.Abs(risk.TotalRisk-55) > 1e-9 || math.Abs(risk.TotalRiskPct-0.055) > 1e-9 {
+		t.Errorf("total = %.2f (%.4f), want 55 (0.055)", risk.TotalRisk, risk.TotalRiskPct)
+	}
+}
+
+func TestCheckPortfolioRiskRejectsOverCap(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MaxPortfolioRiskPct: 0.05})
+	account := portfolioTestAccount(at)
+	data := &market.Data{Symbol: "SOLUSDT", CurrentPrice: 100}
+
+	// 35 + 20 = 55 > 50
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 500, StopLoss: 96}
+	ok, reason := at.checkPortfolioRisk(d, account, data)
+	if ok || !strings.Contains(reason, "组合风险") {
+		t.Errorf("ok = %v reason = %q, want rejection over the 5%% cap", ok, reason)
+	}
+
+	// 35 + 10 = 45 <= 50
+	d.StopLoss = 98
+	if ok, reason := at.checkPortfolioRisk(d, account, data); !ok {
+		t.Errorf("entry within the cap rejected: %s", reason)
+	}
+}
+
+func TestCheckPortfolioRiskDisabled(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	account := portfolioTestAccount(at)
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 5000, StopLoss: 50}
+	if ok, reason := at.checkPortfolioRisk(d, account, &market.Data{Symbol: "SOLUSDT", CurrentPrice: 100}); !ok {
+		t.Errorf("disabled check rejected: %s", reason)
+	}
+}
+
+func TestCorrelationAdjustedRisk(t *testing.T) {
+	risks := map[string]float64{"BTCUSDT": 30, "ETHUSDT": 40}
+	cases := []struct {
+		name string
+		rho  float64
+		want float64
+	}{
+		{"perfectly correlated", 1, 70},       // 30 + 40
+		{"uncorrelated", 0, 50},               // sqrt(30² + 40²)
+		{"perfectly anti-correlated", -1, 10}, // |30 - 40|
+	}
+	for _, tc := range cases {
+		matrix := CorrelationMatrix{"BTCUSDT": {"ETHUSDT": tc.rho}}
+		if got := CorrelationAdjustedRisk(risks, matrix); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: risk = %.4f, want %.4f", tc.name, got, tc.want)
+		}
+	}
+
+	// 完全正相关的多空对冲仓位相互抵消
+	hedged := map[string]float64{"BTCUSDT": 30, "ETHUSDT": -40}
+	if got := CorrelationAdjustedRisk(hedged, CorrelationMatrix{"ETHUSDT": {"BTCUSDT": 1}}); math.Abs(got-10) > 1e-9 {
+		t.Errorf("hedged risk = %.4f, want 10", got)
+	}
+}
+
+func TestCorrelationAdjustedRiskThreeSymbols(t *testing.T) {
+	risks := map[string]float64{"BTCUSDT": 10, "ETHUSDT": 20, "SOLUSDT": 30}
+	matrix := CorrelationMatrix{
+		"BTCUSDT": {"ETHUSDT": 0.5},
+		"SOLUSDT": {"ETHUSDT": -0.5}, // 只填一个方向
+	}
+	// 100 + 400 + 900 + 2·10·20·0.5 + 2·20·30·(-0.5) = 1000
+	if got := CorrelationAdjustedRisk(risks, matrix); math.Abs(got-math.Sqrt(1000)) > 1e-9 {
+		t.Errorf("risk = %.4f, want %.4f", got, math.Sqrt(1000))
+	}
+	if matrix.Correlation("ETHUSDT", "SOLUSDT") != -0.5 || matrix.Correlation("BTCUSDT", "SOLUSDT") != 0 {
+		t.Error("matrix lookup should be symmetric and default to uncorrelated")
+	}
+}
+
+func TestCheckCorrelatedRiskRejectsCorrelatedEntry(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MaxCorrelatedRiskPct: 0.03})
+	at.recordPositionStop("BTCUSDT", "long", 90, 120)
+	account := RiskAccount{
+		Equity:    1000,
+		Positions: []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, Quantity: 2, MarginUsed: 40}},
+	}
+	data := &market.Data{Symbol: "ETHUSDT", CurrentPrice: 100}
+	d := &decision.Decision{Symbol: "ETHUSDT", Action: "open_long", PositionSizeUSD: 500, StopLoss: 96}
+
+	// 不相关：sqrt(20² + 20²) = 28.28 <= 30
+	if ok, reason := at.checkCorrelatedRisk(d, account, data); !ok {
+		t.Errorf("uncorrelated entry rejected: %s", reason)
+	}
+
+	// 完全正相关：20 + 20 = 40 > 30
+	at.config.Risk.CorrelationMatrix = CorrelationMatrix{"BTCUSDT": {"ETHUSDT": 1}}
+	if ok, reason := at.checkCorrelatedRisk(d, account, data); ok || !strings.Contains(reason, "相关性调整风险") {
+		t.Errorf("ok = %v reason = %q, want rejection of the correlated entry", ok, reason)
+	}
+
+	// 反向开空与多仓对冲：|20 - 20| = 0
+	d.Action, d.StopLoss = "open_short", 104
+	if ok, reason := at.checkCorrelatedRisk(d, account, data); !ok {
+		t.Errorf("hedging entry rejected: %s", reason)
+	}
+}
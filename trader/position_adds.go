@@ -0,0 +1,41 @@
+package trader
+
+import "math"
+
+// existingPosition 交易所持仓中同币种同方向的数量和开仓均价（无持仓时数量为0）
+func existingPosition(positions []map[string]interface{}, symbol, side string) (quantity, entryPrice float64) {
+	for _, pos := range positions {
+		if pos["symbol"] != symbol || pos["side"] != side {
+			continue
+		}
+		amount, _ := pos["positionAmt"].(float64)
+		entryPrice, _ = pos["entryPrice"].(float64)
+		return math.Abs(amount), entryPrice
+	}
+	return 0, 0
+}
+
+// mergePositionAdd 加仓后合并持仓记录：止损止盈改为本次决策的价格，开仓均价按数量加权合并；
+// 入场信号、计划价、开仓信心度和持仓期间的最差/最好价格保留首次开仓时的记录。返回合并后的均价
+func (at *AutoTrader) mergePositionAdd(symbol, side string, stopLoss, takeProfit, existingQty, existingEntry, addQty, addPrice float64) float64 {
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+
+	key := symbol + "_" + side
+	stop, ok := at.positionStops[key]
+	if !ok {
+		stop = &positionStop{}
+		at.positionStops[key] = stop
+	}
+	stop.StopLoss = stopLoss
+	stop.TakeProfit = takeProfit
+
+	entry := stop.AvgEntryPrice
+	if entry <= 0 {
+		entry = existingEntry
+	}
+	if entry > 0 && addPrice > 0 && existingQty+addQty > 0 {
+		stop.AvgEntryPrice = (entry*existingQty + addPrice*addQty) / (existingQty + addQty)
+	}
+	return stop.AvgEntryPrice
+}
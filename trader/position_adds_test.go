@@ -0,0 +1,135 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"testing"
+)
+
+func TestMergePositionAddKeepsEntryRecord(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{AllowPositionAdds: true})
+	at.recordPositionStop("BTCUSDT", "long", 95, 120)
+	at.setPositionEntryPrice("BTCUSDT", "long", 100)
+	at.setPositionEntryPlan("BTCUSDT", "long", 99.5, 80)
+	at.stopsMu.Lock()
+	at.positionStops["BTCUSDT_long"].EntrySignals = []logger.SignalContribution{{Indicator: "macd", Weight: 1}}
+	at.stopsMu.Unlock()
+	at.updateExcursion("BTCUSDT", "long", 97)
+	at.updateExcursion("BTCUSDT", "long", 106)
+
+	avg := at.mergePositionAdd("BTCUSDT", "long", 101, 125, 1, 100, 1, 110)
+
+	if math.Abs(avg-105) > 1e-9 {
+		t.Fatalf("merged average = %.4f, want 105", avg)
+	}
+	stop := at.getPositionStop("BTCUSDT", "long")
+	if stop.StopLoss != 101 || stop.TakeProfit != 125 {
+		t.Errorf("stops = %.2f/%.2f, want 101/125", stop.StopLoss, stop.TakeProfit)
+	}
+	if stop.PlannedEntryPrice != 99.5 || stop.EntryConfidence != 80 {
+		t.Errorf("entry plan overwritten: %+v", stop)
+	}
+	if len(stop.EntrySignals) != 1 {
+		t.Errorf("entry signals = %v, want original signals", stop.EntrySignals)
+	}
+	if stop.WorstPrice != 97 || stop.BestPrice != 106 {
+		t.Errorf("excursion = %.2f/%.2f, want 97/106", stop.WorstPrice, stop.BestPrice)
+	}
+}
+
+func TestMergePositionAddWithoutRecord(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{AllowPositionAdds: true})
+
+	// 重启后没有持仓记录：用交易所的开仓均价合并
+	avg := at.mergePositionAdd("ETHUSDT", "short", 3100, 2800, 3, 3000, 1, 2960)
+	if math.Abs(avg-2990) > 1e-9 {
+		t.Fatalf("merged average = %.4f, want 2990", avg)
+	}
+}
+
+func TestExistingPosition(t *testing.T) {
+	positions := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "short", "positionAmt": -2.0, "entryPrice": 100.0},
+		{"symbol": "BTCUSDT", "side": "long", "positionAmt": 1.0, "entryPrice": 90.0},
+	}
+	if qty, entry := existingPosition(positions, "BTCUSDT", "short"); qty != 2 || entry != 100 {
+		t.Errorf("short = %.2f @ %.2f, want 2 @ 100", qty, entry)
+	}
+	if qty, _ := existingPosition(positions, "ETHUSDT", "long"); qty != 0 {
+		t.Errorf("missing position quantity = %.2f, want 0", qty)
+	}
+}
+
+func TestSymbolPositionCapUsesConfig(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	if got := at.symbolPositionCap("SOLUSDT", 1000); got != 1500 {
+		t.Errorf("default altcoin cap = %.2f, want 1500", got)
+	}
+	if got := at.symbolPositionCap("BTCUSDT", 1000); got != 10000 {
+		t.Errorf("default BTC cap = %.2f, want 10000", got)
+	}
+
+	at, _ = newTestAutoTrader(t, RiskConfig{AltcoinPositionCapMultiple: 0.5, BTCETHPositionCapMultiple: 2})
+	if got := at.symbolPositionCap("SOLUSDT", 1000); got != 500 {
+		t.Errorf("altcoin cap = %.2f, want 500", got)
+	}
+	if got := at.symbolPositionCap("ETHUSDT", 1000); got != 2000 {
+		t.Errorf("ETH cap = %.2f, want 2000", got)
+	}
+}
+
+func TestCheckNetExposureShrinksAdd(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{AllowPositionAdds: true, AltcoinPositionCapMultiple: 1})
+	account := RiskAccount{
+		Equity:    1000,
+		Positions: []decision.PositionInfo{{Symbol: "SOLUSDT", Side: "long", Quantity: 7, MarkPrice: 100}},
+	}
+
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 500}
+	if ok, reason := at.checkNetExposure(d, account, nil); !ok {
+		t.Fatalf("rejected: %s", reason)
+	}
+	if d.PositionSizeUSD != 300 {
+		t.Errorf("position size = %.2f, want 300", d.PositionSizeUSD)
+	}
+
+	account.Positions[0].Quantity = 10
+	d = &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 500}
+	if ok, _ := at.checkNetExposure(d, account, nil); ok {
+		t.Error("add beyond cap should be rejected")
+	}
+}
+
+func TestNetPositionSize(t *testing.T) {
+	cases := []struct {
+		name                     string
+		requested, existing, cap float64
+		want                     float64
+		wantReason               bool
+	}{
+		{"no existing position", 500, 0, 1000, 500, false},
+		{"partial existing position", 500, 700, 1000, 300, true},
+		{"room for the full add", 200, 700, 1000, 200, false},
+		{"already at cap", 500, 1000, 1000, 0, true},
+		{"no cap", 500, 5000, 0, 500, false},
+	}
+	for _, c := range cases {
+		got, reason := NetPositionSize(c.requested, c.existing, c.cap)
+		if got != c.want || (reason != "") != c.wantReason {
+			t.Errorf("%s: got %.2f %q, want %.2f (reason %v)", c.name, got, reason, c.want, c.wantReason)
+		}
+	}
+}
+
+func TestCheckNetExposureKeepsFullSizeWithoutExisting(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{AllowPositionAdds: true, AltcoinPositionCapMultiple: 1})
+	account := RiskAccount{
+		Equity:    1000,
+		Positions: []decision.PositionInfo{{Symbol: "SOLUSDT", Side: "short", Quantity: 10, MarkPrice: 100}},
+	}
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 800}
+	if ok, reason := at.checkNetExposure(d, account, nil); !ok || d.PositionSizeUSD != 800 {
+		t.Fatalf("ok=%v reason=%q size=%.2f, want full 800 (opposite side does not count)", ok, reason, d.PositionSizeUSD)
+	}
+}
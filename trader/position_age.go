@@ -0,0 +1,94 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"time"
+)
+
+// 持仓时长上限的币种分类（MaxPositionHoursByClass 的键）
+const (
+	AssetClassBTCETH  = "btc_eth"
+	AssetClassAltcoin = "altcoin"
+)
+
+// PositionAgeViolation 持仓时长超限
+type PositionAgeViolation struct {
+	Symbol   string        `json:"symbol"`
+	Side     string        `json:"side"`
+	Class    string        `json:"class"`
+	HeldFor  time.Duration `json:"held_for"`
+	MaxHeld  time.Duration `json:"max_held"`
+	OpenedAt time.Time     `json:"opened_at"`
+}
+
+// AssetClass 币种分类（与杠杆配置一致：BTC/ETH 与山寨币分开）
+func AssetClass(symbol string) string {
+	if symbol == "BTCUSDT" || symbol == "ETHUSDT" {
+		return AssetClassBTCETH
+	}
+	return AssetClassAltcoin
+}
+
+// CheckPositionAgeViolations 检查持仓时长是否超过所属分类的上限
+// 持有时间按 PositionInfo.UpdateTime（持仓首次出现时间）计算；未配置上限的分类不检查
+func CheckPositionAgeViolations(positions []decision.PositionInfo, maxDuration map[string]time.Duration, assetClassFn func(string) string, now time.Time) []*PositionAgeViolation {
+	var violations []*PositionAgeViolation
+	for _, pos := range positions {
+		if pos.UpdateTime <= 0 {
+			continue
+		}
+		class := assetClassFn(pos.Symbol)
+		limit := maxDuration[class]
+		if limit <= 0 {
+			continue
+		}
+
+		openedAt := time.UnixMilli(pos.UpdateTime)
+		if held := now.Sub(openedAt); held > limit {
+			violations = append(violations, &PositionAgeViolation{
+				Symbol:   pos.Symbol,
+				Side:     pos.Side,
+				Class:    class,
+				HeldFor:  held,
+				MaxHeld:  limit,
+				OpenedAt: openedAt,
+			})
+		}
+	}
+	return violations
+}
+
+// positionAgeExits 为持仓时长超限的持仓生成强制平仓决策
+// 隔夜/周末持仓有跳空风险，超过时长上限后不再等待AI判断；AI本周期已决定平仓的持仓不重复生成
+func (at *AutoTrader) positionAgeExits(positions []decision.PositionInfo, decisions []decision.Decision) []decision.Decision {
+	hours := at.config.Risk.MaxPositionHoursByClass
+	if len(hours) == 0 {
+		return nil
+	}
+	maxDuration := make(map[string]time.Duration, len(hours))
+	for class, h := range hours {
+		maxDuration[class] = time.Duration(h * float64(time.Hour))
+	}
+
+	closing := make(map[string]bool)
+	for _, d := range decisions {
+		if d.Action == "close_long" || d.Action == "close_short" {
+			closing[d.Symbol+"_"+d.Action] = true
+		}
+	}
+
+	var exits []decision.Decision
+	for _, v := range CheckPositionAgeViolations(positions, maxDuration, AssetClass, at.now()) {
+		action := "close_" + v.Side
+		if closing[v.Symbol+"_"+action] {
+			continue
+		}
+		exits = append(exits, decision.Decision{
+			Symbol:    v.Symbol,
+			Action:    action,
+			Reasoning: fmt.Sprintf("max position duration exceeded（已持仓 %.1f 小时，上限 %.1f 小时）", v.HeldFor.Hours(), v.MaxHeld.Hours()),
+		})
+	}
+	return exits
+}
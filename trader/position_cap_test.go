@@ -0,0 +1,61 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+)
+
+func TestClampPositionSize(t *testing.T) {
+	cases := []struct {
+		name         string
+		size, maxAbs float64
+		want         float64
+	}{
+		{"above the absolute cap", 80000, 50000, 50000},
+		{"within bounds", 30000, 50000, 30000},
+		{"exactly at the cap", 50000, 50000, 50000},
+		{"no absolute cap", 80000, 0, 80000},
+		{"negative cap disables", 80000, -1, 80000},
+	}
+	for _, tc := range cases {
+		if got := ClampPositionSize(tc.size, tc.maxAbs); got != tc.want {
+			t.Errorf("%s: ClampPositionSize(%.0f, %.0f) = %.0f, want %.0f", tc.name, tc.size, tc.maxAbs, got, tc.want)
+		}
+	}
+}
+
+func TestSymbolPositionCapTakesTheSmallerCap(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{AltcoinPositionCapMultiple: 1.5, BTCETHPositionCapMultiple: 10, MaxAbsolutePositionUSD: 50000})
+
+	cases := []struct {
+		name   string
+		symbol string
+		equity float64
+		want   float64
+	}{
+		{"equity-relative cap binds", "SOLUSDT", 10000, 15000},
+		{"absolute cap binds after equity growth", "SOLUSDT", 100000, 50000},
+		{"btc multiple hits the absolute cap", "BTCUSDT", 10000, 50000},
+		{"btc multiple within the absolute cap", "BTCUSDT", 2000, 20000},
+	}
+	for _, tc := range cases {
+		if got := at.symbolPositionCap(tc.symbol, tc.equity); got != tc.want {
+			t.Errorf("%s: cap = %.0f, want %.0f", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestApplyAbsolutePositionCap(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MaxAbsolutePositionUSD: 50000})
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", PositionSizeUSD: 120000}
+	at.applyAbsolutePositionCap(d)
+	if d.PositionSizeUSD != 50000 {
+		t.Errorf("size = %.0f, want clamped to 50000", d.PositionSizeUSD)
+	}
+
+	d.PositionSizeUSD = 20000
+	at.applyAbsolutePositionCap(d)
+	if d.PositionSizeUSD != 20000 {
+		t.Errorf("size = %.0f, want unchanged 20000", d.PositionSizeUSD)
+	}
+}
@@ -0,0 +1,188 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"time"
+)
+
+// PositionHeat 单个持仓的风险热度
+type PositionHeat struct {
+	Symbol               string  `json:"symbol"`
+	Side                 string  `json:"side"`
+	HeatLevel            string  `json:"heat_level"`              // cool / warm / hot / critical
+	TimeToLiquidationPct float64 `json:"time_to_liquidation_pct"` // 当前价距强平价的百分比
+	UnrealizedPnLPct     float64 `json:"unrealized_pnl_pct"`      // 未实现盈亏百分比（相对入场价）
+	StopDistancePct      float64 `json:"stop_distance_pct"`       // 当前价距止损价的百分比（-1=未记录止损）
+	MarginUtilisation    float64 `json:"margin_utilisation"`      // 该持仓保证金占账户净值的百分比
+	RiskScore            float64 `json:"risk_score"`              // 综合风险评分（0-100）
+}
+
+// 风险评分的满分阈值：达到这些数值时对应分项记满分
+const (
+	heatLiquidationDistancePct = 20.0 // 距强平 ≤ 0% 满分，≥ 20% 为0分
+	heatStopDistancePct        = 5.0  // 距止损 ≤ 0% 满分，≥ 5% 为0分
+	heatLossPct                = 10.0 // 浮亏达到10%满分
+	heatMarginPct              = 50.0 // 单仓保证金占净值50%满分
+)
+
+// GetPositionHeat 计算单个持仓的风险热度
+// data 可为nil，此时使用持仓的标记价格；否则强平/止损距离按 StopPriceRef、浮动盈亏按 PnLPriceRef 取价
+func (at *AutoTrader) GetPositionHeat(symbol string, position decision.PositionInfo, data *market.Data) *PositionHeat {
+	price, pnlPrice := position.MarkPrice, position.MarkPrice
+	if data != nil && data.CurrentPrice > 0 {
+		price = data.ReferencePrice(at.config.Risk.StopPriceRef)
+		pnlPrice = data.ReferencePrice(at.config.Risk.PnLPriceRef)
+	}
+
+	heat := &PositionHeat{
+		Symbol:          symbol,
+		Side:            position.Side,
+		StopDistancePct: -1,
+	}
+	if price <= 0 {
+		heat.HeatLevel = heatLevel(0)
+		return heat
+	}
+
+	// 距强平价
+	liqScore := 0.0
+	if position.LiquidationPrice > 0 {
+		heat.TimeToLiquidationPct = math.Abs(price-position.LiquidationPrice) / price * 100
+		liqScore = 1 - clamp01(heat.TimeToLiquidationPct/heatLiquidationDistancePct)
+	}
+
+	// 浮动盈亏（相对入场价，不含杠杆）
+	if position.EntryPrice > 0 {
+		if position.Side == "long" {
+			heat.UnrealizedPnLPct = (pnlPrice - position.EntryPrice) / position.EntryPrice * 100
+		} else {
+			heat.UnrealizedPnLPct = (position.EntryPrice - pnlPrice) / position.EntryPrice * 100
+		}
+	}
+	lossScore := clamp01(-heat.UnrealizedPnLPct / heatLossPct)
+
+	// 距止损价（未记录止损视为中等风险）
+	stopScore := 0.5
+	if stop := at.getPositionStop(symbol, position.Side); stop != nil && stop.StopLoss > 0 {
+		if position.Side == "long" {
+			heat.StopDistancePct = (price - stop.StopLoss) / price * 100
+		} else {
+			heat.StopDistancePct = (stop.StopLoss - price) / price * 100
+		}
+		stopScore = 1 - clamp01(heat.StopDistancePct/heatStopDistancePct)
+	}
+
+	// 保证金占用
+	marginScore := 0.0
+	if equity := at.equitySnapshot().Last; equity > 0 {
+		heat.MarginUtilisation = position.MarginUsed / equity * 100
+		marginScore = clamp01(heat.MarginUtilisation / heatMarginPct)
+	}
+
+	heat.RiskScore = (0.4*liqScore + 0.2*stopScore + 0.2*lossScore + 0.2*marginScore) * 100
+	heat.HeatLevel = heatLevel(heat.RiskScore)
+	return heat
+}
+
+// heatLevel 风险评分对应的热度等级
+func heatLevel(score float64) string {
+	switch {
+	case score > 75:
+		return "critical"
+	case score >= 50:
+		return "hot"
+	case score >= 25:
+		return "warm"
+	default:
+		return "cool"
+	}
+}
+
+// portfolioHeat 所有持仓中最高的风险热度等级（按标记价格计算，无持仓为cool）
+func (at *AutoTrader) portfolioHeat(positions []decision.PositionInfo) string {
+	maxScore := 0.0
+	for _, pos := range positions {
+		if heat := at.GetPositionHeat(pos.Symbol, pos, nil); heat.RiskScore > maxScore {
+			maxScore = heat.RiskScore
+		}
+	}
+	return heatLevel(maxScore)
+}
+
+// clamp01 将数值限制在[0,1]
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// GetRiskStatus 获取风险状态（用于API）
+func (at *AutoTrader) GetRiskStatus() (map[string]interface{}, error) {
+	account, err := at.GetAccountInfo()
+	if err != nil {
+		return nil, err
+	}
+	heats, _, err := at.positionHeats()
+	if err != nil {
+		return nil, err
+	}
+
+	status := map[string]interface{}{
+		"trader_id":       at.id,
+		"timestamp":       time.Now().Format(time.RFC3339),
+		"total_equity":    account["total_equity"],
+		"margin_used_pct": account["margin_used_pct"],
+		"position_heats":  heats,
+	}
+	if alerts := at.Alerts(); len(alerts) > 0 {
+		status["rejection_alerts"] = alerts
+	}
+	if at.calendar != nil {
+		if start, description := at.calendar.NextEvent(at.now()); description != "" {
+			status["next_calendar_event"] = fmt.Sprintf("%s %s", start.Format("2006-01-02 15:04 UTC"), description)
+		}
+	}
+	return status, nil
+}
+
+// positionHeats 计算当前所有持仓的风险热度（同时返回解析后的持仓）
+func (at *AutoTrader) positionHeats() ([]PositionHeat, []decision.PositionInfo, error) {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	heats := []PositionHeat{}
+	infos := make([]decision.PositionInfo, 0, len(positions))
+	for _, pos := range positions {
+		info := parsePositionInfo(pos)
+		data, err := at.getMarketData(info.Symbol)
+		if err != nil {
+			data = nil
+		}
+		heats = append(heats, *at.GetPositionHeat(info.Symbol, info, data))
+		infos = append(infos, info)
+	}
+	return heats, infos, nil
+}
+
+// parsePositionInfo 将交易所返回的持仓转换为PositionInfo
+func parsePositionInfo(pos map[string]interface{}) decision.PositionInfo {
+	info := decision.PositionInfo{Leverage: 10}
+	info.Symbol, _ = pos["symbol"].(string)
+	info.Side, _ = pos["side"].(string)
+	info.EntryPrice, _ = pos["entryPrice"].(float64)
+	info.MarkPrice, _ = pos["markPrice"].(float64)
+	info.Quantity, _ = pos["positionAmt"].(float64)
+	if info.Quantity < 0 {
+		info.Quantity = -info.Quantity
+	}
+	info.UnrealizedPnL, _ = pos["unRealizedProfit"].(float64)
+	info.LiquidationPrice, _ = pos["liquidationPrice"].(float64)
+	if lev, ok := pos["leverage"].(float64); ok && lev > 0 {
+		info.Leverage = int(lev)
+	}
+	info.MarginUsed = info.Quantity * info.MarkPrice / float64(info.Leverage)
+	return info
+}
@@ -0,0 +1,140 @@
+package trader
+
+import (
+	"math"
+	"nofx/market"
+	"time"
+)
+
+// startPositionMonitor 启动持仓兜底止损监控（已在运行时不重复启动）
+func (at *AutoTrader) startPositionMonitor() {
+	at.monitorMu.Lock()
+	defer at.monitorMu.Unlock()
+	if at.monitorStop != nil {
+		return
+	}
+	at.monitorStop = make(chan struct{})
+	go at.runPositionMonitor(at.monitorStop)
+}
+
+// stopPositionMonitor 停止持仓兜底止损监控
+func (at *AutoTrader) stopPositionMonitor() {
+	at.monitorMu.Lock()
+	defer at.monitorMu.Unlock()
+	if at.monitorStop == nil {
+		return
+	}
+	close(at.monitorStop)
+	at.monitorStop = nil
+}
+
+// runPositionMonitor 持仓兜底止损监控（独立于AI决策周期，按 MonitorIntervalSec 轮询，stop 关闭时退出）
+// 交易所止损单可能静默失败（被撤销、下单失败等），价格已越过止损位且交易所没有有效止损单时，直接市价平仓。
+// 每次检查持有 cycleMu，与交易周期串行执行，避免周期内开仓/平仓/重挂止损时被监控同时平仓
+func (at *AutoTrader) runPositionMonitor(stop <-chan struct{}) {
+	interval := time.Duration(at.config.Risk.MonitorIntervalSec) * time.Second
+	at.logger.Infof("🛡️  [%s] 兜底止损监控已启动（每 %v 检查一次）", at.name, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			at.logger.Infof("🛡️  [%s] 兜底止损监控已停止", at.name)
+			return
+		case <-ticker.C:
+			at.cycleMu.Lock()
+			at.checkStopLossFallback()
+			at.cycleMu.Unlock()
+		}
+	}
+}
+
+// checkStopLossFallback 检查所有持仓是否触及止损但未被交易所止损单平仓
+func (at *AutoTrader) checkStopLossFallback() {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		at.logger.Warnf("⚠️ [兜底止损] 获取持仓失败: %v", err)
+		return
+	}
+
+	for _, pos := range positions {
+		info := parsePositionInfo(pos)
+		if info.Symbol == "" || info.Quantity == 0 || info.MarkPrice <= 0 {
+			continue
+		}
+
+		at.updateExcursion(info.Symbol, info.Side, info.MarkPrice)
+		stop := at.getPositionStop(info.Symbol, info.Side)
+		if stop == nil || stop.StopLoss <= 0 {
+			continue
+		}
+		triggerRef := at.config.Risk.StopTriggerPriceRef
+		lastPrice := 0.0
+		if triggerRef != market.PriceRefMark && nearStopLoss(info.MarkPrice, stop.StopLoss) {
+			if lastPrice, err = at.trader.GetMarketPrice(info.Symbol); err != nil {
+				at.logger.Warnf("⚠️ [兜底止损] %s 获取最新价失败，改用标记价: %v", info.Symbol, err)
+			}
+		}
+		triggerPrice := StopTriggerPrice(triggerRef, lastPrice, info.MarkPrice)
+		if !CheckStopLossTrigger(info.Side, triggerPrice, stop.StopLoss) {
+			continue
+		}
+
+		// 交易所止损单仍在：交给交易所执行（可能只是尚未成交）
+		exchangeStop, _, err := at.findExchangeStops(info.Symbol, info.Side)
+		if err != nil {
+			at.logger.Warnf("⚠️ [兜底止损] %s 查询挂单失败: %v", info.Symbol, err)
+			continue
+		}
+		if exchangeStop > 0 {
+			continue
+		}
+
+		at.logger.Infof("🛑 [兜底止损] %s %s %s %.4f 已越过止损价 %.4f 且交易所无止损单，市价平仓",
+			info.Symbol, sideName(info.Side), priceRefName(triggerRef), triggerPrice, stop.StopLoss)
+		if info.Side == "short" {
+			_, err = at.trader.CloseShort(info.Symbol, 0)
+		} else {
+			_, err = at.trader.CloseLong(info.Symbol, 0)
+		}
+		if err != nil {
+			at.logger.Errorf("❌ [兜底止损] %s 平仓失败: %v", info.Symbol, err)
+			continue
+		}
+		at.logger.Infof("✓ [兜底止损] %s %s 已平仓", info.Symbol, sideName(info.Side))
+	}
+}
+
+// lastPriceCheckBand 标记价距止损在此比例内才请求最新成交价（两者偏离通常远小于此值，避免每个监控周期对每个持仓都请求一次）
+const lastPriceCheckBand = 0.02
+
+// nearStopLoss 标记价是否已接近（或越过）止损价
+func nearStopLoss(markPrice, stopLoss float64) bool {
+	return math.Abs(markPrice-stopLoss) <= stopLoss*lastPriceCheckBand
+}
+
+// StopTriggerPrice 按止损触发价格类型取价：mark 用标记价格，否则用最新成交价；对应价格缺失时用另一个
+func StopTriggerPrice(ref string, lastPrice, markPrice float64) float64 {
+	if ref == market.PriceRefMark && markPrice > 0 || lastPrice <= 0 {
+		return markPrice
+	}
+	return lastPrice
+}
+
+// priceRefName 触发价格类型的中文名称
+func priceRefName(ref string) string {
+	if ref == market.PriceRefMark {
+		return "标记价"
+	}
+	return "最新价"
+}
+
+// CheckStopLossTrigger 触发价格是否已越过止损价（多仓跌破、空仓涨破），触发价格由 StopTriggerPrice 选取
+func CheckStopLossTrigger(side string, price, stopLoss float64) bool {
+	if side == "short" {
+		return price >= stopLoss
+	}
+	return price <= stopLoss
+}
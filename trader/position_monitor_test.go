@@ -0,0 +1,76 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartPositionMonitorRunsOnce(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MonitorIntervalSec: 3600})
+
+	at.startPositionMonitor()
+	first := at.monitorStop
+	at.startPositionMonitor()
+	if at.monitorStop != first {
+		t.Fatal("second start replaced the running monitor")
+	}
+
+	at.stopPositionMonitor()
+	if at.monitorStop != nil {
+		t.Fatal("monitor still registered after stop")
+	}
+	select {
+	case <-first:
+	default:
+		t.Fatal("stop channel not closed")
+	}
+	at.stopPositionMonitor() // 重复停止不应panic
+}
+
+func TestPositionMonitorWaitsForCycle(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{MonitorIntervalSec: 1})
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 90)
+	at.recordPositionStop("BTCUSDT", "long", 95, 120)
+
+	// 模拟进行中的交易周期
+	at.cycleMu.Lock()
+	at.startPositionMonitor()
+	defer at.stopPositionMonitor()
+
+	time.Sleep(1500 * time.Millisecond)
+	if n := mock.Count("CloseLong"); n != 0 {
+		at.cycleMu.Unlock()
+		t.Fatalf("monitor closed %d times while the cycle was running", n)
+	}
+	at.cycleMu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mock.Count("CloseLong") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("monitor did not close the position after the cycle finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStopLossFallbackFetchesLastPriceOnlyNearStop(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{StopTriggerPriceRef: "last"})
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 100)
+	at.recordPositionStop("BTCUSDT", "long", 90, 120)
+
+	at.checkStopLossFallback()
+	if n := mock.Count("GetMarketPrice"); n != 0 {
+		t.Fatalf("GetMarketPrice called %d times while the mark price is far from the stop", n)
+	}
+
+	// 标记价接近止损，最新成交价已插针越过止损
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 91)
+	mock.prices["BTCUSDT"] = 89
+	at.checkStopLossFallback()
+	if n := mock.Count("GetMarketPrice"); n != 1 {
+		t.Fatalf("GetMarketPrice called %d times, want 1 near the stop", n)
+	}
+	if n := mock.Count("CloseLong"); n != 1 {
+		t.Errorf("CloseLong called %d times, want 1 on the last-price trigger", n)
+	}
+}
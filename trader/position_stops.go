@@ -0,0 +1,82 @@
+package trader
+
+import (
+	"nofx/logger"
+	"sort"
+)
+
+// positionStop 开仓时设置的止损止盈价（key: symbol_side）
+type positionStop struct {
+	StopLoss      float64                     `json:"stop_loss"`
+	TakeProfit    float64                     `json:"take_profit"`
+	AvgEntryPrice float64                     `json:"avg_entry_price,omitempty"` // 实际成交均价（0=交易所未返回）
+	EntrySignals  []logger.SignalContribution `json:"entry_signals,omitempty"`   // 开仓时的技术指标信号
+
+	PlannedEntryPrice float64 `json:"planned_entry_price,omitempty"` // 下单时的计划价格（用于计算滑点）
+	EntryConfidence   int     `json:"entry_confidence,omitempty"`    // 开仓时的信心度
+
+	WorstPrice float64 `json:"worst_price,omitempty"` // 持仓期间最不利的价格（用于计算MAE）
+	BestPrice  float64 `json:"best_price,omitempty"`  // 持仓期间最有利的价格（用于计算MFE）
+}
+
+// recordPositionStop 记录持仓的止损止盈价
+func (at *AutoTrader) recordPositionStop(symbol, side string, stopLoss, takeProfit float64) {
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+	at.positionStops[symbol+"_"+side] = &positionStop{
+		StopLoss:   stopLoss,
+		TakeProfit: takeProfit,
+	}
+}
+
+// setPositionTakeProfit 更新持仓的止盈价（保留止损和成交均价）
+func (at *AutoTrader) setPositionTakeProfit(symbol, side string, takeProfit float64) {
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+	if stop, ok := at.positionStops[symbol+"_"+side]; ok {
+		stop.TakeProfit = takeProfit
+	}
+}
+
+// setPositionEntryPrice 记录持仓的实际成交均价
+func (at *AutoTrader) setPositionEntryPrice(symbol, side string, avgPrice float64) {
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+	if stop, ok := at.positionStops[symbol+"_"+side]; ok {
+		stop.AvgEntryPrice = avgPrice
+	}
+}
+
+// getPositionStop 获取持仓的止损止盈价（未记录时返回nil）
+func (at *AutoTrader) getPositionStop(symbol, side string) *positionStop {
+	at.stopsMu.RLock()
+	defer at.stopsMu.RUnlock()
+	if stop, ok := at.positionStops[symbol+"_"+side]; ok {
+		copied := *stop
+		return &copied
+	}
+	return nil
+}
+
+// positionStopKeys 有止损止盈记录的持仓（symbol_side，已排序）
+func (at *AutoTrader) positionStopKeys() []string {
+	at.stopsMu.RLock()
+	defer at.stopsMu.RUnlock()
+	keys := make([]string, 0, len(at.positionStops))
+	for key := range at.positionStops {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// prunePositionStops 清理已平仓持仓的止损止盈记录
+func (at *AutoTrader) prunePositionStops(currentPositionKeys map[string]bool) {
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+	for key := range at.positionStops {
+		if !currentPositionKeys[key] {
+			delete(at.positionStops, key)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package trader
+
+import (
+	"context"
+	"nofx/logger"
+	"nofx/market"
+	"nofx/market/ws"
+	"sync"
+	"time"
+)
+
+// PriceOracle 价格来源接口
+// 平仓等计算使用的价格从此接口获取，便于按交易所替换价格来源或在回测中注入固定价格；
+// 默认使用 TraderPriceOracle（交易所接口的最新价）
+type PriceOracle interface {
+	GetPrice(symbol string) (float64, error)
+}
+
+// TraderPriceOracle 从交易所接口获取最新价（只请求价格，不拉取K线等完整行情）
+type TraderPriceOracle struct {
+	Trader Trader
+}
+
+// GetPrice 返回交易所的最新价
+func (o TraderPriceOracle) GetPrice(symbol string) (float64, error) {
+	return o.Trader.GetMarketPrice(symbol)
+}
+
+// SetPriceOracle 设置价格来源（nil 表示使用默认的 TraderPriceOracle）
+func (at *AutoTrader) SetPriceOracle(oracle PriceOracle) {
+	if oracle == nil {
+		oracle = TraderPriceOracle{Trader: at.trader}
+	}
+	at.priceOracle = oracle
+}
+
+// streamMaxAge 实时价格的有效期（超过后视为推送中断，回退到 fallback）
+const streamMaxAge = 10 * time.Second
+
+// streamRetryDelay 订阅失败后再次尝试的间隔
+const streamRetryDelay = time.Minute
+
+// priceSubscriber 订阅实时行情（默认 ws.StartStreamProcessing，测试中可替换）
+type priceSubscriber func(symbol string) (<-chan *market.Data, context.CancelFunc, error)
+
+// streamPrice 最近一次推送的价格
+type streamPrice struct {
+	price float64
+	at    time.Time
+}
+
+// StreamPriceOracle 使用WebSocket实时推送的最新价（首次查询某币种时订阅）
+// 尚未收到推送、推送中断或订阅失败时回退到 fallback
+type StreamPriceOracle struct {
+	subscribe priceSubscriber
+	fallback  PriceOracle
+	now       func() time.Time
+
+	mu       sync.Mutex
+	prices   map[string]streamPrice
+	cancels  map[string]context.CancelFunc
+	failedAt map[string]time.Time
+}
+
+// NewStreamPriceOracle 创建基于币安实时行情推送的价格来源
+func NewStreamPriceOracle(feed *ws.WebSocketMarketDataFeed, fallback PriceOracle) *StreamPriceOracle {
+	return newStreamPriceOracle(func(symbol string) (<-chan *market.Data, context.CancelFunc, error) {
+		return ws.StartStreamProcessing(feed, symbol)
+	}, fallback)
+}
+
+func newStreamPriceOracle(subscribe priceSubscriber, fallback PriceOracle) *StreamPriceOracle {
+	return &StreamPriceOracle{
+		subscribe: subscribe,
+		fallback:  fallback,
+		now:       time.Now,
+		prices:    make(map[string]streamPrice),
+		cancels:   make(map[string]context.CancelFunc),
+		failedAt:  make(map[string]time.Time),
+	}
+}
+
+// GetPrice 返回实时推送的最新价，没有有效推送时使用 fallback
+func (o *StreamPriceOracle) GetPrice(symbol string) (float64, error) {
+	symbol = market.Normalize(symbol)
+	o.mu.Lock()
+	if _, subscribed := o.cancels[symbol]; !subscribed && o.now().Sub(o.failedAt[symbol]) >= streamRetryDelay {
+		o.subscribeLocked(symbol)
+	}
+	p, ok := o.prices[symbol]
+	o.mu.Unlock()
+
+	if ok && p.price > 0 && o.now().Sub(p.at) <= streamMaxAge {
+		return p.price, nil
+	}
+	return o.fallback.GetPrice(symbol)
+}
+
+// subscribeLocked 订阅币种的实时行情并在后台更新价格（调用方已加锁）
+func (o *StreamPriceOracle) subscribeLocked(symbol string) {
+	updates, cancel, err := o.subscribe(symbol)
+	if err != nil {
+		logger.Warnf("⚠️ [%s] 订阅实时行情失败，使用交易所价格: %v", symbol, err)
+		o.failedAt[symbol] = o.now()
+		return
+	}
+	o.cancels[symbol] = cancel
+	go func() {
+		for data := range updates {
+			o.mu.Lock()
+			o.prices[symbol] = streamPrice{price: data.CurrentPrice, at: o.now()}
+			o.mu.Unlock()
+		}
+		// 订阅结束（取消或连接关闭），下次查询时重新订阅
+		o.mu.Lock()
+		delete(o.prices, symbol)
+		delete(o.cancels, symbol)
+		o.mu.Unlock()
+	}()
+}
+
+// Close 取消所有订阅
+func (o *StreamPriceOracle) Close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, cancel := range o.cancels {
+		cancel()
+	}
+}
@@ -0,0 +1,124 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"testing"
+	"time"
+)
+
+func TestCloseUsesInjectedPriceOracle(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("BTCUSDT", "long", 1, 100, 100)
+	at.SetPriceOracle(staticPriceOracle{"BTCUSDT": 105})
+
+	var record logger.DecisionAction
+	if err := at.executeCloseLongWithRecord(&decision.Decision{Symbol: "BTCUSDT", Action: "close_long"}, &record); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if record.Price != 105 {
+		t.Errorf("price = %v, want 105 from the oracle", record.Price)
+	}
+	if n := mock.Count("GetMarketPrice"); n != 0 {
+		t.Errorf("GetMarketPrice called %d times, want the oracle only", n)
+	}
+}
+
+func TestDefaultPriceOracleUsesExchangePrice(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("ETHUSDT", "short", 1, 2000, 1990)
+
+	var record logger.DecisionAction
+	if err := at.executeCloseShortWithRecord(&decision.Decision{Symbol: "ETHUSDT", Action: "close_short"}, &record); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if record.Price != 1990 {
+		t.Errorf("price = %v, want the exchange price 1990", record.Price)
+	}
+	if n := mock.Count("GetMarketPrice"); n == 0 {
+		t.Error("default oracle did not query the exchange price")
+	}
+}
+
+// waitForPrice 等待后台协程把推送写入价格来源
+func waitForPrice(t *testing.T, o *StreamPriceOracle, symbol string, want float64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if price, _ := o.GetPrice(symbol); price == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("price for %s never became %v", symbol, want)
+}
+
+func TestStreamPriceOracleUsesStreamedPrice(t *testing.T) {
+	clock := &fixedClock{t: time.Unix(1700000000, 0)}
+	updates := make(chan *market.Data, 1)
+	subscribed := 0
+	oracle := newStreamPriceOracle(func(symbol string) (<-chan *market.Data, context.CancelFunc, error) {
+		subscribed++
+		return updates, func() {}, nil
+	}, staticPriceOracle{"BTCUSDT": 100})
+	oracle.now = clock.Now
+
+	if price, _ := oracle.GetPrice("btc"); price != 100 {
+		t.Errorf("price before any tick = %v, want fallback 100", price)
+	}
+	updates <- &market.Data{Symbol: "BTCUSDT", CurrentPrice: 101.5}
+	waitForPrice(t, oracle, "BTCUSDT", 101.5)
+	if subscribed != 1 {
+		t.Errorf("subscribed %d times, want 1", subscribed)
+	}
+
+	clock.Advance(streamMaxAge + time.Second)
+	if price, _ := oracle.GetPrice("BTCUSDT"); price != 100 {
+		t.Errorf("stale stream price = %v, want fallback 100", price)
+	}
+
+	close(updates)
+}
+
+func TestStreamPriceOracleRetriesFailedSubscription(t *testing.T) {
+	clock := &fixedClock{t: time.Unix(1700000000, 0)}
+	attempts := 0
+	oracle := newStreamPriceOracle(func(symbol string) (<-chan *market.Data, context.CancelFunc, error) {
+		attempts++
+		return nil, nil, errors.New("dial failed")
+	}, staticPriceOracle{"ETHUSDT": 2000})
+	oracle.now = clock.Now
+
+	for i := 0; i < 3; i++ {
+		if price, err := oracle.GetPrice("ETHUSDT"); err != nil || price != 2000 {
+			t.Fatalf("GetPrice = %v, %v; want fallback 2000", price, err)
+		}
+	}
+	if attempts != 1 {
+		t.Errorf("attempts within retry delay = %d, want 1", attempts)
+	}
+
+	clock.Advance(streamRetryDelay)
+	oracle.GetPrice("ETHUSDT")
+	if attempts != 2 {
+		t.Errorf("attempts after retry delay = %d, want 2", attempts)
+	}
+}
+
+func TestStreamPriceOracleCloseCancelsSubscriptions(t *testing.T) {
+	canceled := make(chan string, 2)
+	oracle := newStreamPriceOracle(func(symbol string) (<-chan *market.Data, context.CancelFunc, error) {
+		return make(chan *market.Data), func() { canceled <- symbol }, nil
+	}, staticPriceOracle{"BTCUSDT": 100, "ETHUSDT": 2000})
+
+	oracle.GetPrice("BTCUSDT")
+	oracle.GetPrice("ETHUSDT")
+	oracle.Close()
+
+	if len(canceled) != 2 {
+		t.Errorf("canceled %d subscriptions, want 2", len(canceled))
+	}
+}
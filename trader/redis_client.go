@@ -0,0 +1,147 @@
+package trader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisClient 极简Redis客户端（每条命令单独建立连接，供分布式锁和决策发布使用）
+type redisClient struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+}
+
+// newRedisClient 解析Redis地址
+// redisURL 格式: redis://[:password@]host:port[/db]
+func newRedisClient(redisURL string) (*redisClient, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析Redis地址失败: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("不支持的Redis地址协议: %s", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	password := ""
+	if u.User != nil {
+		if p, ok := u.User.Password(); ok {
+			password = p
+		} else {
+			password = u.User.Username()
+		}
+	}
+
+	db := 0
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		db, err = strconv.Atoi(path)
+		if err != nil {
+			return nil, fmt.Errorf("Redis数据库编号无效: %s", path)
+		}
+	}
+
+	return &redisClient{addr: addr, password: password, db: db, timeout: 5 * time.Second}, nil
+}
+
+// do 建立连接并执行一条Redis命令（调用频率很低，无需连接池）
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	reader := bufio.NewReader(conn)
+
+	if c.password != "" {
+		if _, err := sendCommand(conn, reader, "AUTH", c.password); err != nil {
+			return nil, fmt.Errorf("Redis认证失败: %w", err)
+		}
+	}
+	if c.db != 0 {
+		if _, err := sendCommand(conn, reader, "SELECT", strconv.Itoa(c.db)); err != nil {
+			return nil, fmt.Errorf("切换Redis数据库失败: %w", err)
+		}
+	}
+
+	return sendCommand(conn, reader, args...)
+}
+
+// sendCommand 按RESP协议发送命令并读取回复
+func sendCommand(conn net.Conn, reader *bufio.Reader, args ...string) (interface{}, error) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, arg := range args {
+		sb.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg))
+	}
+	if _, err := conn.Write([]byte(sb.String())); err != nil {
+		return nil, err
+	}
+	return readReply(reader)
+}
+
+// readReply 读取一条RESP回复（nil表示空值）
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("Redis回复为空")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("Redis错误: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readReply(reader)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("无法识别的Redis回复: %s", line)
+	}
+}
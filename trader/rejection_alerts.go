@@ -0,0 +1,154 @@
+package trader
+
+import (
+	"nofx/logger"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 拒绝告警的严重程度
+const (
+	AlertSeverityInfo     = "info"     // 偶发拒绝
+	AlertSeverityWarning  = "warning"  // 同一原因多次拒绝
+	AlertSeverityCritical = "critical" // 需要人工处理（保证金不足、交易暂停等）
+)
+
+// warningRejectionCount 同一原因拒绝达到此次数时升级为 warning
+const warningRejectionCount = 3
+
+// criticalRejectionRules 触发即需要人工处理的风控规则
+var criticalRejectionRules = map[string]bool{
+	"trading_halt":             true,
+	"strategy_circuit_breaker": true,
+	"drawdown_hard_stop":       true,
+}
+
+// criticalRejectionKeywords 错误信息中出现即视为 critical 的关键词
+var criticalRejectionKeywords = []string{"保证金不足", "余额不足", "insufficient", "强平"}
+
+var (
+	ruleNamePattern = regexp.MustCompile(`^\[([a-z_]+)\]`)
+	numberPattern   = regexp.MustCompile(`[-+]?\d+(\.\d+)?`)
+)
+
+// RejectionAlert 一个周期内同一原因的拒绝汇总
+type RejectionAlert struct {
+	Reason   string   `json:"reason"`   // 风控规则名，或去掉币种和数字后的错误信息
+	Severity string   `json:"severity"` // info / warning / critical
+	Count    int      `json:"count"`
+	Symbols  []string `json:"symbols"` // 受影响的币种（去重，按首次出现顺序）
+	Sample   string   `json:"sample"`  // 第一条原始错误信息
+}
+
+// GroupRejections 将执行失败的决策按原因分组（按严重程度、次数从高到低排序）
+func GroupRejections(actions []logger.DecisionAction) []RejectionAlert {
+	var alerts []RejectionAlert
+	index := make(map[string]int)
+	for _, action := range actions {
+		if action.Success || action.Error == "" {
+			continue
+		}
+		reason := rejectionReason(action.Error, action.Symbol)
+		i, ok := index[reason]
+		if !ok {
+			i = len(alerts)
+			index[reason] = i
+			alerts = append(alerts, RejectionAlert{Reason: reason, Sample: action.Error})
+		}
+		alert := &alerts[i]
+		alert.Count++
+		if action.Symbol != "" && !containsString(alert.Symbols, action.Symbol) {
+			alert.Symbols = append(alert.Symbols, action.Symbol)
+		}
+	}
+
+	for i := range alerts {
+		alerts[i].Severity = rejectionSeverity(alerts[i])
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if ri, rj := severityRank(alerts[i].Severity), severityRank(alerts[j].Severity); ri != rj {
+			return ri > rj
+		}
+		return alerts[i].Count > alerts[j].Count
+	})
+	return alerts
+}
+
+// rejectionReason 分组用的原因：风控规则拒绝取规则名，其他错误去掉币种和数字
+func rejectionReason(errMsg, symbol string) string {
+	if m := ruleNamePattern.FindStringSubmatch(errMsg); m != nil {
+		return m[1]
+	}
+	reason := errMsg
+	if symbol != "" {
+		reason = strings.ReplaceAll(reason, symbol, "")
+	}
+	reason = numberPattern.ReplaceAllString(reason, "#")
+	return strings.Join(strings.Fields(reason), " ")
+}
+
+// rejectionSeverity 严重程度：关键规则或关键词为 critical，同一原因多次拒绝为 warning
+func rejectionSeverity(alert RejectionAlert) string {
+	if criticalRejectionRules[alert.Reason] {
+		return AlertSeverityCritical
+	}
+	lower := strings.ToLower(alert.Sample)
+	for _, keyword := range criticalRejectionKeywords {
+		if strings.Contains(lower, keyword) {
+			return AlertSeverityCritical
+		}
+	}
+	if alert.Count >= warningRejectionCount {
+		return AlertSeverityWarning
+	}
+	return AlertSeverityInfo
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case AlertSeverityCritical:
+		return 2
+	case AlertSeverityWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// recordRejectionAlerts 汇总本周期的拒绝并记录（每个原因只输出一条日志）
+func (at *AutoTrader) recordRejectionAlerts(actions []logger.DecisionAction) []RejectionAlert {
+	alerts := GroupRejections(actions)
+
+	at.alertsMu.Lock()
+	at.rejectionAlerts = alerts
+	at.alertsMu.Unlock()
+
+	for _, alert := range alerts {
+		icon := "ℹ️"
+		switch alert.Severity {
+		case AlertSeverityCritical:
+			icon = "🚨"
+		case AlertSeverityWarning:
+			icon = "⚠️"
+		}
+		at.logger.Infof("%s [%s] 拒绝汇总 [%s] %s ×%d: %s", icon, at.name, alert.Severity, alert.Reason, alert.Count, strings.Join(alert.Symbols, ", "))
+	}
+	return alerts
+}
+
+// Alerts 最近一个周期按原因分组的拒绝告警
+func (at *AutoTrader) Alerts() []RejectionAlert {
+	at.alertsMu.Lock()
+	defer at.alertsMu.Unlock()
+	return append([]RejectionAlert(nil), at.rejectionAlerts...)
+}
@@ -0,0 +1,82 @@
+package trader
+
+import (
+	"nofx/logger"
+	"reflect"
+	"testing"
+)
+
+func rejected(symbol, errMsg string) logger.DecisionAction {
+	return logger.DecisionAction{Action: "open_long", Symbol: symbol, Error: errMsg}
+}
+
+func TestGroupRejectionsGroupsRepeatedReasons(t *testing.T) {
+	actions := []logger.DecisionAction{
+		rejected("BTCUSDT", "[portfolio_risk] BTCUSDT 开仓后组合风险 55.00 USDT（5.50%）超过上限 5.00%"),
+		rejected("SOLUSDT", "SOLUSDT 下单失败: 价格 1.23 超出限制"),
+		rejected("ETHUSDT", "[portfolio_risk] ETHUSDT 开仓后组合风险 61.20 USDT（6.12%）超过上限 5.00%"),
+		{Action: "open_long", Symbol: "BNBUSDT", Success: true},
+		rejected("BTCUSDT", "[portfolio_risk] BTCUSDT 开仓后组合风险 70.00 USDT（7.00%）超过上限 5.00%"),
+		rejected("XRPUSDT", "XRPUSDT 下单失败: 价格 0.5 超出限制"),
+		{Action: "hold", Symbol: "DOGEUSDT"}, // 无错误信息
+	}
+
+	alerts := GroupRejections(actions)
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want 2: %+v", len(alerts), alerts)
+	}
+
+	risk := alerts[0]
+	if risk.Reason != "portfolio_risk" || risk.Count != 3 || risk.Severity != AlertSeverityWarning {
+		t.Errorf("portfolio alert = %+v, want portfolio_risk ×3 warning", risk)
+	}
+	if !reflect.DeepEqual(risk.Symbols, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Errorf("symbols = %v, want [BTCUSDT ETHUSDT] without duplicates", risk.Symbols)
+	}
+	if risk.Sample != actions[0].Error {
+		t.Errorf("sample = %q, want the first error", risk.Sample)
+	}
+
+	// 去掉币种和数字后相同的错误归为一组
+	order := alerts[1]
+	if order.Reason != "下单失败: 价格 # 超出限制" || order.Count != 2 || order.Severity != AlertSeverityInfo {
+		t.Errorf("order alert = %+v, want one info group of 2", order)
+	}
+}
+
+func TestGroupRejectionsCriticalFirst(t *testing.T) {
+	actions := []logger.DecisionAction{
+		rejected("BTCUSDT", "[min_net_reward] BTCUSDT 净收益过低"),
+		rejected("BTCUSDT", "[min_net_reward] BTCUSDT 净收益过低"),
+		rejected("ETHUSDT", "ETHUSDT 开仓失败: insufficient margin"),
+		rejected("SOLUSDT", "[trading_halt] 交易已暂停"),
+	}
+	alerts := GroupRejections(actions)
+	if len(alerts) != 3 {
+		t.Fatalf("got %d alerts, want 3: %+v", len(alerts), alerts)
+	}
+	for i, want := range []string{AlertSeverityCritical, AlertSeverityCritical, AlertSeverityInfo} {
+		if alerts[i].Severity != want {
+			t.Errorf("alerts[%d] = %s (%s), want %s", i, alerts[i].Reason, alerts[i].Severity, want)
+		}
+	}
+	if alerts[2].Reason != "min_net_reward" || alerts[2].Count != 2 {
+		t.Errorf("last alert = %+v, want min_net_reward ×2", alerts[2])
+	}
+}
+
+func TestRecordRejectionAlertsReplacesPreviousCycle(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	at.recordRejectionAlerts([]logger.DecisionAction{
+		rejected("BTCUSDT", "[portfolio_risk] BTCUSDT 超过上限"),
+		rejected("ETHUSDT", "[portfolio_risk] ETHUSDT 超过上限"),
+	})
+	if alerts := at.Alerts(); len(alerts) != 1 || alerts[0].Count != 2 {
+		t.Fatalf("alerts = %+v, want one grouped alert", alerts)
+	}
+
+	at.recordRejectionAlerts(nil)
+	if alerts := at.Alerts(); len(alerts) != 0 {
+		t.Errorf("alerts = %+v, want cleared by a cycle without rejections", alerts)
+	}
+}
@@ -0,0 +1,172 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"time"
+)
+
+// setPositionEntryPlan 记录开仓计划价格和信心度（平仓时用于评估执行质量）
+func (at *AutoTrader) setPositionEntryPlan(symbol, side string, plannedPrice float64, confidence int) {
+	at.stopsMu.Lock()
+	defer at.stopsMu.Unlock()
+	if stop, ok := at.positionStops[symbol+"_"+side]; ok {
+		stop.PlannedEntryPrice = plannedPrice
+		stop.EntryConfidence = confidence
+	}
+}
+
+// BuildTradeReportCard 计算一笔交易的执行质量
+// entry 为实际成交均价，plannedEntry 为下单时的计划价格，stopLoss 为计划止损价，
+// heldConfidence 为平仓前AI对该持仓的信心度（0=未知，此时不计算信心度衰减）
+func BuildTradeReportCard(side string, plannedEntry, entry, exit, stopLoss, quantity float64,
+	attribution *logger.PnLAttribution, holdDuration time.Duration, entryConfidence, heldConfidence int) *logger.TradeReportCard {
+	card := &logger.TradeReportCard{HoldDuration: holdDuration}
+
+	// 入场滑点（不利方向为正）
+	if plannedEntry > 0 && entry > 0 {
+		card.EntrySlippage = (entry - plannedEntry) / plannedEntry
+		if side == "short" {
+			card.EntrySlippage = -card.EntrySlippage
+		}
+	}
+
+	// 止损偏离：平仓价已越过止损价时，越过的比例
+	if stopLoss > 0 && exit > 0 && CheckStopLossTrigger(side, exit, stopLoss) {
+		card.StopHit = true
+		card.StopAccuracy = math.Abs(exit-stopLoss) / stopLoss
+	}
+
+	if attribution != nil {
+		card.TotalCommission = -attribution.FeePnL
+		if risk := quantity * math.Abs(entry-stopLoss); stopLoss > 0 && risk > 0 {
+			card.EffectiveRiskRewardRatio = attribution.NetPnL / risk
+		}
+	}
+
+	if entryConfidence > 0 && heldConfidence > 0 {
+		card.SignalDecayIndex = float64(entryConfidence-heldConfidence) / float64(entryConfidence)
+	}
+	return card
+}
+
+// tradeReportCard 平仓时生成执行质量评估并输出日志（持仓未记录开仓计划时返回nil）
+func (at *AutoTrader) tradeReportCard(symbol, side string, exitPrice, closeRatio float64, attribution *logger.PnLAttribution) *logger.TradeReportCard {
+	stop := at.getPositionStop(symbol, side)
+	if stop == nil {
+		return nil
+	}
+	entry, quantity := stop.AvgEntryPrice, 0.0
+	for _, pos := range at.lastPositions {
+		if pos.Symbol == symbol && pos.Side == side {
+			if entry <= 0 {
+				entry = pos.EntryPrice
+			}
+			quantity = pos.Quantity
+			break
+		}
+	}
+	if closeRatio > 0 && closeRatio < 1 {
+		quantity *= closeRatio
+	}
+
+	key := symbol + "_" + side
+	holdDuration := time.Duration(0)
+	if openedAt, ok := at.positionFirstSeenTime[key]; ok && openedAt > 0 {
+		holdDuration = time.Since(time.UnixMilli(openedAt))
+	}
+
+	card := BuildTradeReportCard(side, stop.PlannedEntryPrice, entry, exitPrice, stop.StopLoss, quantity,
+		attribution, holdDuration, stop.EntryConfidence, at.heldConfidence[key])
+
+	// 平仓价也计入持仓期间的价格范围
+	worst, best := stop.WorstPrice, stop.BestPrice
+	if exitPrice > 0 {
+		if worst <= 0 || isWorsePrice(side, exitPrice, worst) {
+			worst = exitPrice
+		}
+		if best <= 0 || isWorsePrice(side, best, exitPrice) {
+			best = exitPrice
+		}
+	}
+	card.MaxAdverseExcursionPct, card.MaxFavorableExcursionPct = ExcursionPct(side, entry, worst, best)
+
+	at.logger.Infof("  📝 执行评估: 滑点 %+.3f%% | 止损偏离 %.3f%% | 手续费 %.2f USDT | 持仓 %s | 实际盈亏比 %.2fR | 信心度衰减 %.0f%% | MAE %.2f%% | MFE %.2f%%",
+		card.EntrySlippage*100, card.StopAccuracy*100, card.TotalCommission, card.HoldDuration.Round(time.Minute),
+		card.EffectiveRiskRewardRatio, card.SignalDecayIndex*100, card.MaxAdverseExcursionPct, card.MaxFavorableExcursionPct)
+	return card
+}
+
+// markClosedBySystem 记录本系统已平掉（并已记录平仓动作）的持仓，下个周期发现持仓消失时不再按交易所触发平仓处理
+func (at *AutoTrader) markClosedBySystem(symbol, side string) {
+	at.closedBySystem[symbol+"_"+side] = true
+}
+
+// exchangeCloses 已消失且不是本系统平掉的持仓（交易所止损止盈单成交、强平等），为其生成平仓动作和执行质量评估（含MAE/MFE），
+// 需在清理止损记录和持仓首次出现时间之前调用。候选持仓包括上个周期的持仓和仍有止损记录的持仓（重启后恢复的记录在停机期间平掉时也能评估）。
+// 平仓价未知：有止损止盈价时取与当前价格最近的一个（通常就是成交的那张单），否则取上次的标记价格
+func (at *AutoTrader) exchangeCloses(currentPositionKeys map[string]bool) []logger.DecisionAction {
+	closedBySystem := at.closedBySystem
+	at.closedBySystem = make(map[string]bool)
+
+	candidates := append([]decision.PositionInfo(nil), at.lastPositions...)
+	seen := make(map[string]bool, len(candidates))
+	for _, pos := range candidates {
+		seen[pos.Symbol+"_"+pos.Side] = true
+	}
+	for _, key := range at.positionStopKeys() {
+		if i := strings.LastIndex(key, "_"); i > 0 && !seen[key] {
+			candidates = append(candidates, decision.PositionInfo{Symbol: key[:i], Side: key[i+1:]})
+		}
+	}
+
+	var actions []logger.DecisionAction
+	for _, pos := range candidates {
+		key := pos.Symbol + "_" + pos.Side
+		if currentPositionKeys[key] || closedBySystem[key] {
+			continue
+		}
+		exitPrice := pos.MarkPrice
+		if stop := at.getPositionStop(pos.Symbol, pos.Side); stop != nil {
+			exitPrice = inferExitPrice(stop, at.currentPrice(pos.Symbol, pos.MarkPrice))
+		}
+
+		at.logger.Infof("  📤 %s %s 已在交易所平仓（止损止盈单成交或强平），按 %.4f 估算平仓价", pos.Symbol, sideName(pos.Side), exitPrice)
+		actions = append(actions, logger.DecisionAction{
+			Action:     "close_" + pos.Side,
+			Symbol:     pos.Symbol,
+			Quantity:   pos.Quantity,
+			Price:      exitPrice,
+			Timestamp:  at.now(),
+			Success:    true,
+			ReportCard: at.tradeReportCard(pos.Symbol, pos.Side, exitPrice, 1, nil),
+		})
+	}
+	return actions
+}
+
+// currentPrice 当前最新价，获取失败时返回 fallback
+func (at *AutoTrader) currentPrice(symbol string, fallback float64) float64 {
+	price, err := at.trader.GetMarketPrice(symbol)
+	if err != nil || price <= 0 {
+		return fallback
+	}
+	return price
+}
+
+// inferExitPrice 止损价和止盈价中离当前价格最近的一个（都未设置时返回当前价格）
+func inferExitPrice(stop *positionStop, price float64) float64 {
+	exit := price
+	best := math.Inf(1)
+	for _, level := range []float64{stop.StopLoss, stop.TakeProfit} {
+		if level <= 0 {
+			continue
+		}
+		if d := math.Abs(price - level); d < best {
+			best, exit = d, level
+		}
+	}
+	return exit
+}
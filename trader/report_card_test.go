@@ -0,0 +1,100 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+	"time"
+)
+
+// setClosedPosition 模拟上个周期还在、本周期已在交易所平掉的持仓
+func setClosedPosition(at *AutoTrader, mock *mockTrader, price float64) {
+	at.lastPositions = []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 97, Quantity: 2}}
+	at.recordPositionStop("BTCUSDT", "long", 95, 120)
+	at.setPositionEntryPlan("BTCUSDT", "long", 100, 80)
+	at.positionFirstSeenTime["BTCUSDT_long"] = time.Now().Add(-time.Hour).UnixMilli()
+	mock.prices["BTCUSDT"] = price
+}
+
+func TestExchangeClosesBuildsReportCardForStopHit(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	setClosedPosition(at, mock, 94)
+
+	actions := at.exchangeCloses(map[string]bool{})
+	if len(actions) != 1 {
+		t.Fatalf("got %d actions, want 1", len(actions))
+	}
+	action := actions[0]
+	if action.Action != "close_long" || !action.Success || action.Price != 95 || action.Quantity != 2 {
+		t.Errorf("action = %+v, want successful close_long at the stop price", action)
+	}
+	card := action.ReportCard
+	if card == nil {
+		t.Fatal("missing report card")
+	}
+	if !card.StopHit || card.MaxAdverseExcursionPct != 5 || card.HoldDuration < time.Hour {
+		t.Errorf("card = %+v, want stop hit with 5%% MAE and ~1h hold", card)
+	}
+}
+
+func TestExchangeClosesPicksTakeProfitNearPrice(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	setClosedPosition(at, mock, 121)
+
+	actions := at.exchangeCloses(map[string]bool{})
+	if len(actions) != 1 || actions[0].Price != 120 || actions[0].ReportCard.StopHit {
+		t.Fatalf("actions = %+v, want close at the take profit", actions)
+	}
+}
+
+func TestExchangeClosesSkipsSystemClosesAndOpenPositions(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	setClosedPosition(at, mock, 94)
+
+	if actions := at.exchangeCloses(map[string]bool{"BTCUSDT_long": true}); len(actions) != 0 {
+		t.Fatalf("open position reported as closed: %+v", actions)
+	}
+
+	at.markClosedBySystem("BTCUSDT", "long")
+	if actions := at.exchangeCloses(map[string]bool{}); len(actions) != 0 {
+		t.Fatalf("system close reported twice: %+v", actions)
+	}
+	// 标记只对一个周期有效
+	if len(at.closedBySystem) != 0 {
+		t.Errorf("closedBySystem = %v, want reset", at.closedBySystem)
+	}
+}
+
+func TestExchangeCloseReportsExcursionAfterDrawdownAndRecovery(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	setClosedPosition(at, mock, 121)
+	at.setPositionEntryPrice("BTCUSDT", "long", 100)
+	for _, price := range []float64{98, 96, 99, 110, 118} {
+		at.updateExcursion("BTCUSDT", "long", price)
+	}
+
+	actions := at.exchangeCloses(map[string]bool{})
+	if len(actions) != 1 || actions[0].ReportCard == nil {
+		t.Fatalf("actions = %+v, want one close with a report card", actions)
+	}
+	card := actions[0].ReportCard
+	if card.MaxAdverseExcursionPct != 4 || card.MaxFavorableExcursionPct != 20 {
+		t.Errorf("MAE/MFE = %.2f/%.2f, want 4/20", card.MaxAdverseExcursionPct, card.MaxFavorableExcursionPct)
+	}
+}
+
+func TestExchangeClosesReportsRestoredStopsWithoutLastPositions(t *testing.T) {
+	// 重启后 lastPositions 为空，但止损记录已从快照恢复
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	at.recordPositionStop("ETHUSDT", "short", 2100, 1800)
+	at.setPositionEntryPrice("ETHUSDT", "short", 2000)
+	at.updateExcursion("ETHUSDT", "short", 2050)
+	mock.prices["ETHUSDT"] = 1790
+
+	actions := at.exchangeCloses(map[string]bool{})
+	if len(actions) != 1 || actions[0].Action != "close_short" || actions[0].Price != 1800 {
+		t.Fatalf("actions = %+v, want close_short at the take profit", actions)
+	}
+	if card := actions[0].ReportCard; card == nil || card.MaxAdverseExcursionPct != 2.5 {
+		t.Errorf("card = %+v, want MAE 2.5%%", card)
+	}
+}
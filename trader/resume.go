@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"fmt"
+)
+
+// ResumeMonitoring 启动时重新接管已有持仓的止损止盈监控
+// 进程重启后内存中的止损止盈记录会丢失：优先使用状态快照恢复的记录，
+// 快照中没有的持仓从交易所挂单中找回止损/止盈单价格重新关联；
+// 交易所上也没有止损单的持仓会告警（需要AI在下个周期处理）
+func (at *AutoTrader) ResumeMonitoring() error {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	currentPositionKeys := make(map[string]bool)
+	for _, pos := range positions {
+		info := parsePositionInfo(pos)
+		if info.Symbol == "" || info.Quantity == 0 {
+			continue
+		}
+		posKey := info.Symbol + "_" + info.Side
+		currentPositionKeys[posKey] = true
+
+		if at.getPositionStop(info.Symbol, info.Side) != nil {
+			at.logger.Infof("  🔗 %s %s 已从快照恢复止损止盈", info.Symbol, sideName(info.Side))
+			continue
+		}
+
+		stopLoss, takeProfit, err := at.findExchangeStops(info.Symbol, info.Side)
+		if err != nil {
+			at.logger.Warnf("  ⚠️ %s 查询挂单失败: %v", info.Symbol, err)
+			continue
+		}
+		if stopLoss == 0 && takeProfit == 0 {
+			at.logger.Warnf("  ⚠️ %s %s 在交易所没有止损止盈单，持仓无保护", info.Symbol, sideName(info.Side))
+			continue
+		}
+
+		at.recordPositionStop(info.Symbol, info.Side, stopLoss, takeProfit)
+		at.logger.Infof("  🔗 %s %s 已从交易所挂单重新关联: 止损 %.4f 止盈 %.4f",
+			info.Symbol, sideName(info.Side), stopLoss, takeProfit)
+	}
+
+	// 快照中已平仓的持仓不再保留
+	at.prunePositionStops(currentPositionKeys)
+
+	at.logger.Infof("♻️  [%s] 已接管 %d 个持仓的监控", at.name, len(currentPositionKeys))
+	return nil
+}
+
+// findExchangeStops 从交易所挂单中查找持仓的止损价和止盈价（未找到为0）
+func (at *AutoTrader) findExchangeStops(symbol, side string) (stopLoss, takeProfit float64, err error) {
+	orders, err := at.trader.GetOpenOrders(symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// 平多仓是卖单，平空仓是买单
+	closeSide := "SELL"
+	if side == "short" {
+		closeSide = "BUY"
+	}
+
+	for _, order := range orders {
+		if orderSide, _ := order["side"].(string); orderSide != closeSide {
+			continue
+		}
+		stopPrice, _ := order["stopPrice"].(float64)
+		switch order["type"] {
+		case "STOP_MARKET", "STOP":
+			stopLoss = stopPrice
+		case "TAKE_PROFIT_MARKET", "TAKE_PROFIT":
+			takeProfit = stopPrice
+		}
+	}
+	return stopLoss, takeProfit, nil
+}
@@ -0,0 +1,68 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResumeMonitoringRelinksExchangeStops(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("BTCUSDT", "long", 0.01, 100, 101)
+	mock.SetPosition("ETHUSDT", "short", 1, 2000, 1990)
+	mock.SetPosition("SOLUSDT", "long", 5, 150, 151)
+	mock.SetStopLoss("BTCUSDT", "LONG", 0.01, 95)
+	mock.SetTakeProfit("BTCUSDT", "LONG", 0.01, 110)
+	mock.SetStopLoss("ETHUSDT", "SHORT", 1, 2200)
+
+	// 快照中已有的记录优先于交易所挂单；已平仓的快照记录被清理
+	at.recordPositionStop("ETHUSDT", "short", 2100, 1800)
+	at.recordPositionStop("XRPUSDT", "long", 0.5, 0.7)
+
+	if err := at.ResumeMonitoring(); err != nil {
+		t.Fatalf("ResumeMonitoring: %v", err)
+	}
+
+	if stop := at.getPositionStop("BTCUSDT", "long"); stop == nil || stop.StopLoss != 95 || stop.TakeProfit != 110 {
+		t.Errorf("BTCUSDT stop = %+v, want 95/110 from exchange orders", stop)
+	}
+	if stop := at.getPositionStop("ETHUSDT", "short"); stop == nil || stop.StopLoss != 2100 || stop.TakeProfit != 1800 {
+		t.Errorf("ETHUSDT stop = %+v, want the snapshot 2100/1800 kept", stop)
+	}
+	if stop := at.getPositionStop("SOLUSDT", "long"); stop != nil {
+		t.Errorf("SOLUSDT stop = %+v, want none (no protective orders)", stop)
+	}
+	if stop := at.getPositionStop("XRPUSDT", "long"); stop != nil {
+		t.Errorf("closed XRPUSDT snapshot kept: %+v", stop)
+	}
+	if n := mock.Count("GetOpenOrders"); n != 2 {
+		t.Errorf("GetOpenOrders called %d times, want 2 (positions without a snapshot)", n)
+	}
+}
+
+func TestResumeMonitoringIgnoresOrdersForTheOtherSide(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetPosition("BTCUSDT", "long", 0.01, 100, 101)
+	// 空仓的止损单（买单）不属于多仓
+	mock.SetStopLoss("BTCUSDT", "SHORT", 0.01, 105)
+
+	if err := at.ResumeMonitoring(); err != nil {
+		t.Fatalf("ResumeMonitoring: %v", err)
+	}
+	if stop := at.getPositionStop("BTCUSDT", "long"); stop != nil {
+		t.Errorf("long stop = %+v, want none", stop)
+	}
+}
+
+func TestResumeMonitoringPositionsError(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.errs["GetPositions"] = errors.New("network down")
+	at.recordPositionStop("BTCUSDT", "long", 95, 110)
+
+	if err := at.ResumeMonitoring(); err == nil {
+		t.Fatal("ResumeMonitoring succeeded without positions")
+	}
+	// 获取持仓失败时不清理快照
+	if at.getPositionStop("BTCUSDT", "long") == nil {
+		t.Error("snapshot stop pruned after a failed position fetch")
+	}
+}
@@ -0,0 +1,83 @@
+package trader
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// reversalThrottle 反手频率限制：记录每个币种最近一次开仓方向和反手时间
+// 开仓方向与该币种统计窗口内上一次开仓方向相反即视为一次反手（中间是否已平仓不影响，
+// 平多后立即开空同样是反手）；上一次开仓早于窗口时不算反手
+type reversalThrottle struct {
+	maxPerWindow int           // 窗口内最多反手次数（0=不限制）
+	window       time.Duration // 统计窗口
+
+	mu        sync.Mutex
+	lastOpen  map[string]lastEntry   // 币种 -> 最近一次开仓
+	reversals map[string][]time.Time // 币种 -> 窗口内的反手时间
+}
+
+// lastEntry 最近一次开仓的方向和时间
+type lastEntry struct {
+	side string
+	at   time.Time
+}
+
+func newReversalThrottle(maxPerWindow int, window time.Duration) *reversalThrottle {
+	return &reversalThrottle{
+		maxPerWindow: maxPerWindow,
+		window:       window,
+		lastOpen:     make(map[string]lastEntry),
+		reversals:    make(map[string][]time.Time),
+	}
+}
+
+// Check 本次开仓若为反手且窗口内反手次数已达上限，返回原因（只允许平仓）
+func (r *reversalThrottle) Check(symbol, side string, now time.Time) error {
+	if r.maxPerWindow <= 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.isReversal(symbol, side, now) {
+		return nil
+	}
+	times := r.prune(symbol, now)
+	if len(times) >= r.maxPerWindow {
+		return fmt.Errorf("%s 最近 %.0f 分钟已反手 %d 次，达到上限 %d，%s前不再反手（只允许平仓）",
+			symbol, r.window.Minutes(), len(times), r.maxPerWindow, times[0].Add(r.window).Format("15:04"))
+	}
+	return nil
+}
+
+// Record 记录一次成功开仓（方向与上次相反时计为反手）
+func (r *reversalThrottle) Record(symbol, side string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isReversal(symbol, side, now) {
+		r.reversals[symbol] = append(r.prune(symbol, now), now)
+	}
+	r.lastOpen[symbol] = lastEntry{side: side, at: now}
+}
+
+// isReversal 本次开仓是否为反手：窗口内上一次开仓方向相反（调用方已加锁）
+func (r *reversalThrottle) isReversal(symbol, side string, now time.Time) bool {
+	last, ok := r.lastOpen[symbol]
+	return ok && last.side != side && now.Sub(last.at) <= r.window
+}
+
+// prune 清理窗口之外的反手记录（调用方已加锁）
+func (r *reversalThrottle) prune(symbol string, now time.Time) []time.Time {
+	cutoff := now.Add(-r.window)
+	times := r.reversals[symbol]
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	times = times[i:]
+	r.reversals[symbol] = times
+	return times
+}
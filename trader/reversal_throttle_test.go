@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReversalThrottleCapsReversalsInWindow(t *testing.T) {
+	r := newReversalThrottle(2, time.Hour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	r.Record("BTCUSDT", "long", now)
+	for i, side := range []string{"short", "long"} {
+		now = now.Add(10 * time.Minute)
+		if err := r.Check("BTCUSDT", side, now); err != nil {
+			t.Fatalf("reversal %d rejected: %v", i+1, err)
+		}
+		r.Record("BTCUSDT", side, now)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if err := r.Check("BTCUSDT", "short", now); err == nil {
+		t.Fatal("third reversal within the window was allowed")
+	}
+	if err := r.Check("BTCUSDT", "long", now); err != nil {
+		t.Errorf("same-side entry rejected: %v", err)
+	}
+	if err := r.Check("ETHUSDT", "short", now); err != nil {
+		t.Errorf("other symbol rejected: %v", err)
+	}
+}
+
+func TestReversalThrottleWindowExpiry(t *testing.T) {
+	r := newReversalThrottle(1, time.Hour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.Record("BTCUSDT", "long", now)
+	now = now.Add(5 * time.Minute)
+	r.Record("BTCUSDT", "short", now)
+
+	if err := r.Check("BTCUSDT", "long", now.Add(30*time.Minute)); err == nil {
+		t.Fatal("reversal allowed while the cap is reached")
+	}
+	if err := r.Check("BTCUSDT", "long", now.Add(61*time.Minute)); err != nil {
+		t.Errorf("reversal rejected after the window expired: %v", err)
+	}
+}
+
+func TestReversalThrottleIgnoresStaleEntries(t *testing.T) {
+	// 几天前开过多单（早已平仓），之后开空不算反手
+	r := newReversalThrottle(1, time.Hour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.Record("BTCUSDT", "long", now)
+
+	now = now.Add(72 * time.Hour)
+	r.Record("BTCUSDT", "short", now)
+	if n := len(r.reversals["BTCUSDT"]); n != 0 {
+		t.Fatalf("recorded %d reversals for an entry long after the last one", n)
+	}
+	if err := r.Check("BTCUSDT", "long", now.Add(time.Minute)); err != nil {
+		t.Errorf("first real reversal rejected: %v", err)
+	}
+}
+
+func TestReversalThrottleDisabled(t *testing.T) {
+	r := newReversalThrottle(0, time.Hour)
+	now := time.Now()
+	for i, side := range []string{"long", "short", "long", "short"} {
+		if err := r.Check("BTCUSDT", side, now); err != nil {
+			t.Fatalf("entry %d rejected with the throttle disabled: %v", i, err)
+		}
+		r.Record("BTCUSDT", side, now)
+	}
+}
@@ -0,0 +1,254 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"time"
+)
+
+// checkEntryRisk 开仓前的风控检查（依次执行 riskRules）
+// 返回错误表示拒绝（或延迟）本次开仓；规则也可能直接调整 d.PositionSizeUSD
+func (at *AutoTrader) checkEntryRisk(d *decision.Decision, data *market.Data, side string) error {
+	defer func(start time.Time) {
+		at.cycleRiskTime += time.Since(start)
+	}(time.Now())
+
+	span := at.startSpan(spanRiskCalculation, map[string]interface{}{"symbol": d.Symbol, "side": side})
+	defer span.End()
+
+	equity := at.equitySnapshot()
+	account := RiskAccount{
+		Equity:     equity.Last,
+		PeakEquity: equity.Peak,
+		Positions:  at.lastPositions,
+		MarketData: at.cycleMarketData,
+	}
+	for _, rule := range at.riskRules {
+		if ok, reason := rule.Check(d, account, data); !ok {
+			err := fmt.Errorf("[%s] %s", rule.Name(), reason)
+			span.RecordError(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// checkConfidence 信心度门槛检查
+func (at *AutoTrader) checkConfidence(d *decision.Decision, data *market.Data) error {
+	if cfg := at.config.Risk; !decision.MeetsConfidence(d.Confidence, cfg.MinConfidence) {
+		return fmt.Errorf("%s 信心度 %d 低于开仓门槛 %d", d.Symbol, d.Confidence, cfg.MinConfidence)
+	}
+	return nil
+}
+
+// checkVolatility 波动过滤：ATR占价格比例过低时不开仓
+func (at *AutoTrader) checkVolatility(d *decision.Decision, data *market.Data) error {
+	if cfg := at.config.Risk; cfg.MinATRRatio > 0 {
+		if ratio := data.ATRRatio(); ratio < cfg.MinATRRatio {
+			return fmt.Errorf("%s 波动过低（ATR/价格 %.3f%% < %.3f%%），不开仓", d.Symbol, ratio*100, cfg.MinATRRatio*100)
+		}
+	}
+	return nil
+}
+
+// ConfidenceVolatilityRisk 信心度不足与波动分位的乘积：(1-信心度/100) × 波动分位
+// 信心度越低、市场越剧烈，值越大
+func ConfidenceVolatilityRisk(confidence int, volatilityPercentile float64) float64 {
+	return (1 - float64(confidence)/100) * volatilityPercentile
+}
+
+// checkConfidenceVolatility 信心度与波动否决：市场越剧烈，开仓所需的信心度越高
+func (at *AutoTrader) checkConfidenceVolatility(d *decision.Decision, data *market.Data) error {
+	budget := at.config.Risk.MaxConfidenceVolatilityRisk
+	if budget <= 0 || data == nil || data.LongerTermContext == nil {
+		return nil
+	}
+	percentile := data.LongerTermContext.ATRPercentile
+	if risk := ConfidenceVolatilityRisk(d.Confidence, percentile); risk > budget {
+		return fmt.Errorf("%s 信心度 %d 不足以应对当前波动（波动分位 %.0f%%，风险值 %.3f > %.3f），不开仓",
+			d.Symbol, d.Confidence, percentile*100, risk, budget)
+	}
+	return nil
+}
+
+// checkTechnicalConfirmation 技术面确认：技术指标独立判断的方向与AI开仓方向相反时否决（技术面中性时放行）
+func (at *AutoTrader) checkTechnicalConfirmation(d *decision.Decision, data *market.Data) error {
+	if !at.config.Risk.RequireTechnicalConfirmation {
+		return nil
+	}
+	side := entrySide(d)
+	direction, bullish, bearish := decision.TechnicalDirection(data)
+	switch direction {
+	case "":
+		at.logger.Infof("  ⚖️ %s 技术面中性（看多 %d / 看空 %d），不否决AI方向", d.Symbol, bullish, bearish)
+	case side:
+		at.logger.Infof("  ✓ %s 技术面确认%s方向（看多 %d / 看空 %d）", d.Symbol, sideName(side), bullish, bearish)
+	default:
+		return fmt.Errorf("%s 技术面偏向%s（看多 %d / 看空 %d），与AI开%s方向冲突，不开仓",
+			d.Symbol, sideName(direction), bullish, bearish, sideName(side))
+	}
+	return nil
+}
+
+// checkStrategyMode 策略模式：开仓信号不属于允许的类型（趋势跟随/均值回归）时不开仓
+func (at *AutoTrader) checkStrategyMode(d *decision.Decision, data *market.Data) error {
+	if err := decision.CheckStrategyMode(at.config.Risk.StrategyMode, entrySide(d), data); err != nil {
+		return fmt.Errorf("%s %v，不开仓", d.Symbol, err)
+	}
+	return nil
+}
+
+// DrawdownAdjustedPositionMultiplier 根据当前净值相对历史最高净值的回撤计算仓位系数
+// 回撤 ≥5%: 0.9, ≥10%: 0.7, ≥15%: 0.5, ≥20%: 0.25；无回撤或数据无效时为1
+func DrawdownAdjustedPositionMultiplier(currentEquity, historicalHighEquity float64) float64 {
+	if historicalHighEquity <= 0 || currentEquity >= historicalHighEquity {
+		return 1.0
+	}
+
+	drawdown := (historicalHighEquity - currentEquity) / historicalHighEquity
+	switch {
+	case drawdown >= 0.20:
+		return 0.25
+	case drawdown >= 0.15:
+		return 0.5
+	case drawdown >= 0.10:
+		return 0.7
+	case drawdown >= 0.05:
+		return 0.9
+	default:
+		return 1.0
+	}
+}
+
+// applyDrawdownSizing 账户处于回撤期时按比例缩减开仓仓位
+// 回撤较深说明策略可能正在失效，继续满仓开仓会放大爆仓风险
+func (at *AutoTrader) applyDrawdownSizing(d *decision.Decision) {
+	if !at.config.Risk.DrawdownSizing {
+		return
+	}
+
+	equity := at.equitySnapshot()
+	multiplier := DrawdownAdjustedPositionMultiplier(equity.Last, equity.Peak)
+	if multiplier >= 1.0 {
+		return
+	}
+
+	original := d.PositionSizeUSD
+	d.PositionSizeUSD = original * multiplier
+	at.logger.Infof("  📉 %s 账户回撤 %.1f%%，仓位 ×%.2f: %.2f → %.2f USDT",
+		d.Symbol, (equity.Peak-equity.Last)/equity.Peak*100, multiplier, original, d.PositionSizeUSD)
+}
+
+// checkFundingTiming 资金费率择时检查
+// 临近资金费结算且费率对开仓方向不利（多仓遇正费率、空仓遇负费率）时，
+// wait 模式拒绝本次开仓，等结算后再由AI重新决策；reduce 模式按比例缩减仓位
+func (at *AutoTrader) checkFundingTiming(d *decision.Decision, data *market.Data, side string, now time.Time) error {
+	cfg := at.config.Risk
+	if cfg.FundingGuardMinutes <= 0 || data.NextFundingTime <= 0 {
+		return nil
+	}
+
+	untilFunding := time.UnixMilli(data.NextFundingTime).Sub(now)
+	if untilFunding < 0 || untilFunding > time.Duration(cfg.FundingGuardMinutes)*time.Minute {
+		return nil
+	}
+
+	// 对当前方向而言需要支付的费率（正数=支付）
+	adverseRate := data.FundingRate
+	if side == "short" {
+		adverseRate = -adverseRate
+	}
+	if adverseRate <= 0 || adverseRate < cfg.FundingGuardMinRate {
+		return nil
+	}
+
+	if cfg.FundingGuardMode == "reduce" {
+		original := d.PositionSizeUSD
+		d.PositionSizeUSD = original * cfg.FundingGuardReduceRatio
+		at.logger.Warnf("  ⚠️ %s 距资金费结算 %.0f 分钟，费率 %.4f%% 对%s不利，仓位 %.2f → %.2f USDT",
+			d.Symbol, untilFunding.Minutes(), data.FundingRate*100, sideName(side), original, d.PositionSizeUSD)
+		return nil
+	}
+
+	return fmt.Errorf("%s 距资金费结算仅 %.0f 分钟且费率 %.4f%% 对%s不利，延迟开仓",
+		d.Symbol, untilFunding.Minutes(), data.FundingRate*100, sideName(side))
+}
+
+// sideName 持仓方向的中文名称
+func sideName(side string) string {
+	if side == "short" {
+		return "空仓"
+	}
+	return "多仓"
+}
+
+// ClampPositionSize 仓位价值取相对净值上限与绝对上限中的较小者（maxAbsoluteUSD<=0 表示无绝对上限）
+func ClampPositionSize(sizeUSD, maxAbsoluteUSD float64) float64 {
+	if maxAbsoluteUSD > 0 && sizeUSD > maxAbsoluteUSD {
+		return maxAbsoluteUSD
+	}
+	return sizeUSD
+}
+
+// applyAbsolutePositionCap 仓位价值的绝对上限（不随净值增长而放大）
+func (at *AutoTrader) applyAbsolutePositionCap(d *decision.Decision) {
+	original := d.PositionSizeUSD
+	if d.PositionSizeUSD = ClampPositionSize(original, at.config.Risk.MaxAbsolutePositionUSD); d.PositionSizeUSD < original {
+		at.logger.Infof("  🧱 %s 仓位超过绝对上限，%.2f → %.2f USDT", d.Symbol, original, d.PositionSizeUSD)
+	}
+}
+
+// NetPositionSize 扣除同币种已有仓位后可加仓的仓位价值
+// capUSD 为单币种仓位价值上限（<=0 表示不限制）；已达上限时返回0和原因
+func NetPositionSize(requestedUSD, existingUSD, capUSD float64) (float64, string) {
+	if capUSD <= 0 {
+		return requestedUSD, ""
+	}
+	remaining := capUSD - existingUSD
+	if remaining <= 0 {
+		return 0, fmt.Sprintf("已有仓位 %.2f USDT 已达单币种上限 %.2f USDT", existingUSD, capUSD)
+	}
+	if requestedUSD > remaining {
+		return remaining, fmt.Sprintf("已有仓位 %.2f USDT，单币种上限 %.2f USDT，加仓缩减为 %.2f USDT", existingUSD, capUSD, remaining)
+	}
+	return requestedUSD, ""
+}
+
+// symbolPositionCap 单币种仓位价值上限（净值 × 配置倍数，默认与决策校验一致：山寨币1.5倍、BTC/ETH 10倍，再取绝对上限）
+func (at *AutoTrader) symbolPositionCap(symbol string, equity float64) float64 {
+	cfg := at.config.Risk
+	capUSD := equity * cfg.AltcoinPositionCapMultiple
+	if AssetClass(symbol) == AssetClassBTCETH {
+		capUSD = equity * cfg.BTCETHPositionCapMultiple
+	}
+	return ClampPositionSize(capUSD, at.config.Risk.MaxAbsolutePositionUSD)
+}
+
+// checkNetExposure 允许加仓时，按同币种同方向已有仓位扣减本次可开仓位
+func (at *AutoTrader) checkNetExposure(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+	if !at.config.Risk.AllowPositionAdds || account.Equity <= 0 {
+		return true, ""
+	}
+
+	side := entrySide(d)
+	existing := 0.0
+	for _, pos := range account.Positions {
+		if pos.Symbol == d.Symbol && pos.Side == side {
+			existing += pos.Quantity * pos.MarkPrice
+		}
+	}
+	if existing <= 0 {
+		return true, ""
+	}
+
+	size, reason := NetPositionSize(d.PositionSizeUSD, existing, at.symbolPositionCap(d.Symbol, account.Equity))
+	if size <= 0 {
+		return false, fmt.Sprintf("%s %s", d.Symbol, reason)
+	}
+	if reason != "" {
+		at.logger.Infof("  ➕ %s %s", d.Symbol, reason)
+	}
+	d.PositionSizeUSD = size
+	return true, ""
+}
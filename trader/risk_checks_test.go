@@ -0,0 +1,150 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fundingData(now time.Time, untilFunding time.Duration, rate float64) *market.Data {
+	return &market.Data{Symbol: "BTCUSDT", CurrentPrice: 100, FundingRate: rate, NextFundingTime: now.Add(untilFunding).UnixMilli()}
+}
+
+func TestFundingTimingWaitsNearAdverseSettlement(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{FundingGuardMinutes: 30, FundingGuardMinRate: 0.0001})
+	now := time.Date(2025, 1, 1, 7, 45, 0, 0, time.UTC)
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", PositionSizeUSD: 1000}
+
+	if err := at.checkFundingTiming(d, fundingData(now, 15*time.Minute, 0.0005), "long", now); err == nil {
+		t.Fatal("long entry allowed 15 minutes before a positive funding payment")
+	}
+	if err := at.checkFundingTiming(d, fundingData(now, 15*time.Minute, -0.0005), "short", now); err == nil {
+		t.Fatal("short entry allowed 15 minutes before a negative funding payment")
+	}
+}
+
+func TestFundingTimingAllowsPlentyOfTimeOrFavorableRate(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{FundingGuardMinutes: 30, FundingGuardMinRate: 0.0001})
+	now := time.Date(2025, 1, 1, 5, 0, 0, 0, time.UTC)
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", PositionSizeUSD: 1000}
+
+	cases := []struct {
+		name  string
+		until time.Duration
+		rate  float64
+		side  string
+	}{
+		{"plenty of time before settlement", 3 * time.Hour, 0.0005, "long"},
+		{"funding favours the direction", 10 * time.Minute, 0.0005, "short"},
+		{"rate below the threshold", 10 * time.Minute, 0.00005, "long"},
+	}
+	for _, c := range cases {
+		if err := at.checkFundingTiming(d, fundingData(now, c.until, c.rate), c.side, now); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+	if d.PositionSizeUSD != 1000 {
+		t.Errorf("size = %v, want 1000 untouched", d.PositionSizeUSD)
+	}
+}
+
+func TestFundingTimingReduceMode(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{FundingGuardMinutes: 30, FundingGuardMode: "reduce", FundingGuardReduceRatio: 0.4})
+	now := time.Date(2025, 1, 1, 15, 50, 0, 0, time.UTC)
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", PositionSizeUSD: 1000}
+
+	if err := at.checkFundingTiming(d, fundingData(now, 10*time.Minute, 0.001), "long", now); err != nil {
+		t.Fatalf("reduce mode rejected the entry: %v", err)
+	}
+	if d.PositionSizeUSD != 400 {
+		t.Errorf("size = %v, want 400", d.PositionSizeUSD)
+	}
+}
+
+func TestConfidenceVolatilityVeto(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MaxConfidenceVolatilityRisk: 0.2})
+	cases := []struct {
+		confidence int
+		percentile float64
+		risk       float64
+		reject     bool
+	}{
+		{90, 0.9, 0.09, false}, // 高信心 + 剧烈波动
+		{60, 0.9, 0.36, true},  // 中等信心 + 剧烈波动
+		{60, 0.3, 0.12, false}, // 中等信心 + 平静市场
+		{50, 0.4, 0.2, false},  // 恰好等于预算
+		{20, 0.5, 0.4, true},   // 低信心 + 中等波动
+		{100, 1, 0, false},
+		{0, 0, 0, false}, // 波动分位不可用
+	}
+	for _, tc := range cases {
+		if got := ConfidenceVolatilityRisk(tc.confidence, tc.percentile); math.Abs(got-tc.risk) > 1e-9 {
+			t.Errorf("ConfidenceVolatilityRisk(%d, %.2f) = %.4f, want %.4f", tc.confidence, tc.percentile, got, tc.risk)
+		}
+
+		d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", Confidence: tc.confidence}
+		data := &market.Data{Symbol: "SOLUSDT", CurrentPrice: 100, LongerTermContext: &market.LongerTermData{ATRPercentile: tc.percentile}}
+		err := at.checkConfidenceVolatility(d, data)
+		if (err != nil) != tc.reject {
+			t.Errorf("confidence %d percentile %.2f: err = %v, want reject %v", tc.confidence, tc.percentile, err, tc.reject)
+		}
+		// 拒绝原因中给出计算出的风险值
+		if err != nil && !strings.Contains(err.Error(), fmt.Sprintf("%.3f", tc.risk)) {
+			t.Errorf("rejection %q does not show the computed risk %.3f", err, tc.risk)
+		}
+	}
+}
+
+func TestConfidenceVolatilityVetoDisabled(t *testing.T) {
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", Confidence: 10}
+	data := &market.Data{Symbol: "SOLUSDT", CurrentPrice: 100, LongerTermContext: &market.LongerTermData{ATRPercentile: 1}}
+
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	if err := at.checkConfidenceVolatility(d, data); err != nil {
+		t.Errorf("veto applied without a budget: %v", err)
+	}
+
+	at.config.Risk.MaxConfidenceVolatilityRisk = 0.2
+	if err := at.checkConfidenceVolatility(d, &market.Data{Symbol: "SOLUSDT", CurrentPrice: 100}); err != nil {
+		t.Errorf("veto applied without 4h data: %v", err)
+	}
+}
+
+func TestTechnicalConfirmation(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{RequireTechnicalConfirmation: true})
+	// 3m价格在EMA20上方、3m MACD为正、4h EMA20在EMA50上方：看多 3 / 看空 0
+	bullish := &market.Data{Symbol: "SOLUSDT", CurrentPrice: 105, CurrentEMA20: 100, CurrentMACD: 1,
+		LongerTermContext: &market.LongerTermData{EMA20: 100, EMA50: 95}}
+	neutral := &market.Data{Symbol: "SOLUSDT", CurrentPrice: 105, CurrentEMA20: 100, CurrentMACD: -1}
+
+	cases := []struct {
+		name   string
+		action string
+		data   *market.Data
+		reject bool
+	}{
+		{"confirmed long", "open_long", bullish, false},
+		{"unconfirmed short", "open_short", bullish, true},
+		{"neutral long", "open_long", neutral, false},
+		{"neutral short", "open_short", neutral, false},
+	}
+	for _, tc := range cases {
+		d := &decision.Decision{Symbol: "SOLUSDT", Action: tc.action}
+		err := at.checkTechnicalConfirmation(d, tc.data)
+		if (err != nil) != tc.reject {
+			t.Errorf("%s: err = %v, want reject %v", tc.name, err, tc.reject)
+		}
+		if err != nil && !strings.Contains(err.Error(), "看多 3 / 看空 0") {
+			t.Errorf("%s: rejection %q does not show the factor counts", tc.name, err)
+		}
+	}
+
+	at.config.Risk.RequireTechnicalConfirmation = false
+	if err := at.checkTechnicalConfirmation(&decision.Decision{Symbol: "SOLUSDT", Action: "open_short"}, bullish); err != nil {
+		t.Errorf("gate applied while disabled: %v", err)
+	}
+}
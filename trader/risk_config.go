@@ -0,0 +1,299 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+)
+
+// RiskConfig 风控规则配置（从系统配置 risk_config 读取，JSON格式）
+// 各规则的零值表示不启用，未填写的参数在 applyDefaults 中补全
+type RiskConfig struct {
+	// 资金费率择时：临近结算且费率对开仓方向不利时，延迟开仓或缩减仓位
+	FundingGuardMinutes     int     `json:"funding_guard_minutes" doc:"距离下次资金费结算多少分钟内生效（0=关闭）"`
+	FundingGuardMode        string  `json:"funding_guard_mode" doc:"wait=延迟开仓，reduce=缩减仓位"`
+	FundingGuardMinRate     float64 `json:"funding_guard_min_rate" doc:"触发的最小不利费率（如0.0001=0.01%）"`
+	FundingGuardReduceRatio float64 `json:"funding_guard_reduce_ratio" doc:"reduce模式下保留的仓位比例"`
+
+	// 数据完整性：有效候选币种不足时只管理持仓，不开新仓
+	MinValidCandidates int `json:"min_valid_candidates" doc:"开仓所需的最少有效候选币种数（0=不限制）"`
+
+	// 波动过滤
+	MinATRRatio float64 `json:"min_atr_ratio" doc:"4小时ATR占价格比例低于此值的币种不开仓（如0.005=0.5%，0=不限制）"`
+
+	// 信心度与波动否决：(1-信心度) × 4小时ATR分位 超过上限时不开仓，即市场越剧烈要求信心度越高
+	MaxConfidenceVolatilityRisk float64 `json:"max_confidence_volatility_risk" doc:"(1-信心度/100)×波动分位(0-1) 的上限，超过则不开仓（如0.2，0=不限制）"`
+
+	// 决策复用：AI调用失败时复用上次成功的决策，信心度随时间衰减
+	DecisionReuseMinutes     int     `json:"decision_reuse_minutes" doc:"AI失败时可复用的最长决策年龄（分钟，0=不复用）"`
+	ConfidenceDecayPerMinute float64 `json:"confidence_decay_per_minute" doc:"复用决策时每分钟衰减的信心度点数"`
+	MinConfidence            int     `json:"min_confidence" doc:"开仓最低信心度（0-100，等于门槛即可开仓，0=不限制；AI决策、复用衰减、开仓执行共用）"`
+
+	// 多次调用合并：同一提示词调用AI多次，丢弃少数派操作并平均信心度，方向分歧时观望
+	DecisionSamples int `json:"decision_samples" doc:"每周期AI调用次数（≤1=只调用一次，最多5次）"`
+
+	// 流式响应：边接收边检测决策JSON，减少大段思维链带来的等待
+	StreamAIResponse bool `json:"stream_ai_response" doc:"是否以流式方式调用AI（仅单次调用时生效）"`
+
+	// 提示词长度预算：概况、账户持仓、候选币种按 40%/30%/30% 分配，超出的部分截断，没用完的预算留给后面的部分
+	PromptBudgetChars int `json:"prompt_budget_chars" doc:"User Prompt 最大字符数（0=不限制）"`
+
+	// 实时行情：平仓等使用的价格改为WebSocket实时推送，推送中断时回退到交易所接口
+	EnableWebSocketFeed bool `json:"enable_websocket_feed" doc:"是否使用WebSocket实时推送的价格（行情来自币安合约组合流）"`
+
+	// 开仓计划模板：网格、DCA等分级入场策略写在配置中，AI开仓时通过 plan_template 指定
+	PlanTemplates []PlanTemplate `json:"plan_templates" doc:"开仓计划模板（kind=grid/dca，同一币种的后续级别属于加仓，需开启 allow_position_adds）"`
+
+	// 技术面确认：技术指标独立判断的方向与AI开仓方向相反时不开仓
+	RequireTechnicalConfirmation bool `json:"require_technical_confirmation" doc:"是否要求技术面不与AI开仓方向冲突"`
+
+	// 策略模式：只允许趋势跟随或均值回归其中一类开仓信号
+	StrategyMode string `json:"strategy_mode" doc:"mixed=都允许，trend=只顺势开仓，mean_reversion=只在RSI(7)超卖做多/超买做空"`
+
+	// 技术面信心度调整：多周期指标与开仓方向一致时加分，冲突时减分
+	ConfidencePerFactor int `json:"confidence_per_factor" doc:"每个确认/冲突的技术因子调整的信心度点数（0=不调整；多周期RSI背离共振固定加8分，不受此项影响）"`
+	MaxConfidence       int `json:"max_confidence" doc:"技术面加分后的信心度上限"`
+
+	// 单周期开仓数
+	MaxNewEntriesPerCycle int `json:"max_new_entries_per_cycle" doc:"一次AI决策中最多执行的开仓数，按信心度取前N个，其余延迟到下个周期（0=不限制）"`
+
+	// 下单频率：所有币种共用的开仓次数上限，超出的开仓延迟到下个周期由AI重新决策
+	MaxOrdersPerMinute int `json:"max_orders_per_minute" doc:"每分钟最多开仓次数（0=不限制）"`
+	MaxOrdersPerHour   int `json:"max_orders_per_hour" doc:"每小时最多开仓次数（0=不限制）"`
+	MaxDailyTrades     int `json:"max_daily_trades" doc:"每个UTC自然日最多开仓次数，达到后当日只平仓不开仓（0=不限制）"`
+
+	// 开仓节奏：距上次开仓（任意币种）不足最小间隔时延迟开仓，避免对噪音连续反应
+	MinEntryIntervalSec int `json:"min_entry_interval_sec" doc:"相邻两次开仓的最小间隔（秒，0=不限制）"`
+
+	// 反手限制：同一币种开仓方向与上次相反计为一次反手，窗口内反手过多时只允许平仓，避免来回反手
+	MaxReversalsPerWindow int `json:"max_reversals_per_window" doc:"每个币种在窗口内最多反手次数（0=不限制）"`
+	ReversalWindowMinutes int `json:"reversal_window_minutes" doc:"反手次数的统计窗口（分钟）"`
+
+	// 禁止开仓区：价格贴近强支撑/阻力位或整数关口时不开仓（AI以突破为理由时除外）
+	NoTradeZonePct        float64 `json:"no_trade_zone_pct" doc:"距离支撑阻力位或整数关口多近算贴近（如0.002=0.2%，0=关闭）"`
+	NoTradeZoneMinTouches int     `json:"no_trade_zone_min_touches" doc:"强支撑/阻力的最少触及次数"`
+	NoTradeZoneInterval   string  `json:"no_trade_zone_interval" doc:"识别支撑阻力的K线周期"`
+
+	// 突破量能确认：AI以突破为开仓理由时，要求最近一根已收盘K线放量
+	BreakoutMinVolumeChangePct float64 `json:"breakout_min_volume_change_pct" doc:"成交量相对上一根K线的最小增幅（%，如50，0=不检查）"`
+	BreakoutVolumeMultiplier   float64 `json:"breakout_volume_multiplier" doc:"成交量至少为近20根K线均量的倍数（如1.5，0=不检查）"`
+	BreakoutVolumeInterval     string  `json:"breakout_volume_interval" doc:"量能确认使用的K线周期（3m或4h）"`
+
+	// 策略熔断：按每日收盘净值评估滚动表现，恶化时停开新仓，需手动重新启用（POST /api/traders/:id/reenable）
+	StrategyRollingDays      int     `json:"strategy_rolling_days" doc:"滚动收益的统计天数"`
+	StrategyMinRollingReturn float64 `json:"strategy_min_rolling_return" doc:"滚动收益下限（如-0.1=-10%，0=不检查）"`
+	StrategyMaxLosingDays    int     `json:"strategy_max_losing_days" doc:"连续亏损天数上限（0=不检查）"`
+
+	// 回撤硬止损：净值相对历史最高回撤达到阈值后停开新仓，不随时间自动恢复，
+	// 需手动恢复（POST /api/traders/:id/resume）或等待每周重置
+	EnableDrawdownHardStop       bool    `json:"enable_drawdown_hard_stop" doc:"是否启用最大回撤硬止损"`
+	DrawdownHardStopThresholdPct float64 `json:"drawdown_hard_stop_threshold_pct" doc:"触发硬止损的回撤比例（如0.3=30%）"`
+	WeeklyReset                  string  `json:"weekly_reset" doc:"每周重置日统计并解除硬止损的时间（UTC，如\"Mon 00:00\"，空=不重置）"`
+
+	// 经济日历：非农、FOMC、CPI等高波动事件期间暂停开新仓（YAML格式，见 LoadEconomicCalendar）
+	EconomicCalendarFile string `json:"economic_calendar_file" doc:"经济日历YAML文件路径（空=不启用）"`
+
+	// 加仓：允许对已有同方向持仓的币种加仓，加仓仓位扣除已有仓位价值后不超过单币种上限
+	AllowPositionAdds          bool    `json:"allow_position_adds" doc:"是否允许加仓（关闭时已有同方向持仓的币种拒绝开仓）"`
+	AltcoinPositionCapMultiple float64 `json:"altcoin_position_cap_multiple" doc:"加仓时山寨币单币种仓位价值上限（净值的倍数，默认1.5）"`
+	BTCETHPositionCapMultiple  float64 `json:"btceth_position_cap_multiple" doc:"加仓时BTC/ETH单币种仓位价值上限（净值的倍数，默认10）"`
+
+	// 仓位绝对上限：与相对净值的上限同时生效，取较小者
+	MaxAbsolutePositionUSD float64 `json:"max_absolute_position_usd" doc:"单笔开仓仓位价值的绝对上限（USDT，0=不限制）"`
+
+	// 回撤减仓（见 DrawdownAdjustedPositionMultiplier）
+	DrawdownSizing bool `json:"drawdown_sizing" doc:"净值低于历史最高净值时按回撤深度缩减开仓仓位"`
+
+	// 组合风险：现有持仓与新开仓位打到止损的合计亏损占净值比例上限
+	MaxPortfolioRiskPct float64 `json:"max_portfolio_risk_pct" doc:"合计风险占净值的上限（如0.06=6%，0=不限制；未记录止损的持仓按已用保证金计）"`
+
+	// 相关性调整风险：按相关系数矩阵合成各币种风险（见 CorrelationAdjustedRisk），高度相关的同向仓位更早触发拒绝
+	MaxCorrelatedRiskPct float64           `json:"max_correlated_risk_pct" doc:"相关性调整后组合风险占净值的上限（如0.05=5%，0=不限制）"`
+	CorrelationMatrix    CorrelationMatrix `json:"correlation_matrix" doc:"币种间相关系数（如 {\"BTCUSDT\": {\"ETHUSDT\": 0.85}}，对称，未填写的币种对视为不相关）"`
+
+	// 计价资产换算：非USD计价交易对（如 ETHBTC）的风险、保证金、盈亏按汇率换算为账户结算币种
+	QuoteConversionRates QuoteRates `json:"quote_conversion_rates" doc:"1单位计价资产折合的结算币种数量（如 {\"BTC\": 65000}），未配置的按1:1"`
+
+	// 信心度下降减仓：AI对持仓币种的信心度较上周期下降时按幅度减仓
+	TrimConfidenceDrop  int `json:"trim_confidence_drop" doc:"触发减仓的信心度下降点数（0=关闭）"`
+	CloseConfidenceDrop int `json:"close_confidence_drop" doc:"下降达到此点数时全部平仓，介于两者之间按 下降/此值 的比例减仓"`
+
+	// 持仓时长上限：超过上限的持仓在下个周期强制平仓（规避隔夜/周末跳空）
+	MaxPositionHoursByClass map[string]float64 `json:"max_position_hours_by_class" doc:"按币种分类的最长持仓小时数，键为 btc_eth / altcoin（如 {\"altcoin\": 24, \"btc_eth\": 72}，未配置=不限制）"`
+
+	// 追保预警：净值再下跌10%即低于维持保证金时，提示AI减仓并将新开仓位减半
+	MaintenanceMarginRate float64 `json:"maintenance_margin_rate" doc:"维持保证金率（如0.005=0.5%，0=关闭追保预警）"`
+
+	// 止盈重定位：每个周期检查当前价与止盈之间是否形成了新的强支撑/阻力，有则把止盈移到该价位内侧
+	TakeProfitRetarget           bool    `json:"take_profit_retarget" doc:"是否启用止盈重定位"`
+	TakeProfitRetargetInterval   string  `json:"take_profit_retarget_interval" doc:"识别支撑阻力的K线周期"`
+	TakeProfitRetargetMinTouches int     `json:"take_profit_retarget_min_touches" doc:"强支撑/阻力的最少触及次数"`
+	TakeProfitRetargetBufferPct  float64 `json:"take_profit_retarget_buffer_pct" doc:"新止盈在支撑阻力位内侧的距离（如0.002=0.2%）"`
+
+	// 止损模式
+	StopMode               string  `json:"stop_mode" doc:"ai=使用AI给出的止损，swing=使用最近的摆动高/低点（无合适摆动点时按ATR）"`
+	SwingStopInterval      string  `json:"swing_stop_interval" doc:"识别摆动点的K线周期"`
+	SwingStopBufferPct     float64 `json:"swing_stop_buffer_pct" doc:"止损在摆动点外的缓冲比例（0.002=0.2%）"`
+	SwingStopATRMultiplier float64 `json:"swing_stop_atr_multiplier" doc:"无摆动点时回退ATR止损的倍数"`
+	SwingStopATRPeriod     int     `json:"swing_stop_atr_period" doc:"回退ATR止损的ATR周期（按 swing_stop_interval 的K线计算）"`
+	SwingStopFallbackPct   float64 `json:"swing_stop_fallback_pct" doc:"ATR不可用时止损距入场价的比例（如0.02=2%，0=不回退，保留AI止损）"`
+
+	// 价格参考：last=最新成交价，mark=标记价格，mid=盘口中间价（缺失时回退到最新成交价）
+	EntryPriceRef string `json:"entry_price_ref" doc:"计算开仓数量和组合风险的价格"`
+	StopPriceRef  string `json:"stop_price_ref" doc:"计算结构止损、止损距离和强平距离的价格"`
+	PnLPriceRef   string `json:"pnl_price_ref" doc:"计算持仓浮动盈亏的价格"`
+
+	// 平仓流动性拆单：平仓数量超过盘口深度的一定比例时拆成多笔，按间隔依次平仓以减少滑点
+	ExitMaxDepthFraction float64 `json:"exit_max_depth_fraction" doc:"单笔平仓数量占同侧盘口深度的上限（如0.1=10%，0=不拆单；仅币安、Hyperliquid支持）"`
+	ExitDepthLevels      int     `json:"exit_depth_levels" doc:"计算盘口深度的档数（5/10/20/50/100/500/1000）"`
+	ExitSliceIntervalSec int     `json:"exit_slice_interval_sec" doc:"拆单平仓的最短间隔（秒），剩余笔数在后续交易周期执行"`
+	ExitMaxSlices        int     `json:"exit_max_slices" doc:"最多拆成的笔数（超过时均分）"`
+
+	// 止损触发价格：用标记价格触发可避免最新成交价插针扫损（交易所止损单和兜底止损监控共用）
+	// Binance/Aster 的止损单按此设置触发；Hyperliquid 的触发单固定按标记价格触发，此项只影响兜底止损监控
+	StopTriggerPriceRef string `json:"stop_trigger_price_ref" doc:"last=最新成交价触发，mark=标记价格触发"`
+
+	// 止损距离（占入场价比例，下限也可按价格步进值个数设置）：过近时自动放宽并缩减仓位，过远时拒绝
+	MinStopDistancePct   float64 `json:"min_stop_distance_pct" doc:"最小止损距离（如0.005=0.5%，0=不检查）"`
+	MaxStopDistancePct   float64 `json:"max_stop_distance_pct" doc:"最大止损距离，放宽后也不能超过（如0.05=5%，0=不限制）"`
+	MinStopDistanceTicks int     `json:"min_stop_distance_ticks" doc:"最小止损距离（价格步进值的个数，与百分比下限取较大者，0=不检查）"`
+
+	// 净收益下限：止盈目标扣除双边手续费（fee_rate）后的收益过小时不开仓
+	MinNetRewardBps float64 `json:"min_net_reward_bps" doc:"扣除手续费后目标净收益占仓位价值的最低基点数（如20=0.2%，0=不检查）"`
+	MinNetRewardUSD float64 `json:"min_net_reward_usd" doc:"扣除手续费后目标净收益的最低金额（USDT，0=不检查）"`
+
+	// 盈亏平衡胜率：按盈亏比和手续费计算保本所需胜率，信心度不足以覆盖时不开仓
+	EnableBreakEvenCheck   bool    `json:"enable_break_even_check" doc:"是否要求信心度不低于盈亏平衡胜率加安全边际"`
+	BreakEvenWinRateMargin float64 `json:"break_even_win_rate_margin" doc:"在盈亏平衡胜率之上要求的安全边际（如0.05=5个百分点）"`
+
+	// 按强平距离选杠杆：用户设定强平价距入场价的最小距离，反推杠杆（见 LeverageForLiquidationDistance）
+	TargetLiquidationDistancePct float64 `json:"target_liquidation_distance_pct" doc:"强平价距入场价的目标距离（如0.3=30%，0=使用AI给出的杠杆；会计入 maintenance_margin_rate）"`
+
+	// 按波动选杠杆：近期4小时ATR分位越低杠杆越高，上限为该币种配置的杠杆（见 RecommendLeverage）
+	VolatilityLeverage bool `json:"volatility_leverage" doc:"是否按波动分位限制杠杆（只下调AI给出的杠杆，关闭时使用AI给出的杠杆）"`
+
+	// 强平保护：强平价距止损不足缓冲时下调杠杆
+	LiquidationBufferPct float64 `json:"liquidation_buffer_pct" doc:"强平价在止损价之外的最小距离（如0.02=2%，0=不检查）"`
+
+	// 兜底止损：独立轮询持仓，价格越过止损位而交易所没有止损单时直接市价平仓
+	SoftStopMonitor    bool `json:"soft_stop_monitor" doc:"是否启用兜底止损监控"`
+	MonitorIntervalSec int  `json:"monitor_interval_sec" doc:"兜底止损监控的轮询间隔（秒）"`
+
+	// 大额开仓二次验证：仓位超过阈值时请另一个（更便宜的）模型为交易计划打分，低于30分拒绝
+	SecondaryVerificationThresholdUSD float64 `json:"secondary_verification_threshold_usd" doc:"触发二次验证的仓位价值（USDT，0=关闭）"`
+	SecondaryVerificationModel        string  `json:"secondary_verification_model" doc:"二次验证使用的模型名（空=与主模型相同）"`
+
+	// 盈亏归因
+	FeeRate float64 `json:"fee_rate" doc:"估算手续费使用的费率（开平仓各计一次，默认按taker 0.05%）"`
+
+	// 风险看板
+	EnableHTTPDashboard bool `json:"enable_http_dashboard" doc:"是否开放 /api/risk 接口返回结构化风险看板"`
+
+	// AI调用费用
+	AIDailyBudgetUSD float64 `json:"ai_daily_budget_usd" doc:"AI调用每日预算（美元，0=不限制）"`
+}
+
+// DefaultRiskConfig 默认风控配置（未配置 risk_config 时的实际取值）
+func DefaultRiskConfig() RiskConfig {
+	var c RiskConfig
+	c.applyDefaults()
+	return c
+}
+
+// applyDefaults 补全未设置的参数
+func (c *RiskConfig) applyDefaults() {
+	if c.FundingGuardMode != "reduce" {
+		c.FundingGuardMode = "wait"
+	}
+	if c.FundingGuardReduceRatio <= 0 || c.FundingGuardReduceRatio > 1 {
+		c.FundingGuardReduceRatio = 0.5
+	}
+	if c.DecisionSamples > decision.MaxDecisionSamples {
+		c.DecisionSamples = decision.MaxDecisionSamples
+	}
+	if c.StopMode != StopModeSwing {
+		c.StopMode = StopModeAI
+	}
+	if c.AltcoinPositionCapMultiple <= 0 {
+		c.AltcoinPositionCapMultiple = 1.5
+	}
+	if c.BTCETHPositionCapMultiple <= 0 {
+		c.BTCETHPositionCapMultiple = 10
+	}
+	if c.SwingStopInterval == "" {
+		c.SwingStopInterval = "4h"
+	}
+	if c.SwingStopBufferPct <= 0 {
+		c.SwingStopBufferPct = 0.002
+	}
+	if c.SwingStopATRMultiplier <= 0 {
+		c.SwingStopATRMultiplier = 1.5
+	}
+	if c.SwingStopATRPeriod <= 0 {
+		c.SwingStopATRPeriod = 14
+	}
+	if c.NoTradeZoneMinTouches <= 0 {
+		c.NoTradeZoneMinTouches = 2
+	}
+	if c.TakeProfitRetargetInterval == "" {
+		c.TakeProfitRetargetInterval = "4h"
+	}
+	if c.TakeProfitRetargetMinTouches <= 0 {
+		c.TakeProfitRetargetMinTouches = 2
+	}
+	if c.TakeProfitRetargetBufferPct <= 0 {
+		c.TakeProfitRetargetBufferPct = 0.002
+	}
+	if c.DrawdownHardStopThresholdPct <= 0 {
+		c.DrawdownHardStopThresholdPct = 0.3
+	}
+	if c.StrategyRollingDays <= 0 {
+		c.StrategyRollingDays = 7
+	}
+	if c.NoTradeZoneInterval == "" {
+		c.NoTradeZoneInterval = "4h"
+	}
+	if c.BreakoutVolumeInterval == "" {
+		c.BreakoutVolumeInterval = "3m"
+	}
+	c.StrategyMode = decision.NormalizeStrategyMode(c.StrategyMode)
+	c.EntryPriceRef = normalizePriceRef(c.EntryPriceRef)
+	c.StopPriceRef = normalizePriceRef(c.StopPriceRef)
+	c.PnLPriceRef = normalizePriceRef(c.PnLPriceRef)
+	if c.StopTriggerPriceRef != market.PriceRefMark {
+		c.StopTriggerPriceRef = market.PriceRefLast
+	}
+	if c.FeeRate <= 0 {
+		c.FeeRate = 0.0005
+	}
+	if c.MaxConfidence <= 0 || c.MaxConfidence > 100 {
+		c.MaxConfidence = 95
+	}
+	if c.ReversalWindowMinutes <= 0 {
+		c.ReversalWindowMinutes = 240
+	}
+	if c.ExitDepthLevels <= 0 {
+		c.ExitDepthLevels = 20
+	}
+	if c.ExitSliceIntervalSec <= 0 {
+		c.ExitSliceIntervalSec = 5
+	}
+	if c.ExitMaxSlices <= 0 {
+		c.ExitMaxSlices = 10
+	}
+	if c.MonitorIntervalSec <= 0 {
+		c.MonitorIntervalSec = 10
+	}
+	if c.ConfidenceDecayPerMinute <= 0 {
+		c.ConfidenceDecayPerMinute = 1
+	}
+}
+
+// normalizePriceRef 未知的价格参考按最新成交价处理
+func normalizePriceRef(ref string) string {
+	switch ref {
+	case market.PriceRefMark, market.PriceRefMid:
+		return ref
+	default:
+		return market.PriceRefLast
+	}
+}
@@ -0,0 +1,38 @@
+package trader
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRiskConfigFieldsDocumented 每个导出的风控配置字段都要有 json 和 doc 标签（docs/CONFIG.md 由 doc 标签生成）
+// 切片元素（如 PlanTemplate）不展开，只检查顶层字段和嵌套结构体
+func TestRiskConfigFieldsDocumented(t *testing.T) {
+	checkDocTags(t, reflect.TypeOf(RiskConfig{}), "")
+}
+
+func checkDocTags(t *testing.T, typ reflect.Type, prefix string) {
+	t.Helper()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if key == "-" {
+			continue
+		}
+		name := prefix + field.Name
+		if key == "" {
+			t.Errorf("%s has no json tag", name)
+		}
+		if field.Type.Kind() == reflect.Struct {
+			checkDocTags(t, field.Type, name+".")
+			continue
+		}
+		if strings.TrimSpace(field.Tag.Get("doc")) == "" {
+			t.Errorf("%s has no doc tag; add one and run make docs", name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RiskDashboard 风险看板（分区的强类型结构，供前端可视化）
+type RiskDashboard struct {
+	TraderID        string               `json:"trader_id"`
+	GeneratedAt     time.Time            `json:"generated_at"`
+	AccountHealth   AccountHealthSection `json:"account_health"`
+	PositionSummary []PositionRiskEntry  `json:"position_summary"`
+	CircuitBreakers CircuitBreakerStatus `json:"circuit_breakers"`
+	Calendar        NextEventEntry       `json:"calendar"`
+}
+
+// AccountHealthSection 账户健康度
+type AccountHealthSection struct {
+	TotalEquity   float64 `json:"total_equity"`
+	MarginUsedPct float64 `json:"margin_used_pct"`
+	DailyPnL      float64 `json:"daily_pnl"`
+	PeakEquity    float64 `json:"peak_equity"`
+	DrawdownPct   float64 `json:"drawdown_pct"` // 距历史最高净值的回撤百分比
+	WorstEquity   float64 `json:"worst_equity"` // 所有持仓打到止损时的净值（见 StressTest）
+	BestEquity    float64 `json:"best_equity"`  // 所有持仓打到止盈时的净值
+}
+
+// PositionRiskEntry 单个持仓的风险热度（热力图的一格）
+type PositionRiskEntry struct {
+	PositionHeat
+	Confidence int `json:"confidence,omitempty"` // 该持仓最近一次的AI信心度
+}
+
+// CircuitBreakerStatus 各类熔断/暂停状态
+type CircuitBreakerStatus struct {
+	TradingHalted        bool      `json:"trading_halted"`
+	HaltedAt             time.Time `json:"halted_at"`
+	HaltedUntil          time.Time `json:"halted_until"`
+	DailyTradeCount      int       `json:"daily_trade_count"`
+	DailyTradesRemaining int       `json:"daily_trades_remaining"` // -1=不限制
+	AIBreakerState       string    `json:"ai_breaker_state,omitempty"`
+}
+
+// NextEventEntry 经济日历的当前/下一个事件
+type NextEventEntry struct {
+	Enabled      bool      `json:"enabled"`
+	InProgress   bool      `json:"in_progress"`
+	CurrentEvent string    `json:"current_event,omitempty"`
+	NextStart    time.Time `json:"next_start"`
+	NextEvent    string    `json:"next_event,omitempty"`
+}
+
+// GenerateRiskDashboard 生成风险看板
+func (at *AutoTrader) GenerateRiskDashboard() (*RiskDashboard, error) {
+	account, err := at.GetAccountInfo()
+	if err != nil {
+		return nil, err
+	}
+	heats, positions, err := at.positionHeats()
+	if err != nil {
+		return nil, err
+	}
+
+	now := at.now()
+	dashboard := &RiskDashboard{
+		TraderID:        at.id,
+		GeneratedAt:     now,
+		PositionSummary: make([]PositionRiskEntry, 0, len(heats)),
+	}
+
+	health := &dashboard.AccountHealth
+	health.TotalEquity, _ = account["total_equity"].(float64)
+	health.MarginUsedPct, _ = account["margin_used_pct"].(float64)
+	equity := at.equitySnapshot()
+	health.DailyPnL = equity.DailyPnL
+	health.PeakEquity = equity.Peak
+	if equity.Peak > 0 && health.TotalEquity < equity.Peak {
+		health.DrawdownPct = (equity.Peak - health.TotalEquity) / equity.Peak * 100
+	}
+	health.WorstEquity, health.BestEquity = at.StressTest(positions, health.TotalEquity)
+
+	confidence := at.heldConfidenceSnapshot()
+	for _, heat := range heats {
+		dashboard.PositionSummary = append(dashboard.PositionSummary, PositionRiskEntry{
+			PositionHeat: heat,
+			Confidence:   confidence[heat.Symbol+"_"+heat.Side],
+		})
+	}
+
+	breakers := &dashboard.CircuitBreakers
+	halt := at.haltSnapshot()
+	breakers.HaltedAt = halt.HaltedAt
+	breakers.HaltedUntil = halt.StopUntil
+	if halt.EntryHaltUntil.After(breakers.HaltedUntil) {
+		breakers.HaltedUntil = halt.EntryHaltUntil
+	}
+	breakers.TradingHalted = now.Before(breakers.HaltedUntil)
+	breakers.DailyTradeCount, breakers.DailyTradesRemaining = at.orderLimiter.DailyStatus(now)
+	if at.mcpClient.Breaker != nil {
+		breakers.AIBreakerState, _ = at.mcpClient.Breaker.GetStatus()["state"].(string)
+	}
+
+	if at.calendar != nil {
+		dashboard.Calendar.Enabled = true
+		dashboard.Calendar.InProgress, dashboard.Calendar.CurrentEvent = at.calendar.IsHighVolatilityPeriod(now)
+		dashboard.Calendar.NextStart, dashboard.Calendar.NextEvent = at.calendar.NextEvent(now)
+	}
+
+	return dashboard, nil
+}
+
+// JSON 格式化输出看板（缩进格式，便于展示）
+func (d *RiskDashboard) JSON() (string, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化风险看板失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// RiskDashboardEnabled 是否通过HTTP暴露风险看板（risk_config.enable_http_dashboard）
+func (at *AutoTrader) RiskDashboardEnabled() bool {
+	return at.config.Risk.EnableHTTPDashboard
+}
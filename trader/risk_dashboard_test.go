@@ -0,0 +1,84 @@
+package trader
+
+import (
+	"encoding/json"
+	"nofx/market"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRiskDashboardJSONRoundTrip(t *testing.T) {
+	now := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	original := &RiskDashboard{
+		TraderID:    "trader_1",
+		GeneratedAt: now,
+		AccountHealth: AccountHealthSection{
+			TotalEquity: 1200, MarginUsedPct: 35.5, DailyPnL: -20, PeakEquity: 1300,
+			DrawdownPct: 7.69, WorstEquity: 1100, BestEquity: 1350,
+		},
+		PositionSummary: []PositionRiskEntry{{
+			PositionHeat: PositionHeat{
+				Symbol: "BTCUSDT", Side: "long", HeatLevel: "warm", TimeToLiquidationPct: 18.2,
+				UnrealizedPnLPct: -1.5, StopDistancePct: 2.1, MarginUtilisation: 12, RiskScore: 42.5,
+			},
+			Confidence: 78,
+		}},
+		CircuitBreakers: CircuitBreakerStatus{
+			TradingHalted: true, HaltedAt: now.Add(-time.Hour), HaltedUntil: now.Add(time.Hour),
+			DailyTradeCount: 4, DailyTradesRemaining: -1, AIBreakerState: "half_open",
+		},
+		Calendar: NextEventEntry{
+			Enabled: true, InProgress: true, CurrentEvent: "CPI", NextStart: now.Add(48 * time.Hour), NextEvent: "FOMC",
+		},
+	}
+
+	data, err := original.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	// 持仓热度字段平铺在持仓条目中
+	for _, key := range []string{`"account_health"`, `"position_summary"`, `"heat_level": "warm"`, `"confidence": 78`, `"ai_breaker_state": "half_open"`} {
+		if !strings.Contains(data, key) {
+			t.Errorf("dashboard JSON missing %s", key)
+		}
+	}
+
+	var decoded RiskDashboard
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, original) {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", decoded, *original)
+	}
+}
+
+func TestGenerateRiskDashboardRoundTrip(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	clock := &fixedClock{t: time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)}
+	at.now = clock.Now
+	mock.SetPosition("BTCUSDT", "long", 0.01, 60000, 61000)
+	withMarketData(at, &market.Data{CurrentPrice: 61000})
+	at.recordPositionStop("BTCUSDT", "long", 58000, 65000)
+
+	dashboard, err := at.GenerateRiskDashboard()
+	if err != nil {
+		t.Fatalf("GenerateRiskDashboard: %v", err)
+	}
+	if len(dashboard.PositionSummary) != 1 || dashboard.PositionSummary[0].Symbol != "BTCUSDT" {
+		t.Fatalf("position summary = %+v, want the BTC position", dashboard.PositionSummary)
+	}
+
+	data, err := dashboard.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var decoded RiskDashboard
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, dashboard) {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", decoded, *dashboard)
+	}
+}
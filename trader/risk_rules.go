@@ -0,0 +1,130 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// RiskAccount 风控规则可见的账户状态
+type RiskAccount struct {
+	Equity     float64                 // 当前净值
+	PeakEquity float64                 // 历史最高净值
+	Positions  []decision.PositionInfo // 当前持仓
+	MarketData map[string]*market.Data // 本周期已获取的行情数据（按币种，规则应复用而非重新请求）
+}
+
+// RiskRule 开仓风控规则
+// 开仓前按注册顺序依次执行，任一规则拒绝即不开仓（后续规则不再执行）。
+// 规则可以直接调整决策（如缩减 d.PositionSizeUSD），调整对后续规则可见
+type RiskRule interface {
+	Name() string
+	// Check 返回 false 表示拒绝本次开仓，reason 为拒绝原因
+	Check(d *decision.Decision, account RiskAccount, data *market.Data) (ok bool, reason string)
+}
+
+// riskRuleFunc 函数形式的风控规则
+type riskRuleFunc struct {
+	name string
+	fn   func(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string)
+}
+
+func (r riskRuleFunc) Name() string { return r.name }
+
+func (r riskRuleFunc) Check(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+	return r.fn(d, account, data)
+}
+
+// NewRiskRule 用函数创建风控规则（如"CPI公布前后不开仓"）
+func NewRiskRule(name string, fn func(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string)) RiskRule {
+	return riskRuleFunc{name: name, fn: fn}
+}
+
+// errorRule 将返回error的检查包装为风控规则
+func errorRule(name string, check func(d *decision.Decision, data *market.Data) error) RiskRule {
+	return NewRiskRule(name, func(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+		if err := check(d, data); err != nil {
+			return false, err.Error()
+		}
+		return true, ""
+	})
+}
+
+// sizingRule 只调整仓位、从不拒绝的规则
+func sizingRule(name string, adjust func(d *decision.Decision)) RiskRule {
+	return NewRiskRule(name, func(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+		adjust(d)
+		return true, ""
+	})
+}
+
+// defaultRiskRules 内置风控规则（按执行顺序）
+func (at *AutoTrader) defaultRiskRules() []RiskRule {
+	return []RiskRule{
+		errorRule("trading_halt", func(d *decision.Decision, data *market.Data) error {
+			if until := at.haltSnapshot().EntryHaltUntil; at.now().Before(until) {
+				return fmt.Errorf("交易暂停中（至 %s），不开新仓", until.Format("15:04:05"))
+			}
+			return nil
+		}),
+		errorRule("strategy_circuit_breaker", func(d *decision.Decision, data *market.Data) error {
+			return at.checkStrategyBreaker()
+		}),
+		errorRule("drawdown_hard_stop", func(d *decision.Decision, data *market.Data) error {
+			return at.checkDrawdownHardStop()
+		}),
+		errorRule("economic_calendar", func(d *decision.Decision, data *market.Data) error {
+			return at.checkEconomicCalendar()
+		}),
+		errorRule("min_confidence", at.checkConfidence),
+		errorRule("min_volatility", at.checkVolatility),
+		errorRule("confidence_volatility", at.checkConfidenceVolatility),
+		errorRule("technical_confirmation", at.checkTechnicalConfirmation),
+		errorRule("strategy_mode", at.checkStrategyMode),
+		errorRule("order_rate_limit", func(d *decision.Decision, data *market.Data) error {
+			return at.orderLimiter.Check(at.now())
+		}),
+		errorRule("reversal_throttle", func(d *decision.Decision, data *market.Data) error {
+			return at.reversals.Check(d.Symbol, entrySide(d), at.now())
+		}),
+		errorRule("no_trade_zone", at.checkNoTradeZone),
+		errorRule("breakout_volume", at.checkBreakoutVolume),
+		errorRule("stop_distance", at.checkStopDistance),
+		errorRule("liquidation_distance_leverage", at.applyLiquidationDistanceLeverage),
+		errorRule("volatility_leverage", at.applyVolatilityLeverage),
+		errorRule("safe_leverage", at.applySafeLeverage),
+		// reduce 模式会缩减仓位，须在按仓位计算的规则（净收益、盈亏平衡胜率、组合风险）之前
+		errorRule("funding_timing", func(d *decision.Decision, data *market.Data) error {
+			return at.checkFundingTiming(d, data, entrySide(d), at.now())
+		}),
+		sizingRule("drawdown_sizing", at.applyDrawdownSizing),
+		sizingRule("margin_call_sizing", at.applyMarginCallSizing),
+		sizingRule("absolute_position_cap", at.applyAbsolutePositionCap),
+		NewRiskRule("net_exposure", at.checkNetExposure),
+		errorRule("min_net_reward", at.checkNetReward), // 按最终仓位计算净收益
+		errorRule("break_even_win_rate", at.checkBreakEvenWinRate),
+		NewRiskRule("volatility_concentration", at.warnVolatilityConcentration),
+		NewRiskRule("portfolio_risk", at.checkPortfolioRisk),
+		NewRiskRule("correlated_risk", at.checkCorrelatedRisk),
+		errorRule("secondary_verification", at.checkSecondaryVerification), // 调用AI，放在最后按最终仓位判断
+	}
+}
+
+// AddRiskRule 在内置规则之后追加自定义风控规则
+// AI二次验证始终最后执行（调用AI且按最终仓位判断），自定义规则插在它之前
+func (at *AutoTrader) AddRiskRule(rule RiskRule) {
+	n := len(at.riskRules)
+	if n > 0 && at.riskRules[n-1].Name() == "secondary_verification" {
+		at.riskRules = append(at.riskRules[:n-1:n-1], rule, at.riskRules[n-1])
+		return
+	}
+	at.riskRules = append(at.riskRules, rule)
+}
+
+// entrySide 开仓决策的方向
+func entrySide(d *decision.Decision) string {
+	if d.Action == "open_short" {
+		return "short"
+	}
+	return "long"
+}
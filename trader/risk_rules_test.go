@@ -0,0 +1,130 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingRule 记录执行顺序的风控规则
+func recordingRule(name string, calls *[]string, ok bool) RiskRule {
+	return NewRiskRule(name, func(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+		*calls = append(*calls, name)
+		if !ok {
+			return false, name + " 拒绝"
+		}
+		return true, ""
+	})
+}
+
+func TestCustomRiskRuleRunsInOrderAndRejects(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	var calls []string
+	at.riskRules = []RiskRule{recordingRule("builtin_a", &calls, true), recordingRule("builtin_b", &calls, true)}
+	at.AddRiskRule(recordingRule("custom_reject", &calls, false))
+	at.AddRiskRule(recordingRule("custom_after", &calls, true))
+
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}
+	err := at.checkEntryRisk(d, &market.Data{Symbol: "BTCUSDT"}, "long")
+	if err == nil || !strings.Contains(err.Error(), "[custom_reject]") {
+		t.Fatalf("err = %v, want rejection by custom_reject", err)
+	}
+	if got := strings.Join(calls, ","); got != "builtin_a,builtin_b,custom_reject" {
+		t.Errorf("calls = %s, want built-in rules first and no rule after the rejection", got)
+	}
+}
+
+func TestCustomRiskRuleSkippedWhenBuiltinRejects(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	var calls []string
+	at.riskRules = []RiskRule{recordingRule("builtin_reject", &calls, false)}
+	at.AddRiskRule(recordingRule("custom", &calls, true))
+
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}
+	if err := at.checkEntryRisk(d, &market.Data{Symbol: "BTCUSDT"}, "long"); err == nil {
+		t.Fatal("expected rejection")
+	}
+	if len(calls) != 1 || calls[0] != "builtin_reject" {
+		t.Errorf("calls = %v, want only builtin_reject", calls)
+	}
+}
+
+func TestAddRiskRuleRunsBeforeSecondaryVerification(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	n := len(at.riskRules)
+	if at.riskRules[0].Name() != "trading_halt" {
+		t.Fatalf("first rule = %s, want trading_halt", at.riskRules[0].Name())
+	}
+	at.AddRiskRule(recordingRule("custom_a", new([]string), true))
+	at.AddRiskRule(recordingRule("custom_b", new([]string), true))
+
+	names := riskRuleNames(at.riskRules)
+	if len(names) != n+2 {
+		t.Fatalf("rules = %v, want %d", names, n+2)
+	}
+	if got := strings.Join(names[n-1:], ","); got != "custom_a,custom_b,secondary_verification" {
+		t.Errorf("tail = %s, want custom rules in order before secondary_verification", got)
+	}
+}
+
+func TestFundingTimingRunsBeforeSizeDependentRules(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	index := make(map[string]int)
+	for i, name := range riskRuleNames(at.riskRules) {
+		index[name] = i
+	}
+	for _, name := range []string{"min_net_reward", "break_even_win_rate", "portfolio_risk", "drawdown_sizing", "absolute_position_cap"} {
+		if index["funding_timing"] > index[name] {
+			t.Errorf("funding_timing runs after %s", name)
+		}
+	}
+}
+
+// riskRuleNames 风控规则名称（按执行顺序）
+func riskRuleNames(rules []RiskRule) []string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.Name()
+	}
+	return names
+}
+
+func TestEntryBlackoutRule(t *testing.T) {
+	rule, err := NewEntryBlackoutRule([]string{"12:25-12:45", "23:50-00:10"})
+	if err != nil {
+		t.Fatalf("NewEntryBlackoutRule: %v", err)
+	}
+	clock := &fixedClock{}
+	rule.(*entryBlackoutRule).now = clock.Now
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long"}
+
+	cases := []struct {
+		at   string
+		want bool
+	}{
+		{"12:24", true},
+		{"12:25", false},
+		{"12:44", false},
+		{"12:45", true},
+		{"23:55", false},
+		{"00:05", false},
+		{"00:10", true},
+	}
+	for _, c := range cases {
+		tm, _ := time.Parse("15:04", c.at)
+		clock.t = time.Date(2025, 1, 15, tm.Hour(), tm.Minute(), 0, 0, time.UTC)
+		if ok, reason := rule.Check(d, RiskAccount{}, nil); ok != c.want {
+			t.Errorf("%s: ok = %v (%s), want %v", c.at, ok, reason, c.want)
+		}
+	}
+}
+
+func TestEntryBlackoutRuleRejectsBadWindows(t *testing.T) {
+	for _, w := range []string{"12:25", "25:00-26:00", "12:00-12:00", "noon-13:00"} {
+		if _, err := NewEntryBlackoutRule([]string{w}); err == nil {
+			t.Errorf("%q accepted, want error", w)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/mcp"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// 二次验证评分阈值
+const (
+	secondaryVerifyWarnScore   = 60 // 低于此分数记录警告
+	secondaryVerifyRejectScore = 30 // 低于此分数拒绝开仓
+)
+
+const secondaryVerifySystemPrompt = "你是加密货币合约交易的风控审核员。根据给出的交易计划和行情摘要，给出你对该交易的认同度评分（0-100，0=完全不认同，100=完全认同）。只输出一个整数，不要输出其他内容。"
+
+var scorePattern = regexp.MustCompile(`\d+`)
+
+// newSecondaryVerifier 创建二次验证用的AI客户端
+// 与主客户端共用API配置和费用统计，可换用更便宜的模型；使用独立熔断器，避免验证失败影响主决策
+func newSecondaryVerifier(primary *mcp.Client, model string) *mcp.Client {
+	verifier := *primary
+	if model != "" {
+		verifier.Model = model
+	}
+	verifier.Timeout = 30 * time.Second
+	verifier.Breaker = mcp.NewCircuitBreaker(3, 10*time.Minute)
+	return &verifier
+}
+
+// checkSecondaryVerification 大额开仓的AI二次验证
+// 单次AI决策可能出错，仓位超过阈值时再请另一个模型给交易计划打分：
+// 低于60分记录警告，低于30分拒绝开仓；验证调用失败时不阻止开仓
+func (at *AutoTrader) checkSecondaryVerification(d *decision.Decision, data *market.Data) error {
+	threshold := at.config.Risk.SecondaryVerificationThresholdUSD
+	if threshold <= 0 || at.verifierClient == nil || d.PositionSizeUSD <= threshold {
+		return nil
+	}
+
+	userPrompt := fmt.Sprintf("交易计划: %s %s，仓位 %.2f USDT，杠杆 %dx，当前价 %.4f，止损 %.4f，止盈 %.4f，信心度 %d\n开仓理由: %s\n行情: 1小时涨跌 %+.2f%%，4小时涨跌 %+.2f%%，RSI7 %.1f，资金费率 %.4f%%\n请给出认同度评分（0-100）:",
+		d.Symbol, sideName(entrySide(d)), d.PositionSizeUSD, d.Leverage, data.CurrentPrice, d.StopLoss, d.TakeProfit, d.Confidence,
+		d.Reasoning, data.PriceChange1h, data.PriceChange4h, data.CurrentRSI7, data.FundingRate*100)
+
+	response, err := at.verifierClient.CallWithMessages(secondaryVerifySystemPrompt, userPrompt)
+	if err != nil {
+		at.logger.Warnf("  ⚠️ %s 二次验证调用失败，跳过验证: %v", d.Symbol, err)
+		return nil
+	}
+	score, err := parseVerificationScore(response)
+	if err != nil {
+		at.logger.Warnf("  ⚠️ %s 二次验证结果无法解析，跳过验证: %v", d.Symbol, err)
+		return nil
+	}
+
+	switch {
+	case score < secondaryVerifyRejectScore:
+		return fmt.Errorf("%s 仓位 %.2f USDT 二次验证认同度 %d < %d，拒绝开仓", d.Symbol, d.PositionSizeUSD, score, secondaryVerifyRejectScore)
+	case score < secondaryVerifyWarnScore:
+		at.logger.Warnf("  ⚠️ %s 二次验证认同度偏低: %d < %d", d.Symbol, score, secondaryVerifyWarnScore)
+	default:
+		at.logger.Infof("  ✓ %s 二次验证认同度: %d", d.Symbol, score)
+	}
+	return nil
+}
+
+// parseVerificationScore 从AI回复中解析0-100的评分
+func parseVerificationScore(response string) (int, error) {
+	match := scorePattern.FindString(response)
+	if match == "" {
+		return 0, fmt.Errorf("回复中没有评分: %q", response)
+	}
+	score, err := strconv.Atoi(match)
+	if err != nil || score < 0 || score > 100 {
+		return 0, fmt.Errorf("评分无效: %q", match)
+	}
+	return score, nil
+}
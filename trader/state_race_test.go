@@ -0,0 +1,68 @@
+package trader
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEquityStateConcurrentAccess HTTP接口（看板、风险状态、恢复交易）与交易周期并发读写净值和暂停状态（配合 go test -race）
+func TestEquityStateConcurrentAccess(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{EnableDrawdownHardStop: true, DrawdownHardStopThresholdPct: 0.5})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			at.updateEquityTracking(1000 + float64(i%7))
+			if i%50 == 0 {
+				at.DailyMaintenance(at.now().Add(time.Duration(i) * time.Hour))
+			}
+		}
+	}()
+
+	handlers := []func(){
+		func() {
+			if _, err := at.GenerateRiskDashboard(); err != nil {
+				t.Errorf("GenerateRiskDashboard: %v", err)
+			}
+		},
+		func() {
+			if _, err := at.GetRiskStatus(); err != nil {
+				t.Errorf("GetRiskStatus: %v", err)
+			}
+		},
+		func() { at.GetStatus() },
+		func() { at.ManualResumeTrading() },
+		func() { at.ManualHaltTrading("test", time.Minute) },
+		func() { at.TakeSnapshot() },
+	}
+	for _, handler := range handlers {
+		wg.Add(1)
+		go func(handler func()) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				handler()
+			}
+		}(handler)
+	}
+	wg.Wait()
+
+	if equity := at.equitySnapshot(); equity.Peak < 1000 {
+		t.Fatalf("peak equity = %.2f, want >= 1000", equity.Peak)
+	}
+}
+
+func TestRiskDashboardDoesNotWriteEquity(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	at.updateEquityTracking(1000)
+	mock.balance["totalWalletBalance"] = 500.0
+
+	if _, err := at.GenerateRiskDashboard(); err != nil {
+		t.Fatalf("GenerateRiskDashboard: %v", err)
+	}
+	if got := at.equitySnapshot().Last; got != 1000 {
+		t.Fatalf("last equity = %.2f, dashboard must not overwrite the cycle's equity", got)
+	}
+}
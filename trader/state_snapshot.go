@@ -0,0 +1,174 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/decision"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotMaxAge 快照超过该时长视为过期，启动时不再恢复
+const snapshotMaxAge = 24 * time.Hour
+
+// AccountStateSnapshot 账户运行状态快照（用于进程崩溃后恢复）
+type AccountStateSnapshot struct {
+	Timestamp            time.Time               `json:"timestamp"`
+	DailyStartEquity     float64                 `json:"daily_start_equity"`     // 当日起始净值
+	HistoricalHighEquity float64                 `json:"historical_high_equity"` // 历史最高净值
+	DailyPnL             float64                 `json:"daily_pnl"`              // 当日盈亏
+	LastResetTime        time.Time               `json:"last_reset_time"`        // 上次日盈亏重置时间
+	IsTradingHalted      bool                    `json:"is_trading_halted"`      // 是否处于风控暂停
+	HaltedAt             time.Time               `json:"halted_at"`              // 暂停开始时间
+	CanResumeAt          time.Time               `json:"can_resume_at"`          // 可恢复交易时间
+	EntryHaltUntil       time.Time               `json:"entry_halt_until"`       // 暂停开新仓截止时间（持仓管理不受影响）
+	DrawdownHardStopPct  float64                 `json:"drawdown_hard_stop_pct"` // 触发回撤硬止损时的回撤（0=未触发，触发后不自动恢复）
+	OpenPositions        []decision.PositionInfo `json:"open_positions"`         // 快照时的持仓
+
+	PositionStops   map[string]positionStop `json:"position_stops,omitempty"`   // 持仓止损止盈价（symbol_side）
+	StrategyBreaker *StrategyBreakerState   `json:"strategy_breaker,omitempty"` // 策略熔断状态和每日收盘净值
+}
+
+// snapshotPath 快照文件路径（与决策日志同目录）
+func (at *AutoTrader) snapshotPath() string {
+	return filepath.Join("decision_logs", at.id, "state_snapshot.json")
+}
+
+// TakeSnapshot 生成当前运行状态快照
+func (at *AutoTrader) TakeSnapshot() *AccountStateSnapshot {
+	now := at.now()
+	positions := make([]decision.PositionInfo, len(at.lastPositions))
+	copy(positions, at.lastPositions)
+
+	at.stopsMu.RLock()
+	stops := make(map[string]positionStop, len(at.positionStops))
+	for key, stop := range at.positionStops {
+		stops[key] = *stop
+	}
+	at.stopsMu.RUnlock()
+
+	breaker := at.strategyBreaker.State()
+	equity, halt := at.equitySnapshot(), at.haltSnapshot()
+	return &AccountStateSnapshot{
+		Timestamp:            now,
+		DailyStartEquity:     equity.DailyStart,
+		HistoricalHighEquity: equity.Peak,
+		DailyPnL:             equity.DailyPnL,
+		LastResetTime:        halt.LastResetTime,
+		IsTradingHalted:      now.Before(halt.StopUntil),
+		HaltedAt:             halt.HaltedAt,
+		CanResumeAt:          halt.StopUntil,
+		EntryHaltUntil:       halt.EntryHaltUntil,
+		DrawdownHardStopPct:  at.drawdownStop.Drawdown(),
+		OpenPositions:        positions,
+		PositionStops:        stops,
+		StrategyBreaker:      &breaker,
+	}
+}
+
+// RestoreSnapshot 从快照恢复运行状态
+func (at *AutoTrader) RestoreSnapshot(snap *AccountStateSnapshot) error {
+	if snap == nil {
+		return fmt.Errorf("快照为空")
+	}
+
+	at.stateMu.Lock()
+	at.dailyStartEquity = snap.DailyStartEquity
+	at.peakEquity = snap.HistoricalHighEquity
+	at.dailyPnL = snap.DailyPnL
+	if !snap.LastResetTime.IsZero() {
+		at.lastResetTime = snap.LastResetTime
+	}
+	if snap.IsTradingHalted && at.now().Before(snap.CanResumeAt) {
+		at.haltedAt = snap.HaltedAt
+		at.stopUntil = snap.CanResumeAt
+	}
+	if at.now().Before(snap.EntryHaltUntil) {
+		at.haltedAt = snap.HaltedAt
+		at.entryHaltUntil = snap.EntryHaltUntil
+	}
+	if snap.DrawdownHardStopPct > 0 {
+		at.haltedAt = snap.HaltedAt
+	}
+	at.stateMu.Unlock()
+	if snap.StrategyBreaker != nil {
+		at.strategyBreaker.Restore(*snap.StrategyBreaker)
+	}
+	if snap.DrawdownHardStopPct > 0 {
+		at.drawdownStop.Trigger(snap.HaltedAt, snap.DrawdownHardStopPct)
+	}
+
+	// 恢复持仓开仓时间（持仓时长不会因重启而清零）
+	for _, pos := range snap.OpenPositions {
+		if pos.UpdateTime > 0 {
+			at.positionFirstSeenTime[pos.Symbol+"_"+pos.Side] = pos.UpdateTime
+		}
+	}
+	at.lastPositions = snap.OpenPositions
+
+	at.stopsMu.Lock()
+	for key, stop := range snap.PositionStops {
+		copied := stop
+		at.positionStops[key] = &copied
+	}
+	at.stopsMu.Unlock()
+
+	return nil
+}
+
+// saveStateSnapshot 原子写入快照文件（先写临时文件再重命名）
+func (at *AutoTrader) saveStateSnapshot() {
+	data, err := json.MarshalIndent(at.TakeSnapshot(), "", "  ")
+	if err != nil {
+		at.logger.Warnf("⚠️ 序列化状态快照失败: %v", err)
+		return
+	}
+
+	path := at.snapshotPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		at.logger.Warnf("⚠️ 创建快照目录失败: %v", err)
+		return
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		at.logger.Warnf("⚠️ 写入状态快照失败: %v", err)
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		at.logger.Warnf("⚠️ 保存状态快照失败: %v", err)
+	}
+}
+
+// restoreStateSnapshot 启动时加载未过期的快照
+func (at *AutoTrader) restoreStateSnapshot() {
+	path := at.snapshotPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if time.Since(info.ModTime()) > snapshotMaxAge {
+		at.logger.Infof("📄 [%s] 状态快照已超过 %v，不再恢复", at.name, snapshotMaxAge)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		at.logger.Warnf("⚠️ 读取状态快照失败: %v", err)
+		return
+	}
+
+	var snap AccountStateSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		at.logger.Warnf("⚠️ 解析状态快照失败: %v", err)
+		return
+	}
+
+	if err := at.RestoreSnapshot(&snap); err != nil {
+		at.logger.Warnf("⚠️ 恢复状态快照失败: %v", err)
+		return
+	}
+	at.logger.Infof("♻️  [%s] 已恢复 %s 的状态快照（持仓 %d 个，当日盈亏 %.2f）",
+		at.name, snap.Timestamp.Format("2006-01-02 15:04:05"), len(snap.OpenPositions), snap.DailyPnL)
+}
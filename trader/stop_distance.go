@@ -0,0 +1,80 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// WidenStop 止损过近时放宽到最小止损距离，并按比例缩减仓位保持风险金额不变
+// 止损距离以入场价的比例表示；放宽后超过 maxDistance（>0时）则返回错误，不放宽
+func WidenStop(d *decision.Decision, entryPrice, minDistance, maxDistance float64) (widened bool, err error) {
+	if entryPrice <= 0 || d.StopLoss <= 0 {
+		return false, nil
+	}
+	distance := math.Abs(entryPrice-d.StopLoss) / entryPrice
+
+	if maxDistance > 0 && distance > maxDistance {
+		return false, fmt.Errorf("止损距离 %.2f%% 超过上限 %.2f%%", distance*100, maxDistance*100)
+	}
+	if minDistance <= 0 || distance >= minDistance {
+		return false, nil
+	}
+	if maxDistance > 0 && minDistance > maxDistance {
+		return false, fmt.Errorf("止损距离 %.2f%% 过近，放宽到 %.2f%% 会超过上限 %.2f%%",
+			distance*100, minDistance*100, maxDistance*100)
+	}
+
+	if d.Action == "open_short" {
+		d.StopLoss = entryPrice * (1 + minDistance)
+	} else {
+		d.StopLoss = entryPrice * (1 - minDistance)
+	}
+	// 风险 = 仓位 × 止损距离，距离变大时仓位等比缩小
+	d.PositionSizeUSD *= distance / minDistance
+	return true, nil
+}
+
+// MinStopDistance 最小止损距离（入场价的比例）：百分比下限与 minTicks 个价格步进值中取较大者
+// 高价币种通常由百分比决定，低价币种的百分比距离可能只有一两个tick，此时由tick数决定
+func MinStopDistance(entryPrice, minPct float64, minTicks int, tickSize float64) float64 {
+	if entryPrice <= 0 || minTicks <= 0 || tickSize <= 0 {
+		return minPct
+	}
+	return math.Max(minPct, float64(minTicks)*tickSize/entryPrice)
+}
+
+// checkStopDistance 止损距离检查
+// 止损过近容易被正常波动扫掉：放宽到最小距离并缩减仓位后重新校验一次，而不是直接拒绝
+func (at *AutoTrader) checkStopDistance(d *decision.Decision, data *market.Data) error {
+	cfg := at.config.Risk
+	if cfg.MinStopDistancePct <= 0 && cfg.MaxStopDistancePct <= 0 && cfg.MinStopDistanceTicks <= 0 {
+		return nil
+	}
+
+	entryPrice := data.ReferencePrice(cfg.EntryPriceRef)
+	minDistance := cfg.MinStopDistancePct
+	if cfg.MinStopDistanceTicks > 0 {
+		tickSize, err := market.GetTickSize(at.exchange, d.Symbol)
+		if errors.Is(err, market.ErrTickSizeUnsupported) {
+			at.logger.Debugf("  %s 无固定价格步进值，只按百分比检查止损距离", d.Symbol)
+		} else if err != nil {
+			at.logger.Warnf("  ⚠️ %s 获取价格步进值失败，只按百分比检查止损距离: %v", d.Symbol, err)
+		} else {
+			minDistance = MinStopDistance(entryPrice, cfg.MinStopDistancePct, cfg.MinStopDistanceTicks, tickSize)
+		}
+	}
+
+	oldStop, oldSize := d.StopLoss, d.PositionSizeUSD
+	widened, err := WidenStop(d, entryPrice, minDistance, cfg.MaxStopDistancePct)
+	if err != nil {
+		return fmt.Errorf("%s %v", d.Symbol, err)
+	}
+	if widened {
+		at.logger.Infof("  📏 %s 止损过近，放宽: %.4f → %.4f，仓位 %.2f → %.2f USDT（风险不变）",
+			d.Symbol, oldStop, d.StopLoss, oldSize, d.PositionSizeUSD)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+	"testing"
+)
+
+func TestWidenStopKeepsRiskConstant(t *testing.T) {
+	// 止损距离0.2%，放宽到0.5%，仓位从1000缩到400，风险仍为2 USDT
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 99.8, PositionSizeUSD: 1000}
+	widened, err := WidenStop(d, 100, 0.005, 0.03)
+	if err != nil || !widened {
+		t.Fatalf("WidenStop = %v, %v; want widened", widened, err)
+	}
+	if !approxEqual(d.StopLoss, 99.5) || !approxEqual(d.PositionSizeUSD, 400) {
+		t.Errorf("stop/size = %.4f/%.2f, want 99.5/400", d.StopLoss, d.PositionSizeUSD)
+	}
+	if risk := d.PositionSizeUSD * (100 - d.StopLoss) / 100; !approxEqual(risk, 2) {
+		t.Errorf("risk after widening = %.4f, want 2", risk)
+	}
+
+	short := &decision.Decision{Symbol: "BTCUSDT", Action: "open_short", StopLoss: 100.1, PositionSizeUSD: 1000}
+	if widened, err := WidenStop(short, 100, 0.005, 0.03); err != nil || !widened || !approxEqual(short.StopLoss, 100.5) {
+		t.Errorf("short stop = %.4f (%v, %v), want widened above entry to 100.5", short.StopLoss, widened, err)
+	}
+}
+
+func TestWidenStopRefusesToBreachMaxDistance(t *testing.T) {
+	// 最小距离超过最大距离上限：不放宽，拒绝开仓，决策保持不变
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 99.8, PositionSizeUSD: 1000}
+	widened, err := WidenStop(d, 100, 0.04, 0.03)
+	if err == nil || widened {
+		t.Fatalf("WidenStop = %v, %v; want a refusal", widened, err)
+	}
+	if d.StopLoss != 99.8 || d.PositionSizeUSD != 1000 {
+		t.Errorf("decision changed to %.4f/%.2f after refusal", d.StopLoss, d.PositionSizeUSD)
+	}
+
+	// 止损本身已超过最大距离
+	far := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 95, PositionSizeUSD: 1000}
+	if _, err := WidenStop(far, 100, 0.005, 0.03); err == nil || !strings.Contains(err.Error(), "超过上限") {
+		t.Errorf("err = %v, want the stop rejected beyond the max distance", err)
+	}
+}
+
+func TestWidenStopLeavesAdequateStops(t *testing.T) {
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 98, PositionSizeUSD: 1000}
+	if widened, err := WidenStop(d, 100, 0.005, 0.03); err != nil || widened || d.StopLoss != 98 || d.PositionSizeUSD != 1000 {
+		t.Errorf("WidenStop = %v, %v (%.2f/%.2f), want unchanged", widened, err, d.StopLoss, d.PositionSizeUSD)
+	}
+}
+
+func TestCheckStopDistanceRejectsEntryWhenWideningBreaches(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MinStopDistancePct: 0.04, MaxStopDistancePct: 0.03})
+	d := &decision.Decision{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 99.8, PositionSizeUSD: 1000}
+	err := at.checkStopDistance(d, &market.Data{Symbol: "BTCUSDT", CurrentPrice: 100})
+	if err == nil || !strings.HasPrefix(err.Error(), "BTCUSDT") {
+		t.Errorf("err = %v, want the entry rejected", err)
+	}
+
+	at.config.Risk.MinStopDistancePct = 0.005
+	if err := at.checkStopDistance(d, &market.Data{Symbol: "BTCUSDT", CurrentPrice: 100}); err != nil || !approxEqual(d.StopLoss, 99.5) {
+		t.Errorf("err = %v stop = %.4f, want widened to 99.5", err, d.StopLoss)
+	}
+}
+
+func TestMinStopDistanceTickFloor(t *testing.T) {
+	cases := []struct {
+		name       string
+		entryPrice float64
+		minPct     float64
+		minTicks   int
+		tickSize   float64
+		want       float64
+	}{
+		// 高价币种：10个tick只有 0.0015%，由百分比决定
+		{"high priced", 65000, 0.005, 10, 0.1, 0.005},
+		// 低价币种：0.5% 只有 0.5 个tick，由tick数决定（10 × 0.0000001 / 0.00001 = 10%）
+		{"micro priced", 0.00001, 0.005, 10, 0.0000001, 0.1},
+		{"micro priced without pct", 0.0012, 0, 3, 0.000001, 0.0025},
+		{"tick floor equal to pct", 2, 0.005, 10, 0.001, 0.005},
+		{"no tick floor", 0.00001, 0.005, 0, 0.0000001, 0.005},
+		{"unknown tick size", 0.00001, 0.005, 10, 0, 0.005},
+		{"invalid price", 0, 0.005, 10, 0.1, 0.005},
+	}
+	for _, tc := range cases {
+		if got := MinStopDistance(tc.entryPrice, tc.minPct, tc.minTicks, tc.tickSize); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("%s: MinStopDistance = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWidenStopToTickFloor(t *testing.T) {
+	// 低价币种：止损距入场价 2 个tick（2%），放宽到 10 个tick（10%），仓位缩小到 1/5
+	entry, tick := 0.00001, 0.0000001
+	d := &decision.Decision{Symbol: "PEPEUSDT", Action: "open_long", StopLoss: entry - 2*tick, PositionSizeUSD: 1000}
+	minDistance := MinStopDistance(entry, 0.005, 10, tick)
+
+	widened, err := WidenStop(d, entry, minDistance, 0)
+	if err != nil || !widened {
+		t.Fatalf("widened = %v err = %v, want widened to the tick floor", widened, err)
+	}
+	if math.Abs(d.StopLoss-(entry-10*tick)) > 1e-15 || !approxEqual(d.PositionSizeUSD, 200) {
+		t.Errorf("stop/size = %.10f/%.2f, want %.10f/200", d.StopLoss, d.PositionSizeUSD, entry-10*tick)
+	}
+
+	// 高价币种：同样的tick数不影响按百分比计算的止损
+	d = &decision.Decision{Symbol: "BTCUSDT", Action: "open_short", StopLoss: 65650, PositionSizeUSD: 1000}
+	if widened, err := WidenStop(d, 65000, MinStopDistance(65000, 0.005, 10, 0.1), 0); err != nil || widened {
+		t.Errorf("widened = %v err = %v, want a 1%% stop left alone", widened, err)
+	}
+}
+
+func TestCheckStopDistanceWithoutTickSizeUsesPct(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{MinStopDistancePct: 0.005, MinStopDistanceTicks: 10})
+	at.exchange = "hyperliquid" // 无固定价格步进值，不请求交易规则
+	d := &decision.Decision{Symbol: "PEPEUSDT", Action: "open_long", StopLoss: 0.0000099, PositionSizeUSD: 1000}
+	if err := at.checkStopDistance(d, &market.Data{Symbol: "PEPEUSDT", CurrentPrice: 0.00001}); err != nil {
+		t.Fatal(err)
+	}
+	// 止损距离 1% 满足百分比下限；若按10个tick（10%）计算则会被放宽
+	if d.StopLoss != 0.0000099 || d.PositionSizeUSD != 1000 {
+		t.Errorf("stop/size = %.10f/%.2f, want unchanged under the percentage floor", d.StopLoss, d.PositionSizeUSD)
+	}
+}
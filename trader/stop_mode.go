@@ -0,0 +1,117 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/market"
+)
+
+// 止损模式
+const (
+	StopModeAI    = "ai"    // 使用AI给出的止损价
+	StopModeSwing = "swing" // 使用最近的摆动高/低点（无合适摆动点时按ATR计算）
+)
+
+// swingPivotStrength 摆动点识别强度（左右各N根K线）
+const swingPivotStrength = 2
+
+// applyStopMode 按配置的止损模式调整开仓决策的止损价
+func (at *AutoTrader) applyStopMode(d *decision.Decision, data *market.Data, side string) {
+	cfg := at.config.Risk
+	if cfg.StopMode != StopModeSwing {
+		return
+	}
+
+	stopLoss, source := at.structureStopPrice(d.Symbol, data, side)
+	if stopLoss <= 0 {
+		at.logger.Warnf("  ⚠️ %s 无法计算结构止损，保留AI止损 %.4f", d.Symbol, d.StopLoss)
+		return
+	}
+
+	at.logger.Infof("  📐 %s 止损按%s调整: %.4f → %.4f", d.Symbol, source, d.StopLoss, stopLoss)
+	price := data.ReferencePrice(cfg.StopPriceRef)
+	if size := resizeForStop(d.PositionSizeUSD, d.RiskUSD, price, d.StopLoss, stopLoss); size > 0 {
+		at.logger.Infof("  📐 %s 止损距离变化，仓位按原风险金额调整: %.2f → %.2f USDT", d.Symbol, d.PositionSizeUSD, size)
+		d.PositionSizeUSD = size
+	}
+	d.StopLoss = stopLoss
+}
+
+// resizeForStop 止损价变化后保持单笔风险不变的仓位价值（USDT）
+// 风险金额按原止损距离计算（仓位价值 × 止损距离/价格）；原止损无效时使用AI给出的 riskUSD。
+// 无法确定风险金额或新止损无效时返回0（保持原仓位）
+func resizeForStop(sizeUSD, riskUSD, price, oldStop, newStop float64) float64 {
+	newDistance := math.Abs(price - newStop)
+	if price <= 0 || newStop <= 0 || newDistance == 0 {
+		return 0
+	}
+
+	risk := 0.0
+	if oldDistance := math.Abs(price - oldStop); oldStop > 0 && oldDistance > 0 && (oldStop-price)*(newStop-price) > 0 {
+		risk = sizeUSD * oldDistance / price
+	} else if riskUSD > 0 {
+		risk = riskUSD
+	}
+	if risk <= 0 {
+		return 0
+	}
+	return risk * price / newDistance
+}
+
+// structureStopPrice 计算结构止损价，返回止损价和来源说明（0表示无法计算）
+func (at *AutoTrader) structureStopPrice(symbol string, data *market.Data, side string) (float64, string) {
+	cfg := at.config.Risk
+	price := data.ReferencePrice(cfg.StopPriceRef)
+
+	klines, err := at.getKlines(symbol, cfg.SwingStopInterval)
+	if err == nil {
+		if price, ok := market.SwingStopPrice(klines, side, price, cfg.SwingStopBufferPct, swingPivotStrength); ok {
+			return price, "摆动点"
+		}
+	}
+
+	// 回退：按同周期K线的ATR计算（K线不可用时用4小时ATR14）
+	atr := 0.0
+	if err == nil {
+		atr, _ = market.CalculateATR(klines, cfg.SwingStopATRPeriod)
+	}
+	if atr <= 0 && data.LongerTermContext != nil {
+		atr = data.LongerTermContext.ATR14
+	}
+	stopLoss, err := CalculateStopLoss(side, price, atr, cfg.SwingStopATRMultiplier, cfg.SwingStopFallbackPct)
+	if err != nil {
+		at.logger.Warnf("  ⚠️ %s %v", symbol, err)
+		return 0, ""
+	}
+	if atr <= 0 {
+		return stopLoss, "固定比例"
+	}
+	return stopLoss, "ATR"
+}
+
+// CalculateStopLoss 按ATR倍数计算止损价；ATR不可用时按入场价的 fallbackPct（比例，如0.02=2%）计算
+// ATR和回退比例都不可用，或止损价不为正时返回错误
+func CalculateStopLoss(side string, price, atr, atrMultiplier, fallbackPct float64) (float64, error) {
+	if price <= 0 {
+		return 0, fmt.Errorf("入场价无效: %.4f", price)
+	}
+
+	var distance float64
+	switch {
+	case atr > 0 && atrMultiplier > 0:
+		distance = atr * atrMultiplier
+	case fallbackPct > 0:
+		distance = price * fallbackPct
+	default:
+		return 0, fmt.Errorf("ATR不可用（%.4f）且未配置回退止损比例，无法计算止损", atr)
+	}
+
+	if side == "short" {
+		return price + distance, nil
+	}
+	if price <= distance {
+		return 0, fmt.Errorf("止损距离 %.4f 超过入场价 %.4f", distance, price)
+	}
+	return price - distance, nil
+}
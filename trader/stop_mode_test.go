@@ -0,0 +1,106 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+)
+
+func TestResizeForStopKeepsRiskConstant(t *testing.T) {
+	cases := []struct {
+		name                   string
+		size, riskUSD, price   float64
+		oldStop, newStop, want float64
+	}{
+		// 1000U 多单，止损从2%放宽到4%：风险20U不变，仓位减半
+		{"wider long stop", 1000, 0, 100, 98, 96, 500},
+		// 止损从4%收紧到2%：仓位加倍
+		{"tighter long stop", 1000, 0, 100, 96, 98, 2000},
+		// 空单同理
+		{"wider short stop", 1000, 0, 100, 102, 105, 400},
+		// 原止损在错误一侧时按AI给出的风险金额计算
+		{"invalid old stop uses risk_usd", 1000, 30, 100, 103, 97, 1000},
+		{"missing old stop uses risk_usd", 1000, 15, 100, 0, 95, 300},
+	}
+	for _, c := range cases {
+		got := resizeForStop(c.size, c.riskUSD, c.price, c.oldStop, c.newStop)
+		if math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("%s: size = %.4f, want %.4f", c.name, got, c.want)
+		}
+		// 调整后的风险金额与原风险一致
+		if c.oldStop > 0 && (c.oldStop-c.price)*(c.newStop-c.price) > 0 {
+			before := c.size * math.Abs(c.price-c.oldStop) / c.price
+			after := got * math.Abs(c.price-c.newStop) / c.price
+			if math.Abs(before-after) > 1e-9 {
+				t.Errorf("%s: risk %.4f → %.4f, want unchanged", c.name, before, after)
+			}
+		}
+	}
+}
+
+func TestResizeForStopKeepsSizeWhenRiskUnknown(t *testing.T) {
+	if got := resizeForStop(1000, 0, 100, 0, 95); got != 0 {
+		t.Errorf("size = %.2f, want 0 (keep original) without a usable risk amount", got)
+	}
+	if got := resizeForStop(1000, 20, 100, 98, 100); got != 0 {
+		t.Errorf("size = %.2f, want 0 for a zero-distance stop", got)
+	}
+	if got := resizeForStop(1000, 20, 0, 98, 96); got != 0 {
+		t.Errorf("size = %.2f, want 0 without a price", got)
+	}
+}
+
+func TestCalculateStopLoss(t *testing.T) {
+	cases := []struct {
+		name        string
+		side        string
+		price, atr  float64
+		multiplier  float64
+		fallbackPct float64
+		want        float64
+		wantErr     bool
+	}{
+		{"atr long", "long", 100, 2, 1.5, 0.05, 97, false},
+		{"atr short", "short", 100, 2, 1.5, 0.05, 103, false},
+		{"fallback long", "long", 100, 0, 1.5, 0.02, 98, false},
+		{"fallback short", "short", 100, 0, 1.5, 0.02, 102, false},
+		{"fallback without multiplier", "long", 100, 2, 0, 0.02, 98, false},
+		{"atr and fallback unusable", "long", 100, 0, 1.5, 0, 0, true},
+		{"stop beyond zero", "long", 100, 0, 1.5, 1.2, 0, true},
+		{"invalid price", "long", 0, 2, 1.5, 0.02, 0, true},
+	}
+	for _, tc := range cases {
+		got, err := CalculateStopLoss(tc.side, tc.price, tc.atr, tc.multiplier, tc.fallbackPct)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: stop = %.4f, want %.4f", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestApplyStopModeFallsBackToPercentWithoutATR(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{StopMode: StopModeSwing, SwingStopATRMultiplier: 1.5, SwingStopFallbackPct: 0.02})
+	data := &market.Data{Symbol: "SOLUSDT", CurrentPrice: 100} // K线不可用且无4小时ATR
+
+	d := &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 1000, StopLoss: 95}
+	at.applyStopMode(d, data, "long")
+	if math.Abs(d.StopLoss-98) > 1e-9 {
+		t.Errorf("stop = %.4f, want 98 (2%% below entry)", d.StopLoss)
+	}
+	// 风险保持50 USDT：止损距离从5%收窄到2%，仓位放大到2500
+	if math.Abs(d.PositionSizeUSD-2500) > 1e-9 {
+		t.Errorf("size = %.2f, want 2500", d.PositionSizeUSD)
+	}
+
+	// 未配置回退比例时保留AI止损
+	at.config.Risk.SwingStopFallbackPct = 0
+	d = &decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 1000, StopLoss: 95}
+	at.applyStopMode(d, data, "long")
+	if d.StopLoss != 95 || d.PositionSizeUSD != 1000 {
+		t.Errorf("stop/size = %.4f/%.2f, want AI stop 95/1000 kept", d.StopLoss, d.PositionSizeUSD)
+	}
+}
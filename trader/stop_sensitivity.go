@@ -0,0 +1,100 @@
+package trader
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ExcursionSample 一笔历史交易的最大不利/有利偏移（相对入场价的价格距离，均为非负数）
+type ExcursionSample struct {
+	MAE float64 // 最大不利偏移（Maximum Adverse Excursion）
+	MFE float64 // 最大有利偏移（Maximum Favorable Excursion）
+}
+
+// StopSensitivityRow 某一止损宽度下的历史表现
+type StopSensitivityRow struct {
+	Multiplier    float64 `json:"multiplier"`      // ATR倍数
+	StopDistance  float64 `json:"stop_distance"`   // 止损距离（价格）
+	StopPrice     float64 `json:"stop_price"`      // 止损价
+	TakeProfit    float64 `json:"take_profit"`     // 对应的止盈价（止损距离 × 盈亏比）
+	SurvivalRate  float64 `json:"survival_rate"`   // 未触及止损的交易占比
+	TargetHitRate float64 `json:"target_hit_rate"` // 未触及止损且到达止盈的交易占比
+	ExpectedValue float64 `json:"expected_value"`  // 每单位仓位的期望收益（占入场价比例）
+}
+
+// StopSensitivityReport 止损宽度敏感性分析结果（按期望收益从高到低排序）
+type StopSensitivityReport struct {
+	Direction  string               `json:"direction"`
+	EntryPrice float64              `json:"entry_price"`
+	ATR        float64              `json:"atr"`
+	RewardRisk float64              `json:"reward_risk"`
+	Samples    int                  `json:"samples"`
+	Rows       []StopSensitivityRow `json:"rows"`
+}
+
+// AnalyseStopSensitivity 用历史交易的MAE/MFE分布评估不同止损宽度（ATR倍数）的表现
+// 止损距离 = ATR × 倍数，止盈距离 = 止损距离 × rewardRisk。MAE 小于止损距离的交易视为存活，
+// 存活且 MFE 达到止盈距离的视为盈利；存活但未到止盈的按保本计。
+// 期望收益 = 盈利占比 × 止盈距离 - 止损占比 × 止损距离（除以入场价）
+func AnalyseStopSensitivity(direction string, entryPrice, atrValue float64, atrMultipliers []float64, rewardRisk float64, excursions []ExcursionSample) (*StopSensitivityReport, error) {
+	if direction != "long" && direction != "short" {
+		return nil, fmt.Errorf("无效的方向: %s", direction)
+	}
+	if entryPrice <= 0 || atrValue <= 0 {
+		return nil, fmt.Errorf("入场价和ATR必须大于0")
+	}
+	if rewardRisk <= 0 {
+		return nil, fmt.Errorf("盈亏比必须大于0: %.2f", rewardRisk)
+	}
+	if len(excursions) == 0 {
+		return nil, fmt.Errorf("没有历史交易的偏移数据")
+	}
+
+	report := &StopSensitivityReport{
+		Direction:  direction,
+		EntryPrice: entryPrice,
+		ATR:        atrValue,
+		RewardRisk: rewardRisk,
+		Samples:    len(excursions),
+	}
+	n := float64(len(excursions))
+	for _, multiplier := range atrMultipliers {
+		if multiplier <= 0 {
+			continue
+		}
+		stopDistance := atrValue * multiplier
+		targetDistance := stopDistance * rewardRisk
+
+		survived, hit := 0, 0
+		for _, e := range excursions {
+			if e.MAE >= stopDistance {
+				continue
+			}
+			survived++
+			if e.MFE >= targetDistance {
+				hit++
+			}
+		}
+
+		row := StopSensitivityRow{
+			Multiplier:    multiplier,
+			StopDistance:  stopDistance,
+			SurvivalRate:  float64(survived) / n,
+			TargetHitRate: float64(hit) / n,
+		}
+		if direction == "long" {
+			row.StopPrice = entryPrice - stopDistance
+			row.TakeProfit = entryPrice + targetDistance
+		} else {
+			row.StopPrice = entryPrice + stopDistance
+			row.TakeProfit = entryPrice - targetDistance
+		}
+		row.ExpectedValue = (row.TargetHitRate*targetDistance - (1-row.SurvivalRate)*stopDistance) / entryPrice
+		report.Rows = append(report.Rows, row)
+	}
+
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i].ExpectedValue > report.Rows[j].ExpectedValue
+	})
+	return report, nil
+}
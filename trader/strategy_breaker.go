@@ -0,0 +1,162 @@
+package trader
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// strategyBreakerHistoryDays 保留的每日收盘净值天数
+const strategyBreakerHistoryDays = 90
+
+// dailyClose 某个UTC自然日的收盘净值（当日最后一次记录的净值）
+type dailyClose struct {
+	Day    time.Time `json:"day"`
+	Equity float64   `json:"equity"`
+}
+
+// StrategyBreakerState 策略熔断器的可持久化状态（写入账户状态快照，重启后恢复）
+type StrategyBreakerState struct {
+	Tripped bool         `json:"tripped"`
+	Reason  string       `json:"reason,omitempty"`
+	Closes  []dailyClose `json:"closes,omitempty"` // 每日收盘净值（按日期升序）
+}
+
+// StrategyCircuitBreaker 策略熔断：按每日收盘净值跟踪滚动表现
+// 滚动N日收益低于阈值或连续亏损天数达到上限时停用策略（只停开新仓），需要手动重新启用
+type StrategyCircuitBreaker struct {
+	mu      sync.Mutex
+	closes  []dailyClose
+	tripped bool
+	reason  string
+
+	rollingDays         int     // 滚动收益的天数
+	minRollingReturn    float64 // 滚动收益下限（如-0.1=-10%，0=不检查）
+	maxConsecutiveLoses int     // 连续亏损天数上限（0=不检查）
+}
+
+// NewStrategyCircuitBreaker 创建策略熔断器
+func NewStrategyCircuitBreaker(rollingDays int, minRollingReturn float64, maxConsecutiveLosingDays int) *StrategyCircuitBreaker {
+	return &StrategyCircuitBreaker{
+		rollingDays:         rollingDays,
+		minRollingReturn:    minRollingReturn,
+		maxConsecutiveLoses: maxConsecutiveLosingDays,
+	}
+}
+
+// RecordEquity 记录净值；跨入新的UTC日时按已收盘的日子评估滚动表现，返回本次是否触发熔断
+func (b *StrategyCircuitBreaker) RecordEquity(now time.Time, equity float64) bool {
+	if equity <= 0 {
+		return false
+	}
+	day := now.UTC().Truncate(24 * time.Hour)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n := len(b.closes); n > 0 && b.closes[n-1].Day.Equal(day) {
+		b.closes[n-1].Equity = equity
+		return false
+	}
+
+	// 新的一天：此前的记录都已收盘，先评估再开始记录今天
+	wasTripped := b.tripped
+	b.evaluate()
+	b.closes = append(b.closes, dailyClose{Day: day, Equity: equity})
+	if len(b.closes) > strategyBreakerHistoryDays {
+		b.closes = b.closes[len(b.closes)-strategyBreakerHistoryDays:]
+	}
+	return b.tripped && !wasTripped
+}
+
+// evaluate 按已收盘的每日净值检查滚动收益和连续亏损天数（调用方持有锁）
+func (b *StrategyCircuitBreaker) evaluate() {
+	if b.tripped {
+		return
+	}
+	n := len(b.closes)
+
+	if b.minRollingReturn != 0 && b.rollingDays > 0 && n > b.rollingDays {
+		start, end := b.closes[n-1-b.rollingDays].Equity, b.closes[n-1].Equity
+		if ret := end/start - 1; ret < b.minRollingReturn {
+			b.tripped = true
+			b.reason = fmt.Sprintf("滚动%d日收益 %.2f%% 低于下限 %.2f%%", b.rollingDays, ret*100, b.minRollingReturn*100)
+			return
+		}
+	}
+
+	if b.maxConsecutiveLoses > 0 {
+		losing := 0
+		for i := n - 1; i > 0 && b.closes[i].Equity < b.closes[i-1].Equity; i-- {
+			losing++
+		}
+		if losing >= b.maxConsecutiveLoses {
+			b.tripped = true
+			b.reason = fmt.Sprintf("连续%d个交易日亏损（上限%d）", losing, b.maxConsecutiveLoses)
+		}
+	}
+}
+
+// Status 是否已熔断及原因
+func (b *StrategyCircuitBreaker) Status() (tripped bool, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tripped, b.reason
+}
+
+// State 导出当前状态（用于快照）
+func (b *StrategyCircuitBreaker) State() StrategyBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return StrategyBreakerState{
+		Tripped: b.tripped,
+		Reason:  b.reason,
+		Closes:  append([]dailyClose(nil), b.closes...),
+	}
+}
+
+// Restore 从快照恢复状态（已熔断的策略重启后仍保持熔断）
+func (b *StrategyCircuitBreaker) Restore(state StrategyBreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tripped = state.Tripped
+	b.reason = state.Reason
+	b.closes = append([]dailyClose(nil), state.Closes...)
+	if len(b.closes) > strategyBreakerHistoryDays {
+		b.closes = b.closes[len(b.closes)-strategyBreakerHistoryDays:]
+	}
+}
+
+// Reset 手动重新启用策略（保留净值历史，从下一个收盘日重新评估）
+func (b *StrategyCircuitBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tripped = false
+	b.reason = ""
+	// 只保留最近一天作为新的评估起点，避免重新启用后立即因旧数据再次熔断
+	if n := len(b.closes); n > 1 {
+		b.closes = b.closes[n-1:]
+	}
+}
+
+// checkStrategyBreaker 策略熔断后拒绝所有开仓
+func (at *AutoTrader) checkStrategyBreaker() error {
+	if tripped, reason := at.strategyBreaker.Status(); tripped {
+		return fmt.Errorf("策略已熔断（%s），需手动重新启用", reason)
+	}
+	return nil
+}
+
+// recordStrategyEquity 记录净值到策略熔断器，触发时告警
+func (at *AutoTrader) recordStrategyEquity(equity float64) {
+	if at.strategyBreaker.RecordEquity(at.now(), equity) {
+		_, reason := at.strategyBreaker.Status()
+		at.logger.Infof("🛑 [%s] 策略熔断: %s，停止开新仓直到手动重新启用", at.name, reason)
+	}
+}
+
+// ReEnableStrategy 手动解除策略熔断
+func (at *AutoTrader) ReEnableStrategy() {
+	at.strategyBreaker.Reset()
+	at.logger.Infof("✅ [%s] 策略已手动重新启用", at.name)
+}
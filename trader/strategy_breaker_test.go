@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// recordDeclining 每天记录一次递减的净值
+func recordDeclining(b *StrategyCircuitBreaker, start time.Time, days int) (tripped bool) {
+	equity := 1000.0
+	for i := 0; i < days; i++ {
+		if b.RecordEquity(start.Add(time.Duration(i)*24*time.Hour), equity) {
+			tripped = true
+		}
+		equity *= 0.98
+	}
+	return tripped
+}
+
+func TestStrategyBreakerTripsOnConsecutiveLosses(t *testing.T) {
+	b := NewStrategyCircuitBreaker(0, 0, 3)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 第4天收盘后才有3个亏损日，第5天开始记录时评估触发
+	if recordDeclining(b, start, 4) {
+		t.Fatal("tripped before three losing days closed")
+	}
+	if !b.RecordEquity(start.Add(4*24*time.Hour), 900) {
+		t.Fatal("expected breaker to trip after three losing days")
+	}
+	if tripped, reason := b.Status(); !tripped || reason == "" {
+		t.Fatalf("Status = %v %q", tripped, reason)
+	}
+}
+
+func TestStrategyBreakerTripsOnRollingReturn(t *testing.T) {
+	b := NewStrategyCircuitBreaker(3, -0.05, 0)
+	if !recordDeclining(b, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), 6) {
+		t.Fatal("expected breaker to trip on rolling return")
+	}
+}
+
+func TestStrategyBreakerSurvivesSnapshot(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{StrategyMaxLosingDays: 3})
+	at.strategyBreaker = NewStrategyCircuitBreaker(0, 0, 3)
+	recordDeclining(at.strategyBreaker, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), 5)
+	if tripped, _ := at.strategyBreaker.Status(); !tripped {
+		t.Fatal("setup: breaker should be tripped")
+	}
+
+	data, err := json.Marshal(at.TakeSnapshot())
+	if err != nil {
+		t.Fatalf("marshal snapshot: %v", err)
+	}
+	var snap AccountStateSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("unmarshal snapshot: %v", err)
+	}
+
+	restored, _ := newTestAutoTrader(t, RiskConfig{StrategyMaxLosingDays: 3})
+	restored.strategyBreaker = NewStrategyCircuitBreaker(0, 0, 3)
+	if err := restored.RestoreSnapshot(&snap); err != nil {
+		t.Fatalf("RestoreSnapshot: %v", err)
+	}
+
+	if err := restored.checkStrategyBreaker(); err == nil {
+		t.Fatal("restored trader should still reject entries")
+	}
+	if got := len(restored.strategyBreaker.State().Closes); got != 5 {
+		t.Fatalf("restored %d daily closes, want 5", got)
+	}
+}
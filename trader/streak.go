@@ -0,0 +1,57 @@
+package trader
+
+import "sync"
+
+// StreakInfo 连胜/连败统计
+type StreakInfo struct {
+	CurrentStreak int    `json:"current_streak"` // 当前连续次数
+	StreakType    string `json:"streak_type"`    // win / loss / none
+	MaxWinStreak  int    `json:"max_win_streak"`
+	MaxLossStreak int    `json:"max_loss_streak"`
+}
+
+// tradeStreak 连胜/连败跟踪（连胜时可考虑加码，异常连胜也可能预示反转）
+type tradeStreak struct {
+	mu                sync.Mutex
+	consecutiveWins   int
+	consecutiveLosses int
+	maxWinStreak      int
+	maxLossStreak     int
+}
+
+// RecordTradeOutcome 记录一笔平仓结果（盈利重置连败，亏损重置连胜）
+func (at *AutoTrader) RecordTradeOutcome(isWin bool) {
+	s := &at.streak
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if isWin {
+		s.consecutiveWins++
+		s.consecutiveLosses = 0
+		if s.consecutiveWins > s.maxWinStreak {
+			s.maxWinStreak = s.consecutiveWins
+		}
+	} else {
+		s.consecutiveLosses++
+		s.consecutiveWins = 0
+		if s.consecutiveLosses > s.maxLossStreak {
+			s.maxLossStreak = s.consecutiveLosses
+		}
+	}
+}
+
+// GetStreakInfo 当前连胜/连败状态
+func (at *AutoTrader) GetStreakInfo() StreakInfo {
+	s := &at.streak
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := StreakInfo{StreakType: "none", MaxWinStreak: s.maxWinStreak, MaxLossStreak: s.maxLossStreak}
+	switch {
+	case s.consecutiveWins > 0:
+		info.CurrentStreak, info.StreakType = s.consecutiveWins, "win"
+	case s.consecutiveLosses > 0:
+		info.CurrentStreak, info.StreakType = s.consecutiveLosses, "loss"
+	}
+	return info
+}
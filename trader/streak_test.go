@@ -0,0 +1,33 @@
+package trader
+
+import "testing"
+
+func TestTradeStreakTransitions(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	if info := at.GetStreakInfo(); info != (StreakInfo{StreakType: "none"}) {
+		t.Fatalf("initial streak = %+v, want none", info)
+	}
+
+	steps := []struct {
+		isWin bool
+		want  StreakInfo
+	}{
+		{false, StreakInfo{CurrentStreak: 1, StreakType: "loss", MaxLossStreak: 1}},
+		{false, StreakInfo{CurrentStreak: 2, StreakType: "loss", MaxLossStreak: 2}},
+		{true, StreakInfo{CurrentStreak: 1, StreakType: "win", MaxWinStreak: 1, MaxLossStreak: 2}}, // 盈利重置连败
+		{true, StreakInfo{CurrentStreak: 2, StreakType: "win", MaxWinStreak: 2, MaxLossStreak: 2}},
+		{true, StreakInfo{CurrentStreak: 3, StreakType: "win", MaxWinStreak: 3, MaxLossStreak: 2}},
+		{false, StreakInfo{CurrentStreak: 1, StreakType: "loss", MaxWinStreak: 3, MaxLossStreak: 2}}, // 亏损重置连胜
+		{true, StreakInfo{CurrentStreak: 1, StreakType: "win", MaxWinStreak: 3, MaxLossStreak: 2}},
+	}
+	for i, step := range steps {
+		at.RecordTradeOutcome(step.isWin)
+		if got := at.GetStreakInfo(); got != step.want {
+			t.Errorf("step %d (win=%v): streak = %+v, want %+v", i, step.isWin, got, step.want)
+		}
+	}
+
+	if got, ok := at.GetStatus()["trade_streak"].(StreakInfo); !ok || got != steps[len(steps)-1].want {
+		t.Errorf("status trade_streak = %+v, want %+v", got, steps[len(steps)-1].want)
+	}
+}
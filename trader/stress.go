@@ -0,0 +1,52 @@
+package trader
+
+import "nofx/decision"
+
+// StressTest 假设所有持仓同时打到止损/止盈时的账户净值（以 equity 为基准）
+// 未记录止损的持仓最坏按强平价计（无强平价时亏完保证金），未记录止盈的持仓最好情况按不变计
+func (at *AutoTrader) StressTest(positions []decision.PositionInfo, equity float64) (worstEquity, bestEquity float64) {
+	stops := make(map[string]*positionStop, len(positions))
+	for _, pos := range positions {
+		if stop := at.getPositionStop(pos.Symbol, pos.Side); stop != nil {
+			stops[pos.Symbol+"_"+pos.Side] = stop
+		}
+	}
+	return CalculateStressTest(positions, stops, equity)
+}
+
+// CalculateStressTest 计算止损/止盈全部触发时的净值区间
+// 价格变动按标记价格计算（未实现盈亏已包含在 equity 中）
+func CalculateStressTest(positions []decision.PositionInfo, stops map[string]*positionStop, equity float64) (worstEquity, bestEquity float64) {
+	worstEquity, bestEquity = equity, equity
+	for _, pos := range positions {
+		if pos.MarkPrice <= 0 || pos.Quantity <= 0 {
+			continue
+		}
+
+		var stopLoss, takeProfit float64
+		if stop := stops[pos.Symbol+"_"+pos.Side]; stop != nil {
+			stopLoss, takeProfit = stop.StopLoss, stop.TakeProfit
+		}
+
+		if stopLoss > 0 {
+			worstEquity += priceMovePnL(pos, stopLoss)
+		} else if pos.LiquidationPrice > 0 {
+			worstEquity += priceMovePnL(pos, pos.LiquidationPrice)
+		} else {
+			worstEquity -= pos.MarginUsed
+		}
+		if takeProfit > 0 {
+			bestEquity += priceMovePnL(pos, takeProfit)
+		}
+	}
+	return worstEquity, bestEquity
+}
+
+// priceMovePnL 持仓从标记价格变动到 price 时的盈亏
+func priceMovePnL(pos decision.PositionInfo, price float64) float64 {
+	pnl := (price - pos.MarkPrice) * pos.Quantity
+	if pos.Side == "short" {
+		return -pnl
+	}
+	return pnl
+}
@@ -0,0 +1,50 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"testing"
+)
+
+// stressTestPositions 四个持仓分别覆盖：止损止盈齐全、空头、仅强平价、仅保证金，以及无标记价格的持仓
+func stressTestPositions() []decision.PositionInfo {
+	return []decision.PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long", MarkPrice: 100, Quantity: 10, MarginUsed: 100},
+		{Symbol: "ETHUSDT", Side: "short", MarkPrice: 200, Quantity: 5, MarginUsed: 100},
+		{Symbol: "SOLUSDT", Side: "long", MarkPrice: 50, Quantity: 4, MarginUsed: 20, LiquidationPrice: 40},
+		{Symbol: "BNBUSDT", Side: "short", MarkPrice: 300, Quantity: 1, MarginUsed: 30},
+		{Symbol: "XRPUSDT", Side: "long", MarkPrice: 0, Quantity: 100, MarginUsed: 50},
+	}
+}
+
+func TestCalculateStressTestShock(t *testing.T) {
+	stops := map[string]*positionStop{
+		"BTCUSDT_long":  {StopLoss: 90, TakeProfit: 120},
+		"ETHUSDT_short": {StopLoss: 210, TakeProfit: 180},
+	}
+
+	worst, best := CalculateStressTest(stressTestPositions(), stops, 10000)
+	// 最坏：BTC -100、ETH -50、SOL 打到强平 -40、BNB 亏完保证金 -30
+	if math.Abs(worst-9780) > 1e-9 {
+		t.Errorf("worst equity = %.2f, want 9780", worst)
+	}
+	// 最好：BTC +200、ETH +100，未设止盈的持仓不计
+	if math.Abs(best-10300) > 1e-9 {
+		t.Errorf("best equity = %.2f, want 10300", best)
+	}
+}
+
+func TestStressTestUsesRecordedStops(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	at.recordPositionStop("BTCUSDT", "long", 90, 120)
+	at.recordPositionStop("ETHUSDT", "short", 210, 180)
+
+	worst, best := at.StressTest(stressTestPositions(), 10000)
+	if math.Abs(worst-9780) > 1e-9 || math.Abs(best-10300) > 1e-9 {
+		t.Errorf("equity range = %.2f ~ %.2f, want 9780 ~ 10300", worst, best)
+	}
+
+	if worst, best := at.StressTest(nil, 10000); worst != 10000 || best != 10000 {
+		t.Errorf("no positions: equity range = %.2f ~ %.2f, want unchanged", worst, best)
+	}
+}
@@ -0,0 +1,190 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/market"
+	"strings"
+)
+
+// tpRetargetPivotStrength 止盈重定位识别摆动点的强度（与禁止开仓区一致）
+const tpRetargetPivotStrength = 2
+
+// TakeProfitRetarget 止盈重定位计划（价格与原止盈之间出现了强支撑/阻力）
+type TakeProfitRetarget struct {
+	Symbol        string       `json:"symbol"`
+	Side          string       `json:"side"`
+	Quantity      float64      `json:"quantity"`
+	StopLoss      float64      `json:"stop_loss"`
+	OldTakeProfit float64      `json:"old_take_profit"`
+	NewTakeProfit float64      `json:"new_take_profit"`
+	Level         market.Level `json:"level"`
+}
+
+// RetargetTakeProfit 检查当前价与止盈价之间是否有触及次数 ≥ minTouches 的支撑/阻力位
+// 有则返回紧贴该价位内侧（bufferPct 比例）的新止盈；多头取价格上方最近的阻力，空头取价格下方最近的支撑
+func RetargetTakeProfit(side string, price, takeProfit float64, levels []market.Level, minTouches int, bufferPct float64) (float64, *market.Level, bool) {
+	if price <= 0 || takeProfit <= 0 {
+		return 0, nil, false
+	}
+
+	var nearest *market.Level
+	for i := range levels {
+		level := &levels[i]
+		if level.Touches < minTouches {
+			continue
+		}
+		if side == "short" {
+			if level.Price < price && level.Price > takeProfit && (nearest == nil || level.Price > nearest.Price) {
+				nearest = level
+			}
+		} else if level.Price > price && level.Price < takeProfit && (nearest == nil || level.Price < nearest.Price) {
+			nearest = level
+		}
+	}
+	if nearest == nil {
+		return 0, nil, false
+	}
+
+	newTP := nearest.Price * (1 - bufferPct)
+	if side == "short" {
+		newTP = nearest.Price * (1 + bufferPct)
+		if newTP >= price {
+			return 0, nil, false
+		}
+	} else if newTP <= price {
+		return 0, nil, false
+	}
+	return newTP, nearest, true
+}
+
+// takeProfitRetargets 为结构变化后止盈不现实的持仓生成止盈修改计划（AI本周期已决定平仓的持仓跳过）
+func (at *AutoTrader) takeProfitRetargets(positions []decision.PositionInfo, decisions []decision.Decision) []*TakeProfitRetarget {
+	cfg := at.config.Risk
+	if !cfg.TakeProfitRetarget {
+		return nil
+	}
+
+	closing := make(map[string]bool)
+	for _, d := range decisions {
+		if strings.HasPrefix(d.Action, "close_") {
+			closing[d.Symbol+"_"+strings.TrimPrefix(d.Action, "close_")] = true
+		}
+	}
+
+	var plans []*TakeProfitRetarget
+	for _, pos := range positions {
+		if closing[pos.Symbol+"_"+pos.Side] {
+			continue
+		}
+		stop := at.getPositionStop(pos.Symbol, pos.Side)
+		if stop == nil || stop.TakeProfit <= 0 {
+			continue
+		}
+
+		klines, err := at.getKlines(pos.Symbol, cfg.TakeProfitRetargetInterval)
+		if err != nil {
+			continue
+		}
+		levels := market.FindSupportResistance(klines, tpRetargetPivotStrength, noTradeZoneLevelMerge)
+		newTP, level, ok := RetargetTakeProfit(pos.Side, pos.MarkPrice, stop.TakeProfit, levels, cfg.TakeProfitRetargetMinTouches, cfg.TakeProfitRetargetBufferPct)
+		if !ok {
+			continue
+		}
+		plans = append(plans, &TakeProfitRetarget{
+			Symbol:        pos.Symbol,
+			Side:          pos.Side,
+			Quantity:      pos.Quantity,
+			StopLoss:      stop.StopLoss,
+			OldTakeProfit: stop.TakeProfit,
+			NewTakeProfit: newTP,
+			Level:         *level,
+		})
+	}
+	return plans
+}
+
+// applyTakeProfitRetarget 执行止盈修改：先挂新止盈，成功后只撤销原止盈单（止损单保持不动）
+// 新止盈挂单失败时原止损止盈都还在；撤销原止盈失败时撤回新止盈，恢复修改前的挂单
+func (at *AutoTrader) applyTakeProfitRetarget(plan *TakeProfitRetarget) error {
+	oldOrders, err := at.takeProfitOrderIDs(plan.Symbol, plan.Side)
+	if err != nil {
+		return fmt.Errorf("获取原止盈单失败: %w", err)
+	}
+
+	positionSide := strings.ToUpper(plan.Side)
+	if err := at.trader.SetTakeProfit(plan.Symbol, positionSide, plan.Quantity, plan.NewTakeProfit); err != nil {
+		return fmt.Errorf("设置新止盈失败（原止损止盈保持不变）: %w", err)
+	}
+
+	for _, orderID := range oldOrders {
+		if err := at.trader.CancelOrder(plan.Symbol, orderID); err != nil {
+			at.rollbackTakeProfit(plan, oldOrders)
+			return fmt.Errorf("撤销原止盈单失败，已撤回新止盈: %w", err)
+		}
+	}
+
+	at.setPositionTakeProfit(plan.Symbol, plan.Side, plan.NewTakeProfit)
+	at.logger.Infof("  🎯 %s %s 止盈重定位: %.4f → %.4f（强%s位 %.4f，触及%d次）",
+		plan.Symbol, sideName(plan.Side), plan.OldTakeProfit, plan.NewTakeProfit,
+		levelTypeName(plan.Level.Type), plan.Level.Price, plan.Level.Touches)
+	return nil
+}
+
+// takeProfitOrderIDs 持仓当前的止盈挂单ID
+func (at *AutoTrader) takeProfitOrderIDs(symbol, side string) ([]int64, error) {
+	orders, err := at.trader.GetOpenOrders(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	// 平多仓是卖单，平空仓是买单
+	closeSide := "SELL"
+	if side == "short" {
+		closeSide = "BUY"
+	}
+
+	var ids []int64
+	for _, order := range orders {
+		if orderSide, _ := order["side"].(string); orderSide != closeSide {
+			continue
+		}
+		if orderType, _ := order["type"].(string); orderType != "TAKE_PROFIT_MARKET" && orderType != "TAKE_PROFIT" {
+			continue
+		}
+		if id, ok := order["orderId"].(int64); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// rollbackTakeProfit 撤回新挂的止盈单；原止盈单已被撤销时按原价重新挂回
+func (at *AutoTrader) rollbackTakeProfit(plan *TakeProfitRetarget, oldOrders []int64) {
+	current, err := at.takeProfitOrderIDs(plan.Symbol, plan.Side)
+	if err != nil {
+		at.logger.Warnf("  ⚠️ %s 撤回新止盈失败（获取挂单失败）: %v", plan.Symbol, err)
+		return
+	}
+
+	old := make(map[int64]bool, len(oldOrders))
+	for _, id := range oldOrders {
+		old[id] = true
+	}
+	remaining := 0
+	for _, id := range current {
+		if old[id] {
+			remaining++
+			continue
+		}
+		if err := at.trader.CancelOrder(plan.Symbol, id); err != nil {
+			at.logger.Warnf("  ⚠️ %s 撤回新止盈失败 (orderId=%d): %v", plan.Symbol, id, err)
+		}
+	}
+
+	if remaining == 0 && plan.OldTakeProfit > 0 {
+		if err := at.trader.SetTakeProfit(plan.Symbol, strings.ToUpper(plan.Side), plan.Quantity, plan.OldTakeProfit); err != nil {
+			at.logger.Warnf("  ⚠️ %s 恢复原止盈 %.4f 失败: %v", plan.Symbol, plan.OldTakeProfit, err)
+		}
+	}
+}
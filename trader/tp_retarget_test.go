@@ -0,0 +1,144 @@
+package trader
+
+import (
+	"errors"
+	"nofx/market"
+	"testing"
+)
+
+func newRetargetPlan() *TakeProfitRetarget {
+	return &TakeProfitRetarget{
+		Symbol:        "BTCUSDT",
+		Side:          "long",
+		Quantity:      1,
+		StopLoss:      95,
+		OldTakeProfit: 120,
+		NewTakeProfit: 110,
+		Level:         market.Level{Price: 111, Touches: 3, Type: "resistance"},
+	}
+}
+
+func TestApplyTakeProfitRetargetReplacesOnlyTakeProfit(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetStopLoss("BTCUSDT", "LONG", 1, 95)
+	mock.SetTakeProfit("BTCUSDT", "LONG", 1, 120)
+	at.recordPositionStop("BTCUSDT", "long", 95, 120)
+
+	if err := at.applyTakeProfitRetarget(newRetargetPlan()); err != nil {
+		t.Fatalf("applyTakeProfitRetarget: %v", err)
+	}
+
+	if n := mock.Count("CancelAllOrders"); n != 0 {
+		t.Errorf("CancelAllOrders called %d times", n)
+	}
+	orders := mock.Orders()
+	if len(orders) != 2 {
+		t.Fatalf("orders = %+v, want stop loss + new take profit", orders)
+	}
+	for _, order := range orders {
+		switch order.Type {
+		case "STOP_MARKET":
+			if order.StopPrice != 95 {
+				t.Errorf("stop loss = %.2f, want 95", order.StopPrice)
+			}
+		case "TAKE_PROFIT_MARKET":
+			if order.StopPrice != 110 {
+				t.Errorf("take profit = %.2f, want 110", order.StopPrice)
+			}
+		}
+	}
+	if stop := at.getPositionStop("BTCUSDT", "long"); stop.TakeProfit != 110 {
+		t.Errorf("recorded take profit = %.2f, want 110", stop.TakeProfit)
+	}
+}
+
+func TestApplyTakeProfitRetargetKeepsOrdersWhenPlacingFails(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetStopLoss("BTCUSDT", "LONG", 1, 95)
+	mock.SetTakeProfit("BTCUSDT", "LONG", 1, 120)
+	at.recordPositionStop("BTCUSDT", "long", 95, 120)
+	mock.errs["SetTakeProfit"] = errors.New("rejected")
+	mock.failAfter["SetTakeProfit"] = 1 // 初始止盈挂单成功，重定位时失败
+
+	if err := at.applyTakeProfitRetarget(newRetargetPlan()); err == nil {
+		t.Fatal("expected error")
+	}
+
+	orders := mock.Orders()
+	if len(orders) != 2 || orders[0].StopPrice != 95 || orders[1].StopPrice != 120 {
+		t.Fatalf("orders = %+v, want original stop loss and take profit", orders)
+	}
+	if stop := at.getPositionStop("BTCUSDT", "long"); stop.TakeProfit != 120 {
+		t.Errorf("recorded take profit = %.2f, want 120", stop.TakeProfit)
+	}
+}
+
+func TestApplyTakeProfitRetargetRollsBackWhenCancelFails(t *testing.T) {
+	at, mock := newTestAutoTrader(t, RiskConfig{})
+	mock.SetStopLoss("BTCUSDT", "LONG", 1, 95)
+	mock.SetTakeProfit("BTCUSDT", "LONG", 1, 120)
+	at.recordPositionStop("BTCUSDT", "long", 95, 120)
+	mock.errs["CancelOrder"] = errors.New("timeout")
+	mock.failFirst["CancelOrder"] = 1 // 撤销原止盈失败，撤回新止盈成功
+
+	if err := at.applyTakeProfitRetarget(newRetargetPlan()); err == nil {
+		t.Fatal("expected error")
+	}
+	orders := mock.Orders()
+	if len(orders) != 2 || orders[0].StopPrice != 95 || orders[1].StopPrice != 120 {
+		t.Fatalf("orders = %+v, want original stop loss and take profit", orders)
+	}
+	if stop := at.getPositionStop("BTCUSDT", "long"); stop.TakeProfit != 120 {
+		t.Errorf("recorded take profit = %.2f, want 120", stop.TakeProfit)
+	}
+}
+
+// retargetKlines 由 (high, low) 序列生成K线
+func retargetKlines(points [][2]float64) []market.Kline {
+	klines := make([]market.Kline, len(points))
+	for i, p := range points {
+		mid := (p[0] + p[1]) / 2
+		klines[i] = market.Kline{Open: mid, Close: mid, High: p[0], Low: p[1]}
+	}
+	return klines
+}
+
+func TestRetargetTakeProfitOnNewlyFormedResistance(t *testing.T) {
+	// 多单入场100、止盈120；价格两次在111附近受阻，形成新的阻力位
+	before := [][2]float64{{101, 99}, {103, 100}, {105, 102}, {111, 106}, {107, 104}, {105, 102}, {108, 104}}
+	after := append(append([][2]float64{}, before...), [][2]float64{{111, 107}, {108, 105}, {107, 104}, {106, 104}}...)
+
+	levels := market.FindSupportResistance(retargetKlines(before), tpRetargetPivotStrength, noTradeZoneLevelMerge)
+	if _, _, ok := RetargetTakeProfit("long", 106, 120, levels, 2, 0.002); ok {
+		t.Fatal("retargeted on a level touched only once")
+	}
+
+	levels = market.FindSupportResistance(retargetKlines(after), tpRetargetPivotStrength, noTradeZoneLevelMerge)
+	newTP, level, ok := RetargetTakeProfit("long", 106, 120, levels, 2, 0.002)
+	if !ok {
+		t.Fatalf("no retarget with levels %+v", levels)
+	}
+	if level.Touches < 2 || level.Price < 110 || level.Price > 112 {
+		t.Errorf("level = %+v, want the 111 resistance", level)
+	}
+	if newTP >= level.Price || newTP <= 106 {
+		t.Errorf("new take profit = %.4f, want just inside %.4f", newTP, level.Price)
+	}
+}
+
+func TestRetargetTakeProfitIgnoresLevelsOutsideRange(t *testing.T) {
+	levels := []market.Level{
+		{Price: 125, Touches: 4, Type: "resistance"}, // 在原止盈之外
+		{Price: 108, Touches: 1, Type: "resistance"}, // 触及次数不足
+		{Price: 95, Touches: 3, Type: "support"},     // 在价格下方
+	}
+	if _, _, ok := RetargetTakeProfit("long", 105, 120, levels, 2, 0.002); ok {
+		t.Error("long retargeted without a strong level between price and target")
+	}
+
+	// 空单：价格下方、止盈上方的支撑
+	newTP, level, ok := RetargetTakeProfit("short", 105, 80, levels, 2, 0.002)
+	if !ok || level.Price != 95 || newTP != 95*1.002 {
+		t.Errorf("short retarget = %.4f %+v %v, want just above the 95 support", newTP, level, ok)
+	}
+}
@@ -0,0 +1,145 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"nofx/logger"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer 链路追踪接口
+// 交易周期各阶段（数据处理、AI决策、风控、下单）以span形式上报，便于分布式部署时排查耗时和故障。
+// 接入 OpenTelemetry 等追踪系统时实现此接口即可；默认使用 NoopTracer，不产生任何开销
+type Tracer interface {
+	// Start 开始一个span，返回携带该span的context（子span从该context派生）
+	Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, Span)
+}
+
+// Span 追踪中的一个阶段
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// NoopTracer 空实现（未配置追踪时使用）
+type NoopTracer struct{}
+
+// Start 返回原context和空span
+func (NoopTracer) Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) RecordError(err error)                      {}
+func (noopSpan) End()                                       {}
+
+// LogTracer 将span耗时和错误写入日志的追踪器（配置 trace_spans=true 时启用）
+// 不依赖外部追踪系统，单机部署时也能看到交易周期各阶段的耗时和失败位置
+type LogTracer struct {
+	logger logger.Logger
+}
+
+// NewLogTracer 创建日志追踪器（l 为nil时使用全局日志）
+func NewLogTracer(l logger.Logger) *LogTracer {
+	if l == nil {
+		l = logger.Default()
+	}
+	return &LogTracer{logger: l}
+}
+
+type logSpanKey struct{}
+
+// Start 开始span，子span的名称带上父span路径（如 trading_cycle/ai_decision）
+func (t *LogTracer) Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, Span) {
+	if parent, ok := ctx.Value(logSpanKey{}).(*logSpan); ok {
+		name = parent.name + "/" + name
+	}
+	span := &logSpan{logger: t.logger, name: name, start: time.Now(), attrs: make(map[string]interface{}, len(attrs))}
+	for k, v := range attrs {
+		span.attrs[k] = v
+	}
+	return context.WithValue(ctx, logSpanKey{}, span), span
+}
+
+type logSpan struct {
+	logger logger.Logger
+	name   string
+	start  time.Time
+
+	mu    sync.Mutex
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *logSpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+func (s *logSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End 输出span耗时和属性（重复调用只输出一次）
+func (s *logSpan) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+
+	keys := make([]string, 0, len(s.attrs))
+	for k := range s.attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, s.attrs[k]))
+	}
+
+	elapsed := time.Since(s.start).Milliseconds()
+	if s.err != nil {
+		s.logger.Warnf("🔍 [trace] %s %dms %s error=%v", s.name, elapsed, strings.Join(parts, " "), s.err)
+		return
+	}
+	s.logger.Infof("🔍 [trace] %s %dms %s", s.name, elapsed, strings.Join(parts, " "))
+}
+
+// 交易周期的span名称
+const (
+	spanTradingCycle    = "trading_cycle"
+	spanDataProcessing  = "data_processing"
+	spanAIDecision      = "ai_decision"
+	spanRiskCalculation = "risk_calculation"
+	spanOrderExecution  = "order_execution"
+)
+
+// SetTracer 设置链路追踪器（nil 表示关闭追踪）
+func (at *AutoTrader) SetTracer(tracer Tracer) {
+	if tracer == nil {
+		tracer = NoopTracer{}
+	}
+	at.tracer = tracer
+}
+
+// startSpan 在当前交易周期的context下开始子span
+func (at *AutoTrader) startSpan(name string, attrs map[string]interface{}) Span {
+	parent := at.cycleCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	_, span := at.tracer.Start(parent, name, attrs)
+	return span
+}
@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureLogger 捕获日志输出的 logger.Logger 实现
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) add(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Debugf(format string, args ...interface{}) { l.add("DEBUG", format, args...) }
+func (l *captureLogger) Infof(format string, args ...interface{})  { l.add("INFO", format, args...) }
+func (l *captureLogger) Warnf(format string, args ...interface{})  { l.add("WARN", format, args...) }
+func (l *captureLogger) Errorf(format string, args ...interface{}) { l.add("ERROR", format, args...) }
+
+func (l *captureLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines...)
+}
+
+func TestLogTracerNestsSpansAndRecordsErrors(t *testing.T) {
+	out := &captureLogger{}
+	tracer := NewLogTracer(out)
+
+	ctx, cycle := tracer.Start(context.Background(), spanTradingCycle, map[string]interface{}{"cycle": 3})
+	_, ai := tracer.Start(ctx, spanAIDecision, nil)
+	ai.RecordError(errors.New("timeout"))
+	ai.End()
+	cycle.SetAttribute("equity", 1000.0)
+	cycle.End()
+	cycle.End() // 重复结束只输出一次
+
+	lines := out.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q, want 2", lines)
+	}
+	if !strings.HasPrefix(lines[0], "WARN") || !strings.Contains(lines[0], "trading_cycle/ai_decision") || !strings.Contains(lines[0], "error=timeout") {
+		t.Errorf("child span line = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "INFO") || !strings.Contains(lines[1], "cycle=3 equity=1000") {
+		t.Errorf("cycle span line = %q", lines[1])
+	}
+}
+
+func TestStartSpanUsesConfiguredTracer(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	out := &captureLogger{}
+	at.SetTracer(NewLogTracer(out))
+
+	ctx, cycle := at.tracer.Start(context.Background(), spanTradingCycle, nil)
+	at.cycleCtx = ctx
+	at.startSpan(spanRiskCalculation, nil).End()
+	cycle.End()
+
+	lines := out.Lines()
+	if len(lines) != 2 || !strings.Contains(lines[0], "trading_cycle/risk_calculation") {
+		t.Fatalf("lines = %q", lines)
+	}
+}
@@ -0,0 +1,43 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+)
+
+// concentrationWarningRatio 新仓位波动率调整后敞口超过现有持仓多少倍时提示集中风险
+const concentrationWarningRatio = 2.0
+
+// NormalisedPositionSize 波动率调整后的仓位（仓位价值 × ATR14/价格）
+// 名义价值相同的 BTC 与山寨币风险并不相同，按波动率换算后才可比
+func NormalisedPositionSize(positionSizeUSD, atr14, price float64) float64 {
+	if price <= 0 || atr14 <= 0 {
+		return 0
+	}
+	return positionSizeUSD * atr14 / price
+}
+
+// warnVolatilityConcentration 新仓位波动率调整后的敞口超过现有持仓2倍时记录集中风险警告（不拒绝开仓）
+func (at *AutoTrader) warnVolatilityConcentration(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+	if len(account.Positions) == 0 || data.LongerTermContext == nil {
+		return true, ""
+	}
+	planExposure := NormalisedPositionSize(d.PositionSizeUSD, data.LongerTermContext.ATR14, data.CurrentPrice)
+	if planExposure <= 0 {
+		return true, ""
+	}
+
+	for _, pos := range account.Positions {
+		posData := account.MarketData[pos.Symbol]
+		if posData == nil || posData.LongerTermContext == nil {
+			continue
+		}
+		exposure := NormalisedPositionSize(pos.Quantity*pos.MarkPrice, posData.LongerTermContext.ATR14, pos.MarkPrice)
+		if exposure > 0 && planExposure > exposure*concentrationWarningRatio {
+			at.logger.Warnf("  ⚠️ [集中风险] %s 波动率调整敞口 %.2f 是现有持仓 %s %s（%.2f）的 %.1f 倍",
+				d.Symbol, planExposure, pos.Symbol, pos.Side, exposure, planExposure/exposure)
+			break
+		}
+	}
+	return true, ""
+}
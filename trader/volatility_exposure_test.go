@@ -0,0 +1,59 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/market"
+	"testing"
+)
+
+func marketDataWithATR(symbol string, price, atr float64) *market.Data {
+	return &market.Data{Symbol: symbol, CurrentPrice: price, LongerTermContext: &market.LongerTermData{ATR14: atr}}
+}
+
+func TestNormalisedPositionSize(t *testing.T) {
+	// 同样1000U，ATR占比5%的山寨币敞口是ATR占比1%的BTC的5倍
+	btc := NormalisedPositionSize(1000, 1000, 100000)
+	alt := NormalisedPositionSize(1000, 0.05, 1)
+	if btc != 10 || alt != 50 {
+		t.Errorf("btc/alt = %.2f/%.2f, want 10/50", btc, alt)
+	}
+	if NormalisedPositionSize(1000, 0, 1) != 0 || NormalisedPositionSize(1000, 1, 0) != 0 {
+		t.Error("missing ATR or price should give 0")
+	}
+}
+
+func TestVolatilityConcentrationUsesCycleMarketData(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	d := &decision.Decision{Symbol: "DOGEUSDT", Action: "open_long", PositionSizeUSD: 1000}
+	account := RiskAccount{
+		Positions: []decision.PositionInfo{
+			{Symbol: "BTCUSDT", Side: "long", Quantity: 0.01, MarkPrice: 100000},
+			{Symbol: "SOLUSDT", Side: "short", Quantity: 5, MarkPrice: 200}, // 本周期无行情数据，跳过
+		},
+		MarketData: map[string]*market.Data{"BTCUSDT": marketDataWithATR("BTCUSDT", 100000, 1000)},
+	}
+
+	// 行情未启动时 market.Get 会panic，规则必须只读取本周期的行情数据
+	ok, reason := at.warnVolatilityConcentration(d, account, marketDataWithATR("DOGEUSDT", 0.1, 0.005))
+	if !ok || reason != "" {
+		t.Errorf("ok/reason = %v/%q, want a warning only", ok, reason)
+	}
+}
+
+func TestCheckEntryRiskPassesCycleMarketData(t *testing.T) {
+	at, _ := newTestAutoTrader(t, RiskConfig{})
+	at.cycleMarketData = map[string]*market.Data{"BTCUSDT": marketDataWithATR("BTCUSDT", 100000, 1000)}
+	var seen map[string]*market.Data
+	at.riskRules = []RiskRule{NewRiskRule("capture", func(d *decision.Decision, account RiskAccount, data *market.Data) (bool, string) {
+		seen = account.MarketData
+		return true, ""
+	})}
+
+	d := &decision.Decision{Symbol: "ETHUSDT", Action: "open_long"}
+	if err := at.checkEntryRisk(d, marketDataWithATR("ETHUSDT", 3000, 60), "long"); err != nil {
+		t.Fatalf("checkEntryRisk: %v", err)
+	}
+	if seen["BTCUSDT"] == nil {
+		t.Error("risk rule did not receive the cycle market data")
+	}
+}